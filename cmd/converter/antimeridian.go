@@ -0,0 +1,26 @@
+package main
+
+import (
+	"github.com/thomersch/grandine/lib/proj"
+	"github.com/thomersch/grandine/lib/spatial"
+)
+
+// antimeridianSplitter splits lines and polygons which cross the antimeridian, if they are in
+// geographic coordinates. Collections without SRID are assumed to be in the source system.
+type antimeridianSplitter struct {
+	src string
+}
+
+func (as *antimeridianSplitter) split(fc *spatial.FeatureCollection) {
+	srid := fc.SRID
+	if len(srid) == 0 {
+		srid = as.src
+	}
+	crs, err := proj.Parse(srid)
+	if err != nil || !crs.Geographic() {
+		return
+	}
+	for i := range fc.Features {
+		fc.Features[i].Geometry = fc.Features[i].Geometry.SplitAntimeridian()
+	}
+}
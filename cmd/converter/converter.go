@@ -1,6 +1,7 @@
 package main
 
 import (
+	"bufio"
 	"errors"
 	"flag"
 	"fmt"
@@ -13,7 +14,9 @@ import (
 	"github.com/thomersch/grandine/lib/geojson"
 	"github.com/thomersch/grandine/lib/geojsonseq"
 	"github.com/thomersch/grandine/lib/mapping"
+	"github.com/thomersch/grandine/lib/mesh"
 	"github.com/thomersch/grandine/lib/spaten"
+	"github.com/thomersch/grandine/lib/spaten/fileformat"
 	"github.com/thomersch/grandine/lib/spatial"
 )
 
@@ -40,7 +43,22 @@ func main() {
 	csvLatColumn := flag.Int("csv-lat", 1, "If parsing CSV, which column contains the Latitude. Zero-indexed.")
 	csvLonColumn := flag.Int("csv-lon", 2, "If parsing CSV, which column contains the Longitude. Zero-indexed.")
 	csvDelimiter := flag.String("csv-delim", ",", "If parsing CSV, what is the delimiter between values")
+	csvInferTypes := flag.Bool("csv-infer-types", false, "If parsing CSV, convert values into bools and numbers, if they can be converted without loss. Otherwise all values are strings.")
 	inCodecName := flag.String("in-codec", "spaten", "Specify codec for in-files. Only used for read from stdin.")
+	twkb := flag.Bool("twkb", false, "If writing Spaten, encode geometries as TWKB, which results in smaller files.")
+	twkbPrecision := flag.Int("twkb-precision", 7, "If writing TWKB, how many decimal digits of coordinates are kept, between -8 and 7.")
+	compression := flag.String("compression", "none", "If writing Spaten, compress blocks with none, gzip, deflate, zstd or snappy.")
+	blockIndex := flag.Bool("block-index", false, "If writing Spaten, append an index of the blocks and their bboxes, which speeds up reading a bbox of the file.")
+	attribution := flag.String("attribution", "", "If writing Spaten, attribution of the source data, which is stored in the file metadata.")
+	hullMode := flag.String("hull", "", "Instead of the features, write their hulls. Either convex or concave.")
+	hullBy := flag.String("hull-by", "", "If writing hulls, one hull is created per value of this property. If empty, all features are combined.")
+	hullRatio := flag.Float64("hull-ratio", 0.3, "If writing concave hulls, how closely they follow the features, between 0 (tightest) and 1 (convex).")
+	invalidMode := flag.String("invalid", "keep", "How to handle features with invalid geometries: keep, repair or skip.")
+	quarantinePath := flag.String("quarantine", "", "Path to file which receives invalid features which are skipped or can't be repaired. If empty, they are dropped.")
+	targetSRS := flag.String("t_srs", "", "Reproject features into this coordinate reference system, e.g. EPSG:3857.")
+	sourceSRS := flag.String("s_srs", "EPSG:4326", "Coordinate reference system of input files which don't specify one, used for reprojecting and splitting at the antimeridian.")
+	splitAntimeridian := flag.Bool("split-antimeridian", true, "Split lines and polygons which cross the antimeridian, if the data is in geographic coordinates.")
+	indexPath := flag.String("index", "", "If writing Spaten to a file, also write a spatial index of the output to this path.")
 	flag.Var(&infiles, "in", "infile(s)")
 	flag.Parse()
 
@@ -63,24 +81,63 @@ func main() {
 		}
 	}
 
-	availableCodecs := []spatial.Codec{
-		&geojson.Codec{},
-		&spaten.Codec{},
-		&csv.Codec{
-			LatCol: *csvLatColumn,
-			LonCol: *csvLonColumn,
-			Delim:  rune((*csvDelimiter)[0]),
-		},
-		&geojsonseq.Codec{},
+	if len(*indexPath) != 0 && !strings.HasSuffix(strings.ToLower(*dest), ".spaten") {
+		log.Fatal("an index can only be written for spaten output files")
+	}
+
+	if len(*hullMode) != 0 {
+		var err error
+		hulls, err = newHullCollector(*hullMode, *hullBy, *hullRatio)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if *invalidMode != "keep" {
+		var err error
+		invalid, err = newInvalidHandler(*invalidMode)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if len(*targetSRS) != 0 {
+		var err error
+		reproj, err = newReprojector(*sourceSRS, *targetSRS)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if *splitAntimeridian {
+		antimeridian = &antimeridianSplitter{src: *sourceSRS}
 	}
 
+	comp, err := spaten.ParseCompression(*compression)
+	if err != nil {
+		log.Fatal(err)
+	}
+	spatenConf := spaten.Codec{
+		Compression: comp,
+		BlockIndex:  *blockIndex,
+		Metadata:    spaten.Metadata{Tool: "grandine-converter", Attribution: *attribution},
+	}
+	if *twkb {
+		spatenConf.GeomSerialization = fileformat.Feature_TWKB
+		spatenConf.TWKBPrecision = twkbPrecision
+	}
+	csvConf := csv.Codec{
+		LatCol:     *csvLatColumn,
+		LonCol:     *csvLonColumn,
+		Delim:      rune((*csvDelimiter)[0]),
+		InferTypes: *csvInferTypes,
+	}
+	availableCodecs := newCodecs(spatenConf, csvConf)
+
 	// Determining which codec we will be using for the output.
-	var (
-		enc interface{}
-		err error
-	)
+	var enc interface{}
 	if len(*dest) == 0 {
-		enc = &spaten.Codec{}
+		enc, err = guessCodec(".spaten", availableCodecs)
 	} else {
 		enc, err = guessCodec(*dest, availableCodecs)
 		if err != nil {
@@ -135,6 +192,7 @@ func main() {
 	}
 
 	for _, infileName := range infiles {
+		fc = spatial.FeatureCollection{}
 		dec, err := guessCodec(infileName, availableCodecs)
 		if err != nil {
 			log.Fatalf("file type of %s is not supported (please check for correct file extension)", infileName)
@@ -181,9 +239,72 @@ func main() {
 			finished()
 		}
 	}
+
+	if hulls != nil {
+		fc := hulls.collection()
+		err = encodeAll(out, &fc, encoder)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if invalid != nil {
+		invalid.report()
+		if len(*quarantinePath) != 0 {
+			// the codecs of the output are not reused, as they keep the state of the output stream
+			err = writeQuarantine(*quarantinePath, invalid.collection(), newCodecs(spatenConf, csvConf))
+			if err != nil {
+				log.Fatal(err)
+			}
+		}
+	}
+
+	if len(*indexPath) != 0 {
+		err = writeIndex(*dest, *indexPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
 }
 
-var featBuf []spatial.FeatureCollection // TODO: this is not optimal, needs better wrapping
+func writeIndex(path, indexPath string) error {
+	r, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	w, err := os.Create(indexPath)
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+	return spaten.BuildIndex(bufio.NewReader(r), w)
+}
+
+func writeQuarantine(path string, fc spatial.FeatureCollection, codecs []spatial.Codec) error {
+	cd, err := guessCodec(path, codecs)
+	if err != nil {
+		return fmt.Errorf("file type of quarantine file %s is not supported", path)
+	}
+	enc, ok := cd.(spatial.Encoder)
+	if !ok {
+		return fmt.Errorf("%T codec does not support writing", cd)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return encodeAll(f, &fc, enc)
+}
+
+var (
+	featBuf      []spatial.FeatureCollection // TODO: this is not optimal, needs better wrapping
+	hulls        *hullCollector
+	invalid      *invalidHandler
+	reproj       *reprojector
+	antimeridian *antimeridianSplitter
+)
 
 func write(w io.Writer, fs *spatial.FeatureCollection, enc spatial.Encoder, conds []mapping.Condition) (flush func() error, err error) {
 	if len(conds) > 0 {
@@ -198,6 +319,28 @@ func write(w io.Writer, fs *spatial.FeatureCollection, enc spatial.Encoder, cond
 		fs.Features = filtered
 	}
 
+	// Geometries are split while they are still in geographic coordinates, after reprojecting
+	// the antimeridian can't be detected anymore.
+	if antimeridian != nil {
+		antimeridian.split(fs)
+	}
+
+	if reproj != nil {
+		err = reproj.reproject(fs)
+		if err != nil {
+			return func() error { return nil }, err
+		}
+	}
+
+	if invalid != nil {
+		invalid.filter(fs)
+	}
+
+	if hulls != nil {
+		hulls.add(fs)
+		return func() error { return nil }, nil
+	}
+
 	if e, ok := enc.(spatial.ChunkedEncoder); ok {
 		err = e.EncodeChunk(w, fs)
 		if err != nil {
@@ -217,6 +360,30 @@ func write(w io.Writer, fs *spatial.FeatureCollection, enc spatial.Encoder, cond
 	}, nil
 }
 
+func encodeAll(w io.Writer, fs *spatial.FeatureCollection, enc spatial.Encoder) error {
+	if e, ok := enc.(spatial.ChunkedEncoder); ok {
+		err := e.EncodeChunk(w, fs)
+		if err != nil {
+			return err
+		}
+		return e.Close(w)
+	}
+	return enc.Encode(w, fs)
+}
+
+// newCodecs returns a new instance of every supported codec. The spaten and CSV codecs are
+// copies of the given configuration.
+func newCodecs(sp spaten.Codec, cv csv.Codec) []spatial.Codec {
+	return []spatial.Codec{
+		// must precede geojson, which also matches the .json suffix
+		&mesh.Codec{},
+		&geojson.Codec{},
+		&sp,
+		&cv,
+		&geojsonseq.Codec{},
+	}
+}
+
 func guessCodec(filename string, codecs []spatial.Codec) (spatial.Codec, error) {
 	fn := strings.ToLower(filename)
 	for _, cd := range codecs {
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thomersch/grandine/lib/csv"
+	"github.com/thomersch/grandine/lib/geojson"
+	"github.com/thomersch/grandine/lib/spaten"
+	"github.com/thomersch/grandine/lib/spatial"
+)
+
+func TestWriteReprojectAntimeridian(t *testing.T) {
+	defer func() {
+		reproj, antimeridian, featBuf = nil, nil, nil
+	}()
+	var err error
+	reproj, err = newReprojector("EPSG:4326", "EPSG:3857")
+	assert.Nil(t, err)
+	antimeridian = &antimeridianSplitter{src: "EPSG:4326"}
+
+	// Fiji
+	fc := spatial.FeatureCollection{Features: []spatial.Feature{{
+		Props:    map[string]interface{}{},
+		Geometry: spatial.MustNewGeom(spatial.Polygon{{{177, -16}, {177, -19}, {-179, -19}, {-179, -16}}}),
+	}}}
+	var buf bytes.Buffer
+	flush, err := write(&buf, &fc, &geojson.Codec{}, nil)
+	assert.Nil(t, err)
+	assert.Nil(t, flush())
+
+	var read spatial.FeatureCollection
+	assert.Nil(t, (&geojson.Codec{}).Decode(&buf, &read))
+	assert.Len(t, read.Features, 1)
+	mp, err := read.Features[0].Geometry.MultiPolygon()
+	assert.Nil(t, err)
+	assert.Len(t, mp, 2)
+	for _, poly := range mp {
+		// both parts are about 1° of longitude wide, not the whole world
+		bb := poly.BBox()
+		assert.True(t, bb.NE.X-bb.SW.X < 500000, "%v", bb)
+	}
+}
+
+func TestWriteQuarantineSpaten(t *testing.T) {
+	defer func() {
+		invalid = nil
+	}()
+	var err error
+	invalid, err = newInvalidHandler("skip")
+	assert.Nil(t, err)
+
+	dir, err := ioutil.TempDir("", "quarantine")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	conf := spaten.Codec{BlockIndex: true}
+	enc, err := guessCodec("out.spaten", newCodecs(conf, csv.Codec{}))
+	assert.Nil(t, err)
+
+	fc := spatial.FeatureCollection{Features: []spatial.Feature{
+		{Props: map[string]interface{}{"valid": "yes"}, Geometry: spatial.MustNewGeom(spatial.Polygon{{{0, 0}, {1, 0}, {1, 1}, {0, 1}}})},
+		// bowtie
+		{Props: map[string]interface{}{"valid": "no"}, Geometry: spatial.MustNewGeom(spatial.Polygon{{{0, 0}, {1, 1}, {1, 0}, {0, 1}}})},
+	}}
+	var out bytes.Buffer
+	flush, err := write(&out, &fc, enc.(spatial.Encoder), nil)
+	assert.Nil(t, err)
+	assert.Nil(t, flush())
+
+	path := filepath.Join(dir, "quarantine.spaten")
+	assert.Nil(t, writeQuarantine(path, invalid.collection(), newCodecs(conf, csv.Codec{})))
+
+	var read spatial.FeatureCollection
+	assert.Nil(t, (&spaten.Codec{}).Decode(&out, &read))
+	assert.Len(t, read.Features, 1)
+	assert.Equal(t, "yes", read.Features[0].Props["valid"])
+
+	f, err := os.Open(path)
+	assert.Nil(t, err)
+	defer f.Close()
+	read = spatial.FeatureCollection{}
+	assert.Nil(t, (&spaten.Codec{}).Decode(f, &read))
+	assert.Len(t, read.Features, 1)
+	assert.Equal(t, "no", read.Features[0].Props["valid"])
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/thomersch/grandine/lib/spatial"
+)
+
+// hullCollector groups features by the value of a property and calculates a hull for every
+// group, instead of passing the features through.
+type hullCollector struct {
+	concave bool
+	ratio   float64
+	key     string
+
+	srid   string
+	order  []string
+	groups map[string]*hullGroup
+}
+
+type hullGroup struct {
+	value interface{}
+	fts   []spatial.Feature
+}
+
+func newHullCollector(mode, key string, ratio float64) (*hullCollector, error) {
+	hc := &hullCollector{key: key, ratio: ratio, groups: map[string]*hullGroup{}}
+	switch mode {
+	case "convex":
+	case "concave":
+		hc.concave = true
+	default:
+		return nil, fmt.Errorf("unknown hull mode: %s (allowed values: convex, concave)", mode)
+	}
+	return hc, nil
+}
+
+// add assigns the features to their groups. If grouping by property, features without it are
+// skipped.
+func (hc *hullCollector) add(fc *spatial.FeatureCollection) {
+	if len(fc.SRID) != 0 {
+		hc.srid = fc.SRID
+	}
+	for _, ft := range fc.Features {
+		var value interface{}
+		if len(hc.key) != 0 {
+			var ok bool
+			if value, ok = ft.Props[hc.key]; !ok {
+				continue
+			}
+		}
+		id := fmt.Sprint(value)
+		grp, ok := hc.groups[id]
+		if !ok {
+			grp = &hullGroup{value: value}
+			hc.groups[id] = grp
+			hc.order = append(hc.order, id)
+		}
+		grp.fts = append(grp.fts, ft)
+	}
+}
+
+// collection returns one feature per group, in the order of their first appearance.
+func (hc *hullCollector) collection() spatial.FeatureCollection {
+	fc := spatial.FeatureCollection{SRID: hc.srid}
+	for _, id := range hc.order {
+		var (
+			grp   = hc.groups[id]
+			props = map[string]interface{}{}
+			hull  spatial.Geom
+		)
+		if len(hc.key) != 0 {
+			props[hc.key] = grp.value
+		}
+		if hc.concave {
+			hull = spatial.ConcaveHull(grp.fts, hc.ratio)
+		} else {
+			hull = spatial.ConvexHull(grp.fts)
+		}
+		if hull.Typ() == spatial.GeomTypeEmpty {
+			continue
+		}
+		fc.Features = append(fc.Features, spatial.Feature{Props: props, Geometry: hull})
+	}
+	return fc
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/thomersch/grandine/lib/spatial"
+)
+
+// invalidHandler checks the geometries of features and repairs or sets aside the invalid ones.
+type invalidHandler struct {
+	repair bool
+
+	srid        string
+	quarantined []spatial.Feature
+	invalid     int
+	repaired    int
+}
+
+func newInvalidHandler(mode string) (*invalidHandler, error) {
+	switch mode {
+	case "repair":
+		return &invalidHandler{repair: true}, nil
+	case "skip":
+		return &invalidHandler{}, nil
+	}
+	return nil, fmt.Errorf("unknown invalid mode: %s (allowed values: keep, repair, skip)", mode)
+}
+
+// filter removes invalid features from the collection. If repairing, they are replaced by
+// their repaired version. Features which can't be repaired are kept for the quarantine.
+func (ih *invalidHandler) filter(fc *spatial.FeatureCollection) {
+	if len(fc.SRID) != 0 {
+		ih.srid = fc.SRID
+	}
+	var valid []spatial.Feature
+	for _, ft := range fc.Features {
+		if len(ft.Geometry.Validate()) == 0 {
+			valid = append(valid, ft)
+			continue
+		}
+		ih.invalid++
+		if ih.repair {
+			g, err := ft.Geometry.MakeValid()
+			if err == nil && !g.IsEmpty() {
+				ft.Geometry = g
+				valid = append(valid, ft)
+				ih.repaired++
+				continue
+			}
+		}
+		ih.quarantined = append(ih.quarantined, ft)
+	}
+	fc.Features = valid
+}
+
+func (ih *invalidHandler) collection() spatial.FeatureCollection {
+	return spatial.FeatureCollection{SRID: ih.srid, Features: ih.quarantined}
+}
+
+func (ih *invalidHandler) report() {
+	log.Printf("%v invalid features, %v repaired, %v quarantined", ih.invalid, ih.repaired, len(ih.quarantined))
+}
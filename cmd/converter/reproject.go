@@ -0,0 +1,42 @@
+package main
+
+import (
+	"github.com/thomersch/grandine/lib/proj"
+	"github.com/thomersch/grandine/lib/spatial"
+)
+
+// reprojector transforms feature collections into the target coordinate reference system.
+// Collections without SRID are assumed to be in the source system.
+type reprojector struct {
+	src, dst     *proj.CRS
+	transformers map[string]*proj.Transformer
+}
+
+func newReprojector(src, dst string) (*reprojector, error) {
+	s, err := proj.Parse(src)
+	if err != nil {
+		return nil, err
+	}
+	d, err := proj.Parse(dst)
+	if err != nil {
+		return nil, err
+	}
+	return &reprojector{src: s, dst: d, transformers: map[string]*proj.Transformer{}}, nil
+}
+
+func (rp *reprojector) reproject(fc *spatial.FeatureCollection) error {
+	t, ok := rp.transformers[fc.SRID]
+	if !ok {
+		src := rp.src
+		if len(fc.SRID) != 0 {
+			var err error
+			src, err = proj.Parse(fc.SRID)
+			if err != nil {
+				return err
+			}
+		}
+		t = proj.NewTransformer(src, rp.dst)
+		rp.transformers[fc.SRID] = t
+	}
+	return t.FeatureCollection(fc)
+}
@@ -38,6 +38,31 @@ func prettyPrint(m map[string]interface{}) string {
 	return o
 }
 
+func printMetadata(w io.Writer, md spaten.Metadata) {
+	if len(md.SRID) != 0 {
+		fmt.Fprintf(w, "SRID: %v\n", md.SRID)
+	}
+	if md.HasStats {
+		fmt.Fprintf(w, "Features: %v\n", md.Count)
+		if md.Count > 0 {
+			fmt.Fprintf(w, "BBox: %v %v, %v %v\n", md.BBox.SW.X, md.BBox.SW.Y, md.BBox.NE.X, md.BBox.NE.Y)
+		}
+	}
+	if len(md.Tool) != 0 {
+		fmt.Fprintf(w, "Created by: %v\n", md.Tool)
+	}
+	if len(md.Attribution) != 0 {
+		fmt.Fprintf(w, "Attribution: %v\n", md.Attribution)
+	}
+	if len(md.Schema) != 0 {
+		fmt.Fprintf(w, "Schema:\n")
+		for _, p := range md.Schema {
+			fmt.Fprintf(w, "  %v %v\n", colorKV(p.Name), p.Type)
+		}
+	}
+	fmt.Fprintln(w)
+}
+
 func main() {
 	if len(os.Args) < 2 {
 		fmt.Printf("Usage: %s filepath\n", os.Args[0])
@@ -71,6 +96,7 @@ func main() {
 			return
 		}
 		fmt.Fprintf(stdin, "Spaten file, Version %v\n", hd.Version)
+		printMetadata(stdin, hd.Metadata)
 		_, err = f.Seek(0, 0)
 		if err != nil {
 			log.Fatal(err)
@@ -9,23 +9,46 @@ import (
 
 	"github.com/thomersch/grandine/lib/mapping"
 	"github.com/thomersch/grandine/lib/spaten"
+	"github.com/thomersch/grandine/lib/spaten/fileformat"
 	"github.com/thomersch/grandine/lib/spatial"
 
 	"github.com/thomersch/gosmparse"
 )
 
 type nd struct {
+	ID       int64
 	Lat, Lon float64
 	Tags     map[string]interface{}
+	Cond     *mapping.Condition
 }
 type wy struct {
 	ID      int64
 	NodeIDs []int64
 	Tags    map[string]interface{}
+	Cond    *mapping.Condition
 }
 type rl struct {
+	ID      int64
 	Members []gosmparse.RelationMember
 	Tags    map[string]interface{}
+	Cond    *mapping.Condition
+}
+
+// OSM element types, which are encoded into feature IDs, see osmFeatureID.
+const (
+	osmNode     = 1
+	osmWay      = 2
+	osmRelation = 3
+)
+
+// osmFeatureID derives a feature ID from the OSM element type and ID, as nodes, ways and
+// relations have separate ID spaces: way 123 becomes 1232. Elements with negative IDs, which
+// haven't been uploaded to OSM yet, get no ID.
+func osmFeatureID(typ, id int64) uint64 {
+	if id <= 0 {
+		return 0
+	}
+	return uint64(id)*10 + uint64(typ)
 }
 
 type dataHandler struct {
@@ -42,13 +65,16 @@ type dataHandler struct {
 }
 
 func (d *dataHandler) ReadNode(n gosmparse.Node) {
-	for _, cond := range d.conds {
+	for i := range d.conds {
+		cond := &d.conds[i]
 		if cond.Matches(mapping.InterfaceMap(n.Tags)) {
 			d.nodesMtx.Lock()
 			d.nodes = append(d.nodes, nd{
+				ID:   n.ID,
 				Lat:  n.Lat,
 				Lon:  n.Lon,
 				Tags: cond.Map(mapping.InterfaceMap(n.Tags)),
+				Cond: cond,
 			})
 			d.nodesMtx.Unlock()
 		}
@@ -56,7 +82,8 @@ func (d *dataHandler) ReadNode(n gosmparse.Node) {
 }
 
 func (d *dataHandler) ReadWay(w gosmparse.Way) {
-	for _, cond := range d.conds {
+	for i := range d.conds {
+		cond := &d.conds[i]
 		if cond.Matches(mapping.InterfaceMap(w.Tags)) {
 			d.ec.AddNodes(w.NodeIDs...)
 			d.ec.setMembers(w.ID, w.NodeIDs)
@@ -66,6 +93,7 @@ func (d *dataHandler) ReadWay(w gosmparse.Way) {
 				ID:      w.ID,
 				NodeIDs: w.NodeIDs,
 				Tags:    cond.Map(mapping.InterfaceMap(w.Tags)),
+				Cond:    cond,
 			})
 			d.waysMtx.Unlock()
 		}
@@ -73,12 +101,15 @@ func (d *dataHandler) ReadWay(w gosmparse.Way) {
 }
 
 func (d *dataHandler) ReadRelation(r gosmparse.Relation) {
-	for _, cond := range d.conds {
+	for i := range d.conds {
+		cond := &d.conds[i]
 		if cond.Matches(mapping.InterfaceMap(r.Tags)) {
 			d.relsMtx.Lock()
 			d.rels = append(d.rels, rl{
+				ID:      r.ID,
 				Members: r.Members,
 				Tags:    cond.Map(mapping.InterfaceMap(r.Tags)),
+				Cond:    cond,
 			})
 			d.relsMtx.Unlock()
 
@@ -178,8 +209,18 @@ func main() {
 	outfile := flag.String("out", "osm.spaten", "")
 	mappingPath := flag.String("mapping", "", "path to mapping file. default mapping will be applied if none is specified")
 	memprofile := flag.String("memprofile", "", "write memory profile to this file")
+	twkb := flag.Bool("twkb", false, "encode geometries as TWKB, which results in smaller files")
+	twkbPrecision := flag.Int("twkb-precision", 7, "if writing TWKB, how many decimal digits of coordinates are kept, between -8 and 7")
+	compression := flag.String("compression", "none", "compress blocks with none, gzip, deflate, zstd or snappy")
+	blockIndex := flag.Bool("block-index", false, "append an index of the blocks and their bboxes, which speeds up reading a bbox of the file")
+	attribution := flag.String("attribution", "© OpenStreetMap contributors", "attribution of the source data, which is stored in the file metadata")
 	flag.Parse()
 
+	comp, err := spaten.ParseCompression(*compression)
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	var conds []mapping.Condition
 	if len(*mappingPath) == 0 {
 		log.Println("No mapping specified. Using default tag mapping.")
@@ -260,10 +301,12 @@ func main() {
 		for k, v := range pt.Tags {
 			props[k] = v
 		}
-		fc = append(fc, spatial.Feature{
-			Props:    props,
-			Geometry: spatial.MustNewGeom(spatial.Point{float64(pt.Lon), float64(pt.Lat)}),
-		})
+		for _, g := range pt.Cond.Apply(spatial.MustNewGeom(spatial.Point{float64(pt.Lon), float64(pt.Lat)})) {
+			if !pt.Cond.Accepts(g) {
+				continue
+			}
+			fc = append(fc, spatial.Feature{ID: osmFeatureID(osmNode, pt.ID), Props: pt.Cond.Compute(props, g), Geometry: g})
+		}
 	}
 
 	log.Println("Assembling ways...")
@@ -291,10 +334,12 @@ func main() {
 			geom = ln
 		}
 
-		fc = append(fc, spatial.Feature{
-			Props:    props,
-			Geometry: spatial.MustNewGeom(geom),
-		})
+		for _, g := range wy.Cond.Apply(spatial.MustNewGeom(geom)) {
+			if !wy.Cond.Accepts(g) {
+				continue
+			}
+			fc = append(fc, spatial.Feature{ID: osmFeatureID(osmWay, wy.ID), Props: wy.Cond.Compute(props, g), Geometry: g})
+		}
 	}
 
 	log.Println("Assembling relations...")
@@ -304,7 +349,7 @@ func main() {
 				continue
 			}
 		}
-		var poly spatial.Polygon
+		var outers, inners []spatial.Line
 
 		for _, memb := range rl.Members {
 			if memb.Role == "outer" || memb.Role == "inner" {
@@ -316,13 +361,22 @@ func main() {
 				if (memb.Role == "outer" && ring.Clockwise()) || (memb.Role == "inner" && !ring.Clockwise()) {
 					ring.Reverse()
 				}
-				poly = append(poly, ring)
+				if memb.Role == "outer" {
+					outers = append(outers, ring)
+				} else {
+					inners = append(inners, ring)
+				}
 			}
 		}
-		fc = append(fc, spatial.Feature{
-			Props:    rl.Tags,
-			Geometry: spatial.MustNewGeom(poly),
-		})
+		if len(outers) == 0 {
+			continue
+		}
+		for _, g := range rl.Cond.Apply(spatial.MustNewGeom(assembleMultipolygon(outers, inners))) {
+			if !rl.Cond.Accepts(g) {
+				continue
+			}
+			fc = append(fc, spatial.Feature{ID: osmFeatureID(osmRelation, rl.ID), Props: rl.Cond.Compute(rl.Tags, g), Geometry: g})
+		}
 	}
 
 	log.Println("Writing out")
@@ -331,8 +385,41 @@ func main() {
 		log.Fatal(err)
 	}
 	var outCodec spaten.Codec
+	if *twkb {
+		outCodec.GeomSerialization = fileformat.Feature_TWKB
+		outCodec.TWKBPrecision = twkbPrecision
+	}
+	outCodec.Compression = comp
+	outCodec.BlockIndex = *blockIndex
+	outCodec.Metadata = spaten.Metadata{Tool: "grandine-spatialize", Attribution: *attribution}
+	outCodec.Metadata.Schema, err = spaten.InferSchema(fc)
+	if err != nil {
+		log.Fatal(err)
+	}
 	err = outCodec.Encode(of, &spatial.FeatureCollection{Features: fc, SRID: "4326"})
 	if err != nil {
 		log.Fatal(err)
 	}
 }
+
+// assembleMultipolygon assigns every inner ring to the outer ring which contains it. If there
+// is only one outer ring, a simple polygon is returned.
+func assembleMultipolygon(outers, inners []spatial.Line) interface{} {
+	if len(outers) == 1 {
+		return append(spatial.Polygon{outers[0]}, inners...)
+	}
+
+	var mp = make(spatial.MultiPolygon, 0, len(outers))
+	for _, outer := range outers {
+		mp = append(mp, spatial.Polygon{outer})
+	}
+	for _, inner := range inners {
+		for n, outer := range outers {
+			if inner[0].InPolygon(spatial.Polygon{outer}) {
+				mp[n] = append(mp[n], inner)
+				break
+			}
+		}
+	}
+	return mp
+}
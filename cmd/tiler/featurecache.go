@@ -41,7 +41,7 @@ func availableCaches() []string {
 }
 
 type FeatureCache interface {
-	AddFeature(spatial.Feature)
+	AddFeature(spatial.Feature) error
 	GetFeatures(tile.ID) []spatial.Feature
 
 	BBox() spatial.BBox
@@ -70,12 +70,12 @@ func NewFeatureTable(zoomlevels []int) (*FeatureTable, error) {
 	return &ftab, nil
 }
 
-func (ftab *FeatureTable) AddFeature(ft spatial.Feature) {
+func (ftab *FeatureTable) AddFeature(ft spatial.Feature) error {
 	for _, zl := range ftab.Zoomlevels {
 		if !renderable(ft.Props, zl) {
 			continue
 		}
-		for _, tid := range tile.Coverage(ft.Geometry.BBox(), zl) {
+		for _, tid := range tile.Coverage(ft.Geometry.WrappedBBox(), zl) {
 			ftab.table[zl][tid.X][tid.Y] = append(ftab.table[zl][tid.X][tid.Y], ft)
 		}
 	}
@@ -87,6 +87,7 @@ func (ftab *FeatureTable) AddFeature(ft spatial.Feature) {
 	}
 
 	ftab.count++
+	return nil
 }
 
 func (ftab *FeatureTable) GetFeatures(tid tile.ID) []spatial.Feature {
@@ -119,12 +120,12 @@ func NewFeatureMap(zl []int) (*FeatureMap, error) {
 	}, nil
 }
 
-func (fm *FeatureMap) AddFeature(ft spatial.Feature) {
+func (fm *FeatureMap) AddFeature(ft spatial.Feature) error {
 	for _, zl := range fm.Zoomlevels {
 		if !renderable(ft.Props, zl) {
 			continue
 		}
-		for _, tid := range tile.Coverage(ft.Geometry.BBox(), zl) {
+		for _, tid := range tile.Coverage(ft.Geometry.WrappedBBox(), zl) {
 			_, ok := fm.m[tid]
 			if !ok {
 				fm.m[tid] = make([]spatial.Feature, 0, 1)
@@ -140,6 +141,7 @@ func (fm *FeatureMap) AddFeature(ft spatial.Feature) {
 	}
 
 	fm.count++
+	return nil
 }
 
 func (fm *FeatureMap) GetFeatures(tid tile.ID) []spatial.Feature {
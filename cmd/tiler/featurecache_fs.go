@@ -95,21 +95,23 @@ func (fsc *FileSystemCache) flush() error {
 	return nil
 }
 
-func (fsc *FileSystemCache) AddFeature(ft spatial.Feature) {
+func (fsc *FileSystemCache) AddFeature(ft spatial.Feature) error {
 	fsc.count++
 
 	if fsc.count%fsc.CacheSize == 0 {
 		showMemStats()
 		err := fsc.flush()
 		if err != nil {
-			panic(err)
+			return err
 		}
 
 		log.Printf("Written %v features to disk (%.0f/s)", fsc.count, float64(fsc.CacheSize)/time.Since(fsc.lastCheckpoint).Seconds())
 		fsc.lastCheckpoint = time.Now()
 	}
 
-	fsc.cache.AddFeature(ft)
+	if err := fsc.cache.AddFeature(ft); err != nil {
+		return err
+	}
 
 	if fsc.bbox == nil {
 		var bb = ft.Geometry.BBox()
@@ -117,6 +119,7 @@ func (fsc *FileSystemCache) AddFeature(ft spatial.Feature) {
 	} else {
 		fsc.bbox.ExtendWith(ft.Geometry.BBox())
 	}
+	return nil
 }
 
 func (fsc *FileSystemCache) GetFeatures(tid tile.ID) []spatial.Feature {
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"math"
+	"sync"
+
+	"github.com/thomersch/grandine/lib/spaten"
+	"github.com/thomersch/grandine/lib/spatial"
+	"github.com/thomersch/grandine/lib/tile"
+)
+
+// IndexedCache doesn't hold any features, instead it reads the features of each tile from a
+// Spaten file using its spatial index.
+type IndexedCache struct {
+	file    *spaten.IndexedFile
+	prepare func(spatial.Feature) (fts []spatial.Feature, repaired bool)
+
+	// invalid records the features which have been skipped (false) or repaired (true) by
+	// prepare. Features are prepared for every tile they appear in, so they are identified by
+	// their index reference to count them once.
+	mu      sync.Mutex
+	invalid map[uint64]bool
+}
+
+func newIndexedCache(path, indexPath string, prepare func(spatial.Feature) ([]spatial.Feature, bool)) (*IndexedCache, error) {
+	f, err := spaten.OpenIndexed(path, indexPath)
+	if err != nil {
+		return nil, err
+	}
+	return &IndexedCache{file: f, prepare: prepare, invalid: map[uint64]bool{}}, nil
+}
+
+// AddFeature is not supported, because the features are determined by the index.
+func (ic *IndexedCache) AddFeature(spatial.Feature) error {
+	return errors.New("features can't be added to an indexed cache")
+}
+
+// invalidCounts returns the number of invalid features which have been repaired or skipped in
+// the tiles read so far.
+func (ic *IndexedCache) invalidCounts() (repaired, skipped int) {
+	ic.mu.Lock()
+	defer ic.mu.Unlock()
+	for _, rep := range ic.invalid {
+		if rep {
+			repaired++
+		} else {
+			skipped++
+		}
+	}
+	return repaired, skipped
+}
+
+func (ic *IndexedCache) GetFeatures(tid tile.ID) []spatial.Feature {
+	var (
+		bb  = tid.BBox()
+		max = pow(2, tid.Z) - 1
+	)
+	// tile.Coverage assigns features outside of the tile range to the border tiles
+	if tid.X == 0 {
+		bb.SW.X = math.Inf(-1)
+	}
+	if tid.X == max {
+		bb.NE.X = math.Inf(1)
+	}
+	if tid.Y == 0 {
+		bb.NE.Y = math.Inf(1)
+	}
+	if tid.Y == max {
+		bb.SW.Y = math.Inf(-1)
+	}
+
+	refs, fts, err := ic.file.QueryRefs(bb)
+	if err != nil {
+		log.Fatal(err)
+	}
+	var res []spatial.Feature
+	for i, ft := range fts {
+		if !renderable(ft.Props, tid.Z) {
+			continue
+		}
+		pfts, repaired := ic.prepare(ft)
+		if len(pfts) == 0 || repaired {
+			ic.mu.Lock()
+			ic.invalid[refs[i]] = repaired
+			ic.mu.Unlock()
+		}
+		for _, pft := range pfts {
+			if tileCovers(tid, pft.Geometry.WrappedBBox()) {
+				res = append(res, pft)
+			}
+		}
+	}
+	return res
+}
+
+// tileCovers reports whether the tile is part of the coverage of the bbox.
+func tileCovers(tid tile.ID, bb spatial.BBox) bool {
+	var (
+		nw = tile.TileName(spatial.Point{X: bb.SW.X, Y: bb.NE.Y}, tid.Z)
+		se = tile.TileName(spatial.Point{X: bb.NE.X, Y: bb.SW.Y}, tid.Z)
+	)
+	if tid.Y < nw.Y || tid.Y > se.Y {
+		return false
+	}
+	if bb.Wrapped() {
+		return tid.X >= nw.X || tid.X <= se.X
+	}
+	return tid.X >= nw.X && tid.X <= se.X
+}
+
+func (ic *IndexedCache) BBox() spatial.BBox {
+	return ic.file.BBox()
+}
+
+func (ic *IndexedCache) Count() int {
+	return ic.file.Count()
+}
+
+func (ic *IndexedCache) Close() error {
+	return ic.file.Close()
+}
@@ -118,21 +118,23 @@ func (ldb *LevelDBCache) flush() error {
 	return nil
 }
 
-func (ldb *LevelDBCache) AddFeature(ft spatial.Feature) {
+func (ldb *LevelDBCache) AddFeature(ft spatial.Feature) error {
 	ldb.count++
 
 	if ldb.count%ldb.CacheSize == 0 {
 		showMemStats()
 		err := ldb.flush()
 		if err != nil {
-			panic(err)
+			return err
 		}
 
 		log.Printf("Written %v features to disk (%.0f/s)", ldb.count, float64(ldb.CacheSize)/time.Since(ldb.lastCheckpoint).Seconds())
 		ldb.lastCheckpoint = time.Now()
 	}
 
-	ldb.cache.AddFeature(ft)
+	if err := ldb.cache.AddFeature(ft); err != nil {
+		return err
+	}
 
 	if ldb.bbox == nil {
 		var bb = ft.Geometry.BBox()
@@ -140,6 +142,7 @@ func (ldb *LevelDBCache) AddFeature(ft spatial.Feature) {
 	} else {
 		ldb.bbox.ExtendWith(ft.Geometry.BBox())
 	}
+	return nil
 }
 
 func (ldb *LevelDBCache) GetFeatures(tid tile.ID) []spatial.Feature {
@@ -72,6 +72,47 @@ func (b *bbox) Set(value string) error {
 	return nil
 }
 
+// simplifications configures the simplification per layer, e.g. "roads=topology:2,*=vw:1".
+type simplifications map[string]mvt.Simplification
+
+var simplifyMethods = map[string]spatial.SimplifyMethod{
+	"dp":       spatial.SimplifyDouglasPeucker,
+	"vw":       spatial.SimplifyVisvalingam,
+	"topology": spatial.SimplifyTopology,
+}
+
+func (s simplifications) String() string {
+	return fmt.Sprintf("%v", map[string]mvt.Simplification(s))
+}
+
+func (s simplifications) Set(value string) error {
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		var layer = "*"
+		if pos := strings.Index(entry, "="); pos != -1 {
+			layer, entry = entry[:pos], entry[pos+1:]
+		}
+		parts := strings.SplitN(entry, ":", 2)
+		method, ok := simplifyMethods[parts[0]]
+		if !ok {
+			return fmt.Errorf("unknown simplification method %s (allowed values: dp, vw, topology)", parts[0])
+		}
+		var simp = mvt.Simplification{Method: method, Tolerance: 1}
+		if len(parts) == 2 {
+			tol, err := strconv.ParseFloat(parts[1], 64)
+			if err != nil {
+				return fmt.Errorf("could not parse simplification tolerance: %v", err)
+			}
+			simp.Tolerance = tol
+		}
+		s[layer] = simp
+	}
+	return nil
+}
+
 var (
 	zoomlevels zmLvl
 	quiet      *bool
@@ -81,6 +122,8 @@ func main() {
 	var (
 		sourceStdIn bool
 		tileCodec   tile.Codec
+		simplify    = simplifications{}
+		bounds      bbox
 	)
 	source := flag.String("in", "", "file to read from, supported format: spaten")
 	target := flag.String("out", "tiles", "path where the tiles will be written")
@@ -89,10 +132,16 @@ func main() {
 	cpuProfile := flag.String("cpuprof", "", "writes CPU profiling data into a file")
 	geojsonCodec := flag.Bool("geojson", false, "encode tiles into geojson instead of MVT, for debugging purposes")
 	compressTiles := flag.Bool("compress", false, "compress tiles with gzip")
+	zAttribute := flag.String("z-attribute", "", "name of the MVT attribute which receives the Z value of 3D geometries, disabled if empty")
 	cacheStrategy := flag.String("cache", "leveldb", fmt.Sprintf("cache strategy, possible values: %v", availableCaches()))
+	invalidMode := flag.String("invalid", "keep", "how to handle features with invalid geometries, possible values: keep, repair, skip")
+	labelSuffix := flag.String("label-layer-suffix", "", "if set, a label point is added for each polygon, in a layer named like the polygon's layer with this suffix")
+	indexPath := flag.String("index", "", "spatial index of the input file, created with grandine-converter -index; features are read from the file per tile instead of being cached in memory")
 	quiet = flag.Bool("q", false, "argument to use if program should be run in quiet mode with reduced logging")
 
 	flag.Var(&zoomlevels, "zoom", "one or more zoom levels (comma separated) of which the tiles will be rendered")
+	flag.Var(&bounds, "bbox", "only render tiles which intersect with this bbox (SW Lon, SW Lat, NE Lon, NE Lat); only the blocks of the input file which intersect with it are read")
+	flag.Var(simplify, "simplify", "simplification per layer as layer=method:tolerance (comma separated), methods: dp, vw, topology, tolerance in tile units (4096 per tile), the layer * applies to all others")
 	flag.Parse()
 
 	if len(*source) == 0 {
@@ -100,10 +149,28 @@ func main() {
 		sourceStdIn = true
 	}
 
+	if sourceStdIn && len(*indexPath) != 0 {
+		log.Fatal("an index can only be used together with an input file")
+	}
+
+	var clip *spatial.BBox
+	if bounds != (bbox{}) {
+		if sourceStdIn {
+			log.Fatal("a bbox can only be used together with an input file")
+		}
+		clip = (*spatial.BBox)(&bounds)
+	}
+
 	if len(zoomlevels) == 0 {
 		log.Fatal("no zoom levels specified")
 	}
 
+	switch *invalidMode {
+	case "keep", "repair", "skip":
+	default:
+		log.Fatalf("invalid mode '%s', available: keep, repair, skip", *invalidMode)
+	}
+
 	if len(*cpuProfile) != 0 {
 		f, err := os.Create(*cpuProfile)
 		if err != nil {
@@ -116,7 +183,7 @@ func main() {
 	if *geojsonCodec {
 		tileCodec = &tile.GeoJSONCodec{}
 	} else {
-		tileCodec = &mvt.Codec{}
+		tileCodec = &mvt.Codec{ZAttribute: *zAttribute, Simplify: simplify}
 	}
 
 	var (
@@ -156,17 +223,30 @@ func main() {
 		tw = &diskTileWriter{basedir: *target, compressTiles: *compressTiles}
 	}
 
-	log.Println("Preparing feature table...")
+	dlm := defaultLayerMapper{defaultLayer: *defaultLayer}
 
-	cinit, ok := caches[*cacheStrategy]
-	if !ok {
-		log.Fatalf("invalid cache strategy name '%s', available: %v", *cacheStrategy, availableCaches())
-	} else if !*quiet {
-		log.Printf("Using %s cache", *cacheStrategy)
-	}
-	ft, err := cinit(zoomlevels)
-	if err != nil {
-		log.Fatal(err)
+	var ft FeatureCache
+	if len(*indexPath) != 0 {
+		log.Println("Opening index...")
+		ft, err = newIndexedCache(*source, *indexPath, func(feat spatial.Feature) ([]spatial.Feature, bool) {
+			return prepareFeature(feat, *invalidMode, *labelSuffix, &dlm)
+		})
+		if err != nil {
+			log.Fatal(err)
+		}
+	} else {
+		log.Println("Preparing feature table...")
+
+		cinit, ok := caches[*cacheStrategy]
+		if !ok {
+			log.Fatalf("invalid cache strategy name '%s', available: %v", *cacheStrategy, availableCaches())
+		} else if !*quiet {
+			log.Printf("Using %s cache", *cacheStrategy)
+		}
+		ft, err = cinit(zoomlevels)
+		if err != nil {
+			log.Fatal(err)
+		}
 	}
 
 	defer func(ft FeatureCache) {
@@ -178,35 +258,25 @@ func main() {
 	}(ft)
 	showMemStats()
 
-	log.Println("Parsing input...")
-
-	var codec spaten.Codec
-	cd, err := codec.ChunkedDecode(f)
-	if err != nil {
-		log.Fatalf("Could not read incoming file: %v", err)
-	}
-
-	var fc spatial.FeatureCollection
-	for cd.Next() {
-		cd.Scan(&fc)
-		for _, feat := range fc.Features {
-			ft.AddFeature(feat)
-		}
-		fc.Reset()
+	if len(*indexPath) == 0 {
+		log.Println("Parsing input...")
+		readFeatures(f, clip, ft, *invalidMode, *labelSuffix, &dlm)
 	}
 	log.Printf("%v feature are in-cache", ft.Count())
 	showMemStats()
 
 	log.Println("Determining which tiles need to be generated")
 	var tc []tile.ID
+	coverageBBox := ft.BBox()
+	if clip != nil {
+		coverageBBox = *clip
+	}
 	for _, zoomlevel := range zoomlevels {
-		tc = append(tc, tile.Coverage(ft.BBox(), zoomlevel)...)
+		tc = append(tc, tile.Coverage(coverageBBox, zoomlevel)...)
 	}
 
 	log.Printf("Starting to generate %d tiles...", len(tc))
 
-	dlm := defaultLayerMapper{defaultLayer: *defaultLayer}
-
 	shuffleWork(tc) // randomize order for better worker saturation
 	var (
 		wg       sync.WaitGroup
@@ -222,6 +292,9 @@ func main() {
 	}
 	wg.Wait()
 	done()
+	if ic, ok := ft.(*IndexedCache); ok {
+		logInvalid(ic.invalidCounts())
+	}
 
 	showMemStats()
 	log.Println("Done.")
@@ -233,13 +306,10 @@ func renderable(props map[string]interface{}, zl int) bool {
 		if !ok {
 			return defaultVal
 		}
-		i, ok := v.(int)
-		if ok {
-			return i
-		}
-		f, ok := v.(float64)
-		if ok {
-			return int(f)
+		if val, err := spatial.ValueOf(v); err == nil {
+			if f, ok := val.Float(); ok {
+				return int(f)
+			}
 		}
 		log.Printf("%v is neither int nor float: %v", props, v)
 		return defaultVal
@@ -307,6 +377,26 @@ func (dlm *defaultLayerMapper) LayerName(props map[string]interface{}) string {
 	return ""
 }
 
+// labelFeature returns a point at the pole of inaccessibility of polygons, which has the
+// ID and properties of the polygon and is put into the polygon's layer with the suffix appended.
+func labelFeature(feat spatial.Feature, lm layerMapper, suffix string) (spatial.Feature, bool) {
+	switch feat.Geometry.Typ() {
+	case spatial.GeomTypePolygon, spatial.GeomTypeMultiPolygon:
+	default:
+		return spatial.Feature{}, false
+	}
+	layer := lm.LayerName(feat.Props)
+	if len(layer) == 0 {
+		return spatial.Feature{}, false
+	}
+	props := make(map[string]interface{}, len(feat.Props)+1)
+	for k, v := range feat.Props {
+		props[k] = v
+	}
+	props["@layer"] = layer + suffix
+	return spatial.Feature{ID: feat.ID, Props: props, Geometry: feat.Geometry.PoleOfInaccessibility(0)}, true
+}
+
 type layerMapper interface {
 	LayerName(map[string]interface{}) string
 }
@@ -315,6 +405,84 @@ type tileWriter interface {
 	WriteTile(tile.ID, []byte, string) error
 }
 
+// readFeatures adds all features of a Spaten file to the cache. If clip is set, only features
+// which intersect with it are read, r needs to be seekable then.
+func readFeatures(r io.Reader, clip *spatial.BBox, ft FeatureCache, invalidMode, labelSuffix string, lm layerMapper) {
+	var (
+		codec spaten.Codec
+		cd    spatial.Chunks
+		err   error
+	)
+	if clip != nil {
+		cd, err = codec.ChunkedDecodeBBox(r.(io.ReadSeeker), *clip)
+	} else {
+		cd, err = codec.ChunkedDecode(r)
+	}
+	if err != nil {
+		log.Fatalf("Could not read incoming file: %v", err)
+	}
+
+	var (
+		fc                spatial.FeatureCollection
+		skipped, repaired int
+	)
+	for cd.Next() {
+		cd.Scan(&fc)
+		for _, feat := range fc.Features {
+			if feat.Geometry.IsEmpty() {
+				// there is nothing to render
+				continue
+			}
+			fts, rep := prepareFeature(feat, invalidMode, labelSuffix, lm)
+			if len(fts) == 0 {
+				skipped++
+				continue
+			}
+			if rep {
+				repaired++
+			}
+			for _, pft := range fts {
+				if err = ft.AddFeature(pft); err != nil {
+					log.Fatal(err)
+				}
+			}
+		}
+		fc.Reset()
+	}
+	logInvalid(repaired, skipped)
+}
+
+func logInvalid(repaired, skipped int) {
+	if skipped+repaired > 0 {
+		log.Printf("%v invalid features have been repaired, %v skipped", repaired, skipped)
+	}
+}
+
+// prepareFeature splits geometries which cross the antimeridian, handles invalid geometries
+// according to invalidMode and adds a label point, if labelSuffix is set. No features are
+// returned if the feature is skipped, repaired reports whether its geometry has been repaired.
+func prepareFeature(feat spatial.Feature, invalidMode, labelSuffix string, lm layerMapper) (fts []spatial.Feature, repaired bool) {
+	feat.Geometry = feat.Geometry.SplitAntimeridian()
+	if invalidMode != "keep" && len(feat.Geometry.Validate()) != 0 {
+		if invalidMode == "skip" {
+			return nil, false
+		}
+		g, err := feat.Geometry.MakeValid()
+		if err != nil || g.IsEmpty() {
+			return nil, false
+		}
+		feat.Geometry = g
+		repaired = true
+	}
+	fts = []spatial.Feature{feat}
+	if len(labelSuffix) != 0 {
+		if lf, ok := labelFeature(feat, lm, labelSuffix); ok {
+			fts = append(fts, lf)
+		}
+	}
+	return fts, repaired
+}
+
 func generateTiles(tIDs []tile.ID, fts FeatureCache, tw tileWriter, encoder tile.Codec, lm layerMapper, pb chan<- struct{}) {
 	for _, tID := range tIDs {
 		var (
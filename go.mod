@@ -8,7 +8,9 @@ require (
 	github.com/dhconnelly/rtreego v1.0.0
 	github.com/dustin/go-humanize v1.0.0
 	github.com/golang/protobuf v1.4.2
+	github.com/golang/snappy v0.0.1
 	github.com/jmhodges/levigo v1.0.0
+	github.com/klauspost/compress v1.10.3
 	github.com/minio/minio-go/v7 v7.0.2
 	github.com/paulsmith/gogeos v0.1.2 // indirect
 	github.com/pkg/errors v0.8.1
@@ -4,6 +4,7 @@ import (
 	"encoding/csv"
 	"fmt"
 	"io"
+	"math"
 	"strconv"
 
 	"github.com/thomersch/grandine/lib/spatial"
@@ -54,6 +55,9 @@ func (c *Chunks) Scan(fc *spatial.FeatureCollection) error {
 type Codec struct {
 	LatCol, LonCol int
 	Delim          rune
+	// InferTypes converts values into bools and numbers, see inferValue. Otherwise all values
+	// are strings.
+	InferTypes bool
 
 	keys []string
 }
@@ -87,11 +91,39 @@ func (c *Codec) decodeLine(csvr csvReader) (spatial.Feature, error) {
 			// there are more value in this line than header keys
 			continue
 		}
-		ft.Props[c.keys[i]] = val
+		if c.InferTypes {
+			ft.Props[c.keys[i]] = inferValue(val)
+		} else {
+			ft.Props[c.keys[i]] = val
+		}
 	}
 	return ft, nil
 }
 
+// inferValue converts "true" and "false" into bools and numbers into int64, uint64 or float64
+// values. A value is only converted if it is the canonical representation of the result, so no
+// information gets lost, e.g. "007", "1.50" or "1e3" are kept as strings. Empty values stay empty
+// strings.
+func inferValue(s string) interface{} {
+	switch s {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil && strconv.FormatInt(i, 10) == s {
+		return i
+	}
+	if u, err := strconv.ParseUint(s, 10, 64); err == nil && strconv.FormatUint(u, 10) == s {
+		return u
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil && !math.IsInf(f, 0) && !math.IsNaN(f) &&
+		strconv.FormatFloat(f, 'f', -1, 64) == s {
+		return f
+	}
+	return s
+}
+
 func (c *Codec) newReader(r io.Reader) csvReader {
 	csvRdr := csv.NewReader(r)
 	if c.Delim == 0 {
@@ -2,6 +2,7 @@ package csv
 
 import (
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/thomersch/grandine/lib/spatial"
@@ -26,3 +27,42 @@ func TestCSVDecode(t *testing.T) {
 	assert.Equal(t, 1.53414, pt.X)
 	assert.Equal(t, 42.50729, pt.Y)
 }
+
+func TestInferValue(t *testing.T) {
+	for _, tc := range []struct {
+		in       string
+		expected interface{}
+	}{
+		{"", ""},
+		{"abc", "abc"},
+		{"true", true},
+		{"False", "False"},
+		{"42", int64(42)},
+		{"-7", int64(-7)},
+		{"007", "007"},
+		{"+5", "+5"},
+		{"18446744073709551615", uint64(18446744073709551615)},
+		{"1.5", 1.5},
+		{"-0.25", -0.25},
+		{"1.50", "1.50"},
+		{"1e3", "1e3"},
+		{"NaN", "NaN"},
+	} {
+		assert.Equal(t, tc.expected, inferValue(tc.in), tc.in)
+	}
+}
+
+func TestCSVDecodeInferTypes(t *testing.T) {
+	var (
+		csvr  = Codec{LatCol: 1, LonCol: 2, Delim: ',', InferTypes: true}
+		fcoll = spatial.FeatureCollection{}
+	)
+	err := csvr.Decode(strings.NewReader("name,lat,lon,population\nBerlin,52.52,13.405,3645000\n"), &fcoll)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"name":       "Berlin",
+		"lat":        52.52,
+		"lon":        13.405,
+		"population": int64(3645000),
+	}, fcoll.Features[0].Props)
+}
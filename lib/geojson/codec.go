@@ -1,9 +1,11 @@
 package geojson
 
 import (
+	"bytes"
 	"encoding/json"
-	"errors"
+	"fmt"
 	"io"
+	"strconv"
 	"strings"
 
 	"github.com/thomersch/grandine/lib/spatial"
@@ -18,12 +20,13 @@ func (c *Codec) Decode(r io.Reader, fc *spatial.FeatureCollection) error {
 		return err
 	}
 	fc.Features = append(fc.Features, gjfc.Features...)
-	if srid, ok := ogcSRID[gjfc.CRS.Properties.Name]; ok {
+	if name := gjfc.CRS.Properties.Name; len(name) != 0 {
+		srid := sridFromCRS(name)
 		if len(fc.SRID) == 0 {
 			fc.SRID = srid
 		}
-		if len(srid) != 0 && fc.SRID != srid {
-			return errors.New("incompatible projections: ")
+		if fc.SRID != srid {
+			return fmt.Errorf("incompatible projections: %s and %s", fc.SRID, srid)
 		}
 	}
 	return nil
@@ -34,7 +37,7 @@ func (c *Codec) Encode(w io.Writer, fc *spatial.FeatureCollection) error {
 		geojsonFC := featureColl{}
 		geojsonFC.Type = "FeatureCollection"
 		geojsonFC.Features = fc.Features
-		geojsonFC.CRS.Properties.Name, _ = sridOGC[fc.SRID]
+		geojsonFC.CRS.Properties.Name = crsFromSRID(fc.SRID)
 		geojsonFC.CRS.Type = "name"
 		return json.NewEncoder(w).Encode(&geojsonFC)
 	}
@@ -67,26 +70,27 @@ type FeatureProto struct {
 	Geometry struct {
 		Type        string          `json:"type"`
 		Coordinates json.RawMessage `json:"coordinates"`
+		Geometries  json.RawMessage `json:"geometries"`
 	}
-	ID         string
+	ID         json.RawMessage        `json:"id"`
 	Properties map[string]interface{} `json:"properties"`
 }
 
 type FeatList []spatial.Feature
 
+// UnmarshalJSON decodes a list of GeoJSON features. Integral numbers in properties become int64,
+// or uint64 if they are too large, other numbers become float64, see spatial.ValueOf.
 func (fl *FeatList) UnmarshalJSON(buf []byte) error {
 	var fts []FeatureProto
-	err := json.Unmarshal(buf, &fts)
+	dec := json.NewDecoder(bytes.NewReader(buf))
+	dec.UseNumber()
+	err := dec.Decode(&fts)
 	if err != nil {
 		return err
 	}
 
 	*fl = make([]spatial.Feature, 0, len(fts))
 	for _, inft := range fts {
-		if inft.ID != "" {
-			inft.Properties["id"] = inft.ID
-		}
-
 		err = fl.UnmarshalJSONCoords(inft)
 		if err != nil {
 			return err
@@ -96,36 +100,48 @@ func (fl *FeatList) UnmarshalJSON(buf []byte) error {
 }
 
 func (fl *FeatList) UnmarshalJSONCoords(fp FeatureProto) error {
-	var err error
-	if singularType(fp.Geometry.Type) {
-		var ft spatial.Feature
-		ft.Props = fp.Properties
-		err = ft.Geometry.UnmarshalJSONCoords(fp.Geometry.Type, fp.Geometry.Coordinates)
-		if err == spatial.ErrorEmptyGeomType {
-			// TODO: Shall we warn here somehow?
-			return nil
-		}
-		if err != nil {
-			return err
-		}
-		*fl = append(*fl, ft)
-	} else {
-		// Because the lib doesn't have native Multi* types, we split those into single geometries.
-		var singles []json.RawMessage
-		json.Unmarshal(fp.Geometry.Coordinates, &singles)
-		for _, single := range singles {
-			var ft spatial.Feature
-			ft.Props = fp.Properties
-			err = ft.Geometry.UnmarshalJSONCoords(fp.Geometry.Type[5:], single)
-			if err != nil {
-				return err
-			}
-			*fl = append(*fl, ft)
-		}
+	var (
+		ft     spatial.Feature
+		err    error
+		coords = fp.Geometry.Coordinates
+	)
+	if strings.ToLower(fp.Geometry.Type) == "geometrycollection" {
+		coords = fp.Geometry.Geometries
+	}
+	ft.Props = fp.Properties
+	if err = spatial.NormalizeProps(ft.Props); err != nil {
+		return err
 	}
+	if len(fp.ID) != 0 {
+		featureID(fp.ID, &ft)
+	}
+	err = ft.Geometry.UnmarshalJSONCoords(fp.Geometry.Type, coords)
+	if err == spatial.ErrorEmptyGeomType {
+		// TODO: Shall we warn here somehow?
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	*fl = append(*fl, ft)
 	return nil
 }
 
-func singularType(typ string) bool {
-	return !strings.HasPrefix(strings.ToLower(typ), "multi")
+// featureID decodes the GeoJSON id member. Non-negative integers become the feature ID. As
+// Feature.ID is numeric, strings are kept in the "id" property and are only used as ID, if they
+// contain an integer.
+func featureID(raw json.RawMessage, ft *spatial.Feature) {
+	var s string
+	if err := json.Unmarshal(raw, &s); err != nil {
+		ft.ID, _ = strconv.ParseUint(string(raw), 10, 64)
+		return
+	}
+	if s == "" {
+		return
+	}
+	if ft.Props == nil {
+		ft.Props = map[string]interface{}{}
+	}
+	ft.Props["id"] = s
+	ft.ID, _ = strconv.ParseUint(s, 10, 64)
 }
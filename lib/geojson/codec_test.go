@@ -3,6 +3,7 @@ package geojson
 import (
 	"bytes"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/thomersch/grandine/lib/spatial"
@@ -39,6 +40,53 @@ func TestDecodeID(t *testing.T) {
 	assert.NotContains(t, fc.Features[1].Properties(), "id")
 }
 
+func TestDecodeNumericID(t *testing.T) {
+	var (
+		c  = &Codec{}
+		fc = spatial.FeatureCollection{}
+		in = `{"type": "FeatureCollection", "features": [
+			{"type": "Feature", "id": 12, "properties": {}, "geometry": {"type": "Point", "coordinates": [1, 2]}},
+			{"type": "Feature", "id": "34", "geometry": {"type": "Point", "coordinates": [1, 2]}},
+			{"type": "Feature", "id": -1, "properties": {}, "geometry": {"type": "Point", "coordinates": [1, 2]}}
+		]}`
+	)
+	assert.Nil(t, c.Decode(bytes.NewBufferString(in), &fc))
+	assert.Len(t, fc.Features, 3)
+	assert.Equal(t, uint64(12), fc.Features[0].ID)
+	assert.NotContains(t, fc.Features[0].Properties(), "id")
+	assert.Equal(t, uint64(34), fc.Features[1].ID)
+	assert.Equal(t, "34", fc.Features[1].Properties()["id"])
+	assert.Equal(t, uint64(0), fc.Features[2].ID)
+
+	var buf bytes.Buffer
+	assert.Nil(t, c.Encode(&buf, &fc))
+	assert.Contains(t, buf.String(), `"id":12`)
+}
+
+func TestDecodePropertyTypes(t *testing.T) {
+	var (
+		c  = &Codec{}
+		fc = spatial.FeatureCollection{}
+		in = `{"type": "FeatureCollection", "features": [
+			{"type": "Feature", "geometry": {"type": "Point", "coordinates": [1, 2]}, "properties": {
+				"int": 12, "big": 18446744073709551615, "float": 1.5, "exp": 1e3, "null": null, "bool": true,
+				"list": [1, "a"], "object": {"a": 2.5}
+			}}
+		]}`
+	)
+	assert.Nil(t, c.Decode(bytes.NewBufferString(in), &fc))
+	assert.Equal(t, map[string]interface{}{
+		"int":    int64(12),
+		"big":    uint64(18446744073709551615),
+		"float":  1.5,
+		"exp":    1000.0,
+		"null":   nil,
+		"bool":   true,
+		"list":   []interface{}{int64(1), "a"},
+		"object": map[string]interface{}{"a": 2.5},
+	}, fc.Features[0].Props)
+}
+
 func TestDecodeMultipolygon(t *testing.T) {
 	f, err := os.Open("testdata/multipolygon.geojson")
 	assert.Nil(t, err)
@@ -49,7 +97,9 @@ func TestDecodeMultipolygon(t *testing.T) {
 	)
 	err = c.Decode(f, &fc)
 	assert.Nil(t, err)
-	assert.Len(t, fc.Features, 2)
+	assert.Len(t, fc.Features, 1)
+	assert.Equal(t, spatial.GeomTypeMultiPolygon, fc.Features[0].Geometry.Typ())
+	assert.Len(t, fc.Features[0].Geometry.MustMultiPolygon(), 2)
 }
 
 func TestEncode(t *testing.T) {
@@ -63,3 +113,55 @@ func TestEncode(t *testing.T) {
 	err := c.Encode(buf, &fc)
 	assert.Nil(t, err)
 }
+
+func TestDecodeEPSG(t *testing.T) {
+	f, err := os.Open("testdata/epsg3857.geojson")
+	assert.Nil(t, err)
+	defer f.Close()
+
+	var (
+		c  = &Codec{}
+		fc = spatial.FeatureCollection{}
+	)
+	err = c.Decode(f, &fc)
+	assert.Nil(t, err)
+	assert.Equal(t, "3857", fc.SRID)
+
+	fc.SRID = "4326"
+	f.Seek(0, 0)
+	assert.NotNil(t, c.Decode(f, &fc))
+}
+
+func TestCRSNames(t *testing.T) {
+	for name, srid := range map[string]string{
+		"urn:ogc:def:crs:OGC:1.3:CRS84":              "4326",
+		"urn:ogc:def:crs:EPSG::3857":                 "3857",
+		"urn:ogc:def:crs:EPSG:6.6:25832":             "25832",
+		"EPSG:27700":                                 "27700",
+		"urn:ogc:def:crs:OGC::CRS84":                 "4326",
+		"http://www.opengis.net/def/crs/EPSG/0/3857": "3857",
+		// not supported by proj, but still an EPSG code
+		"EPSG:2056": "2056",
+		// unknown names are kept
+		"urn:ogc:def:crs:OGC:1.3:CRS83": "urn:ogc:def:crs:OGC:1.3:CRS83",
+	} {
+		assert.Equal(t, srid, sridFromCRS(name), name)
+	}
+
+	var (
+		buf bytes.Buffer
+		c   Codec
+	)
+	err := c.Encode(&buf, &spatial.FeatureCollection{SRID: "3857"})
+	assert.Nil(t, err)
+	assert.Contains(t, buf.String(), `"name":"urn:ogc:def:crs:EPSG::3857"`)
+
+	// unknown names are decoded and written unchanged
+	var fc spatial.FeatureCollection
+	err = c.Decode(strings.NewReader(`{"type":"FeatureCollection","features":[],"crs":{"type":"name","properties":{"name":"urn:x-local:crs:site"}}}`), &fc)
+	assert.Nil(t, err)
+	assert.Equal(t, "urn:x-local:crs:site", fc.SRID)
+	buf.Reset()
+	assert.Nil(t, c.Encode(&buf, &fc))
+	assert.Contains(t, buf.String(), `"name":"urn:x-local:crs:site"`)
+}
@@ -1,10 +1,35 @@
 package geojson
 
+import (
+	"strconv"
+
+	"github.com/thomersch/grandine/lib/proj"
+)
+
 var (
-	ogcSRID = map[string]string{
-		"urn:ogc:def:crs:OGC:1.3:CRS84": "4326",
-	}
 	sridOGC = map[string]string{
 		"4326": "urn:ogc:def:crs:OGC:1.3:CRS84",
 	}
 )
+
+// sridFromCRS returns the SRID for the name of a crs member. EPSG references in all forms
+// understood by proj.ParseCode become the EPSG code, other names are kept as they are, so
+// only reprojecting fails for them.
+func sridFromCRS(name string) string {
+	if code, err := proj.ParseCode(name); err == nil {
+		return strconv.Itoa(code)
+	}
+	return name
+}
+
+// crsFromSRID returns the name of the crs member for a SRID.
+func crsFromSRID(srid string) string {
+	if name, ok := sridOGC[srid]; ok {
+		return name
+	}
+	if _, err := strconv.Atoi(srid); err != nil {
+		// a name which has been read from a crs member
+		return srid
+	}
+	return "urn:ogc:def:crs:EPSG::" + srid
+}
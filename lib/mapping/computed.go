@@ -0,0 +1,76 @@
+package mapping
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/thomersch/grandine/lib/spatial"
+)
+
+// computedAttrs are the values which are calculated from the geometry instead of being taken
+// from the source properties. They are referenced like properties, e.g. "$area_m2".
+var computedAttrs = map[string]func(spatial.Geom) float64{
+	"area_m2":     spatial.Geom.Area,
+	"length_m":    spatial.Geom.Length,
+	"perimeter_m": spatial.Geom.Perimeter,
+}
+
+// Compute returns the properties with the computed attributes of the condition, which are
+// calculated from the given geometry. If there are none, props is returned unchanged.
+func (c *Condition) Compute(props map[string]interface{}, g spatial.Geom) map[string]interface{} {
+	if len(c.computed) == 0 {
+		return props
+	}
+	var vals = make(map[string]interface{}, len(props)+len(c.computed))
+	for k, v := range props {
+		vals[k] = v
+	}
+	for keyName, field := range c.computed {
+		v := computedAttrs[field.Name](g)
+		if field.Typ == mapTypeInt {
+			vals[keyName] = int(math.Round(v))
+		} else {
+			vals[keyName] = v
+		}
+	}
+	return vals
+}
+
+// filter restricts a computed attribute to a range, bounds are inclusive.
+type filter struct {
+	attr     string
+	min, max float64
+}
+
+func parseFilters(ffs []fileFilter) ([]filter, error) {
+	var filters []filter
+	for _, ff := range ffs {
+		if len(ff.Key) == 0 || ff.Key[0] != '$' || computedAttrs[ff.Key[1:]] == nil {
+			return nil, fmt.Errorf("%s is not a computed attribute (allowed values: $area_m2, $length_m, $perimeter_m)", ff.Key)
+		}
+		if ff.Min == nil && ff.Max == nil {
+			return nil, errors.New("min or max is required")
+		}
+		f := filter{attr: ff.Key[1:], min: math.Inf(-1), max: math.Inf(1)}
+		if ff.Min != nil {
+			f.min = *ff.Min
+		}
+		if ff.Max != nil {
+			f.max = *ff.Max
+		}
+		filters = append(filters, f)
+	}
+	return filters, nil
+}
+
+// Accepts reports whether the computed attributes of a geometry are within the ranges of the
+// filters of the condition. Conditions without filters accept all geometries.
+func (c *Condition) Accepts(g spatial.Geom) bool {
+	for _, f := range c.filters {
+		if v := computedAttrs[f.attr](g); v < f.min || v > f.max {
+			return false
+		}
+	}
+	return true
+}
@@ -11,6 +11,10 @@ type Condition struct {
 	value  []string
 	mapper tagMapFn
 	op     geomOp
+	// computed maps destination keys to attributes which are calculated from the geometry
+	computed map[string]typedField
+	// filters restrict the computed attributes of matching geometries, see Accepts
+	filters []filter
 }
 
 func (c *Condition) Matches(kv map[string]interface{}) bool {
@@ -33,20 +37,27 @@ func (c *Condition) Map(kv map[string]interface{}) map[string]interface{} {
 }
 
 // Transform applies property mapping and performs geometry operations.
-// Can emit multiple features, depending on the operation.
+// Can emit multiple features, depending on the operation. Computed attributes are calculated
+// from the resulting geometries, which are dropped if they are not accepted by the filters.
 func (c *Condition) Transform(f spatial.Feature) []spatial.Feature {
-	if c.op == nil {
-		return []spatial.Feature{
-			{Props: c.Map(f.Props), Geometry: f.Geometry},
-		}
-	}
-
 	var (
 		fts   []spatial.Feature
 		props = c.Map(f.Props)
 	)
-	for _, ng := range c.op(f.Geometry) {
-		fts = append(fts, spatial.Feature{Props: props, Geometry: ng})
+	for _, ng := range c.Apply(f.Geometry) {
+		if !c.Accepts(ng) {
+			continue
+		}
+		fts = append(fts, spatial.Feature{ID: f.ID, Props: c.Compute(props, ng), Geometry: ng})
 	}
 	return fts
 }
+
+// Apply performs the geometry operation of the condition. If there is none, the geometry is
+// returned unchanged.
+func (c *Condition) Apply(g spatial.Geom) []spatial.Geom {
+	if c.op == nil {
+		return []spatial.Geom{g}
+	}
+	return c.op(g)
+}
@@ -50,20 +50,20 @@ var (
 	}
 
 	Default = []Condition{
-		{"aeroway", []string{"aerodrome"}, aerowayMapFn, nil},
-		{"aeroway", []string{"apron"}, aerowayMapFn, nil},
-		{"aeroway", []string{"heliport"}, aerowayMapFn, nil},
-		{"aeroway", []string{"runway"}, aerowayMapFn, nil},
-		{"aeroway", []string{"helipad"}, aerowayMapFn, nil},
-		{"aeroway", []string{"taxiway"}, aerowayMapFn, nil},
-		{"highway", []string{"motorway"}, transportationMapFn, nil},
-		{"highway", []string{"primary"}, transportationMapFn, nil},
-		{"highway", []string{"trunk"}, transportationMapFn, nil},
-		{"highway", []string{"secondary"}, transportationMapFn, nil},
-		{"highway", []string{"tertiary"}, transportationMapFn, nil},
-		{"building", []string{""}, buildingMapFn, nil},
-		{"landuse", []string{"forest"}, landuseMapFn, nil},
-		{"railway", []string{"rail"}, transportationMapFn, nil},
-		{"waterway", []string{"river"}, waterwayMapFn, nil},
+		{"aeroway", []string{"aerodrome"}, aerowayMapFn, nil, nil, nil},
+		{"aeroway", []string{"apron"}, aerowayMapFn, nil, nil, nil},
+		{"aeroway", []string{"heliport"}, aerowayMapFn, nil, nil, nil},
+		{"aeroway", []string{"runway"}, aerowayMapFn, nil, nil, nil},
+		{"aeroway", []string{"helipad"}, aerowayMapFn, nil, nil, nil},
+		{"aeroway", []string{"taxiway"}, aerowayMapFn, nil, nil, nil},
+		{"highway", []string{"motorway"}, transportationMapFn, nil, nil, nil},
+		{"highway", []string{"primary"}, transportationMapFn, nil, nil, nil},
+		{"highway", []string{"trunk"}, transportationMapFn, nil, nil, nil},
+		{"highway", []string{"secondary"}, transportationMapFn, nil, nil, nil},
+		{"highway", []string{"tertiary"}, transportationMapFn, nil, nil, nil},
+		{"building", []string{""}, buildingMapFn, nil, nil, nil},
+		{"landuse", []string{"forest"}, landuseMapFn, nil, nil, nil},
+		{"railway", []string{"rail"}, transportationMapFn, nil, nil, nil},
+		{"waterway", []string{"river"}, waterwayMapFn, nil, nil, nil},
 	}
 )
@@ -7,6 +7,8 @@ import (
 	"log"
 	"strconv"
 
+	"github.com/thomersch/grandine/lib/spatial"
+
 	yaml "gopkg.in/yaml.v2"
 )
 
@@ -17,9 +19,17 @@ type fileMapKV struct {
 }
 
 type fileMap struct {
-	Src  fileMapKV   `yaml:"src"`
-	Dest []fileMapKV `yaml:"dest"`
-	Op   string      `yaml:"op"`
+	Src    fileMapKV              `yaml:"src"`
+	Filter []fileFilter           `yaml:"filter"`
+	Dest   []fileMapKV            `yaml:"dest"`
+	Op     string                 `yaml:"op"`
+	Args   map[string]interface{} `yaml:"args"`
+}
+
+type fileFilter struct {
+	Key string   `yaml:"key"`
+	Min *float64 `yaml:"min"`
+	Max *float64 `yaml:"max"`
 }
 
 type fileMappings []fileMap
@@ -68,24 +78,41 @@ func ParseMapping(r io.Reader) ([]Condition, error) {
 		}
 
 		var (
-			staticKV  = map[string]interface{}{}
-			dynamicKV = map[string]typedField{}
+			staticKV   = map[string]interface{}{}
+			dynamicKV  = map[string]typedField{}
+			computedKV = map[string]typedField{}
 		)
 		for _, kvm := range fm.Dest {
+			if _, ok := computedKV[kvm.Key]; ok {
+				return nil, fmt.Errorf("destination key %s of source key %s is also a computed attribute", kvm.Key, fm.Src.Key)
+			}
 			if dv, ok := kvm.Value.(string); !ok {
 				staticKV[kvm.Key] = kvm.Value
 			} else {
 				if dv[0:1] != "$" {
 					staticKV[kvm.Key] = dv
+				} else if _, ok := computedAttrs[dv[1:]]; ok {
+					_, static := staticKV[kvm.Key]
+					_, dynamic := dynamicKV[kvm.Key]
+					if static || dynamic {
+						return nil, fmt.Errorf("destination key %s of source key %s is also a computed attribute", kvm.Key, fm.Src.Key)
+					}
+					computedKV[kvm.Key] = typedField{Name: dv[1:], Typ: kvm.Typ}
 				} else {
 					// TODO: this can probably be optimized by generating more specific methods at parse time
 					dynamicKV[kvm.Key] = typedField{Name: dv[1:], Typ: kvm.Typ}
 				}
 			}
 		}
+		filters, err := parseFilters(fm.Filter)
+		if err != nil {
+			return nil, fmt.Errorf("filter for key %s: %v", fm.Src.Key, err)
+		}
 		cond := Condition{
-			key:   fm.Src.Key,
-			value: sv,
+			key:      fm.Src.Key,
+			value:    sv,
+			computed: computedKV,
+			filters:  filters,
 		}
 		if len(dynamicKV) == 0 {
 			sm := staticMapper{staticElems: staticKV}
@@ -96,8 +123,26 @@ func ParseMapping(r io.Reader) ([]Condition, error) {
 		}
 
 		switch fm.Op {
+		case "":
 		case "lines":
 			cond.op = polyToLines
+		case "buffer":
+			cond.op, err = bufferOp(fm.Args)
+			if err != nil {
+				return nil, fmt.Errorf("buffer operation for key %s: %v", fm.Src.Key, err)
+			}
+		case "label_point":
+			cond.op, err = labelPointOp(fm.Args)
+			if err != nil {
+				return nil, fmt.Errorf("label_point operation for key %s: %v", fm.Src.Key, err)
+			}
+		case "densify":
+			cond.op, err = densifyOp(fm.Args)
+			if err != nil {
+				return nil, fmt.Errorf("densify operation for key %s: %v", fm.Src.Key, err)
+			}
+		default:
+			return nil, fmt.Errorf("unknown op: %s (allowed values: lines, buffer, label_point, densify)", fm.Op)
 		}
 
 		conds = append(conds, cond)
@@ -158,9 +203,12 @@ func (dm *dynamicMapper) toInt(i interface{}) (int, error) {
 			return 0, nil
 		}
 		return 0, err
-	case int:
-		return v, nil
 	default:
+		if val, err := spatial.ValueOf(v); err == nil {
+			if k, ok := val.Int(); ok {
+				return int(k), nil
+			}
+		}
 		return 0, fmt.Errorf("cannot convert %v (type %T) to int", v, v)
 	}
 }
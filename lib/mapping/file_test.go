@@ -2,8 +2,11 @@ package mapping
 
 import (
 	"os"
+	"strings"
 	"testing"
 
+	"github.com/thomersch/grandine/lib/spatial"
+
 	"github.com/stretchr/testify/assert"
 )
 
@@ -63,3 +66,173 @@ func TestParseMapping(t *testing.T) {
 	}
 	assert.True(t, conds[3].Matches(srcKV))
 }
+
+func TestParseMappingLines(t *testing.T) {
+	f, err := os.Open("mapping.yml")
+	assert.Nil(t, err)
+
+	conds, err := ParseMapping(f)
+	assert.Nil(t, err)
+
+	track := spatial.MustNewGeom(spatial.Line{{0, 0}, {1, 0}, {2, 1}})
+	fts := conds[2].Transform(spatial.Feature{
+		Props:    map[string]interface{}{"railway": "rail"},
+		Geometry: track,
+	})
+	assert.Len(t, fts, 1)
+	assert.Equal(t, track, fts[0].Geometry)
+
+	platform := spatial.MustNewGeom(spatial.Polygon{
+		{{0, 0}, {4, 0}, {4, 4}, {0, 4}},
+		{{1, 1}, {1, 2}, {2, 2}, {2, 1}},
+	})
+	fts = conds[2].Transform(spatial.Feature{
+		Props:    map[string]interface{}{"railway": "platform"},
+		Geometry: platform,
+	})
+	assert.Len(t, fts, 2)
+	for _, ft := range fts {
+		assert.Equal(t, spatial.GeomTypeLineString, ft.Geometry.Typ())
+	}
+
+	fts = conds[2].Transform(spatial.Feature{
+		Props:    map[string]interface{}{"railway": "station"},
+		Geometry: spatial.MustNewGeom(spatial.Point{1, 1}),
+	})
+	assert.Len(t, fts, 0)
+}
+
+func TestParseMappingBuffer(t *testing.T) {
+	f, err := os.Open("mapping.yml")
+	assert.Nil(t, err)
+
+	conds, err := ParseMapping(f)
+	assert.Nil(t, err)
+
+	fts := conds[4].Transform(spatial.Feature{
+		Props:    map[string]interface{}{"amenity": "school"},
+		Geometry: spatial.MustNewGeom(spatial.Point{13.4, 52.5}),
+	})
+	assert.Len(t, fts, 1)
+	assert.Equal(t, map[string]interface{}{"@layer": "catchment"}, fts[0].Props)
+	assert.Equal(t, spatial.GeomTypePolygon, fts[0].Geometry.Typ())
+
+}
+
+func TestParseMappingComputed(t *testing.T) {
+	f, err := os.Open("mapping.yml")
+	assert.Nil(t, err)
+
+	conds, err := ParseMapping(f)
+	assert.Nil(t, err)
+
+	// roughly 111 m x 111 m
+	lake := spatial.MustNewGeom(spatial.Polygon{{{0, 0}, {0.001, 0}, {0.001, 0.001}, {0, 0.001}}})
+	fts := conds[5].Transform(spatial.Feature{
+		Props:    map[string]interface{}{"natural": "water"},
+		Geometry: lake,
+	})
+	assert.Len(t, fts, 1)
+	assert.Equal(t, map[string]interface{}{"@layer": "water", "area": 12309}, fts[0].Props)
+
+	// the static properties of the mapping must not be modified
+	assert.Equal(t, map[string]interface{}{"@layer": "water"}, conds[5].Map(fts[0].Props))
+}
+
+func TestParseMappingFilter(t *testing.T) {
+	conds, err := ParseMapping(strings.NewReader(`[
+		{src: {key: natural, value: water}, filter: [{key: $area_m2, min: 10000}], dest: [{key: "@layer", value: water}]},
+		{src: {key: natural, value: water}, filter: [{key: $area_m2, min: 1000, max: 10000}]}
+	]`))
+	assert.Nil(t, err)
+
+	// roughly 111 m x 111 m and 11 m x 11 m
+	lake := spatial.MustNewGeom(spatial.Polygon{{{0, 0}, {0.001, 0}, {0.001, 0.001}, {0, 0.001}}})
+	pond := spatial.MustNewGeom(spatial.Polygon{{{0, 0}, {0.0001, 0}, {0.0001, 0.0001}, {0, 0.0001}}})
+
+	assert.True(t, conds[0].Accepts(lake))
+	assert.False(t, conds[0].Accepts(pond))
+	assert.False(t, conds[1].Accepts(lake))
+	assert.False(t, conds[1].Accepts(pond))
+
+	fts := conds[0].Transform(spatial.Feature{Props: map[string]interface{}{"natural": "water"}, Geometry: lake})
+	assert.Len(t, fts, 1)
+	fts = conds[0].Transform(spatial.Feature{Props: map[string]interface{}{"natural": "water"}, Geometry: pond})
+	assert.Len(t, fts, 0)
+}
+
+func TestParseMappingInvalidComputed(t *testing.T) {
+	for _, m := range []string{
+		`[{src: {key: a, value: b}, filter: [{key: $name, min: 1}]}]`,
+		`[{src: {key: a, value: b}, filter: [{key: area_m2, min: 1}]}]`,
+		`[{src: {key: a, value: b}, filter: [{key: $area_m2}]}]`,
+		`[{src: {key: a, value: b}, dest: [{key: area, value: $area_m2}, {key: area, value: $area}]}]`,
+		`[{src: {key: a, value: b}, dest: [{key: area, value: large}, {key: area, value: $area_m2}]}]`,
+		`[{src: {key: a, value: b}, dest: [{key: size, value: $area_m2}, {key: size, value: $length_m}]}]`,
+	} {
+		_, err := ParseMapping(strings.NewReader(m))
+		assert.NotNil(t, err, m)
+	}
+}
+
+func TestParseMappingLabelPoint(t *testing.T) {
+	conds, err := ParseMapping(strings.NewReader(`[
+		{src: {key: leisure, value: park}, dest: [{key: name, value: $name}], op: label_point},
+		{src: {key: leisure, value: park}, op: label_point, args: {method: centroid}}
+	]`))
+	assert.Nil(t, err)
+
+	park := spatial.MustNewGeom(spatial.Polygon{{{0, 0}, {6, 0}, {6, 6}, {4, 6}, {4, 2}, {2, 2}, {2, 6}, {0, 6}}})
+	fts := conds[0].Transform(spatial.Feature{
+		Props:    map[string]interface{}{"leisure": "park", "name": "U Park"},
+		Geometry: park,
+	})
+	assert.Len(t, fts, 1)
+	assert.Equal(t, map[string]interface{}{"name": "U Park"}, fts[0].Props)
+	assert.Equal(t, spatial.GeomTypePoint, fts[0].Geometry.Typ())
+	pt := fts[0].Geometry.MustPoint()
+	assert.True(t, pt.InPolygon(park.MustPolygon()))
+
+	fts = conds[1].Transform(spatial.Feature{Props: map[string]interface{}{}, Geometry: park})
+	assert.Equal(t, park.Centroid(), fts[0].Geometry)
+}
+
+func TestParseMappingDensify(t *testing.T) {
+	conds, err := ParseMapping(strings.NewReader(`[
+		{src: {key: route, value: ferry}, op: densify, args: {max_length: 0.5}},
+		{src: {key: aeroway, value: route}, op: densify, args: {max_length: 100000, geodesic: true}}
+	]`))
+	assert.Nil(t, err)
+
+	fts := conds[0].Transform(spatial.Feature{
+		Props:    map[string]interface{}{"route": "ferry"},
+		Geometry: spatial.MustNewGeom(spatial.Line{{0, 0}, {2, 0}}),
+	})
+	assert.Len(t, fts, 1)
+	assert.Equal(t, spatial.MustNewGeom(spatial.Line{{0, 0}, {0.5, 0}, {1, 0}, {1.5, 0}, {2, 0}}), fts[0].Geometry)
+
+	fts = conds[1].Transform(spatial.Feature{
+		Props:    map[string]interface{}{"aeroway": "route"},
+		Geometry: spatial.MustNewGeom(spatial.Line{{8.5706, 50.0333}, {-73.7789, 40.6397}}),
+	})
+	assert.Len(t, fts, 1)
+	assert.True(t, len(fts[0].Geometry.MustLineString()) > 60)
+}
+
+func TestParseMappingInvalidOp(t *testing.T) {
+	for _, m := range []string{
+		`[{src: {key: a, value: b}, op: explode}]`,
+		`[{src: {key: a, value: b}, op: buffer}]`,
+		`[{src: {key: a, value: b}, op: buffer, args: {distance: ten}}]`,
+		`[{src: {key: a, value: b}, op: buffer, args: {distance: 1, cap: pointy}}]`,
+		`[{src: {key: a, value: b}, op: buffer, args: {distance: 1, width: 2}}]`,
+		`[{src: {key: a, value: b}, op: label_point, args: {method: middle}}]`,
+		`[{src: {key: a, value: b}, op: label_point, args: {precision: high}}]`,
+		`[{src: {key: a, value: b}, op: densify}]`,
+		`[{src: {key: a, value: b}, op: densify, args: {max_length: -1}}]`,
+		`[{src: {key: a, value: b}, op: densify, args: {max_length: 1, geodesic: yes please}}]`,
+	} {
+		_, err := ParseMapping(strings.NewReader(m))
+		assert.NotNil(t, err, m)
+	}
+}
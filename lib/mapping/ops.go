@@ -1,15 +1,199 @@
 package mapping
 
-import "github.com/thomersch/grandine/lib/spatial"
+import (
+	"fmt"
+	"log"
 
+	"github.com/thomersch/grandine/lib/spatial"
+)
+
+// polyToLines converts the rings of polygons into line strings. Line strings are passed through,
+// other geometries are dropped.
 func polyToLines(g spatial.Geom) []spatial.Geom {
-	poly, err := g.Polygon()
-	if err != nil {
+	var polys []spatial.Polygon
+	switch g.Typ() {
+	case spatial.GeomTypeLineString, spatial.GeomTypeMultiLineString:
+		return []spatial.Geom{g}
+	case spatial.GeomTypePolygon:
+		polys = []spatial.Polygon{g.MustPolygon()}
+	case spatial.GeomTypeMultiPolygon:
+		polys = g.MustMultiPolygon()
+	default:
 		return nil
 	}
-	var lines = make([]spatial.Geom, 0, len(poly))
-	for _, ring := range poly {
-		lines = append(lines, spatial.MustNewGeom(ring))
+	var lines []spatial.Geom
+	for _, poly := range polys {
+		for _, ring := range poly {
+			lines = append(lines, spatial.MustNewGeom(ring))
+		}
 	}
 	return lines
 }
+
+// bufferOp creates an operation which buffers geometries. The arguments are:
+// distance (required), cap (round, flat, square), join (round, mitre), mitre_limit, segments
+// (per quarter circle) and geodesic (distance in meters for WGS84 coordinates).
+func bufferOp(args map[string]interface{}) (geomOp, error) {
+	var (
+		opts     spatial.BufferOptions
+		distance float64
+		hasDist  bool
+		err      error
+	)
+	for k, v := range args {
+		switch k {
+		case "distance":
+			distance, err = argFloat(k, v)
+			hasDist = true
+		case "cap":
+			switch v {
+			case "round":
+				opts.Cap = spatial.CapRound
+			case "flat":
+				opts.Cap = spatial.CapFlat
+			case "square":
+				opts.Cap = spatial.CapSquare
+			default:
+				err = fmt.Errorf("unknown cap: %v (allowed values: round, flat, square)", v)
+			}
+		case "join":
+			switch v {
+			case "round":
+				opts.Join = spatial.JoinRound
+			case "mitre":
+				opts.Join = spatial.JoinMitre
+			default:
+				err = fmt.Errorf("unknown join: %v (allowed values: round, mitre)", v)
+			}
+		case "mitre_limit":
+			opts.MitreLimit, err = argFloat(k, v)
+		case "segments":
+			var segs float64
+			segs, err = argFloat(k, v)
+			opts.QuadrantSegments = int(segs)
+		case "geodesic":
+			var ok bool
+			if opts.Geodesic, ok = v.(bool); !ok {
+				err = fmt.Errorf("geodesic must be a boolean (has: %v)", v)
+			}
+		default:
+			err = fmt.Errorf("unknown argument: %s", k)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if !hasDist {
+		return nil, fmt.Errorf("distance is required")
+	}
+
+	return func(g spatial.Geom) []spatial.Geom {
+		bg, err := g.Buffer(distance, opts)
+		if err != nil {
+			log.Println(err)
+			return nil
+		}
+		if bg.Typ() == spatial.GeomTypeEmpty {
+			return nil
+		}
+		return []spatial.Geom{bg}
+	}, nil
+}
+
+// densifyOp creates an operation which inserts vertices into long segments. The arguments are:
+// max_length (required, in units of the coordinates) and geodesic (max_length in meters, new
+// vertices on great circles for WGS84 coordinates).
+func densifyOp(args map[string]interface{}) (geomOp, error) {
+	var (
+		maxLength float64
+		hasMax    bool
+		geodesic  bool
+		err       error
+	)
+	for k, v := range args {
+		switch k {
+		case "max_length":
+			maxLength, err = argFloat(k, v)
+			if err == nil && maxLength <= 0 {
+				err = fmt.Errorf("max_length must be positive (has: %v)", v)
+			}
+			hasMax = true
+		case "geodesic":
+			var ok bool
+			if geodesic, ok = v.(bool); !ok {
+				err = fmt.Errorf("geodesic must be a boolean (has: %v)", v)
+			}
+		default:
+			err = fmt.Errorf("unknown argument: %s", k)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if !hasMax {
+		return nil, fmt.Errorf("max_length is required")
+	}
+
+	return func(g spatial.Geom) []spatial.Geom {
+		if geodesic {
+			return []spatial.Geom{g.DensifyGeodesic(maxLength)}
+		}
+		return []spatial.Geom{g.Densify(maxLength)}
+	}, nil
+}
+
+func argFloat(name string, v interface{}) (float64, error) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), nil
+	case float64:
+		return n, nil
+	}
+	return 0, fmt.Errorf("%s must be a number (has: %v)", name, v)
+}
+
+// labelPointOp creates an operation which replaces polygons by a single point, e.g. for
+// placing labels. The arguments are: method (pole_of_inaccessibility (default), centroid,
+// point_on_surface) and precision (of the pole of inaccessibility, in units of the
+// coordinates).
+func labelPointOp(args map[string]interface{}) (geomOp, error) {
+	var (
+		method    = "pole_of_inaccessibility"
+		precision float64
+		err       error
+	)
+	for k, v := range args {
+		switch k {
+		case "method":
+			switch v {
+			case "pole_of_inaccessibility", "centroid", "point_on_surface":
+				method = v.(string)
+			default:
+				err = fmt.Errorf("unknown method: %v (allowed values: pole_of_inaccessibility, centroid, point_on_surface)", v)
+			}
+		case "precision":
+			precision, err = argFloat(k, v)
+		default:
+			err = fmt.Errorf("unknown argument: %s", k)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return func(g spatial.Geom) []spatial.Geom {
+		var pt spatial.Geom
+		switch method {
+		case "centroid":
+			pt = g.Centroid()
+		case "point_on_surface":
+			pt = g.PointOnSurface()
+		default:
+			pt = g.PoleOfInaccessibility(precision)
+		}
+		if pt.Typ() == spatial.GeomTypeEmpty {
+			return nil
+		}
+		return []spatial.Geom{pt}
+	}, nil
+}
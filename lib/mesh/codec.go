@@ -0,0 +1,87 @@
+// Package mesh writes polygons as triangle meshes, which can be loaded into 3D scenes, e.g.
+// building footprints which are extruded by the consumer.
+package mesh
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/thomersch/grandine/lib/spatial"
+)
+
+// Codec encodes features as indexed triangles in JSON:
+//
+//	{
+//		"type": "TriangleMesh",
+//		"features": [{
+//			"properties": {"height": 12},
+//			"dimensions": 2,
+//			"vertices": [0, 0, 1, 0, 1, 1, 0, 1],
+//			"indices": [3, 0, 1, 1, 2, 3]
+//		}]
+//	}
+//
+// Vertices are flattened, every vertex has as many coordinates as dimensions, which is 3 if
+// the geometry has Z ordinates. Every three indices into the vertices form a counter-clockwise
+// triangle. Only polygons are written, other geometries are skipped.
+type Codec struct{}
+
+type meshCollection struct {
+	Type     string        `json:"type"`
+	SRID     string        `json:"srid,omitempty"`
+	Features []meshFeature `json:"features"`
+}
+
+type meshFeature struct {
+	Properties map[string]interface{} `json:"properties"`
+	Dimensions int                    `json:"dimensions"`
+	Vertices   []float64              `json:"vertices"`
+	Indices    []int                  `json:"indices"`
+}
+
+func (c *Codec) Encode(w io.Writer, fc *spatial.FeatureCollection) error {
+	mc := meshCollection{
+		Type:     "TriangleMesh",
+		SRID:     fc.SRID,
+		Features: []meshFeature{},
+	}
+	for _, ft := range fc.Features {
+		if mf, ok := newMeshFeature(ft); ok {
+			mc.Features = append(mc.Features, mf)
+		}
+	}
+	return json.NewEncoder(w).Encode(&mc)
+}
+
+func newMeshFeature(ft spatial.Feature) (meshFeature, bool) {
+	vertices, indices := ft.Geometry.Triangulate()
+	if len(indices) == 0 {
+		return meshFeature{}, false
+	}
+	var (
+		z  = ft.Geometry.Z()
+		mf = meshFeature{
+			Properties: ft.Props,
+			Dimensions: 2,
+			Indices:    indices,
+		}
+	)
+	if z != nil {
+		mf.Dimensions = 3
+	}
+	mf.Vertices = make([]float64, 0, len(vertices)*mf.Dimensions)
+	for i, v := range vertices {
+		mf.Vertices = append(mf.Vertices, v.X, v.Y)
+		if z != nil {
+			mf.Vertices = append(mf.Vertices, z[i])
+		}
+	}
+	if mf.Properties == nil {
+		mf.Properties = map[string]interface{}{}
+	}
+	return mf, true
+}
+
+func (c *Codec) Extensions() []string {
+	return []string{"mesh.json"}
+}
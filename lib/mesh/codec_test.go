@@ -0,0 +1,44 @@
+package mesh
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thomersch/grandine/lib/spatial"
+)
+
+func TestEncode(t *testing.T) {
+	building := spatial.MustNewGeom(spatial.Polygon{{{0, 0}, {2, 0}, {2, 2}, {0, 2}}})
+	assert.Nil(t, building.SetZ([]float64{5, 5, 6, 6}))
+
+	fc := spatial.FeatureCollection{Features: []spatial.Feature{
+		{Props: map[string]interface{}{"height": 12}, Geometry: building},
+		{Geometry: spatial.MustNewGeom(spatial.Point{1, 1})},
+		{Geometry: spatial.MustNewGeom(spatial.MultiPolygon{
+			{{{0, 0}, {1, 0}, {0, 1}}},
+			{{{4, 0}, {5, 0}, {4, 1}}},
+		})},
+	}}
+	var buf bytes.Buffer
+	c := Codec{}
+	assert.Nil(t, c.Encode(&buf, &fc))
+
+	var mc meshCollection
+	assert.Nil(t, json.Unmarshal(buf.Bytes(), &mc))
+	assert.Equal(t, "TriangleMesh", mc.Type)
+	assert.Len(t, mc.Features, 2)
+
+	ft := mc.Features[0]
+	assert.Equal(t, map[string]interface{}{"height": 12.0}, ft.Properties)
+	assert.Equal(t, 3, ft.Dimensions)
+	assert.Equal(t, []float64{0, 0, 5, 2, 0, 5, 2, 2, 6, 0, 2, 6}, ft.Vertices)
+	assert.Len(t, ft.Indices, 6)
+
+	ft = mc.Features[1]
+	assert.Equal(t, map[string]interface{}{}, ft.Properties)
+	assert.Equal(t, 2, ft.Dimensions)
+	assert.Len(t, ft.Vertices, 12)
+	assert.ElementsMatch(t, []int{0, 1, 2, 3, 4, 5}, ft.Indices)
+}
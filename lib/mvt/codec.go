@@ -32,10 +32,35 @@ var (
 	errNoGeom = errors.New("no valid geometries")
 )
 
-type Codec struct{}
+type Codec struct {
+	// ZAttribute is the name of the attribute which receives the Z ordinate of features that have
+	// one, as MVT geometries are two-dimensional. Points get their Z value, all other geometries
+	// their highest Z value (e.g. the height of a building). Empty disables the attribute.
+	ZAttribute string
+	// Simplify maps layer names to the simplification of their geometries. The entry "*" applies
+	// to all layers without an entry of their own. If there is none, geometries are not simplified.
+	Simplify map[string]Simplification
+}
+
+// Simplification configures how the geometries of a layer are simplified.
+type Simplification struct {
+	Method spatial.SimplifyMethod
+	// Tolerance is given in tile coordinates, a tile is 4096 units wide.
+	Tolerance float64
+}
 
 func (c *Codec) EncodeTile(features map[string][]spatial.Feature, tid tile.ID) ([]byte, error) {
-	return EncodeTile(features, tid)
+	return encodeTile(features, tid, c)
+}
+
+func (c *Codec) simplification(layerName string) *Simplification {
+	if s, ok := c.Simplify[layerName]; ok {
+		return &s
+	}
+	if s, ok := c.Simplify["*"]; ok {
+		return &s
+	}
+	return nil
 }
 
 func (c *Codec) Extension() string {
@@ -55,7 +80,11 @@ func encodeZigZag(i int) uint32 {
 }
 
 func EncodeTile(features map[string][]spatial.Feature, tid tile.ID) ([]byte, error) {
-	vtile, err := assembleTile(features, tid)
+	return encodeTile(features, tid, &Codec{})
+}
+
+func encodeTile(features map[string][]spatial.Feature, tid tile.ID, c *Codec) ([]byte, error) {
+	vtile, err := assembleTile(features, tid, c)
 	if err != nil {
 		return nil, err
 	}
@@ -65,10 +94,10 @@ func EncodeTile(features map[string][]spatial.Feature, tid tile.ID) ([]byte, err
 	return proto.Marshal(&vtile)
 }
 
-func assembleTile(features map[string][]spatial.Feature, tid tile.ID) (vt.Tile, error) {
+func assembleTile(features map[string][]spatial.Feature, tid tile.ID, c *Codec) (vt.Tile, error) {
 	var vtile vt.Tile
 	for layerName, layerFeats := range features {
-		layer, err := assembleLayer(layerFeats, tid)
+		layer, err := assembleLayer(layerFeats, tid, c.ZAttribute, c.simplification(layerName))
 		if err != nil {
 			return vtile, err
 		}
@@ -104,6 +133,7 @@ func (te tagElems) Strings() []string {
 	return l
 }
 
+// Values returns the values, which need to be converted by tileValue before.
 func (te tagElems) Values() []*vt.Tile_Value {
 	var l = make([]*vt.Tile_Value, len(te))
 	for val, pos := range te {
@@ -111,32 +141,42 @@ func (te tagElems) Values() []*vt.Tile_Value {
 		switch v := val.(type) {
 		case string:
 			tv.StringValue = &v
-		case float32:
-			tv.FloatValue = &v
 		case float64:
 			tv.DoubleValue = &v
-		case int:
-			i := int64(v)
-			tv.SintValue = &i
 		case int64:
 			tv.SintValue = &v
-		case uint:
-			i := uint64(v)
-			tv.UintValue = &i
 		case uint64:
 			tv.UintValue = &v
 		case bool:
 			tv.BoolValue = &v
-		default:
-			s := fmt.Sprintf("%s", v)
-			tv.StringValue = &s
 		}
 		l[pos] = &tv
 	}
 	return l
 }
 
-func assembleLayer(features []spatial.Feature, tid tile.ID) (vt.Tile_Layer, error) {
+// tileValue converts a property value into a value that can be stored in a vector tile. Integers
+// are stored as sint, unless they only fit into uint64, lists and maps are stored as JSON strings.
+// Null values can't be represented, so the result is false for them and the property is omitted.
+func tileValue(v interface{}) (interface{}, bool, error) {
+	val, err := spatial.ValueOf(v)
+	if err != nil {
+		return nil, false, err
+	}
+	switch val.Typ() {
+	case spatial.ValueTypeNull:
+		return nil, false, nil
+	case spatial.ValueTypeUint:
+		if i, ok := val.Int(); ok {
+			return i, true, nil
+		}
+	case spatial.ValueTypeList, spatial.ValueTypeMap:
+		return val.String(), true, nil
+	}
+	return val.Interface(), true, nil
+}
+
+func assembleLayer(features []spatial.Feature, tid tile.ID, zAttr string, simp *Simplification) (vt.Tile_Layer, error) {
 	var (
 		tl       vt.Tile_Layer
 		err      error
@@ -153,22 +193,55 @@ func assembleLayer(features []spatial.Feature, tid tile.ID) (vt.Tile_Layer, erro
 		ng.Project(func(pt spatial.Point) spatial.Point {
 			return tilePoint(pt, tp)
 		})
+		if simp != nil {
+			if ng = ng.SimplifyWith(simp.Tolerance, simp.Method); collapsed(ng) {
+				continue
+			}
+		}
 		for _, geom := range ng.ClipToBBox(clipbbox) {
-			clippedFts = append(clippedFts, spatial.Feature{Props: ft.Props, Geometry: geom})
+			if geom.Typ() == spatial.GeomTypeGeometryCollection {
+				// MVT has no notion of heterogeneous collections, so every member becomes a feature.
+				for _, member := range geom.MustGeometryCollection() {
+					clippedFts = append(clippedFts, spatial.Feature{ID: ft.ID, Props: ft.Props, Geometry: member})
+				}
+				continue
+			}
+			clippedFts = append(clippedFts, spatial.Feature{ID: ft.ID, Props: ft.Props, Geometry: geom})
 		}
 	}
 
 	for _, feat := range spatial.MergeFeatures(clippedFts) {
-		var tileFeat vt.Tile_Feature
+		var (
+			tileFeat vt.Tile_Feature
+			z, hasZ  = zValue(feat.Geometry)
+		)
+		hasZ = hasZ && len(zAttr) > 0
 
 		for k, v := range feat.Properties() {
 			if skipAtKeys && k[0] == '@' {
 				continue
 			}
+			if hasZ && k == zAttr {
+				continue // will be replaced by the Z value
+			}
+			tv, ok, err := tileValue(v)
+			if err != nil {
+				return tl, fmt.Errorf("property %s: %v", k, err)
+			}
+			if !ok {
+				continue
+			}
 			kpos := keys.Index(k)
-			vpos := vals.Index(v)
+			vpos := vals.Index(tv)
 			tileFeat.Tags = append(tileFeat.Tags, uint32(kpos), uint32(vpos))
 		}
+		if hasZ {
+			tileFeat.Tags = append(tileFeat.Tags, uint32(keys.Index(zAttr)), uint32(vals.Index(z)))
+		}
+		if feat.ID != 0 {
+			id := feat.ID
+			tileFeat.Id = &id
+		}
 
 		tileFeat.Geometry, err = encodeGeometry([]spatial.Geom{feat.Geometry}, tid)
 		if len(tileFeat.Geometry) == 0 || err == errNoGeom {
@@ -178,11 +251,11 @@ func assembleLayer(features []spatial.Feature, tid tile.ID) (vt.Tile_Layer, erro
 			return tl, err
 		}
 		switch feat.Geometry.Typ() {
-		case spatial.GeomTypePoint:
+		case spatial.GeomTypePoint, spatial.GeomTypeMultiPoint:
 			tileFeat.Type = &vtPoint
-		case spatial.GeomTypeLineString:
+		case spatial.GeomTypeLineString, spatial.GeomTypeMultiLineString:
 			tileFeat.Type = &vtLine
-		case spatial.GeomTypePolygon:
+		case spatial.GeomTypePolygon, spatial.GeomTypeMultiPolygon:
 			tileFeat.Type = &vtPoly
 		default:
 			return tl, errors.New("unknown geometry type")
@@ -197,6 +270,32 @@ func assembleLayer(features []spatial.Feature, tid tile.ID) (vt.Tile_Layer, erro
 	return tl, nil
 }
 
+// collapsed reports whether nothing is left of a simplified polygon.
+func collapsed(g spatial.Geom) bool {
+	switch g.Typ() {
+	case spatial.GeomTypePolygon:
+		return len(g.MustPolygon()) == 0
+	case spatial.GeomTypeMultiPolygon:
+		return len(g.MustMultiPolygon()) == 0
+	}
+	return false
+}
+
+// zValue returns the Z value of a point or the highest Z value of any other geometry.
+func zValue(g spatial.Geom) (float64, bool) {
+	zs := g.Z()
+	if len(zs) == 0 {
+		return 0, false
+	}
+	var max = zs[0]
+	for _, z := range zs[1:] {
+		if z > max {
+			max = z
+		}
+	}
+	return max, true
+}
+
 // Encodes one or more geometries of the same type into one (multi-)geometry.
 // Geometry coordinates must be in tile coordinate system.
 func encodeGeometry(geoms []spatial.Geom, tid tile.ID) (commands []uint32, err error) {
@@ -218,24 +317,55 @@ func encodeGeometry(geoms []spatial.Geom, tid tile.ID) (commands []uint32, err e
 			pt := geom.MustPoint()
 			dx = int(pt.X) - cur[0]
 			dy = int(pt.Y) - cur[1]
-			// TODO: support multipoint
 			commands = append(commands, encodeCommandInt(cmdMoveTo, 1), encodeZigZag(dx), encodeZigZag(dy))
+		case spatial.GeomTypeMultiPoint:
+			mp := geom.MustMultiPoint()
+			commands = append(commands, encodeCommandInt(cmdMoveTo, uint32(len(mp))))
+			for _, pt := range mp {
+				dx = int(pt.X) - cur[0]
+				dy = int(pt.Y) - cur[1]
+				cur[0] = int(pt.X)
+				cur[1] = int(pt.Y)
+				commands = append(commands, encodeZigZag(dx), encodeZigZag(dy))
+			}
 		case spatial.GeomTypeLineString:
 			commands = append(commands, encodeLine(geom.MustLineString(), &cur)...)
+		case spatial.GeomTypeMultiLineString:
+			for _, ln := range geom.MustMultiLineString() {
+				commands = append(commands, encodeLine(ln, &cur)...)
+			}
 		case spatial.GeomTypePolygon:
-			for _, ring := range geom.MustPolygon() {
-				l := encodeLine(ring, &cur)
-				if l == nil {
-					return nil, errNoGeom
+			l, err := encodePolygon(geom.MustPolygon(), &cur)
+			if err != nil {
+				return nil, err
+			}
+			commands = append(commands, l...)
+		case spatial.GeomTypeMultiPolygon:
+			for _, poly := range geom.MustMultiPolygon() {
+				l, err := encodePolygon(poly, &cur)
+				if err != nil {
+					return nil, err
 				}
 				commands = append(commands, l...)
-				commands = append(commands, encodeCommandInt(cmdClosePath, 1))
 			}
 		}
 	}
 	return commands, nil
 }
 
+func encodePolygon(poly spatial.Polygon, cur *[2]int) ([]uint32, error) {
+	var commands []uint32
+	for _, ring := range poly {
+		l := encodeLine(ring, cur)
+		if l == nil {
+			return nil, errNoGeom
+		}
+		commands = append(commands, l...)
+		commands = append(commands, encodeCommandInt(cmdClosePath, 1))
+	}
+	return commands, nil
+}
+
 func encodeLine(ln spatial.Line, cur *[2]int) []uint32 {
 	var (
 		commands = make([]uint32, len(ln)*2+2) // len=number of coordinates + initial move to + size
@@ -5,9 +5,11 @@ import (
 	"fmt"
 	"testing"
 
+	vt "github.com/thomersch/grandine/lib/mvt/vector_tile"
 	"github.com/thomersch/grandine/lib/spatial"
 	"github.com/thomersch/grandine/lib/tile"
 
+	"github.com/golang/protobuf/proto"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -30,6 +32,18 @@ func TestEncodeGeometry(t *testing.T) {
 			// TODO: validate coordinates
 			expectedResult: []uint32{9, 50, 34},
 		},
+		{
+			geom: []interface{}{
+				spatial.MultiPoint{{5, 7}, {3, 2}},
+			},
+			expectedResult: []uint32{17, 10, 14, 3, 9},
+		},
+		{
+			geom: []interface{}{
+				spatial.MultiLine{{{2, 2}, {2, 10}, {10, 10}}, {{1, 1}, {3, 5}}},
+			},
+			expectedResult: []uint32{9, 4, 4, 18, 0, 16, 16, 0, 9, 17, 17, 10, 4, 8},
+		},
 	}
 
 	for n, tc := range tcs {
@@ -156,3 +170,176 @@ func BenchmarkEncodeLine(b *testing.B) {
 		encodeLine(ln, &cur)
 	}
 }
+
+func TestEncodeTileZAttribute(t *testing.T) {
+	pt := spatial.MustNewGeom(spatial.Point{45, 45})
+	assert.Nil(t, pt.SetZ([]float64{312.5}))
+	flat := spatial.MustNewGeom(spatial.Point{50, 47})
+
+	layers := map[string][]spatial.Feature{
+		"main": {
+			{Props: map[string]interface{}{"height": "overwritten"}, Geometry: pt},
+			{Props: map[string]interface{}{}, Geometry: flat},
+		},
+	}
+	c := Codec{ZAttribute: "height"}
+	buf, err := c.EncodeTile(layers, tile.ID{X: 1, Y: 0, Z: 1})
+	assert.Nil(t, err)
+
+	var vtile vt.Tile
+	assert.Nil(t, proto.Unmarshal(buf, &vtile))
+	assert.Len(t, vtile.Layers, 1)
+	layer := vtile.Layers[0]
+	assert.Equal(t, []string{"height"}, layer.Keys)
+	assert.Len(t, layer.Values, 1)
+	assert.Equal(t, 312.5, layer.Values[0].GetDoubleValue())
+
+	var tagged int
+	for _, f := range layer.Features {
+		if len(f.Tags) > 0 {
+			tagged++
+		}
+	}
+	assert.Equal(t, 1, tagged)
+}
+
+func TestEncodeTileSimplify(t *testing.T) {
+	ln := spatial.MustNewGeom(spatial.Line{{10, 10}, {50, 10.01}, {90, 10}})
+	layers := map[string][]spatial.Feature{
+		"roads":  {{Props: map[string]interface{}{}, Geometry: ln}},
+		"rivers": {{Props: map[string]interface{}{}, Geometry: ln}},
+	}
+	c := Codec{Simplify: map[string]Simplification{
+		"roads": {Method: spatial.SimplifyVisvalingam, Tolerance: 4},
+	}}
+	buf, err := c.EncodeTile(layers, tile.ID{X: 1, Y: 0, Z: 1})
+	assert.Nil(t, err)
+
+	var vtile vt.Tile
+	assert.Nil(t, proto.Unmarshal(buf, &vtile))
+	assert.Len(t, vtile.Layers, 2)
+	for _, layer := range vtile.Layers {
+		// MoveTo with one point, LineTo with the remaining points
+		switch layer.GetName() {
+		case "roads":
+			assert.Len(t, layer.Features[0].Geometry, 6)
+		case "rivers":
+			assert.Len(t, layer.Features[0].Geometry, 8)
+		}
+	}
+}
+
+func TestEncodeTileDissolve(t *testing.T) {
+	var (
+		forest = map[string]interface{}{"landuse": "forest"}
+		layers = map[string][]spatial.Feature{
+			"landuse": {
+				{Props: forest, Geometry: spatial.MustNewGeom(spatial.Polygon{{{10, 10}, {20, 10}, {20, 20}, {10, 20}}})},
+				{Props: forest, Geometry: spatial.MustNewGeom(spatial.Polygon{{{20, 10}, {30, 10}, {30, 20}, {20, 20}}})},
+				{Props: map[string]interface{}{"landuse": "meadow"}, Geometry: spatial.MustNewGeom(spatial.Polygon{{{30, 10}, {40, 10}, {40, 20}, {30, 20}}})},
+			},
+		}
+	)
+	buf, err := EncodeTile(layers, tile.ID{X: 1, Y: 0, Z: 1})
+	assert.Nil(t, err)
+
+	var vtile vt.Tile
+	assert.Nil(t, proto.Unmarshal(buf, &vtile))
+	assert.Len(t, vtile.Layers, 1)
+	assert.Len(t, vtile.Layers[0].Features, 2)
+	for _, f := range vtile.Layers[0].Features {
+		// a rectangle each: MoveTo, LineTo with four points and ClosePath
+		assert.Len(t, f.Geometry, 11)
+	}
+}
+
+func TestEncodeTileFeatureID(t *testing.T) {
+	var (
+		building = map[string]interface{}{"building": "yes"}
+		layers   = map[string][]spatial.Feature{
+			"buildings": {
+				{ID: 42, Props: map[string]interface{}{"building": "house"}, Geometry: spatial.MustNewGeom(spatial.Polygon{{{10, 10}, {20, 10}, {20, 20}, {10, 20}}})},
+				{ID: 43, Props: building, Geometry: spatial.MustNewGeom(spatial.Point{40, 40})},
+				// touching buildings with the same properties are dissolved, so their IDs are dropped
+				{ID: 44, Props: building, Geometry: spatial.MustNewGeom(spatial.Polygon{{{50, 10}, {60, 10}, {60, 20}, {50, 20}}})},
+				{ID: 45, Props: building, Geometry: spatial.MustNewGeom(spatial.Polygon{{{60, 10}, {70, 10}, {70, 20}, {60, 20}}})},
+			},
+		}
+	)
+	buf, err := EncodeTile(layers, tile.ID{X: 1, Y: 0, Z: 1})
+	assert.Nil(t, err)
+
+	var vtile vt.Tile
+	assert.Nil(t, proto.Unmarshal(buf, &vtile))
+	assert.Len(t, vtile.Layers, 1)
+
+	var ids []uint64
+	for _, f := range vtile.Layers[0].Features {
+		if f.Id != nil {
+			ids = append(ids, f.GetId())
+		}
+	}
+	assert.ElementsMatch(t, []uint64{42, 43}, ids)
+	assert.Len(t, vtile.Layers[0].Features, 3)
+}
+
+func TestEncodeTileValueTypes(t *testing.T) {
+	layers := map[string][]spatial.Feature{
+		"main": {
+			{
+				Props: map[string]interface{}{
+					"int":   3,
+					"big":   uint64(1 << 63),
+					"small": uint8(2),
+					"float": float32(0.5),
+					"bool":  true,
+					"null":  nil,
+					"list":  []interface{}{1, "a"},
+				},
+				Geometry: spatial.MustNewGeom(spatial.Point{45, 45}),
+			},
+		},
+	}
+	buf, err := EncodeTile(layers, tile.ID{X: 1, Y: 0, Z: 1})
+	assert.Nil(t, err)
+
+	var vtile vt.Tile
+	assert.Nil(t, proto.Unmarshal(buf, &vtile))
+	layer := vtile.Layers[0]
+	assert.Len(t, layer.Features[0].Tags, 12)
+
+	var props = map[string]*vt.Tile_Value{}
+	for i := 0; i < len(layer.Features[0].Tags); i += 2 {
+		props[layer.Keys[layer.Features[0].Tags[i]]] = layer.Values[layer.Features[0].Tags[i+1]]
+	}
+	assert.NotContains(t, props, "null")
+	assert.Equal(t, int64(3), props["int"].GetSintValue())
+	assert.Equal(t, uint64(1<<63), props["big"].GetUintValue())
+	assert.Equal(t, int64(2), props["small"].GetSintValue())
+	assert.Equal(t, 0.5, props["float"].GetDoubleValue())
+	assert.Equal(t, true, props["bool"].GetBoolValue())
+	assert.Equal(t, `[1,"a"]`, props["list"].GetStringValue())
+
+	layers["main"][0].Props["invalid"] = struct{}{}
+	_, err = EncodeTile(layers, tile.ID{X: 1, Y: 0, Z: 1})
+	assert.NotNil(t, err)
+}
+
+func TestEncodeTileValueProps(t *testing.T) {
+	var (
+		list  = spatial.ListValue([]spatial.Value{spatial.IntValue(1), spatial.StringValue("a")})
+		props = map[string]interface{}{"list": list, "map": spatial.MapValue(map[string]spatial.Value{"a": list})}
+	)
+	layers := map[string][]spatial.Feature{
+		"main": {
+			{Props: props, Geometry: spatial.MustNewGeom(spatial.Line{{10, 10}, {20, 20}})},
+			{Props: props, Geometry: spatial.MustNewGeom(spatial.Line{{20, 20}, {30, 10}})},
+		},
+	}
+	buf, err := EncodeTile(layers, tile.ID{X: 1, Y: 0, Z: 1})
+	assert.Nil(t, err)
+
+	var vtile vt.Tile
+	assert.Nil(t, proto.Unmarshal(buf, &vtile))
+	assert.Len(t, vtile.Layers[0].Features, 1)
+}
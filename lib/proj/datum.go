@@ -0,0 +1,118 @@
+package proj
+
+import "math"
+
+type ellipsoid struct {
+	a float64 // semi-major axis in meters
+	f float64 // flattening
+}
+
+var (
+	ellpsWGS84    = ellipsoid{a: 6378137, f: 1 / 298.257223563}
+	ellpsGRS80    = ellipsoid{a: 6378137, f: 1 / 298.257222101}
+	ellpsAiry     = ellipsoid{a: 6377563.396, f: 1 / 299.3249646}
+	ellpsBessel   = ellipsoid{a: 6377397.155, f: 1 / 299.1528128}
+	ellpsIntl1924 = ellipsoid{a: 6378388, f: 1 / 297.0}
+)
+
+// es returns the square of the first eccentricity.
+func (e ellipsoid) es() float64 {
+	return e.f * (2 - e.f)
+}
+
+// helmert holds the parameters of a seven parameter transformation into WGS84, in the position
+// vector convention (EPSG method 9606): translations in meters, rotations in arc seconds and
+// the scale difference in parts per million.
+type helmert struct {
+	tx, ty, tz float64
+	rx, ry, rz float64
+	s          float64
+}
+
+type datum struct {
+	name  string
+	ellps ellipsoid
+	// toWGS84 is nil for datums which are considered to be identical with WGS84.
+	toWGS84 *helmert
+}
+
+var (
+	datumWGS84  = &datum{name: "WGS 84", ellps: ellpsWGS84}
+	datumETRS89 = &datum{name: "ETRS89", ellps: ellpsGRS80}
+	datumNAD83  = &datum{name: "NAD83", ellps: ellpsGRS80}
+	datumOSGB36 = &datum{name: "OSGB 1936", ellps: ellpsAiry,
+		toWGS84: &helmert{446.448, -125.157, 542.06, 0.15, 0.247, 0.842, -20.489}}
+	datumDHDN = &datum{name: "DHDN", ellps: ellpsBessel,
+		toWGS84: &helmert{598.1, 73.7, 418.2, 0.202, 0.045, -2.455, 6.7}}
+	datumED50 = &datum{name: "ED50", ellps: ellpsIntl1924,
+		toWGS84: &helmert{-87, -98, -121, 0, 0, 0, 0}}
+)
+
+// sameAs reports whether no datum shift is necessary between the datums.
+func (d *datum) sameAs(o *datum) bool {
+	return d == o || (d.toWGS84 == nil && o.toWGS84 == nil)
+}
+
+// shift converts geographic coordinates in radians from the datum d into the datum o. Heights
+// are assumed to be zero.
+func (d *datum) shift(lon, lat float64, o *datum) (float64, float64) {
+	x, y, z := toGeocentric(lon, lat, 0, d.ellps)
+	if d.toWGS84 != nil {
+		x, y, z = d.toWGS84.apply(x, y, z, 1)
+	}
+	if o.toWGS84 != nil {
+		x, y, z = o.toWGS84.apply(x, y, z, -1)
+	}
+	return fromGeocentric(x, y, z, o.ellps)
+}
+
+// apply transforms geocentric coordinates, if dir is -1 the transformation is reversed. The
+// reverse transformation negates rotations and scale, which is precise enough for the small
+// values of datum shifts.
+func (h *helmert) apply(x, y, z, dir float64) (float64, float64, float64) {
+	const arcSec = math.Pi / (180 * 3600)
+	var (
+		rx = dir * h.rx * arcSec
+		ry = dir * h.ry * arcSec
+		rz = dir * h.rz * arcSec
+		s  = 1 + dir*h.s*1e-6
+	)
+	if dir < 0 {
+		x, y, z = x-h.tx, y-h.ty, z-h.tz
+	}
+	x, y, z = s*(x-rz*y+ry*z), s*(rz*x+y-rx*z), s*(-ry*x+rx*y+z)
+	if dir > 0 {
+		x, y, z = x+h.tx, y+h.ty, z+h.tz
+	}
+	return x, y, z
+}
+
+func toGeocentric(lon, lat, h float64, e ellipsoid) (x, y, z float64) {
+	var (
+		es     = e.es()
+		sinLat = math.Sin(lat)
+		n      = e.a / math.Sqrt(1-es*sinLat*sinLat)
+	)
+	return (n + h) * math.Cos(lat) * math.Cos(lon), (n + h) * math.Cos(lat) * math.Sin(lon), (n*(1-es) + h) * sinLat
+}
+
+func fromGeocentric(x, y, z float64, e ellipsoid) (lon, lat float64) {
+	var (
+		es = e.es()
+		p  = math.Hypot(x, y)
+	)
+	lat = math.Atan2(z, p*(1-es))
+	for i := 0; i < 10; i++ {
+		var (
+			sinLat = math.Sin(lat)
+			n      = e.a / math.Sqrt(1-es*sinLat*sinLat)
+			prev   = lat
+		)
+		// z = (n(1-es) + h) sin(lat) and p = (n + h) cos(lat), eliminating the height h
+		lat = math.Atan2(z+es*n*sinLat, p)
+		if math.Abs(lat-prev) < 1e-14 {
+			break
+		}
+	}
+	return math.Atan2(y, x), lat
+}
@@ -0,0 +1,176 @@
+// Package proj transforms geometries between coordinate reference systems, which are
+// identified by their EPSG codes. It supports geographic coordinates, Web Mercator, UTM,
+// ETRS89-LAEA and a number of national grids, including datum shifts between them.
+//
+// Geographic coordinates are given as longitude and latitude in degrees, projected
+// coordinates as easting and northing in meters.
+package proj
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// CRS is a coordinate reference system.
+type CRS struct {
+	// Code is the EPSG code of the system.
+	Code int
+	// Name is the EPSG name, e.g. "WGS 84 / UTM zone 32N".
+	Name string
+
+	datum *datum
+	proj  projection // nil for geographic coordinates
+}
+
+// String returns the CRS in the form "EPSG:4326".
+func (c *CRS) String() string {
+	return "EPSG:" + strconv.Itoa(c.Code)
+}
+
+// SRID returns the EPSG code as string, as used in spatial.FeatureCollection.
+func (c *CRS) SRID() string {
+	return strconv.Itoa(c.Code)
+}
+
+// Geographic reports whether the coordinates are longitude and latitude.
+func (c *CRS) Geographic() bool {
+	return c.proj == nil
+}
+
+// Lookup returns the CRS with the given EPSG code. Supported are:
+//
+//	4326         WGS 84
+//	4258         ETRS89
+//	4269         NAD83
+//	4230         ED50
+//	4277         OSGB 1936
+//	4314         DHDN
+//	3857         WGS 84 / Pseudo-Mercator (also as 900913)
+//	3395         WGS 84 / World Mercator
+//	32601-32660  WGS 84 / UTM zones north
+//	32701-32760  WGS 84 / UTM zones south
+//	25828-25838  ETRS89 / UTM zones 28N to 38N
+//	26901-26923  NAD83 / UTM zones 1N to 23N
+//	23028-23038  ED50 / UTM zones 28N to 38N
+//	3035         ETRS89 / LAEA Europe
+//	27700        OSGB 1936 / British National Grid
+//	31466-31469  DHDN / 3-degree Gauss-Kruger zones 2 to 5
+func Lookup(code int) (*CRS, error) {
+	switch code {
+	case 4326:
+		return &CRS{Code: code, Name: "WGS 84", datum: datumWGS84}, nil
+	case 4258:
+		return &CRS{Code: code, Name: "ETRS89", datum: datumETRS89}, nil
+	case 4269:
+		return &CRS{Code: code, Name: "NAD83", datum: datumNAD83}, nil
+	case 4230:
+		return &CRS{Code: code, Name: "ED50", datum: datumED50}, nil
+	case 4277:
+		return &CRS{Code: code, Name: "OSGB 1936", datum: datumOSGB36}, nil
+	case 4314:
+		return &CRS{Code: code, Name: "DHDN", datum: datumDHDN}, nil
+	case 3857, 900913:
+		return &CRS{Code: 3857, Name: "WGS 84 / Pseudo-Mercator", datum: datumWGS84, proj: webMercator{}}, nil
+	case 3395:
+		return &CRS{Code: code, Name: "WGS 84 / World Mercator", datum: datumWGS84, proj: newMercator(ellpsWGS84, 0, 1)}, nil
+	case 3035:
+		return &CRS{Code: code, Name: "ETRS89 / LAEA Europe", datum: datumETRS89,
+			proj: newLambertAzimuthalEqualArea(ellpsGRS80, degToRad(52), degToRad(10), 4321000, 3210000)}, nil
+	case 27700:
+		return &CRS{Code: code, Name: "OSGB 1936 / British National Grid", datum: datumOSGB36,
+			proj: newTransverseMercator(ellpsAiry, degToRad(49), degToRad(-2), 0.9996012717, 400000, -100000)}, nil
+	}
+
+	switch {
+	case code > 32600 && code <= 32660:
+		return utm(code, "WGS 84", datumWGS84, code-32600, false), nil
+	case code > 32700 && code <= 32760:
+		return utm(code, "WGS 84", datumWGS84, code-32700, true), nil
+	case code >= 25828 && code <= 25838:
+		return utm(code, "ETRS89", datumETRS89, code-25800, false), nil
+	case code >= 26901 && code <= 26923:
+		return utm(code, "NAD83", datumNAD83, code-26900, false), nil
+	case code >= 23028 && code <= 23038:
+		return utm(code, "ED50", datumED50, code-23000, false), nil
+	case code >= 31466 && code <= 31469:
+		zone := code - 31464
+		return &CRS{
+			Code:  code,
+			Name:  fmt.Sprintf("DHDN / 3-degree Gauss-Kruger zone %d", zone),
+			datum: datumDHDN,
+			proj:  newTransverseMercator(ellpsBessel, 0, degToRad(float64(3*zone)), 1, float64(zone)*1e6+500000, 0),
+		}, nil
+	}
+	return nil, fmt.Errorf("unsupported coordinate reference system EPSG:%d", code)
+}
+
+func utm(code int, datumName string, d *datum, zone int, south bool) *CRS {
+	var (
+		hemisphere = "N"
+		falseN     float64
+	)
+	if south {
+		hemisphere = "S"
+		falseN = 10000000
+	}
+	return &CRS{
+		Code:  code,
+		Name:  fmt.Sprintf("%s / UTM zone %d%s", datumName, zone, hemisphere),
+		datum: d,
+		proj:  newTransverseMercator(d.ellps, 0, degToRad(float64(6*zone-183)), 0.9996, 500000, falseN),
+	}
+}
+
+// Parse returns the CRS for a textual reference, see ParseCode.
+func Parse(s string) (*CRS, error) {
+	code, err := ParseCode(s)
+	if err != nil {
+		return nil, err
+	}
+	return Lookup(code)
+}
+
+// ParseCode returns the EPSG code of a textual reference, even if the system is not supported
+// by Lookup. Accepted are EPSG codes ("3857"), prefixed codes ("EPSG:3857"), OGC URNs
+// ("urn:ogc:def:crs:EPSG::3857") and URLs ("http://www.opengis.net/def/crs/EPSG/0/3857").
+// CRS84, as used by GeoJSON, is the same as EPSG:4326.
+func ParseCode(s string) (int, error) {
+	ref := strings.ToUpper(strings.TrimSpace(s))
+	if strings.HasSuffix(ref, "CRS84") {
+		return 4326, nil
+	}
+	for _, prefix := range []string{"EPSG:", "URN:OGC:DEF:CRS:EPSG:", "HTTP://WWW.OPENGIS.NET/DEF/CRS/EPSG/"} {
+		if strings.HasPrefix(ref, prefix) {
+			// URNs and URLs may contain a version before the code
+			ref = ref[strings.LastIndexAny(ref, ":/")+1:]
+			break
+		}
+	}
+	code, err := strconv.Atoi(ref)
+	if err != nil || code <= 0 {
+		return 0, fmt.Errorf("invalid coordinate reference system %q", s)
+	}
+	return code, nil
+}
+
+// forward converts geographic coordinates in radians into coordinates of the system.
+func (c *CRS) forward(lon, lat float64) (float64, float64) {
+	if c.proj == nil {
+		return radToDeg(normalizeLon(lon)), radToDeg(lat)
+	}
+	return c.proj.forward(lon, lat)
+}
+
+// inverse converts coordinates of the system into geographic coordinates in radians.
+func (c *CRS) inverse(x, y float64) (float64, float64) {
+	if c.proj == nil {
+		return degToRad(x), degToRad(y)
+	}
+	return c.proj.inverse(x, y)
+}
+
+func finite(v float64) bool {
+	return !math.IsNaN(v) && !math.IsInf(v, 0)
+}
@@ -0,0 +1,183 @@
+package proj
+
+import (
+	"testing"
+
+	"github.com/thomersch/grandine/lib/spatial"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// dms converts degrees, minutes and seconds into decimal degrees.
+func dms(d, m, s float64) float64 {
+	return d + m/60 + s/3600
+}
+
+func mustLookup(t *testing.T, code int) *CRS {
+	crs, err := Lookup(code)
+	assert.Nil(t, err)
+	return crs
+}
+
+func transform(t *testing.T, src, dst int, pt spatial.Point) spatial.Point {
+	res, err := NewTransformer(mustLookup(t, src), mustLookup(t, dst)).Point(pt)
+	assert.Nil(t, err)
+	return res
+}
+
+func TestProjections(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		src, dst int
+		in, out  spatial.Point
+		delta    float64
+	}{
+		// examples from IOGP Guidance Note 7-2
+		{"transverse mercator", 4277, 27700, spatial.Point{dms(0, 30, 0), dms(50, 30, 0)}, spatial.Point{577274.99, 69740.49}, 0.01},
+		{"lambert azimuthal equal area", 4258, 3035, spatial.Point{5, 50}, spatial.Point{3962799.45, 2999718.85}, 0.01},
+
+		{"web mercator", 4326, 3857, spatial.Point{41.1, 20.1}, spatial.Point{4575231.07160354, 2284881.07006733}, 1e-6},
+		{"utm central meridian", 4326, 32632, spatial.Point{9, 0}, spatial.Point{500000, 0}, 1e-6},
+		{"utm south", 4326, 32733, spatial.Point{15, 0}, spatial.Point{500000, 10000000}, 1e-6},
+		{"etrs89 utm", 4258, 25832, spatial.Point{9, 0}, spatial.Point{500000, 0}, 1e-6},
+		{"gauss kruger", 4314, 31467, spatial.Point{9, 0}, spatial.Point{3500000, 0}, 1e-6},
+		{"world mercator", 4326, 3395, spatial.Point{0, 0}, spatial.Point{0, 0}, 1e-6},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			res := transform(t, tc.src, tc.dst, tc.in)
+			assert.InDelta(t, tc.out.X, res.X, tc.delta)
+			assert.InDelta(t, tc.out.Y, res.Y, tc.delta)
+
+			back := transform(t, tc.dst, tc.src, res)
+			assert.InDelta(t, tc.in.X, back.X, 1e-9)
+			assert.InDelta(t, tc.in.Y, back.Y, 1e-9)
+		})
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, tc := range []struct {
+		code int
+		pt   spatial.Point
+	}{
+		{3857, spatial.Point{-122.4, 37.8}},
+		{3395, spatial.Point{151.2, -33.9}},
+		{32632, spatial.Point{8.7, 48.1}},
+		{32755, spatial.Point{144.9, -37.8}},
+		{25833, spatial.Point{13.4, 52.5}},
+		{26910, spatial.Point{-122.4, 37.8}},
+		{23031, spatial.Point{2.3, 41.4}},
+		{3035, spatial.Point{-9.1, 38.7}},
+		{27700, spatial.Point{-1.5, 51.5}},
+		{31468, spatial.Point{11.6, 48.1}},
+		{4277, spatial.Point{-1.5, 51.5}},
+		{4314, spatial.Point{11.6, 48.1}},
+		{4230, spatial.Point{2.3, 41.4}},
+	} {
+		var (
+			res   = transform(t, 4326, tc.code, tc.pt)
+			back  = transform(t, tc.code, 4326, res)
+			crs   = mustLookup(t, tc.code)
+			delta = 1e-9
+		)
+		if !crs.datum.sameAs(datumWGS84) {
+			// datum shifts drop the height, which moves points by about a millimeter
+			delta = 1e-7
+		}
+		assert.InDelta(t, tc.pt.X, back.X, delta, "EPSG:%d", tc.code)
+		assert.InDelta(t, tc.pt.Y, back.Y, delta, "EPSG:%d", tc.code)
+	}
+}
+
+func TestGeocentric(t *testing.T) {
+	// IOGP Guidance Note 7-2
+	var (
+		lon     = degToRad(dms(2, 7, 46.38))
+		lat     = degToRad(dms(53, 48, 33.82))
+		x, y, z = toGeocentric(lon, lat, 73, ellpsWGS84)
+	)
+	assert.InDelta(t, 3771793.968, x, 0.001)
+	assert.InDelta(t, 140253.342, y, 0.001)
+	assert.InDelta(t, 5124304.349, z, 0.001)
+
+	rLon, rLat := fromGeocentric(x, y, z, ellpsWGS84)
+	assert.InDelta(t, lon, rLon, 1e-12)
+	assert.InDelta(t, lat, rLat, 1e-12)
+}
+
+func TestHelmert(t *testing.T) {
+	// IOGP Guidance Note 7-2, WGS 72 to WGS 84
+	h := helmert{0, 0, 4.5, 0, 0, 0.554, 0.219}
+	x, y, z := h.apply(3657660.66, 255768.55, 5201382.11, 1)
+	assert.InDelta(t, 3657660.78, x, 0.01)
+	assert.InDelta(t, 255778.43, y, 0.005)
+	assert.InDelta(t, 5201387.75, z, 0.005)
+
+	x, y, z = h.apply(x, y, z, -1)
+	assert.InDelta(t, 3657660.66, x, 0.005)
+	assert.InDelta(t, 255768.55, y, 0.005)
+	assert.InDelta(t, 5201382.11, z, 0.005)
+}
+
+func TestDatumShift(t *testing.T) {
+	// The Airy transit circle defines the prime meridian of OSGB 1936, the meridian of WGS 84
+	// is located about 102 m east of it. The transformation is accurate to about 10 m.
+	res := transform(t, 4326, 4277, spatial.Point{-0.001475, 51.477811})
+	assert.InDelta(t, 0, res.X, 0.0002)
+
+	// ETRS89 and WGS 84 are considered identical
+	res = transform(t, 4326, 4258, spatial.Point{10, 50})
+	assert.Equal(t, spatial.Point{10, 50}, res)
+}
+
+func TestParse(t *testing.T) {
+	for _, ref := range []string{"3857", "EPSG:3857", "epsg:3857", "urn:ogc:def:crs:EPSG::3857", "urn:ogc:def:crs:EPSG:6.18:3857", "http://www.opengis.net/def/crs/EPSG/0/3857", "900913"} {
+		crs, err := Parse(ref)
+		if assert.Nil(t, err, ref) {
+			assert.Equal(t, 3857, crs.Code)
+			assert.Equal(t, "EPSG:3857", crs.String())
+		}
+	}
+
+	crs, err := Parse("urn:ogc:def:crs:OGC:1.3:CRS84")
+	assert.Nil(t, err)
+	assert.Equal(t, "4326", crs.SRID())
+	assert.True(t, crs.Geographic())
+
+	crs, err = Parse("EPSG:25832")
+	assert.Nil(t, err)
+	assert.Equal(t, "ETRS89 / UTM zone 32N", crs.Name)
+	assert.False(t, crs.Geographic())
+
+	for _, ref := range []string{"", "EPSG:", "EPSG:1234", "wgs84", "EPSG:32661"} {
+		_, err = Parse(ref)
+		assert.NotNil(t, err, ref)
+	}
+	// unsupported systems still have a code
+	code, err := ParseCode("http://www.opengis.net/def/crs/EPSG/0/2056")
+	assert.Nil(t, err)
+	assert.Equal(t, 2056, code)
+	for _, ref := range []string{"", "EPSG:", "wgs84", "EPSG:-1"} {
+		_, err = ParseCode(ref)
+		assert.NotNil(t, err, ref)
+	}
+}
+
+func TestTransformGeom(t *testing.T) {
+	var (
+		tr = NewTransformer(mustLookup(t, 4326), mustLookup(t, 3857))
+		fc = spatial.FeatureCollection{SRID: "4326", Features: []spatial.Feature{
+			{Geometry: spatial.MustNewGeom(spatial.Line{{0, 0}, {180, 0}})},
+			{Geometry: spatial.MustNewGeom(spatial.GeomCollection{spatial.MustNewGeom(spatial.Point{-180, 0})})},
+			{},
+		}}
+	)
+	assert.Nil(t, tr.FeatureCollection(&fc))
+	assert.Equal(t, "3857", fc.SRID)
+	assert.InDelta(t, 20037508.34, fc.Features[0].Geometry.MustLineString()[1].X, 0.01)
+	gc := fc.Features[1].Geometry.MustGeometryCollection()
+	assert.InDelta(t, -20037508.34, gc[0].MustPoint().X, 0.01)
+
+	g := spatial.MustNewGeom(spatial.Point{0, 90})
+	assert.NotNil(t, tr.Geom(g))
+}
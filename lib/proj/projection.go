@@ -0,0 +1,253 @@
+package proj
+
+import "math"
+
+// projection converts geographic coordinates in radians into projected coordinates in meters
+// and back.
+type projection interface {
+	forward(lon, lat float64) (x, y float64)
+	inverse(x, y float64) (lon, lat float64)
+}
+
+// webMercator is the spherical Mercator projection used by web maps (EPSG:3857), which applies
+// the spherical formulas to WGS84 coordinates.
+type webMercator struct{}
+
+func (webMercator) forward(lon, lat float64) (float64, float64) {
+	if math.Abs(lat) >= math.Pi/2 {
+		// the poles are infinitely far away
+		return math.NaN(), math.NaN()
+	}
+	return ellpsWGS84.a * lon, ellpsWGS84.a * math.Log(math.Tan(math.Pi/4+lat/2))
+}
+
+func (webMercator) inverse(x, y float64) (float64, float64) {
+	return x / ellpsWGS84.a, math.Pi/2 - 2*math.Atan(math.Exp(-y/ellpsWGS84.a))
+}
+
+// mercator is the ellipsoidal Mercator projection (EPSG method 9804).
+type mercator struct {
+	a, e float64
+	lon0 float64
+	k0   float64
+}
+
+func newMercator(el ellipsoid, lon0, k0 float64) *mercator {
+	return &mercator{a: el.a, e: math.Sqrt(el.es()), lon0: lon0, k0: k0}
+}
+
+func (m *mercator) forward(lon, lat float64) (float64, float64) {
+	if math.Abs(lat) >= math.Pi/2 {
+		return math.NaN(), math.NaN()
+	}
+	return m.a * m.k0 * normalizeLon(lon-m.lon0), m.a * m.k0 * isometricLat(lat, m.e)
+}
+
+func (m *mercator) inverse(x, y float64) (float64, float64) {
+	return m.lon0 + x/(m.a*m.k0), fromIsometricLat(y/(m.a*m.k0), m.e)
+}
+
+// isometricLat returns the isometric latitude, which is the Mercator northing on the unit
+// sphere.
+func isometricLat(lat, e float64) float64 {
+	return math.Asinh(math.Tan(lat)) - e*math.Atanh(e*math.Sin(lat))
+}
+
+func fromIsometricLat(psi, e float64) float64 {
+	lat := math.Atan(math.Sinh(psi))
+	for i := 0; i < 20; i++ {
+		prev := lat
+		lat = math.Atan(math.Sinh(psi + e*math.Atanh(e*math.Sin(lat))))
+		if math.Abs(lat-prev) < 1e-14 {
+			break
+		}
+	}
+	return lat
+}
+
+// transverseMercator implements the series by Krüger in the form given by Karney ("Transverse
+// Mercator with an accuracy of a few nanometers", 2011), truncated after the fourth order, which
+// is accurate to well below a millimeter within 3000 km of the central meridian. It is used for
+// UTM and most national grids.
+type transverseMercator struct {
+	e              float64
+	lon0           float64
+	k0             float64
+	falseE, falseN float64
+	a              float64 // rectifying radius
+	m0             float64 // distance from the equator to the latitude of origin
+	alpha, beta    [4]float64
+}
+
+func newTransverseMercator(el ellipsoid, lat0, lon0, k0, falseE, falseN float64) *transverseMercator {
+	var (
+		n  = el.f / (2 - el.f)
+		n2 = n * n
+		n3 = n2 * n
+		n4 = n3 * n
+		tm = &transverseMercator{
+			e:      math.Sqrt(el.es()),
+			lon0:   lon0,
+			k0:     k0,
+			falseE: falseE,
+			falseN: falseN,
+			a:      el.a / (1 + n) * (1 + n2/4 + n2*n2/64),
+			alpha: [4]float64{
+				n/2 - 2*n2/3 + 5*n3/16 + 41*n4/180,
+				13*n2/48 - 3*n3/5 + 557*n4/1440,
+				61*n3/240 - 103*n4/140,
+				49561 * n4 / 161280,
+			},
+			beta: [4]float64{
+				n/2 - 2*n2/3 + 37*n3/96 - n4/360,
+				n2/48 + n3/15 - 437*n4/1440,
+				17*n3/480 - 37*n4/840,
+				4397 * n4 / 161280,
+			},
+		}
+	)
+	_, tm.m0 = tm.gaussKrueger(0, lat0)
+	return tm
+}
+
+// gaussKrueger returns the unscaled coordinates relative to the central meridian and the
+// equator.
+func (tm *transverseMercator) gaussKrueger(dLon, lat float64) (float64, float64) {
+	var (
+		t   = math.Sinh(isometricLat(lat, tm.e)) // tangent of the conformal latitude
+		xi  = math.Atan2(t, math.Cos(dLon))
+		eta = math.Atanh(math.Sin(dLon) / math.Sqrt(1+t*t))
+		x   = eta
+		y   = xi
+	)
+	for j, a := range tm.alpha {
+		k := 2 * float64(j+1)
+		x += a * math.Cos(k*xi) * math.Sinh(k*eta)
+		y += a * math.Sin(k*xi) * math.Cosh(k*eta)
+	}
+	return tm.a * x, tm.a * y
+}
+
+func (tm *transverseMercator) forward(lon, lat float64) (float64, float64) {
+	x, y := tm.gaussKrueger(normalizeLon(lon-tm.lon0), lat)
+	return tm.falseE + tm.k0*x, tm.falseN + tm.k0*(y-tm.m0)
+}
+
+func (tm *transverseMercator) inverse(x, y float64) (float64, float64) {
+	var (
+		xi      = ((y-tm.falseN)/tm.k0 + tm.m0) / tm.a
+		eta     = (x - tm.falseE) / tm.k0 / tm.a
+		xiPrim  = xi
+		etaPrim = eta
+	)
+	for j, b := range tm.beta {
+		k := 2 * float64(j+1)
+		xiPrim -= b * math.Sin(k*xi) * math.Cosh(k*eta)
+		etaPrim -= b * math.Cos(k*xi) * math.Sinh(k*eta)
+	}
+	var (
+		chi = math.Asin(math.Sin(xiPrim) / math.Cosh(etaPrim)) // conformal latitude
+		lat = fromIsometricLat(math.Asinh(math.Tan(chi)), tm.e)
+	)
+	return tm.lon0 + math.Atan2(math.Sinh(etaPrim), math.Cos(xiPrim)), lat
+}
+
+// lambertAzimuthalEqualArea implements the oblique ellipsoidal aspect of the projection
+// (EPSG method 9820), following Snyder, Map Projections: A Working Manual, p. 187.
+type lambertAzimuthalEqualArea struct {
+	e, es          float64
+	lat0, lon0     float64
+	falseE, falseN float64
+	qp             float64
+	rq             float64
+	sinB1, cosB1   float64
+	d              float64
+}
+
+func newLambertAzimuthalEqualArea(el ellipsoid, lat0, lon0, falseE, falseN float64) *lambertAzimuthalEqualArea {
+	var (
+		es = el.es()
+		p  = &lambertAzimuthalEqualArea{e: math.Sqrt(es), es: es, lat0: lat0, lon0: lon0, falseE: falseE, falseN: falseN}
+	)
+	p.qp = p.q(1)
+	p.rq = el.a * math.Sqrt(p.qp/2)
+	var (
+		sinLat0 = math.Sin(lat0)
+		b1      = math.Asin(p.q(sinLat0) / p.qp)
+		m1      = math.Cos(lat0) / math.Sqrt(1-es*sinLat0*sinLat0)
+	)
+	p.sinB1, p.cosB1 = math.Sin(b1), math.Cos(b1)
+	p.d = el.a * m1 / (p.rq * p.cosB1)
+	return p
+}
+
+// q is used to calculate authalic latitudes, given the sine of the latitude (Snyder 3-12).
+func (p *lambertAzimuthalEqualArea) q(sinLat float64) float64 {
+	return (1 - p.es) * (sinLat/(1-p.es*sinLat*sinLat) - 1/(2*p.e)*math.Log((1-p.e*sinLat)/(1+p.e*sinLat)))
+}
+
+func (p *lambertAzimuthalEqualArea) forward(lon, lat float64) (float64, float64) {
+	var (
+		dLon = normalizeLon(lon - p.lon0)
+		beta = math.Asin(math.Max(-1, math.Min(1, p.q(math.Sin(lat))/p.qp)))
+		sinB = math.Sin(beta)
+		cosB = math.Cos(beta)
+		b    = p.rq * math.Sqrt(2/(1+p.sinB1*sinB+p.cosB1*cosB*math.Cos(dLon)))
+	)
+	return p.falseE + b*p.d*cosB*math.Sin(dLon),
+		p.falseN + b/p.d*(p.cosB1*sinB-p.sinB1*cosB*math.Cos(dLon))
+}
+
+func (p *lambertAzimuthalEqualArea) inverse(x, y float64) (float64, float64) {
+	x -= p.falseE
+	y -= p.falseN
+	rho := math.Hypot(x/p.d, p.d*y)
+	if rho == 0 {
+		return p.lon0, p.lat0
+	}
+	var (
+		ce   = 2 * math.Asin(rho/(2*p.rq))
+		sinC = math.Sin(ce)
+		cosC = math.Cos(ce)
+		beta = math.Asin(cosC*p.sinB1 + p.d*y*sinC*p.cosB1/rho)
+		lon  = p.lon0 + math.Atan2(x*sinC, p.d*rho*p.cosB1*cosC-p.d*p.d*y*p.sinB1*sinC)
+	)
+	return lon, p.latFromAuthalic(beta)
+}
+
+// latFromAuthalic iterates Snyder 3-16 to find the latitude for an authalic latitude.
+func (p *lambertAzimuthalEqualArea) latFromAuthalic(beta float64) float64 {
+	var (
+		q   = p.qp * math.Sin(beta)
+		lat = beta
+	)
+	if math.Abs(math.Abs(beta)-math.Pi/2) < 1e-12 {
+		return beta
+	}
+	for i := 0; i < 20; i++ {
+		var (
+			sinLat = math.Sin(lat)
+			w      = 1 - p.es*sinLat*sinLat
+			dLat   = w * w / (2 * math.Cos(lat)) *
+				(q/(1-p.es) - sinLat/w + 1/(2*p.e)*math.Log((1-p.e*sinLat)/(1+p.e*sinLat)))
+		)
+		lat += dLat
+		if math.Abs(dLat) < 1e-14 {
+			break
+		}
+	}
+	return lat
+}
+
+// normalizeLon wraps a longitude in radians into the range of -π to π.
+func normalizeLon(lon float64) float64 {
+	return math.Remainder(lon, 2*math.Pi)
+}
+
+func degToRad(v float64) float64 {
+	return v / (180 / math.Pi)
+}
+
+func radToDeg(v float64) float64 {
+	return v * (180 / math.Pi)
+}
@@ -0,0 +1,62 @@
+package proj
+
+import (
+	"fmt"
+
+	"github.com/thomersch/grandine/lib/spatial"
+)
+
+// Transformer converts coordinates from one coordinate reference system into another.
+type Transformer struct {
+	src, dst *CRS
+}
+
+// NewTransformer returns a Transformer from src to dst.
+func NewTransformer(src, dst *CRS) *Transformer {
+	return &Transformer{src: src, dst: dst}
+}
+
+// Point transforms a single point. An error is returned if the point can't be represented in
+// the target system, e.g. the poles in Web Mercator.
+func (t *Transformer) Point(pt spatial.Point) (spatial.Point, error) {
+	if t.src == t.dst || t.src.Code == t.dst.Code {
+		return pt, nil
+	}
+	lon, lat := t.src.inverse(pt.X, pt.Y)
+	if !t.src.datum.sameAs(t.dst.datum) {
+		lon, lat = t.src.datum.shift(lon, lat, t.dst.datum)
+	}
+	x, y := t.dst.forward(lon, lat)
+	if !finite(x) || !finite(y) {
+		return pt, fmt.Errorf("%v can't be transformed from %v into %v", pt, t.src, t.dst)
+	}
+	return spatial.Point{X: x, Y: y}, nil
+}
+
+// Geom transforms the geometry in place. If some of the points can't be transformed, the first
+// error is returned.
+func (t *Transformer) Geom(g spatial.Geom) error {
+	if g.Typ() == spatial.GeomTypeEmpty {
+		return nil
+	}
+	var err error
+	g.Project(func(pt spatial.Point) spatial.Point {
+		res, perr := t.Point(pt)
+		if perr != nil && err == nil {
+			err = perr
+		}
+		return res
+	})
+	return err
+}
+
+// FeatureCollection transforms all features in place and sets the SRID of the collection.
+func (t *Transformer) FeatureCollection(fc *spatial.FeatureCollection) error {
+	for _, ft := range fc.Features {
+		if err := t.Geom(ft.Geometry); err != nil {
+			return err
+		}
+	}
+	fc.SRID = t.dst.SRID()
+	return nil
+}
@@ -0,0 +1,237 @@
+package spaten
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/thomersch/grandine/lib/spatial"
+)
+
+// BlockInfo describes a block of a Spaten file.
+type BlockInfo struct {
+	// Offset of the block header from the start of the file.
+	Offset int64
+	// Summarized is false for blocks which have been written without a summary, e.g. by versions
+	// before 1. Their Count and BBox are unknown.
+	Summarized bool
+	// Count is the number of features in the block.
+	Count int
+	// BBox contains the geometries of all features. If there are none, SW is greater than NE.
+	BBox spatial.BBox
+}
+
+// Intersects reports whether the block may contain features which intersect with bbox. Blocks
+// without summary always may.
+func (bi BlockInfo) Intersects(bbox spatial.BBox) bool {
+	return !bi.Summarized || intersects(bi.BBox, bbox)
+}
+
+// intersects reports whether b intersects with the bbox q, which may cross the antimeridian.
+func intersects(b, q spatial.BBox) bool {
+	for _, qb := range q.Unwrap() {
+		if b.SW.X <= qb.NE.X && b.NE.X >= qb.SW.X && b.SW.Y <= qb.NE.Y && b.NE.Y >= qb.SW.Y {
+			return true
+		}
+	}
+	return false
+}
+
+// The block index is the last block of a file. Its body consists of one entry per block and the
+// footer, which contains the offset of the index block and indexMagic, so it can be found from the
+// end of the file.
+const (
+	indexEntrySize  = 8 + blockSummarySize
+	indexFooterSize = 8 + 4
+	indexMagic      = "SPBI"
+)
+
+func writeBlockIndex(w io.Writer, offset int64, blocks []BlockInfo) error {
+	var (
+		bodyLen = len(blocks)*indexEntrySize + indexFooterSize
+		buf     = make([]byte, blockHeaderSize, blockHeaderSize+bodyLen)
+	)
+	binary.LittleEndian.PutUint32(buf[:4], uint32(bodyLen))
+	buf[7] = messageTypeBlockIndex
+
+	var obuf = make([]byte, 8)
+	for _, bi := range blocks {
+		binary.LittleEndian.PutUint64(obuf, uint64(bi.Offset))
+		buf = appendSummary(append(buf, obuf...), bi)
+	}
+	binary.LittleEndian.PutUint64(obuf, uint64(offset))
+	buf = append(append(buf, obuf...), indexMagic...)
+
+	_, err := w.Write(buf)
+	return err
+}
+
+// ReadBlockIndex returns all blocks of a Spaten file with features. If the file has a block
+// index, only the index is read. Otherwise all block headers are read, skipping the block bodies.
+func ReadBlockIndex(r io.ReadSeeker) ([]BlockInfo, error) {
+	_, blocks, err := readBlockIndex(r)
+	return blocks, err
+}
+
+func readBlockIndex(r io.ReadSeeker) (Header, []BlockInfo, error) {
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return Header{}, nil, err
+	}
+	fhd, offset, err := readFileHeader(r)
+	if err != nil {
+		return fhd, nil, err
+	}
+	size, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return fhd, nil, err
+	}
+
+	blocks, err := readIndexFooter(r, offset, size)
+	if err != nil || blocks != nil {
+		return fhd, blocks, err
+	}
+
+	for {
+		if _, err := r.Seek(offset, io.SeekStart); err != nil {
+			return fhd, nil, err
+		}
+		hd, err := readBlockHeader(r)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fhd, nil, err
+		}
+		if hd.messageType == messageTypeFeatures {
+			info := hd.summary
+			info.Offset = offset
+			blocks = append(blocks, info)
+		}
+		offset += blockHeaderSize + int64(hd.bodyLen)
+		if offset > size {
+			return fhd, nil, errors.New("incomplete block")
+		}
+	}
+	return fhd, blocks, nil
+}
+
+// readIndexFooter reads the block index, if the file ends with one. Otherwise no blocks are
+// returned. start is the offset of the first block.
+func readIndexFooter(r io.ReadSeeker, start, size int64) ([]BlockInfo, error) {
+	if size < start+blockHeaderSize+indexFooterSize {
+		return nil, nil
+	}
+	if _, err := r.Seek(size-indexFooterSize, io.SeekStart); err != nil {
+		return nil, err
+	}
+	var footer = make([]byte, indexFooterSize)
+	if _, err := io.ReadFull(r, footer); err != nil {
+		return nil, err
+	}
+	if string(footer[8:]) != indexMagic {
+		return nil, nil
+	}
+
+	offset := int64(binary.LittleEndian.Uint64(footer[:8]))
+	if offset < start || offset > size-blockHeaderSize-indexFooterSize {
+		return nil, fmt.Errorf("invalid block index offset %v", offset)
+	}
+	if _, err := r.Seek(offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+	hd, err := readBlockHeader(r)
+	if err != nil {
+		return nil, err
+	}
+	entriesLen := int64(hd.bodyLen) - indexFooterSize
+	if hd.messageType != messageTypeBlockIndex || offset+blockHeaderSize+int64(hd.bodyLen) != size || entriesLen%indexEntrySize != 0 {
+		return nil, errors.New("invalid block index")
+	}
+
+	var (
+		buf    = make([]byte, entriesLen)
+		blocks = make([]BlockInfo, 0, entriesLen/indexEntrySize)
+	)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return nil, fmt.Errorf("could not read block index: %v", err)
+	}
+	for ; len(buf) > 0; buf = buf[indexEntrySize:] {
+		info := parseSummary(buf[8:indexEntrySize])
+		info.Offset = int64(binary.LittleEndian.Uint64(buf[:8]))
+		if info.Offset < start || info.Offset >= offset {
+			return nil, fmt.Errorf("invalid block offset %v in block index", info.Offset)
+		}
+		blocks = append(blocks, info)
+	}
+	return blocks, nil
+}
+
+// ChunkedDecodeBBox reads the features whose bbox intersects with bbox a block at a time. Only
+// blocks which intersect with bbox are read, see ReadBlockIndex.
+func (c *Codec) ChunkedDecodeBBox(r io.ReadSeeker, bbox spatial.BBox) (spatial.Chunks, error) {
+	hd, blocks, err := readBlockIndex(r)
+	if err != nil {
+		return nil, err
+	}
+	var matching []BlockInfo
+	for _, bi := range blocks {
+		if bi.Intersects(bbox) {
+			matching = append(matching, bi)
+		}
+	}
+	return &BBoxChunks{reader: r, bbox: bbox, blocks: matching, srid: hd.Metadata.SRID}, nil
+}
+
+// BBoxChunks reads the features of a file which intersect with a bbox, see
+// Codec.ChunkedDecodeBBox.
+type BBoxChunks struct {
+	reader    io.ReadSeeker
+	bbox      spatial.BBox
+	blocks    []BlockInfo
+	srid      string
+	readerMtx sync.Mutex
+}
+
+func (c *BBoxChunks) Next() bool {
+	c.readerMtx.Lock()
+	defer c.readerMtx.Unlock()
+	return len(c.blocks) > 0
+}
+
+// Scan appends the matching features of the next intersecting block to fc.
+func (c *BBoxChunks) Scan(fc *spatial.FeatureCollection) error {
+	c.readerMtx.Lock()
+	defer c.readerMtx.Unlock()
+	if len(c.blocks) == 0 {
+		return io.EOF
+	}
+	bi := c.blocks[0]
+	c.blocks = c.blocks[1:]
+	if len(c.srid) != 0 {
+		fc.SRID = c.srid
+	}
+
+	if _, err := c.reader.Seek(bi.Offset, io.SeekStart); err != nil {
+		return err
+	}
+	body, err := readBlockBody(c.reader)
+	if err != nil {
+		return err
+	}
+	defer blockBodyPool.Put(body)
+	for _, f := range body.GetFeature() {
+		ft, err := UnpackFeature(f)
+		if err != nil {
+			return err
+		}
+		if ft.Geometry.IsEmpty() {
+			continue
+		}
+		if intersects(ft.Geometry.BBox(), c.bbox) {
+			fc.Features = append(fc.Features, ft)
+		}
+	}
+	return nil
+}
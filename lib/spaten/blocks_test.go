@@ -0,0 +1,170 @@
+package spaten
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math/rand"
+	"sort"
+	"strconv"
+	"testing"
+
+	"github.com/thomersch/grandine/lib/spaten/fileformat"
+	"github.com/thomersch/grandine/lib/spatial"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+)
+
+// countingReadSeeker counts the bytes which have been read.
+type countingReadSeeker struct {
+	io.ReadSeeker
+	n int
+}
+
+func (c *countingReadSeeker) Read(p []byte) (int, error) {
+	n, err := c.ReadSeeker.Read(p)
+	c.n += n
+	return n, err
+}
+
+func gridFeatures(n int) spatial.FeatureCollection {
+	var (
+		rnd = rand.New(rand.NewSource(1))
+		fc  spatial.FeatureCollection
+	)
+	for i := 0; i < n; i++ {
+		// features are sorted by their x coordinate, so blocks cover stripes of the area
+		x, y := float64(i)/float64(n)*20, rnd.Float64()*20
+		fc.Features = append(fc.Features, spatial.Feature{
+			Props:    map[string]interface{}{"id": strconv.Itoa(i)},
+			Geometry: spatial.MustNewGeom(spatial.Line{{X: x, Y: y}, {X: x + 0.01, Y: y + 0.5}}),
+		})
+	}
+	return fc
+}
+
+func TestReadBlockIndex(t *testing.T) {
+	fc := gridFeatures(2500)
+
+	for _, c := range []*Codec{{}, {BlockIndex: true}, {BlockIndex: true, Compression: CompressionZstd}} {
+		var buf bytes.Buffer
+		assert.Nil(t, c.Encode(&buf, &fc))
+
+		_, start, err := readFileHeader(bytes.NewReader(buf.Bytes()))
+		assert.Nil(t, err)
+		blocks, err := ReadBlockIndex(bytes.NewReader(buf.Bytes()))
+		assert.Nil(t, err)
+		assert.Len(t, blocks, 3)
+		assert.Equal(t, start, blocks[0].Offset)
+		for i, bi := range blocks {
+			assert.True(t, bi.Summarized)
+			r := bytes.NewReader(buf.Bytes()[bi.Offset:])
+			body, err := readBlockBody(r)
+			assert.Nil(t, err)
+			assert.Len(t, body.GetFeature(), bi.Count)
+
+			var bb = emptyBBox
+			for _, ft := range fc.Features[i*blockSize : i*blockSize+bi.Count] {
+				bb.ExtendWith(ft.Geometry.BBox())
+			}
+			assert.Equal(t, bb, bi.BBox)
+		}
+		assert.Equal(t, 500, blocks[2].Count)
+
+		// the block index is skipped by sequential readers
+		var read spatial.FeatureCollection
+		assert.Nil(t, c.Decode(bytes.NewReader(buf.Bytes()), &read))
+		assert.Len(t, read.Features, len(fc.Features))
+	}
+}
+
+func TestReadBlockIndexEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	assert.Nil(t, (&Codec{BlockIndex: true}).Encode(&buf, &spatial.FeatureCollection{}))
+
+	_, start, err := readFileHeader(bytes.NewReader(buf.Bytes()))
+	assert.Nil(t, err)
+	blocks, err := ReadBlockIndex(bytes.NewReader(buf.Bytes()))
+	assert.Nil(t, err)
+	assert.Equal(t, []BlockInfo{{Offset: start, Summarized: true, BBox: emptyBBox}}, blocks)
+	assert.False(t, blocks[0].Intersects(spatial.BBox{SW: spatial.Point{X: -180, Y: -90}, NE: spatial.Point{X: 180, Y: 90}}))
+}
+
+func TestReadBlockIndexWithoutSummary(t *testing.T) {
+	pf, err := PackFeature(spatial.Feature{Geometry: spatial.MustNewGeom(spatial.Point{X: 1, Y: 2})})
+	assert.Nil(t, err)
+	body, err := proto.Marshal(&fileformat.Body{Feature: []*fileformat.Feature{&pf}})
+	assert.Nil(t, err)
+
+	// a file written by version 0
+	var (
+		buf = []byte("SPAT\x00\x00\x00\x00")
+		hd  = make([]byte, 8)
+	)
+	binary.LittleEndian.PutUint32(hd, uint32(len(body)))
+	buf = append(append(buf, hd...), body...)
+
+	blocks, err := ReadBlockIndex(bytes.NewReader(buf))
+	assert.Nil(t, err)
+	assert.Equal(t, []BlockInfo{{Offset: 8}}, blocks)
+	assert.True(t, blocks[0].Intersects(spatial.BBox{SW: spatial.Point{X: 50, Y: 50}, NE: spatial.Point{X: 51, Y: 51}}))
+
+	chunks, err := (&Codec{}).ChunkedDecodeBBox(bytes.NewReader(buf), spatial.BBox{SW: spatial.Point{X: 0, Y: 0}, NE: spatial.Point{X: 5, Y: 5}})
+	assert.Nil(t, err)
+	var fc spatial.FeatureCollection
+	for chunks.Next() {
+		assert.Nil(t, chunks.Scan(&fc))
+	}
+	assert.Len(t, fc.Features, 1)
+}
+
+func TestInvalidBlockIndex(t *testing.T) {
+	var buf bytes.Buffer
+	assert.Nil(t, (&Codec{BlockIndex: true}).Encode(&buf, &spatial.FeatureCollection{Features: gridFeatures(10).Features}))
+	data := buf.Bytes()
+
+	// offset in the footer points into the first block
+	binary.LittleEndian.PutUint64(data[len(data)-indexFooterSize:], 12)
+	_, err := ReadBlockIndex(bytes.NewReader(data))
+	assert.NotNil(t, err)
+
+	_, err = ReadBlockIndex(bytes.NewReader(data[:len(data)-3]))
+	assert.NotNil(t, err)
+}
+
+func TestChunkedDecodeBBox(t *testing.T) {
+	fc := gridFeatures(10000)
+	for _, c := range []*Codec{{}, {BlockIndex: true, Compression: CompressionSnappy}} {
+		var buf bytes.Buffer
+		assert.Nil(t, c.Encode(&buf, &fc))
+
+		for _, bb := range []spatial.BBox{
+			{SW: spatial.Point{X: 3, Y: 3}, NE: spatial.Point{X: 4.5, Y: 10}},
+			{SW: spatial.Point{X: 19.5, Y: -90}, NE: spatial.Point{X: -179, Y: 90}},
+			{SW: spatial.Point{X: 30, Y: 30}, NE: spatial.Point{X: 40, Y: 40}},
+		} {
+			var expected, found []string
+			for _, ft := range fc.Features {
+				if intersects(ft.Geometry.BBox(), bb) {
+					expected = append(expected, ft.Props["id"].(string))
+				}
+			}
+
+			r := &countingReadSeeker{ReadSeeker: bytes.NewReader(buf.Bytes())}
+			chunks, err := c.ChunkedDecodeBBox(r, bb)
+			assert.Nil(t, err)
+			for chunks.Next() {
+				var chunk spatial.FeatureCollection
+				assert.Nil(t, chunks.Scan(&chunk))
+				for _, ft := range chunk.Features {
+					found = append(found, ft.Props["id"].(string))
+				}
+			}
+			sort.Strings(expected)
+			sort.Strings(found)
+			assert.Equal(t, expected, found)
+			assert.True(t, r.n < buf.Len()/4, "read %v of %v bytes", r.n, buf.Len())
+		}
+	}
+}
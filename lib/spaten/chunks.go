@@ -10,6 +10,8 @@ import (
 type Chunks struct {
 	endReached bool
 	reader     io.Reader
+	// srid is the SRID from the file metadata, which is set on every scanned collection.
+	srid string
 	// Parallel reading of a file is not allowed, could be theoretically improved by reading from
 	// stream and passing the buffer into the decoder, but this needs underlying changes.
 	readerMtx sync.Mutex
@@ -22,6 +24,9 @@ func (c *Chunks) Next() bool {
 func (c *Chunks) Scan(fc *spatial.FeatureCollection) error {
 	c.readerMtx.Lock()
 	defer c.readerMtx.Unlock()
+	if len(c.srid) != 0 {
+		fc.SRID = c.srid
+	}
 	err := readBlock(c.reader, fc)
 	if err == io.EOF {
 		c.endReached = true
@@ -3,18 +3,76 @@ package spaten
 import (
 	"io"
 
+	"github.com/thomersch/grandine/lib/spaten/fileformat"
 	"github.com/thomersch/grandine/lib/spatial"
 )
 
+// DefaultTWKBPrecision is the number of decimal digits of TWKB geometries, if TWKBPrecision is
+// nil. It keeps geographic coordinates accurate to about 1 cm.
+const DefaultTWKBPrecision = 7
+
 type Codec struct {
+	// GeomSerialization selects how geometries are written, WKB by default. TWKB produces
+	// considerably smaller files, but rounds coordinates to TWKBPrecision.
+	GeomSerialization fileformat.Feature_GeomSerialization
+	// TWKBPrecision is the number of decimal digits kept in TWKB geometries, between -8 and 7,
+	// e.g. 0 keeps whole units. If it is nil, DefaultTWKBPrecision is used. Z and M ordinates
+	// are stored with the same number of digits, but at least with 0.
+	TWKBPrecision *int
+	// Compression is the algorithm blocks are compressed with, none by default. Decoding
+	// detects the compression of every block, so it doesn't need to be set for reading.
+	Compression Compression
+	// BlockIndex appends an index of all blocks to the file, so readers which look for features
+	// in a bbox find the matching blocks without reading every block header, see ReadBlockIndex.
+	BlockIndex bool
+	// Metadata is written after the file header, see ReadFileHeader. If its SRID is empty, the
+	// SRID of the (first) feature collection is used. The feature count and bbox are computed.
+	Metadata Metadata
+
 	headerWritten bool
 	writeQueue    []spatial.Feature
+	// offset is the number of bytes written so far, blocks are the blocks written so far, which
+	// are needed for the block index.
+	offset int64
+	blocks []BlockInfo
+	// meta is the metadata which has been written. If it has no stats, they are rewritten by
+	// Close at metaPos, if the writer can seek, otherwise metaPos is -1.
+	meta    Metadata
+	metaPos int64
+	count   uint64
+	bbox    spatial.BBox
+}
+
+func (c *Codec) geomOptions() geomOptions {
+	var precision = DefaultTWKBPrecision
+	if c.TWKBPrecision != nil {
+		precision = *c.TWKBPrecision
+	}
+	var ordPrecision = precision
+	if ordPrecision < 0 {
+		ordPrecision = 0
+	}
+	return geomOptions{
+		serial: c.GeomSerialization,
+		twkb: spatial.TWKBOptions{
+			Precision:  precision,
+			ZPrecision: ordPrecision,
+			MPrecision: ordPrecision,
+		},
+	}
 }
 
 const blockSize = 1000
 
 func (c *Codec) Encode(w io.Writer, fc *spatial.FeatureCollection) error {
-	err := WriteFileHeader(w)
+	md := c.metadata(fc)
+	md.HasStats, md.Count, md.BBox = true, uint64(len(fc.Features)), emptyBBox
+	for _, ft := range fc.Features {
+		if !ft.Geometry.IsEmpty() {
+			md.BBox.ExtendWith(ft.Geometry.BBox())
+		}
+	}
+	err := c.writeHeader(w, md)
 	if err != nil {
 		return err
 	}
@@ -27,18 +85,91 @@ func (c *Codec) Encode(w io.Writer, fc *spatial.FeatureCollection) error {
 			}
 		}
 
-		err = WriteBlock(w, ftBlk, meta)
+		err = c.writeBlock(w, ftBlk, meta)
 		if err != nil {
 			return err
 		}
 	}
+	return c.writeBlockIndex(w)
+}
+
+func (c *Codec) metadata(fc *spatial.FeatureCollection) Metadata {
+	md := c.Metadata
+	if len(md.SRID) == 0 {
+		md.SRID = fc.SRID
+	}
+	md.HasStats, md.Count, md.BBox = false, 0, spatial.BBox{}
+	return md
+}
+
+func (c *Codec) writeHeader(w io.Writer, md Metadata) error {
+	c.offset, c.blocks, c.count, c.bbox = 0, nil, 0, emptyBBox
+	c.meta, c.metaPos = md, -1
+	if ws, ok := w.(io.WriteSeeker); ok {
+		// fails for pipes, whose stats can't be rewritten
+		if pos, err := ws.Seek(0, io.SeekCurrent); err == nil {
+			c.metaPos = pos + headerSize
+		}
+	}
+	n, err := writeFileHeader(w, md)
+	if err != nil {
+		return err
+	}
+	c.offset = int64(n)
 	return nil
 }
 
-// EncodeChunk enqueues features to be written out. Call Close when done with the stream.
+// writeStats rewrites the metadata block with the stats of the written features, if they
+// haven't been known when writing the header.
+func (c *Codec) writeStats(w io.Writer) error {
+	if c.meta.HasStats || c.metaPos < 0 {
+		return nil
+	}
+	ws := w.(io.WriteSeeker)
+	end, err := ws.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	if _, err := ws.Seek(c.metaPos, io.SeekStart); err != nil {
+		return err
+	}
+	c.meta.HasStats, c.meta.Count, c.meta.BBox = true, c.count, c.bbox
+	if _, err := writeMetadata(ws, c.meta); err != nil {
+		return err
+	}
+	_, err = ws.Seek(end, io.SeekStart)
+	return err
+}
+
+func (c *Codec) writeBlock(w io.Writer, fs []spatial.Feature, meta map[string]interface{}) error {
+	info, n, err := writeBlock(w, fs, meta, c.geomOptions(), c.Compression)
+	if err != nil {
+		return err
+	}
+	info.Offset = c.offset
+	c.offset += int64(n)
+	c.count += uint64(info.Count)
+	c.bbox.ExtendWith(info.BBox)
+	if c.BlockIndex {
+		c.blocks = append(c.blocks, info)
+	}
+	return nil
+}
+
+func (c *Codec) writeBlockIndex(w io.Writer) error {
+	if !c.BlockIndex {
+		return nil
+	}
+	err := writeBlockIndex(w, c.offset, c.blocks)
+	c.blocks = nil
+	return err
+}
+
+// EncodeChunk enqueues features to be written out. Call Close when done with the stream. The
+// feature count and bbox are only stored in the metadata, if w is an io.WriteSeeker.
 func (c *Codec) EncodeChunk(w io.Writer, fc *spatial.FeatureCollection) error {
 	if !c.headerWritten {
-		err := WriteFileHeader(w)
+		err := c.writeHeader(w, c.metadata(fc))
 		if err != nil {
 			return err
 		}
@@ -52,7 +183,7 @@ func (c *Codec) EncodeChunk(w io.Writer, fc *spatial.FeatureCollection) error {
 			// the block is not full, so let's schedule for next write
 			newQueue = append(newQueue, ftBlk...)
 		} else {
-			err := WriteBlock(w, ftBlk, nil)
+			err := c.writeBlock(w, ftBlk, nil)
 			if err != nil {
 				return err
 			}
@@ -62,31 +193,42 @@ func (c *Codec) EncodeChunk(w io.Writer, fc *spatial.FeatureCollection) error {
 	return nil
 }
 
+// Close writes the remaining features, the block index, if enabled, and the stats.
 func (c *Codec) Close(w io.Writer) error {
 	if len(c.writeQueue) > 0 {
-		return WriteBlock(w, c.writeQueue, nil)
+		if err := c.writeBlock(w, c.writeQueue, nil); err != nil {
+			return err
+		}
+		c.writeQueue = nil
 	}
-	return nil
+	if err := c.writeBlockIndex(w); err != nil {
+		return err
+	}
+	return c.writeStats(w)
 }
 
 // ChunkedDecode is the preferred method for reading large datasets. It retrieves a file block
 // at a time, making it possible to traverse the file in a streaming manner without allocating
 // enough memory to fit the whole file.
 func (c *Codec) ChunkedDecode(r io.Reader) (spatial.Chunks, error) {
-	_, err := ReadFileHeader(r)
+	hd, err := ReadFileHeader(r)
 	if err != nil {
 		return nil, err
 	}
 	return &Chunks{
 		reader: r,
+		srid:   hd.Metadata.SRID,
 	}, nil
 }
 
 func (c *Codec) Decode(r io.Reader, fc *spatial.FeatureCollection) error {
-	_, err := ReadFileHeader(r)
+	hd, err := ReadFileHeader(r)
 	if err != nil {
 		return err
 	}
+	if len(hd.Metadata.SRID) != 0 {
+		fc.SRID = hd.Metadata.SRID
+	}
 	err = ReadBlocks(r, fc)
 	return err
 }
@@ -5,9 +5,124 @@ import (
 	"testing"
 
 	"github.com/thomersch/grandine/lib/geojson"
+	"github.com/thomersch/grandine/lib/spaten/fileformat"
 	"github.com/thomersch/grandine/lib/spatial"
+
+	"github.com/stretchr/testify/assert"
 )
 
+func TestCodecTWKB(t *testing.T) {
+	line := spatial.MustNewGeom(spatial.Line{{24.123456, 1.5}, {25, 0}, {9, -4}})
+	assert.Nil(t, line.SetZ([]float64{100.25, 200, 150}))
+
+	var (
+		buf bytes.Buffer
+		fc  = spatial.FeatureCollection{Features: []spatial.Feature{
+			{Props: map[string]interface{}{"name": "Rhine"}, Geometry: line},
+			{Props: map[string]interface{}{"name": "Isle"}, Geometry: spatial.MustNewGeom(spatial.MultiPolygon{
+				{{{24, 1}, {25, 0}, {9, -4}}},
+				{{{-24, 1}, {-25, 0}, {-9, -4}}},
+			})},
+		}}
+		precision = 2
+		c         = Codec{GeomSerialization: fileformat.Feature_TWKB, TWKBPrecision: &precision}
+	)
+	assert.Nil(t, c.Encode(&buf, &fc))
+
+	var wkbBuf bytes.Buffer
+	assert.Nil(t, (&Codec{}).Encode(&wkbBuf, &fc))
+	assert.True(t, buf.Len() < wkbBuf.Len())
+
+	var read spatial.FeatureCollection
+	assert.Nil(t, c.Decode(&buf, &read))
+	assert.Len(t, read.Features, 2)
+	assert.Equal(t, spatial.Line{{24.12, 1.5}, {25, 0}, {9, -4}}, read.Features[0].Geometry.MustLineString())
+	assert.Equal(t, []float64{100.25, 200, 150}, read.Features[0].Geometry.Z())
+	assert.Equal(t, fc.Features[1], read.Features[1])
+
+	// without precision, coordinates are not rounded to whole degrees
+	buf.Reset()
+	c = Codec{GeomSerialization: fileformat.Feature_TWKB}
+	assert.Nil(t, c.Encode(&buf, &fc))
+	read = spatial.FeatureCollection{}
+	assert.Nil(t, c.Decode(&buf, &read))
+	assert.Equal(t, spatial.Line{{24.123456, 1.5}, {25, 0}, {9, -4}}, read.Features[0].Geometry.MustLineString())
+
+	// a precision of 0 keeps whole units
+	buf.Reset()
+	precision = 0
+	c = Codec{GeomSerialization: fileformat.Feature_TWKB, TWKBPrecision: &precision}
+	assert.Nil(t, c.Encode(&buf, &fc))
+	read = spatial.FeatureCollection{}
+	assert.Nil(t, c.Decode(&buf, &read))
+	assert.Equal(t, spatial.Line{{24, 2}, {25, 0}, {9, -4}}, read.Features[0].Geometry.MustLineString())
+}
+
+func TestCodecCompression(t *testing.T) {
+	var fc spatial.FeatureCollection
+	for i := 0; i < 2500; i++ {
+		fc.Features = append(fc.Features, spatial.Feature{
+			Props:    map[string]interface{}{"name": "feature", "n": int64(i)},
+			Geometry: spatial.MustNewGeom(spatial.Line{{X: float64(i), Y: 1}, {X: float64(i), Y: 2}}),
+		})
+	}
+	var raw bytes.Buffer
+	assert.Nil(t, (&Codec{}).Encode(&raw, &fc))
+
+	for _, comp := range []Compression{CompressionGzip, CompressionDeflate, CompressionZstd, CompressionSnappy} {
+		t.Run(comp.String(), func(t *testing.T) {
+			var (
+				buf bytes.Buffer
+				c   = Codec{Compression: comp}
+			)
+			assert.Nil(t, c.Encode(&buf, &fc))
+			assert.True(t, buf.Len() < raw.Len()/2, "%v of %v bytes", buf.Len(), raw.Len())
+			_, start, err := readFileHeader(bytes.NewReader(buf.Bytes()))
+			assert.Nil(t, err)
+			assert.Equal(t, uint8(comp), buf.Bytes()[start+6])
+
+			var read spatial.FeatureCollection
+			assert.Nil(t, (&Codec{}).Decode(bytes.NewReader(buf.Bytes()), &read))
+			assert.Equal(t, fc.Features, read.Features)
+
+			var index bytes.Buffer
+			assert.Nil(t, BuildIndex(bytes.NewReader(buf.Bytes()), &index))
+
+			// streamed writing and reading
+			buf.Reset()
+			assert.Nil(t, c.EncodeChunk(&buf, &spatial.FeatureCollection{Features: fc.Features[:1500]}))
+			assert.Nil(t, c.EncodeChunk(&buf, &spatial.FeatureCollection{Features: fc.Features[1500:]}))
+			assert.Nil(t, c.Close(&buf))
+
+			chunks, err := c.ChunkedDecode(&buf)
+			assert.Nil(t, err)
+			var n int
+			for chunks.Next() {
+				var chunk spatial.FeatureCollection
+				assert.Nil(t, chunks.Scan(&chunk))
+				for _, ft := range chunk.Features {
+					assert.Equal(t, fc.Features[n], ft)
+					n++
+				}
+			}
+			assert.Equal(t, len(fc.Features), n)
+		})
+	}
+}
+
+func TestParseCompression(t *testing.T) {
+	for _, comp := range []Compression{CompressionNone, CompressionGzip, CompressionDeflate, CompressionZstd, CompressionSnappy} {
+		parsed, err := ParseCompression(comp.String())
+		assert.Nil(t, err)
+		assert.Equal(t, comp, parsed)
+	}
+	parsed, err := ParseCompression("")
+	assert.Nil(t, err)
+	assert.Equal(t, CompressionNone, parsed)
+	_, err = ParseCompression("lzma")
+	assert.NotNil(t, err)
+}
+
 func BenchmarkCodecThroughput(b *testing.B) {
 	var (
 		fc  = &spatial.FeatureCollection{Features: []spatial.Feature{}}
@@ -0,0 +1,162 @@
+package spaten
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sync"
+
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Compression is the algorithm block bodies are compressed with. It is stored in the block
+// header, so every block can be decompressed without further configuration.
+type Compression uint8
+
+const (
+	CompressionNone    Compression = 0
+	CompressionGzip    Compression = 1
+	CompressionDeflate Compression = 2
+	CompressionZstd    Compression = 3
+	// CompressionSnappy uses the framing format of Snappy, not the block format.
+	CompressionSnappy Compression = 4
+)
+
+var compressionNames = map[Compression]string{
+	CompressionNone:    "none",
+	CompressionGzip:    "gzip",
+	CompressionDeflate: "deflate",
+	CompressionZstd:    "zstd",
+	CompressionSnappy:  "snappy",
+}
+
+func (c Compression) String() string {
+	if name, ok := compressionNames[c]; ok {
+		return name
+	}
+	return fmt.Sprintf("unknown (%d)", uint8(c))
+}
+
+// ParseCompression returns the compression with the given name, e.g. "zstd". An empty name
+// means no compression.
+func ParseCompression(name string) (Compression, error) {
+	if name == "" {
+		return CompressionNone, nil
+	}
+	for c, n := range compressionNames {
+		if n == name {
+			return c, nil
+		}
+	}
+	return CompressionNone, fmt.Errorf("unknown compression %q, supported are none, gzip, deflate, zstd and snappy", name)
+}
+
+// Encoders and decoders allocate large buffers, so they are reused across blocks.
+var (
+	zstdEncoderPool = sync.Pool{
+		New: func() interface{} {
+			enc, _ := zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1))
+			return enc
+		},
+	}
+	zstdDecoderPool = sync.Pool{
+		New: func() interface{} {
+			dec, _ := zstd.NewReader(nil, zstd.WithDecoderConcurrency(1))
+			return dec
+		},
+	}
+	gzipReaderPool sync.Pool
+)
+
+// compress returns buf compressed with c. For CompressionNone buf is returned as is.
+func compress(c Compression, buf []byte) ([]byte, error) {
+	if c == CompressionNone {
+		return buf, nil
+	}
+
+	var (
+		out = bytes.NewBuffer(make([]byte, 0, len(buf)/2))
+		cw  io.WriteCloser
+	)
+	switch c {
+	case CompressionGzip:
+		cw = gzip.NewWriter(out)
+	case CompressionDeflate:
+		fw, err := flate.NewWriter(out, flate.DefaultCompression)
+		if err != nil {
+			return nil, err
+		}
+		cw = fw
+	case CompressionZstd:
+		enc := zstdEncoderPool.Get().(*zstd.Encoder)
+		defer zstdEncoderPool.Put(enc)
+		enc.Reset(out)
+		cw = enc
+	case CompressionSnappy:
+		cw = snappy.NewBufferedWriter(out)
+	default:
+		return nil, fmt.Errorf("compression %v is not supported", c)
+	}
+
+	if _, err := cw.Write(buf); err != nil {
+		return nil, err
+	}
+	if err := cw.Close(); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// decompress reads the compressed block body from r and decompresses it while reading, so the
+// compressed body is never held in memory as a whole. r must return EOF at the end of the body.
+func decompress(c Compression, r io.Reader, sizeHint int) ([]byte, error) {
+	var (
+		out = bytes.NewBuffer(make([]byte, 0, sizeHint))
+		err error
+	)
+	switch c {
+	case CompressionGzip:
+		var gr *gzip.Reader
+		if pooled := gzipReaderPool.Get(); pooled != nil {
+			gr = pooled.(*gzip.Reader)
+			err = gr.Reset(r)
+		} else {
+			gr, err = gzip.NewReader(r)
+		}
+		if err != nil {
+			return nil, err
+		}
+		_, err = io.Copy(out, gr)
+		gzipReaderPool.Put(gr)
+	case CompressionDeflate:
+		fr := flate.NewReader(r)
+		_, err = io.Copy(out, fr)
+		fr.Close()
+	case CompressionZstd:
+		dec := zstdDecoderPool.Get().(*zstd.Decoder)
+		if err = dec.Reset(r); err != nil {
+			return nil, err
+		}
+		_, err = io.Copy(out, dec)
+		// release the reference to r, it must not be read after returning
+		dec.Reset(nil)
+		zstdDecoderPool.Put(dec)
+	case CompressionSnappy:
+		_, err = io.Copy(out, snappy.NewReader(r))
+	default:
+		return nil, fmt.Errorf("compression %v is not supported", c)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not decompress block (%v): %v", c, err)
+	}
+	// Decompressors may stop before the end of the body, e.g. if there is trailing data, which
+	// needs to be skipped, so the next block can be read.
+	if _, err := io.Copy(ioutil.Discard, r); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
@@ -37,10 +37,14 @@ const _ = proto.ProtoPackageIsVersion2 // please upgrade the proto package
 type Feature_GeomType int32
 
 const (
-	Feature_UNKNOWN Feature_GeomType = 0
-	Feature_POINT   Feature_GeomType = 1
-	Feature_LINE    Feature_GeomType = 2
-	Feature_POLYGON Feature_GeomType = 3
+	Feature_UNKNOWN      Feature_GeomType = 0
+	Feature_POINT        Feature_GeomType = 1
+	Feature_LINE         Feature_GeomType = 2
+	Feature_POLYGON      Feature_GeomType = 3
+	Feature_MULTIPOINT   Feature_GeomType = 4
+	Feature_MULTILINE    Feature_GeomType = 5
+	Feature_MULTIPOLYGON Feature_GeomType = 6
+	Feature_COLLECTION   Feature_GeomType = 7
 )
 
 var Feature_GeomType_name = map[int32]string{
@@ -48,12 +52,20 @@ var Feature_GeomType_name = map[int32]string{
 	1: "POINT",
 	2: "LINE",
 	3: "POLYGON",
+	4: "MULTIPOINT",
+	5: "MULTILINE",
+	6: "MULTIPOLYGON",
+	7: "COLLECTION",
 }
 var Feature_GeomType_value = map[string]int32{
-	"UNKNOWN": 0,
-	"POINT":   1,
-	"LINE":    2,
-	"POLYGON": 3,
+	"UNKNOWN":      0,
+	"POINT":        1,
+	"LINE":         2,
+	"POLYGON":      3,
+	"MULTIPOINT":   4,
+	"MULTILINE":    5,
+	"MULTIPOLYGON": 6,
+	"COLLECTION":   7,
 }
 
 func (x Feature_GeomType) String() string {
@@ -64,14 +76,17 @@ func (Feature_GeomType) EnumDescriptor() ([]byte, []int) { return fileDescriptor
 type Feature_GeomSerialization int32
 
 const (
-	Feature_WKB Feature_GeomSerialization = 0
+	Feature_WKB  Feature_GeomSerialization = 0
+	Feature_TWKB Feature_GeomSerialization = 1
 )
 
 var Feature_GeomSerialization_name = map[int32]string{
 	0: "WKB",
+	1: "TWKB",
 }
 var Feature_GeomSerialization_value = map[string]int32{
-	"WKB": 0,
+	"WKB":  0,
+	"TWKB": 1,
 }
 
 func (x Feature_GeomSerialization) String() string {
@@ -85,19 +100,42 @@ type Tag_ValueType int32
 
 const (
 	Tag_STRING Tag_ValueType = 0
+	// int64
 	Tag_INT    Tag_ValueType = 1
 	Tag_DOUBLE Tag_ValueType = 2
+	Tag_BOOL   Tag_ValueType = 3
+	// uint64
+	Tag_UINT Tag_ValueType = 4
+	// float32
+	Tag_FLOAT Tag_ValueType = 5
+	Tag_BYTES Tag_ValueType = 6
+	// null has an empty value
+	Tag_NULL Tag_ValueType = 7
+	// homogeneous list, the first byte is the type of the elements
+	Tag_LIST Tag_ValueType = 8
 )
 
 var Tag_ValueType_name = map[int32]string{
 	0: "STRING",
 	1: "INT",
 	2: "DOUBLE",
+	3: "BOOL",
+	4: "UINT",
+	5: "FLOAT",
+	6: "BYTES",
+	7: "NULL",
+	8: "LIST",
 }
 var Tag_ValueType_value = map[string]int32{
 	"STRING": 0,
 	"INT":    1,
 	"DOUBLE": 2,
+	"BOOL":   3,
+	"UINT":   4,
+	"FLOAT":  5,
+	"BYTES":  6,
+	"NULL":   7,
+	"LIST":   8,
 }
 
 func (x Tag_ValueType) String() string {
@@ -155,6 +193,8 @@ type Feature struct {
 	Top    float64 `protobuf:"fixed64,6,opt,name=top,proto3" json:"top,omitempty"`
 	Bottom float64 `protobuf:"fixed64,7,opt,name=bottom,proto3" json:"bottom,omitempty"`
 	Tags   []*Tag  `protobuf:"bytes,8,rep,name=tags" json:"tags,omitempty"`
+	// optional feature id, 0 if the feature has none
+	Id uint64 `protobuf:"varint,9,opt,name=id,proto3" json:"id,omitempty"`
 }
 
 func (m *Feature) Reset()                    { *m = Feature{} }
@@ -218,6 +258,13 @@ func (m *Feature) GetTags() []*Tag {
 	return nil
 }
 
+func (m *Feature) GetId() uint64 {
+	if m != nil {
+		return m.Id
+	}
+	return 0
+}
+
 type Tag struct {
 	Key   string        `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
 	Value []byte        `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
@@ -396,6 +443,11 @@ func (m *Feature) MarshalTo(dAtA []byte) (int, error) {
 			i += n
 		}
 	}
+	if m.Id != 0 {
+		dAtA[i] = 0x48
+		i++
+		i = encodeVarintFileformat(dAtA, i, uint64(m.Id))
+	}
 	return i, nil
 }
 
@@ -502,6 +554,9 @@ func (m *Feature) Size() (n int) {
 			n += 1 + l + sovFileformat(uint64(l))
 		}
 	}
+	if m.Id != 0 {
+		n += 1 + sovFileformat(uint64(m.Id))
+	}
 	return n
 }
 
@@ -903,6 +958,25 @@ func (m *Feature) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
+		case 9:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Id", wireType)
+			}
+			m.Id = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowFileformat
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Id |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipFileformat(dAtA[iNdEx:])
@@ -1161,32 +1235,37 @@ var (
 func init() { proto.RegisterFile("fileformat.proto", fileDescriptorFileformat) }
 
 var fileDescriptorFileformat = []byte{
-	// 418 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x64, 0x52, 0xc1, 0x6e, 0xd3, 0x40,
-	0x14, 0xcc, 0xda, 0x1b, 0xdb, 0x79, 0xa9, 0xaa, 0xed, 0x0a, 0xa1, 0x05, 0xa1, 0xc8, 0xda, 0x53,
-	0x0e, 0xe0, 0x43, 0x38, 0xc1, 0x31, 0x22, 0x44, 0x51, 0x83, 0x5d, 0x6d, 0x5d, 0x2a, 0x8e, 0x5b,
-	0xb1, 0x31, 0x16, 0x31, 0x1b, 0xb9, 0x5b, 0xa4, 0x70, 0xe1, 0x37, 0xf8, 0x24, 0x6e, 0xf0, 0x09,
-	0x28, 0xfc, 0x08, 0xda, 0xe7, 0x26, 0xa2, 0xca, 0x6d, 0x66, 0xde, 0x1b, 0xef, 0x9b, 0x91, 0x81,
-	0xad, 0xea, 0xb5, 0x59, 0xd9, 0xb6, 0xd1, 0x2e, 0xdb, 0xb4, 0xd6, 0x59, 0x39, 0x03, 0x3a, 0xb5,
-	0x1f, 0xb7, 0xfc, 0x09, 0xd0, 0xc6, 0x38, 0x2d, 0x48, 0x4a, 0xc6, 0xc3, 0x49, 0x3f, 0x7b, 0x67,
-	0x9c, 0x56, 0x28, 0x71, 0x09, 0xf1, 0xca, 0x68, 0x77, 0xd7, 0x1a, 0x11, 0xa4, 0xe1, 0x78, 0x38,
-	0x49, 0xb2, 0xb7, 0x1d, 0x57, 0xfb, 0x81, 0x4c, 0x81, 0x7a, 0x07, 0x17, 0x40, 0x9d, 0xae, 0x6e,
-	0x05, 0xc1, 0x45, 0x9a, 0x95, 0xba, 0x52, 0xa8, 0xc8, 0x5f, 0x01, 0xc4, 0xf7, 0x36, 0xfe, 0x02,
-	0x92, 0xca, 0xd8, 0xc6, 0x6d, 0x37, 0x06, 0x1f, 0x3c, 0x9d, 0x9c, 0xed, 0x3f, 0x99, 0xcd, 0x8d,
-	0x6d, 0xca, 0xed, 0xc6, 0xa8, 0xc3, 0x0a, 0x7f, 0x0d, 0xe0, 0xf1, 0xad, 0x69, 0x6b, 0xbd, 0x16,
-	0x01, 0x1a, 0x9e, 0x3e, 0x30, 0x5c, 0xe2, 0xa8, 0xfe, 0xa6, 0x5d, 0x6d, 0xbf, 0xa8, 0xff, 0xb6,
-	0x39, 0x07, 0xea, 0x99, 0x08, 0x53, 0x32, 0x3e, 0x51, 0x88, 0xbd, 0xb6, 0x36, 0x2b, 0x27, 0x68,
-	0x4a, 0xc6, 0x44, 0x21, 0xe6, 0x8f, 0xa0, 0xdf, 0xd6, 0xd5, 0x27, 0x27, 0xfa, 0x28, 0x76, 0x84,
-	0x33, 0x08, 0x9d, 0xdd, 0x88, 0x08, 0x35, 0x0f, 0xf9, 0x63, 0x88, 0x6e, 0xac, 0x73, 0xb6, 0x11,
-	0x31, 0x8a, 0xf7, 0xec, 0x10, 0x3c, 0x39, 0x0a, 0xfe, 0x0a, 0x92, 0x7d, 0x26, 0x3e, 0x84, 0xf8,
-	0x2a, 0x3f, 0xcf, 0x8b, 0xeb, 0x9c, 0xf5, 0xf8, 0x00, 0xfa, 0x17, 0xc5, 0x22, 0x2f, 0x19, 0xe1,
-	0x09, 0xd0, 0xe5, 0x22, 0x9f, 0xb1, 0xc0, 0x6f, 0x5c, 0x14, 0xcb, 0x0f, 0xf3, 0x22, 0x67, 0xa1,
-	0x7c, 0x06, 0x67, 0x47, 0xe9, 0x78, 0x0c, 0xe1, 0xf5, 0xf9, 0x94, 0xf5, 0xe4, 0x77, 0x08, 0x4b,
-	0x5d, 0xf9, 0x1b, 0x3f, 0x9b, 0x2d, 0xf6, 0x38, 0x50, 0x1e, 0xfa, 0x2c, 0x5f, 0xf5, 0xfa, 0xce,
-	0x60, 0x55, 0x27, 0xaa, 0x23, 0x5c, 0x02, 0xc5, 0xc2, 0x43, 0xec, 0xef, 0xd4, 0x5f, 0x98, 0xbd,
-	0xf7, 0x13, 0x6c, 0x1b, 0x67, 0xf2, 0x39, 0x0c, 0x0e, 0x12, 0x07, 0x88, 0x2e, 0x4b, 0xb5, 0xc8,
-	0xe7, 0xac, 0xe7, 0x1f, 0xed, 0x2e, 0x05, 0x88, 0xde, 0x14, 0x57, 0xd3, 0xe5, 0x8c, 0x05, 0x53,
-	0xf6, 0x73, 0x37, 0x22, 0xbf, 0x77, 0x23, 0xf2, 0x67, 0x37, 0x22, 0x3f, 0xfe, 0x8e, 0x7a, 0x37,
-	0x11, 0xfe, 0x54, 0x2f, 0xff, 0x05, 0x00, 0x00, 0xff, 0xff, 0x38, 0xb9, 0xec, 0xaa, 0x68, 0x02,
-	0x00, 0x00,
+	// 505 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x64, 0x52, 0xcb, 0x6e, 0xda, 0x4c,
+	0x14, 0x66, 0xf0, 0xf8, 0x76, 0xe0, 0x47, 0x93, 0xd1, 0xaf, 0x6a, 0xda, 0x05, 0xb2, 0xbc, 0xa8,
+	0xd8, 0xd4, 0x0b, 0xba, 0xeb, 0xae, 0x4e, 0x09, 0x42, 0x71, 0xec, 0x68, 0x18, 0x1a, 0x65, 0xe9,
+	0x88, 0x81, 0xba, 0x85, 0x1a, 0x91, 0x49, 0x25, 0xf2, 0x24, 0x7d, 0x86, 0xbe, 0x43, 0xf7, 0x5d,
+	0xf6, 0x11, 0x2a, 0xfa, 0x22, 0xd5, 0x1c, 0x03, 0xea, 0x65, 0xf7, 0x9d, 0xef, 0xe2, 0xd1, 0x77,
+	0x8e, 0x81, 0x2d, 0xaa, 0x95, 0x5e, 0xd4, 0xdb, 0x75, 0x69, 0x92, 0xcd, 0xb6, 0x36, 0x75, 0x3c,
+	0x02, 0x9a, 0xd6, 0xf3, 0x1d, 0x7f, 0x0a, 0x74, 0xad, 0x4d, 0x29, 0x48, 0x44, 0x06, 0x9d, 0xa1,
+	0x9b, 0x5c, 0x69, 0x53, 0x4a, 0xa4, 0x78, 0x0c, 0xfe, 0x42, 0x97, 0xe6, 0x61, 0xab, 0x45, 0x3b,
+	0x72, 0x06, 0x9d, 0x61, 0x90, 0x5c, 0x34, 0xb3, 0x3c, 0x0a, 0x71, 0x04, 0xd4, 0x26, 0xb8, 0x00,
+	0x6a, 0xca, 0xe5, 0xbd, 0x20, 0x68, 0xa4, 0x89, 0x2a, 0x97, 0x12, 0x99, 0xf8, 0x8b, 0x03, 0xfe,
+	0x21, 0xc6, 0x5f, 0x40, 0xb0, 0xd4, 0xf5, 0xda, 0xec, 0x36, 0x1a, 0x1f, 0xec, 0x0d, 0xcf, 0x8e,
+	0x9f, 0x4c, 0xc6, 0xba, 0x5e, 0xab, 0xdd, 0x46, 0xcb, 0x93, 0x85, 0xbf, 0x02, 0xb0, 0xf8, 0x5e,
+	0x6f, 0xab, 0x72, 0x25, 0xda, 0x18, 0x78, 0xf6, 0x47, 0x60, 0x8a, 0x52, 0xf5, 0x58, 0x9a, 0xaa,
+	0xfe, 0x28, 0x7f, 0x73, 0x73, 0x0e, 0xd4, 0x4e, 0xc2, 0x89, 0xc8, 0xa0, 0x2b, 0x11, 0x5b, 0x6e,
+	0xa5, 0x17, 0x46, 0xd0, 0x88, 0x0c, 0x88, 0x44, 0xcc, 0xff, 0x07, 0x77, 0x5b, 0x2d, 0xdf, 0x19,
+	0xe1, 0x22, 0xd9, 0x0c, 0x9c, 0x81, 0x63, 0xea, 0x8d, 0xf0, 0x90, 0xb3, 0x90, 0x3f, 0x01, 0xef,
+	0xae, 0x36, 0xa6, 0x5e, 0x0b, 0x1f, 0xc9, 0xc3, 0x74, 0x2a, 0x1e, 0xfc, 0x5d, 0x9c, 0xf7, 0xa0,
+	0x5d, 0xcd, 0x45, 0x18, 0x91, 0x01, 0x95, 0xed, 0x6a, 0x1e, 0x3f, 0x42, 0x70, 0xec, 0xc8, 0x3b,
+	0xe0, 0xcf, 0xf2, 0xcb, 0xbc, 0xb8, 0xc9, 0x59, 0x8b, 0x87, 0xe0, 0x5e, 0x17, 0x93, 0x5c, 0x31,
+	0xc2, 0x03, 0xa0, 0xd9, 0x24, 0x1f, 0xb1, 0xb6, 0x75, 0x5c, 0x17, 0xd9, 0xed, 0xb8, 0xc8, 0x99,
+	0xc3, 0x7b, 0x00, 0x57, 0xb3, 0x4c, 0x4d, 0x1a, 0x1b, 0xe5, 0xff, 0x41, 0x88, 0x33, 0x7a, 0x5d,
+	0xce, 0xa0, 0x7b, 0x90, 0x9b, 0x80, 0x67, 0x03, 0xe7, 0x45, 0x96, 0x8d, 0xce, 0xd5, 0xa4, 0xc8,
+	0x99, 0x1f, 0x3f, 0x87, 0xb3, 0x7f, 0xd6, 0xc5, 0x7d, 0x70, 0x6e, 0x2e, 0x53, 0xd6, 0xb2, 0xaf,
+	0x2a, 0x8b, 0x48, 0xfc, 0x95, 0x80, 0xa3, 0xca, 0xa5, 0xed, 0xff, 0x41, 0xef, 0xf0, 0x46, 0xa1,
+	0xb4, 0xd0, 0xee, 0xe9, 0x53, 0xb9, 0x7a, 0xd0, 0x78, 0x86, 0xae, 0x6c, 0x06, 0x1e, 0x03, 0xc5,
+	0x63, 0x3a, 0x78, 0x9b, 0x9e, 0x6d, 0x9f, 0xbc, 0xb5, 0x0a, 0x5e, 0x12, 0xb5, 0xf8, 0x3d, 0x84,
+	0x27, 0x8a, 0x03, 0x78, 0x53, 0x25, 0x27, 0xf9, 0x98, 0xb5, 0xec, 0xfb, 0x4d, 0x6b, 0x00, 0xef,
+	0x4d, 0x31, 0x4b, 0x33, 0xdb, 0x3b, 0x00, 0x9a, 0x16, 0x45, 0xc6, 0x1c, 0x8b, 0x66, 0x4d, 0xdd,
+	0x10, 0xdc, 0x8b, 0xac, 0x78, 0xad, 0x98, 0x6b, 0x61, 0x7a, 0xab, 0x46, 0x53, 0xe6, 0x59, 0x3d,
+	0x9f, 0x65, 0x19, 0xf3, 0x9b, 0xad, 0x4d, 0x15, 0x0b, 0x52, 0xf6, 0x6d, 0xdf, 0x27, 0xdf, 0xf7,
+	0x7d, 0xf2, 0x63, 0xdf, 0x27, 0x9f, 0x7f, 0xf6, 0x5b, 0x77, 0x1e, 0xfe, 0xee, 0x2f, 0x7f, 0x0d,
+	0x00, 0x9b, 0x3a, 0xf3, 0xa4, 0x02, 0x03, 0x00, 0x00,
 }
@@ -1,62 +1,264 @@
 package fileformat
 
 import (
-	"bytes"
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"math"
+	"reflect"
+
+	"github.com/thomersch/grandine/lib/spatial"
 )
 
-var vtypers map[string]valueTyper
-
-func init() {
-	vtypers = map[string]valueTyper{
-		"string": func(s interface{}) ([]byte, Tag_ValueType, error) {
-			return []byte(s.(string)), Tag_STRING, nil
-		},
-		"int": func(s interface{}) ([]byte, Tag_ValueType, error) {
-			var buf = make([]byte, 8)
-			binary.LittleEndian.PutUint64(buf, uint64(s.(int)))
-			return buf, Tag_INT, nil
-		},
-		"float64": func(f interface{}) ([]byte, Tag_ValueType, error) {
-			var buf = make([]byte, 8)
-			binary.LittleEndian.PutUint64(buf, math.Float64bits(f.(float64)))
-			return buf, Tag_DOUBLE, nil
-		},
-		"<nil>": func(f interface{}) ([]byte, Tag_ValueType, error) {
-			return []byte{}, Tag_STRING, nil
-		},
+// tagMap marks maps in tagValue, they are stored as JSON strings.
+const tagMap Tag_ValueType = -1
+
+// ValueType serializes a property value into a tag value. Values are interpreted by
+// spatial.ValueOf, except for float32 and []byte, which keep their type:
+//
+//	null       NULL with an empty value
+//	bool       BOOL, 1 byte
+//	int64      INT, 8 bytes
+//	uint64     UINT, 8 bytes
+//	float64    DOUBLE, 8 bytes
+//	float32    FLOAT, 4 bytes
+//	string     STRING
+//	[]byte     BYTES
+//	list       LIST, if all elements have the same type, which is neither null, list nor map
+//	map        STRING with the JSON representation, like lists which are not homogeneous
+//
+// Numbers are little endian. Lists start with a byte containing the type of their elements,
+// followed by the elements, strings and bytes are prefixed with their length as uvarint. Empty
+// lists have the element type NULL.
+func ValueType(i interface{}) ([]byte, Tag_ValueType, error) {
+	typ, v, err := tagValue(i)
+	if err != nil {
+		return nil, Tag_STRING, err
 	}
+	switch typ {
+	case tagMap:
+		return []byte(v.(spatial.Value).String()), Tag_STRING, nil
+	case Tag_LIST:
+		if buf, ok := homogeneousList(i); ok {
+			return buf, Tag_LIST, nil
+		}
+		// lists with mixed types are stored like maps
+		return []byte(v.(spatial.Value).String()), Tag_STRING, nil
+	}
+	return appendValue(nil, typ, v, false), typ, nil
 }
 
-type valueTyper func(interface{}) ([]byte, Tag_ValueType, error)
+// tagValue determines the tag type of a value and returns it in its canonical form. Lists and
+// maps are returned as spatial.Value.
+func tagValue(i interface{}) (Tag_ValueType, interface{}, error) {
+	switch t := i.(type) {
+	case float32:
+		return Tag_FLOAT, t, nil
+	case []byte:
+		return Tag_BYTES, t, nil
+	}
 
-func ValueType(i interface{}) ([]byte, Tag_ValueType, error) {
-	t := fmt.Sprintf("%T", i)
-	vt, ok := vtypers[t]
-	if !ok {
-		return nil, Tag_STRING, fmt.Errorf("unknown type: %s (value: %v)", t, i)
+	v, err := spatial.ValueOf(i)
+	if err != nil {
+		return Tag_NULL, nil, err
+	}
+	switch v.Typ() {
+	case spatial.ValueTypeNull:
+		return Tag_NULL, nil, nil
+	case spatial.ValueTypeBool:
+		return Tag_BOOL, v.Interface(), nil
+	case spatial.ValueTypeInt:
+		return Tag_INT, v.Interface(), nil
+	case spatial.ValueTypeUint:
+		return Tag_UINT, v.Interface(), nil
+	case spatial.ValueTypeFloat:
+		return Tag_DOUBLE, v.Interface(), nil
+	case spatial.ValueTypeString:
+		return Tag_STRING, v.Interface(), nil
+	case spatial.ValueTypeList:
+		return Tag_LIST, v, nil
+	default:
+		return tagMap, v, nil
+	}
+}
+
+// homogeneousList serializes a list, whose elements all have the same scalar type. Elements of
+// Go slices keep their type, so []float32 is stored as a list of FLOAT.
+func homogeneousList(i interface{}) ([]byte, bool) {
+	var elems []interface{}
+	if v, ok := i.(spatial.Value); ok {
+		for _, e := range v.List() {
+			elems = append(elems, e)
+		}
+	} else {
+		rv := reflect.ValueOf(i)
+		for rv.Kind() == reflect.Ptr {
+			rv = rv.Elem()
+		}
+		if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+			return nil, false
+		}
+		for n := 0; n < rv.Len(); n++ {
+			elems = append(elems, rv.Index(n).Interface())
+		}
+	}
+	if len(elems) == 0 {
+		return []byte{byte(Tag_NULL)}, true
+	}
+
+	var (
+		buf      = []byte{0}
+		elemType Tag_ValueType
+	)
+	for n, e := range elems {
+		typ, v, err := tagValue(e)
+		if err != nil || typ == Tag_NULL || typ == Tag_LIST || typ == tagMap || (n > 0 && typ != elemType) {
+			return nil, false
+		}
+		elemType = typ
+		buf = appendValue(buf, typ, v, true)
 	}
-	return vt(i)
+	buf[0] = byte(elemType)
+	return buf, true
 }
 
-// KeyValue retrieves key and value from a Tag.
+// appendValue appends a scalar value of the given type to buf. Strings and bytes are prefixed
+// with their length, if they are elements of a list.
+func appendValue(buf []byte, typ Tag_ValueType, v interface{}, inList bool) []byte {
+	var num = make([]byte, 8)
+	switch typ {
+	case Tag_BOOL:
+		if v.(bool) {
+			return append(buf, 1)
+		}
+		return append(buf, 0)
+	case Tag_INT:
+		binary.LittleEndian.PutUint64(num, uint64(v.(int64)))
+		return append(buf, num...)
+	case Tag_UINT:
+		binary.LittleEndian.PutUint64(num, v.(uint64))
+		return append(buf, num...)
+	case Tag_DOUBLE:
+		binary.LittleEndian.PutUint64(num, math.Float64bits(v.(float64)))
+		return append(buf, num...)
+	case Tag_FLOAT:
+		binary.LittleEndian.PutUint32(num, math.Float32bits(v.(float32)))
+		return append(buf, num[:4]...)
+	case Tag_STRING, Tag_BYTES:
+		var b []byte
+		if s, ok := v.(string); ok {
+			b = []byte(s)
+		} else {
+			b = v.([]byte)
+		}
+		if inList {
+			n := binary.PutUvarint(num, uint64(len(b)))
+			buf = append(buf, num[:n]...)
+		}
+		return append(buf, b...)
+	}
+	// NULL
+	return buf
+}
+
+// KeyValue retrieves key and value from a Tag. Values are nil, bool, int64, uint64, float64,
+// float32, string, []byte or []interface{} with elements of one of these types.
 func KeyValue(t *Tag) (string, interface{}, error) {
-	switch t.GetType() {
-	case Tag_STRING:
-		return t.Key, string(t.GetValue()), nil
+	if t.GetType() == Tag_LIST {
+		l, err := listValue(t.GetValue())
+		if err != nil {
+			return t.Key, nil, fmt.Errorf("invalid LIST tag %s: %v", t.Key, err)
+		}
+		return t.Key, l, nil
+	}
+
+	v, n, err := readValue(t.GetValue(), t.GetType(), false)
+	if err == nil && n != len(t.GetValue()) {
+		err = fmt.Errorf("%d bytes", len(t.GetValue()))
+	}
+	if err != nil {
+		return t.Key, nil, fmt.Errorf("invalid %v tag %s: %v", t.GetType(), t.Key, err)
+	}
+	return t.Key, v, nil
+}
+
+func listValue(buf []byte) ([]interface{}, error) {
+	if len(buf) == 0 {
+		return nil, errors.New("missing element type")
+	}
+	var (
+		typ = Tag_ValueType(buf[0])
+		l   = []interface{}{}
+	)
+	buf = buf[1:]
+	if typ == Tag_NULL {
+		if len(buf) != 0 {
+			return nil, errors.New("elements in empty list")
+		}
+		return l, nil
+	}
+	if typ == Tag_LIST {
+		return nil, errors.New("nested lists are not supported")
+	}
+	for len(buf) > 0 {
+		v, n, err := readValue(buf, typ, true)
+		if err != nil {
+			return nil, err
+		}
+		l = append(l, v)
+		buf = buf[n:]
+	}
+	return l, nil
+}
+
+// readValue reads a scalar value of the given type from the start of buf and returns the value
+// and the number of bytes read.
+func readValue(buf []byte, typ Tag_ValueType, inList bool) (interface{}, int, error) {
+	var size int
+	switch typ {
+	case Tag_NULL:
+		return nil, 0, nil
+	case Tag_BOOL:
+		size = 1
+	case Tag_INT, Tag_UINT, Tag_DOUBLE:
+		size = 8
+	case Tag_FLOAT:
+		size = 4
+	case Tag_STRING, Tag_BYTES:
+		var offset int
+		size = len(buf)
+		if inList {
+			l, n := binary.Uvarint(buf)
+			if n <= 0 || l > uint64(len(buf)-n) {
+				return nil, 0, errors.New("invalid length")
+			}
+			offset, size = n, int(l)
+		}
+		b := buf[offset : offset+size]
+		if typ == Tag_STRING {
+			return string(b), offset + size, nil
+		}
+		return append([]byte{}, b...), offset + size, nil
+	default:
+		return nil, 0, fmt.Errorf("unknown type %v", typ)
+	}
+
+	if len(buf) < size {
+		return nil, 0, fmt.Errorf("%d bytes", len(buf))
+	}
+	switch typ {
+	case Tag_BOOL:
+		if buf[0] > 1 {
+			return nil, 0, fmt.Errorf("invalid bool %d", buf[0])
+		}
+		return buf[0] == 1, size, nil
 	case Tag_INT:
-		return t.Key, int(binary.LittleEndian.Uint64(t.GetValue())), nil
+		return int64(binary.LittleEndian.Uint64(buf)), size, nil
+	case Tag_UINT:
+		return binary.LittleEndian.Uint64(buf), size, nil
 	case Tag_DOUBLE:
-		var (
-			buf = bytes.NewBuffer(t.GetValue())
-			f   float64
-		)
-		err := binary.Read(buf, binary.LittleEndian, &f)
-		return t.Key, f, err
+		return math.Float64frombits(binary.LittleEndian.Uint64(buf)), size, nil
 	default:
-		// TODO
-		return t.Key, nil, nil
+		return math.Float32frombits(binary.LittleEndian.Uint32(buf)), size, nil
 	}
 }
@@ -0,0 +1,189 @@
+package spaten
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"sort"
+
+	"github.com/thomersch/grandine/lib/spatial"
+)
+
+// References in the index consist of the block offset in the upper 40 bits and the position of
+// the feature within the block in the lower 24 bits.
+const (
+	refPosBits = 24
+	refPosMask = 1<<refPosBits - 1
+	maxOffset  = 1 << (64 - refPosBits)
+)
+
+// BuildIndex reads a Spaten file and writes a spatial index of its features to w. Only the
+// bboxes of the features are kept in memory. Together with the Spaten file, the index allows
+// to query features by bbox without reading the whole file, see OpenIndexed.
+func BuildIndex(r io.Reader, w io.Writer) error {
+	_, offset, err := readFileHeader(r)
+	if err != nil {
+		return err
+	}
+	var (
+		boxes []spatial.BBox
+		refs  []uint64
+	)
+	for {
+		hd, err := readBlockHeader(r)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		n := blockHeaderSize + int64(hd.bodyLen)
+		if hd.messageType != messageTypeFeatures {
+			if _, err := io.CopyN(ioutil.Discard, r, hd.payloadLen()); err != nil {
+				return err
+			}
+			offset += n
+			continue
+		}
+		body, err := readBody(r, hd)
+		if err != nil {
+			return err
+		}
+		if offset >= maxOffset || len(body.GetFeature()) > refPosMask+1 {
+			return errors.New("file is too large to be indexed")
+		}
+		for pos, f := range body.GetFeature() {
+			ft, err := UnpackFeature(f)
+			if err != nil {
+				return err
+			}
+			if ft.Geometry.IsEmpty() {
+				continue
+			}
+			boxes = append(boxes, ft.Geometry.BBox())
+			refs = append(refs, uint64(offset)<<refPosBits|uint64(pos))
+		}
+		blockBodyPool.Put(body)
+		offset += n
+	}
+
+	ix, err := spatial.NewPackedIndex(boxes, refs)
+	if err != nil {
+		return err
+	}
+	_, err = ix.WriteTo(w)
+	return err
+}
+
+// IndexedFile provides access to the features of a Spaten file by bbox, using an index which
+// has been created with BuildIndex. It is safe for concurrent use.
+type IndexedFile struct {
+	r   io.ReaderAt
+	idx *spatial.PackedIndex
+
+	file *os.File
+}
+
+// NewIndexedFile uses r, which contains a Spaten file, and its index.
+func NewIndexedFile(r io.ReaderAt, idx *spatial.PackedIndex) (*IndexedFile, error) {
+	if _, err := ReadFileHeader(io.NewSectionReader(r, 0, math.MaxInt64)); err != nil {
+		return nil, err
+	}
+	return &IndexedFile{r: r, idx: idx}, nil
+}
+
+// OpenIndexed opens a Spaten file and its index file, which is memory mapped. The file must be
+// closed after use.
+func OpenIndexed(path, indexPath string) (*IndexedFile, error) {
+	idx, err := spatial.OpenPackedIndex(indexPath)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		idx.Close()
+		return nil, err
+	}
+	ixf, err := NewIndexedFile(f, idx)
+	if err != nil {
+		f.Close()
+		idx.Close()
+		return nil, err
+	}
+	ixf.file = f
+	return ixf, nil
+}
+
+// Query returns all features whose bbox intersects with bbox. Only the blocks which contain
+// such features are read.
+func (f *IndexedFile) Query(bbox spatial.BBox) ([]spatial.Feature, error) {
+	_, fts, err := f.QueryRefs(bbox)
+	return fts, err
+}
+
+// QueryRefs is like Query, but also returns the index references of the features, which
+// identify them within the file, e.g. for recognizing features returned by multiple queries.
+func (f *IndexedFile) QueryRefs(bbox spatial.BBox) ([]uint64, []spatial.Feature, error) {
+	var refs []uint64
+	f.idx.Search(bbox, func(ref uint64) {
+		refs = append(refs, ref)
+	})
+	sort.Slice(refs, func(i, j int) bool { return refs[i] < refs[j] })
+
+	var (
+		sorted = refs
+		fts    = make([]spatial.Feature, 0, len(refs))
+	)
+	for len(refs) > 0 {
+		var (
+			offset = int64(refs[0] >> refPosBits)
+			end    int
+		)
+		for end < len(refs) && int64(refs[end]>>refPosBits) == offset {
+			end++
+		}
+		body, err := readBlockBody(io.NewSectionReader(f.r, offset, math.MaxInt64-offset))
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, ref := range refs[:end] {
+			pos := int(ref & refPosMask)
+			if pos >= len(body.GetFeature()) {
+				blockBodyPool.Put(body)
+				return nil, nil, errors.New("index does not match the file")
+			}
+			ft, err := UnpackFeature(body.GetFeature()[pos])
+			if err != nil {
+				return nil, nil, err
+			}
+			fts = append(fts, ft)
+		}
+		blockBodyPool.Put(body)
+		refs = refs[end:]
+	}
+	return sorted, fts, nil
+}
+
+// BBox returns the bbox of all features.
+func (f *IndexedFile) BBox() spatial.BBox {
+	return f.idx.BBox()
+}
+
+// Count returns the number of indexed features.
+func (f *IndexedFile) Count() int {
+	return f.idx.Len()
+}
+
+// Close closes the files, if they have been opened by OpenIndexed.
+func (f *IndexedFile) Close() error {
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	if ierr := f.idx.Close(); err == nil {
+		err = ierr
+	}
+	return err
+}
@@ -0,0 +1,116 @@
+package spaten
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"testing"
+
+	"github.com/thomersch/grandine/lib/spatial"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIndexedFile(t *testing.T) {
+	var (
+		rnd = rand.New(rand.NewSource(1))
+		fc  spatial.FeatureCollection
+	)
+	for i := 0; i < 2500; i++ {
+		var (
+			x, y = rnd.Float64() * 20, rnd.Float64() * 20
+			g    = spatial.MustNewGeom(spatial.Point{X: x, Y: y})
+		)
+		if i%2 == 0 {
+			g = spatial.MustNewGeom(spatial.Line{{X: x, Y: y}, {X: x + rnd.Float64(), Y: y + rnd.Float64()}})
+		}
+		fc.Features = append(fc.Features, spatial.Feature{
+			Props:    map[string]interface{}{"id": strconv.Itoa(i)},
+			Geometry: g,
+		})
+	}
+
+	var file, index bytes.Buffer
+	assert.Nil(t, (&Codec{}).Encode(&file, &fc))
+	assert.Nil(t, BuildIndex(bytes.NewReader(file.Bytes()), &index))
+
+	dir, err := ioutil.TempDir("", "spatenindex")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	var (
+		path      = filepath.Join(dir, "features.spaten")
+		indexPath = filepath.Join(dir, "features.spaten.idx")
+	)
+	assert.Nil(t, ioutil.WriteFile(path, file.Bytes(), 0644))
+	assert.Nil(t, ioutil.WriteFile(indexPath, index.Bytes(), 0644))
+
+	ixf, err := OpenIndexed(path, indexPath)
+	assert.Nil(t, err)
+	defer ixf.Close()
+	assert.Equal(t, 2500, ixf.Count())
+
+	for i := 0; i < 20; i++ {
+		var (
+			x, y     = rnd.Float64() * 20, rnd.Float64() * 20
+			bb       = spatial.BBox{SW: spatial.Point{X: x, Y: y}, NE: spatial.Point{X: x + 3, Y: y + 3}}
+			expected []string
+			found    []string
+		)
+		for _, ft := range fc.Features {
+			fbb := ft.Geometry.BBox()
+			if fbb.SW.X <= bb.NE.X && fbb.NE.X >= bb.SW.X && fbb.SW.Y <= bb.NE.Y && fbb.NE.Y >= bb.SW.Y {
+				expected = append(expected, ft.Props["id"].(string))
+			}
+		}
+		refs, fts, err := ixf.QueryRefs(bb)
+		assert.Nil(t, err)
+		assert.Len(t, refs, len(fts))
+		for i, ft := range fts {
+			// another query which returns the feature has the same reference for it
+			otherRefs, others, err := ixf.QueryRefs(ft.Geometry.BBox())
+			assert.Nil(t, err)
+			for j, other := range others {
+				if other.Props["id"] == ft.Props["id"] {
+					assert.Equal(t, refs[i], otherRefs[j])
+				} else {
+					assert.NotEqual(t, refs[i], otherRefs[j])
+				}
+			}
+
+			found = append(found, ft.Props["id"].(string))
+			n, _ := strconv.Atoi(ft.Props["id"].(string))
+			assert.Equal(t, fc.Features[n].Geometry, ft.Geometry)
+		}
+		sort.Strings(expected)
+		sort.Strings(found)
+		assert.Equal(t, expected, found)
+	}
+}
+
+func TestIndexedFileMismatch(t *testing.T) {
+	var (
+		file, other, index bytes.Buffer
+		fc                 = spatial.FeatureCollection{Features: []spatial.Feature{
+			{Geometry: spatial.MustNewGeom(spatial.Point{X: 1, Y: 1})},
+			{Geometry: spatial.MustNewGeom(spatial.Point{X: 2, Y: 2})},
+		}}
+	)
+	assert.Nil(t, (&Codec{}).Encode(&file, &fc))
+	assert.Nil(t, BuildIndex(bytes.NewReader(file.Bytes()), &index))
+	fc.Features = fc.Features[:1]
+	assert.Nil(t, (&Codec{}).Encode(&other, &fc))
+
+	idx, err := spatial.ReadPackedIndex(index.Bytes())
+	assert.Nil(t, err)
+	ixf, err := NewIndexedFile(bytes.NewReader(other.Bytes()), idx)
+	assert.Nil(t, err)
+	_, err = ixf.Query(spatial.BBox{SW: spatial.Point{X: 0, Y: 0}, NE: spatial.Point{X: 3, Y: 3}})
+	assert.NotNil(t, err)
+
+	_, err = NewIndexedFile(bytes.NewReader([]byte("nope")), idx)
+	assert.NotNil(t, err)
+}
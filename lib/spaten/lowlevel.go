@@ -6,6 +6,8 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"math"
 	"sync"
 
 	"github.com/thomersch/grandine/lib/spaten/fileformat"
@@ -15,95 +17,189 @@ import (
 )
 
 const (
-	cookie  = "SPAT"
-	version = 0
+	cookie     = "SPAT"
+	headerSize = 8
+	// Version 1 added block summaries, version 2 the tag types BOOL, UINT, FLOAT, BYTES, NULL
+	// and LIST, version 3 the metadata block. Readers refuse files with a newer version.
+	version = 3
 )
 
+// Header contains the version of a file and, since version 3, its metadata.
 type Header struct {
-	Version int
+	Version  int
+	Metadata Metadata
 }
 
 var encoding = binary.LittleEndian
 
+// WriteFileHeader writes the file header, followed by a metadata block without any metadata.
 func WriteFileHeader(w io.Writer) error {
-	const headerSize = 8
+	_, err := writeFileHeader(w, Metadata{})
+	return err
+}
 
+// writeFileHeader writes the file header and the metadata block and returns the number of bytes
+// written.
+func writeFileHeader(w io.Writer, md Metadata) (int, error) {
 	buf := make([]byte, headerSize)
 	buf = append([]byte(cookie), buf[:4]...)
 	binary.LittleEndian.PutUint32(buf[4:], version)
 
 	n, err := w.Write(buf)
+	if err != nil {
+		return n, err
+	}
 	if n != headerSize {
-		return io.EOF
+		return n, io.EOF
 	}
-	return err
+	mn, err := writeMetadata(w, md)
+	return n + mn, err
 }
 
+// ReadFileHeader reads the file header and, if the file has one, the metadata block.
 func ReadFileHeader(r io.Reader) (Header, error) {
+	hd, _, err := readFileHeader(r)
+	return hd, err
+}
+
+// readFileHeader returns the header and the number of bytes read, which is the offset of the
+// first block.
+func readFileHeader(r io.Reader) (Header, int64, error) {
 	var (
 		ck   = make([]byte, 4)
 		vers uint32
 		hd   Header
 	)
 	if _, err := r.Read(ck); err != nil {
-		return hd, fmt.Errorf("could not read file header cookie: %s", err)
+		return hd, 0, fmt.Errorf("could not read file header cookie: %s", err)
 	}
 	if string(ck) != cookie {
-		return hd, errors.New("invalid cookie")
+		return hd, 0, errors.New("invalid cookie")
 	}
 
 	if err := binary.Read(r, binary.LittleEndian, &vers); err != nil {
-		return hd, err
+		return hd, 0, err
 	}
 	hd.Version = int(vers)
 	if vers > version {
-		return hd, errors.New("invalid file version")
+		return hd, 0, fmt.Errorf("unsupported file version %d, versions up to %d are supported", vers, version)
 	}
-	return hd, nil
+	if vers < 3 {
+		return hd, headerSize, nil
+	}
+
+	md, n, err := readMetadata(r)
+	if err != nil {
+		return hd, 0, err
+	}
+	hd.Metadata = md
+	return hd, headerSize + int64(n), nil
+}
+
+// geomOptions determine how feature geometries are serialized.
+type geomOptions struct {
+	serial fileformat.Feature_GeomSerialization
+	twkb   spatial.TWKBOptions
 }
 
 // WriteBlock writes a block of spatial data (note that every valid Spaten file needs a file header in front).
-// meta may be nil, if you don't wish to add any block meta.
+// meta may be nil, if you don't wish to add any block meta. Geometries are written as WKB, the block
+// is not compressed.
 func WriteBlock(w io.Writer, fs []spatial.Feature, meta map[string]interface{}) error {
-	blockBody := &fileformat.Body{}
+	_, _, err := writeBlock(w, fs, meta, geomOptions{}, CompressionNone)
+	return err
+}
+
+// writeBlock writes a block and returns its summary and the number of bytes written.
+func writeBlock(w io.Writer, fs []spatial.Feature, meta map[string]interface{}, gopts geomOptions, comp Compression) (BlockInfo, int, error) {
+	var (
+		blockBody = &fileformat.Body{}
+		info      = BlockInfo{Summarized: true, Count: len(fs), BBox: emptyBBox}
+	)
 	props, err := propertiesToTags(meta)
 	if err != nil {
-		return err
+		return info, 0, err
 	}
 	blockBody.Meta = &fileformat.Meta{
 		Tags: props,
 	}
 
 	for _, f := range fs {
-		nf, err := PackFeature(f)
+		nf, err := packFeature(f, gopts)
 		if err != nil {
-			return err
+			return info, 0, err
+		}
+		if !f.Geometry.IsEmpty() {
+			info.BBox.ExtendWith(f.Geometry.BBox())
 		}
 
 		blockBody.Feature = append(blockBody.Feature, &nf)
 	}
 	bodyBuf, err := proto.Marshal(blockBody)
 	if err != nil {
-		return err
+		return info, 0, err
+	}
+	bodyBuf, err = compress(comp, bodyBuf)
+	if err != nil {
+		return info, 0, err
 	}
 
-	blockHeaderBuf := make([]byte, 8)
-	// Body Length
-	binary.LittleEndian.PutUint32(blockHeaderBuf[:4], uint32(len(bodyBuf)))
+	blockHeaderBuf := make([]byte, blockHeaderSize, blockHeaderSize+blockSummarySize+len(bodyBuf))
+	// Body Length, the summary is part of the body
+	binary.LittleEndian.PutUint32(blockHeaderBuf[:4], uint32(blockSummarySize+len(bodyBuf)))
 	// Flags
-	binary.LittleEndian.PutUint16(blockHeaderBuf[4:6], 0)
+	binary.LittleEndian.PutUint16(blockHeaderBuf[4:6], flagSummary)
 	// Compression
-	blockHeaderBuf[6] = 0
+	blockHeaderBuf[6] = uint8(comp)
 	// Message Type
-	blockHeaderBuf[7] = 0
+	blockHeaderBuf[7] = messageTypeFeatures
 
-	w.Write(append(blockHeaderBuf, bodyBuf...))
-	return nil
+	buf := append(appendSummary(blockHeaderBuf, info), bodyBuf...)
+	n, err := w.Write(buf)
+	return info, n, err
+}
+
+// Blocks with flagSummary start with the number of features and the bbox of their geometries, so
+// readers can skip blocks without decoding them. Blocks without geometries have emptyBBox.
+const (
+	blockHeaderSize         = 8
+	blockSummarySize        = 4 + 4*8
+	flagSummary      uint16 = 1
+)
+
+var emptyBBox = spatial.BBox{
+	SW: spatial.Point{X: math.Inf(1), Y: math.Inf(1)},
+	NE: spatial.Point{X: math.Inf(-1), Y: math.Inf(-1)},
+}
+
+func appendSummary(buf []byte, info BlockInfo) []byte {
+	var sbuf = make([]byte, blockSummarySize)
+	binary.LittleEndian.PutUint32(sbuf[0:4], uint32(info.Count))
+	for i, f := range []float64{info.BBox.SW.X, info.BBox.SW.Y, info.BBox.NE.X, info.BBox.NE.Y} {
+		binary.LittleEndian.PutUint64(sbuf[4+i*8:], math.Float64bits(f))
+	}
+	return append(buf, sbuf...)
+}
+
+func parseSummary(buf []byte) BlockInfo {
+	var f [4]float64
+	for i := range f {
+		f[i] = math.Float64frombits(binary.LittleEndian.Uint64(buf[4+i*8:]))
+	}
+	return BlockInfo{
+		Summarized: true,
+		Count:      int(binary.LittleEndian.Uint32(buf[0:4])),
+		BBox:       spatial.BBox{SW: spatial.Point{X: f[0], Y: f[1]}, NE: spatial.Point{X: f[2], Y: f[3]}},
+	}
 }
 
 // PackFeature encapusaltes a spatial feature into an encodable Spaten feature.
 // This is a low level interface and not guaranteed to be stable.
 func PackFeature(f spatial.Feature) (fileformat.Feature, error) {
+	return packFeature(f, geomOptions{})
+}
+
+func packFeature(f spatial.Feature, gopts geomOptions) (fileformat.Feature, error) {
 	var (
 		nf  fileformat.Feature
 		err error
@@ -113,14 +209,33 @@ func PackFeature(f spatial.Feature) (fileformat.Feature, error) {
 		return nf, err
 	}
 
-	// TODO: make encoder configurable
-	nf.Geom, err = f.MarshalWKB()
+	nf.Geomserial = gopts.serial
+	switch gopts.serial {
+	case fileformat.Feature_WKB:
+		nf.Geom, err = f.MarshalWKB()
+	case fileformat.Feature_TWKB:
+		nf.Geom, err = f.Geometry.MarshalTWKB(gopts.twkb)
+	default:
+		err = fmt.Errorf("unsupported geometry serialization: %v", gopts.serial)
+	}
 	if err != nil {
 		return nf, err
 	}
+	nf.Geomtype = geomTypes[f.Geometry.Typ()]
+	nf.Id = f.ID
 	return nf, nil
 }
 
+var geomTypes = map[spatial.GeomType]fileformat.Feature_GeomType{
+	spatial.GeomTypePoint:              fileformat.Feature_POINT,
+	spatial.GeomTypeLineString:         fileformat.Feature_LINE,
+	spatial.GeomTypePolygon:            fileformat.Feature_POLYGON,
+	spatial.GeomTypeMultiPoint:         fileformat.Feature_MULTIPOINT,
+	spatial.GeomTypeMultiLineString:    fileformat.Feature_MULTILINE,
+	spatial.GeomTypeMultiPolygon:       fileformat.Feature_MULTIPOLYGON,
+	spatial.GeomTypeGeometryCollection: fileformat.Feature_COLLECTION,
+}
+
 var featureBufPool = sync.Pool{
 	New: func() interface{} {
 		return bytes.NewBuffer(make([]byte, 0, 16))
@@ -133,12 +248,24 @@ func UnpackFeature(pf *fileformat.Feature) (spatial.Feature, error) {
 	var geomBuf = featureBufPool.Get().(*bytes.Buffer)
 	geomBuf.Reset()
 	geomBuf.Write(pf.GetGeom())
-	geom, err := spatial.GeomFromWKB(geomBuf)
+	var (
+		geom spatial.Geom
+		err  error
+	)
+	switch pf.GetGeomserial() {
+	case fileformat.Feature_WKB:
+		geom, err = spatial.GeomFromWKB(geomBuf)
+	case fileformat.Feature_TWKB:
+		geom, err = spatial.GeomFromTWKB(geomBuf)
+	default:
+		err = fmt.Errorf("unsupported geometry serialization: %v", pf.GetGeomserial())
+	}
 	if err != nil {
 		return spatial.Feature{}, err
 	}
 	featureBufPool.Put(geomBuf)
 	feature := spatial.Feature{
+		ID:       pf.GetId(),
 		Props:    map[string]interface{}{},
 		Geometry: geom,
 	}
@@ -178,8 +305,27 @@ type blockHeader struct {
 	flags       uint16
 	compression uint8
 	messageType uint8
+
+	// summary is set if the flags contain flagSummary, it has been read together with the header.
+	summary BlockInfo
+}
+
+// payloadLen is the length of the block body without its summary.
+func (hd blockHeader) payloadLen() int64 {
+	if hd.summary.Summarized {
+		return int64(hd.bodyLen) - blockSummarySize
+	}
+	return int64(hd.bodyLen)
 }
 
+// Message types denote the content of a block. Readers skip block indexes, see Codec.BlockIndex.
+// The metadata block is read together with the file header, see ReadFileHeader.
+const (
+	messageTypeFeatures   = 0
+	messageTypeBlockIndex = 1
+	messageTypeMetadata   = 2
+)
+
 var blockBodyPool = sync.Pool{
 	New: func() interface{} {
 		return &fileformat.Body{}
@@ -187,57 +333,126 @@ var blockBodyPool = sync.Pool{
 }
 
 func readBlock(r io.Reader, fs *spatial.FeatureCollection) error {
+	blockBody, err := readBlockBody(r)
+	if err != nil {
+		return err
+	}
+	if len(fs.Features) == 0 {
+		// only prealloc if empty, so no user data gets truncated
+		fs.Features = make([]spatial.Feature, 0, len(blockBody.GetFeature()))
+	}
+	for _, f := range blockBody.GetFeature() {
+		feature, err := UnpackFeature(f)
+		if err != nil {
+			return err
+		}
+		fs.Features = append(fs.Features, feature)
+	}
+	blockBodyPool.Put(blockBody)
+	return nil
+}
+
+// readBlockBody reads the next block with features and returns its decoded body, which should
+// be put back into blockBodyPool after use.
+func readBlockBody(r io.Reader) (*fileformat.Body, error) {
+	for {
+		hd, err := readBlockHeader(r)
+		if err != nil {
+			return nil, err
+		}
+		if hd.messageType == messageTypeFeatures {
+			return readBody(r, hd)
+		}
+		if _, err := io.CopyN(ioutil.Discard, r, hd.payloadLen()); err != nil {
+			return nil, fmt.Errorf("incomplete block: %v", err)
+		}
+	}
+}
+
+// readBlockHeader reads the header of the next block and its summary, if it is a feature block
+// with summary.
+func readBlockHeader(r io.Reader) (blockHeader, error) {
 	var hd blockHeader
 
-	headerBuf := make([]byte, 8)
-	n, err := r.Read(headerBuf)
+	headerBuf := make([]byte, blockHeaderSize)
+	n, err := io.ReadFull(r, headerBuf)
 	if n == 0 {
-		return io.EOF
+		return hd, io.EOF
 	}
 	if err != nil {
-		return fmt.Errorf("could not read block header: %v", err)
+		return hd, fmt.Errorf("could not read block header: %v", err)
 	}
 
 	hd.bodyLen = binary.LittleEndian.Uint32(headerBuf[0:4])
 	hd.flags = binary.LittleEndian.Uint16(headerBuf[4:6])
 	hd.compression = uint8(headerBuf[6])
-	if hd.compression != 0 {
-		return errors.New("compression is not supported")
+	if _, ok := compressionNames[Compression(hd.compression)]; !ok {
+		return hd, fmt.Errorf("compression %v is not supported", Compression(hd.compression))
 	}
 
 	hd.messageType = uint8(headerBuf[7])
-	if hd.messageType != 0 {
-		return errors.New("message type is not supported")
+	if hd.messageType > messageTypeMetadata {
+		return hd, errors.New("message type is not supported")
 	}
 
+	// flags depend on the message type, only feature blocks have summaries
+	if hd.messageType == messageTypeFeatures && hd.flags&flagSummary != 0 {
+		if hd.bodyLen < blockSummarySize {
+			return hd, fmt.Errorf("invalid block: %v bytes are too short for a summary", hd.bodyLen)
+		}
+		sbuf := make([]byte, blockSummarySize)
+		if _, err := io.ReadFull(r, sbuf); err != nil {
+			return hd, fmt.Errorf("could not read block summary: %v", err)
+		}
+		hd.summary = parseSummary(sbuf)
+	}
+	return hd, nil
+}
+
+// readBody reads and decodes the body of a block, whose header has been read.
+func readBody(r io.Reader, hd blockHeader) (*fileformat.Body, error) {
 	var (
-		buf       = make([]byte, hd.bodyLen)
-		blockBody = blockBodyPool.Get().(*fileformat.Body)
+		bodyLen = int(hd.payloadLen())
+		buf     []byte
+		err     error
 	)
-	blockBody.Reset()
-	n, err = io.ReadFull(r, buf)
-	if n != int(hd.bodyLen) {
-		return fmt.Errorf("incomplete block: expected %v bytes, %v available", hd.bodyLen, n)
-	}
-	if err != nil {
-		return err
-	}
-	if err := blockBody.Unmarshal(buf); err != nil {
-		return err
-	}
-	if len(fs.Features) == 0 {
-		// only prealloc if empty, so no user data gets truncated
-		fs.Features = make([]spatial.Feature, 0, len(blockBody.GetFeature()))
-	}
-	for _, f := range blockBody.GetFeature() {
-		feature, err := UnpackFeature(f)
+	if Compression(hd.compression) == CompressionNone {
+		buf = make([]byte, bodyLen)
+		n, err := io.ReadFull(r, buf)
+		if n != bodyLen {
+			return nil, fmt.Errorf("incomplete block: expected %v bytes, %v available", bodyLen, n)
+		}
 		if err != nil {
-			return err
+			return nil, err
+		}
+	} else {
+		cr := &countingReader{r: io.LimitReader(r, int64(bodyLen))}
+		buf, err = decompress(Compression(hd.compression), cr, 4*bodyLen)
+		if err != nil {
+			return nil, err
+		}
+		if cr.n != bodyLen {
+			return nil, fmt.Errorf("incomplete block: expected %v bytes, %v available", bodyLen, cr.n)
 		}
-		fs.Features = append(fs.Features, feature)
 	}
-	blockBodyPool.Put(blockBody)
-	return nil
+
+	blockBody := blockBodyPool.Get().(*fileformat.Body)
+	blockBody.Reset()
+	if err := blockBody.Unmarshal(buf); err != nil {
+		return nil, err
+	}
+	return blockBody, nil
+}
+
+type countingReader struct {
+	r io.Reader
+	n int
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += n
+	return n, err
 }
 
 // ReadBlocks is a function for reading all features from a file at once.
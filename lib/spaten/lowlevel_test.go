@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/thomersch/grandine/lib/spaten/fileformat"
 	"github.com/thomersch/grandine/lib/spatial"
 )
 
@@ -40,24 +41,35 @@ func TestBlockSelfTest(t *testing.T) {
 			Features: []spatial.Feature{
 				{
 					Props: map[string]interface{}{
-						"key1": 1,
+						"key1": int64(1),
 						"key2": "string",
 						"key3": -12.981,
 					},
 					Geometry: spatial.MustNewGeom(spatial.Point{24, 1}),
 				},
 				{
+					ID: 42,
 					Props: map[string]interface{}{
 						"yes": "NO",
 					},
 					Geometry: spatial.MustNewGeom(spatial.Line{{24, 1}, {25, 0}, {9, -4}}),
 				},
 				{
+					ID: 1<<63 + 1,
 					Props: map[string]interface{}{
 						"name": "RichardF Box",
 					},
 					Geometry: spatial.MustNewGeom(spatial.Polygon{{{24, 1}, {25, 0}, {9, -4}}}),
 				},
+				{
+					Props: map[string]interface{}{
+						"name": "Archipelago",
+					},
+					Geometry: spatial.MustNewGeom(spatial.MultiPolygon{
+						{{{24, 1}, {25, 0}, {9, -4}}},
+						{{{-24, 1}, {-25, 0}, {-9, -4}}},
+					}),
+				},
 			},
 		}
 	)
@@ -71,6 +83,89 @@ func TestBlockSelfTest(t *testing.T) {
 	assert.Equal(t, fcoll, fcollRead)
 }
 
+func TestBlockPropertyTypes(t *testing.T) {
+	var (
+		buf bytes.Buffer
+		fs  = []spatial.Feature{
+			{
+				Props: map[string]interface{}{
+					"int32":    int32(-3),
+					"uint":     uint64(1 << 63),
+					"double":   0.25,
+					"float":    float32(0.5),
+					"yes":      true,
+					"no":       false,
+					"null":     nil,
+					"bytes":    []byte{0, 255},
+					"list":     []string{"a", "", "b"},
+					"numbers":  []interface{}{1, int64(-2)},
+					"floats":   []float32{1.5},
+					"blobs":    [][]byte{{1}, {}},
+					"bools":    []bool{true, false},
+					"empty":    []int{},
+					"mixed":    []interface{}{1, "a"},
+					"withnull": []interface{}{1, nil},
+					"object":   map[string]interface{}{"a": 1},
+					"value":    spatial.ListValue([]spatial.Value{spatial.StringValue("a"), spatial.StringValue("b")}),
+					"valuemix": spatial.ListValue([]spatial.Value{spatial.IntValue(1), spatial.StringValue("b")}),
+				},
+				Geometry: spatial.MustNewGeom(spatial.Point{1, 2}),
+			},
+		}
+	)
+	assert.Nil(t, WriteBlock(&buf, fs, nil))
+
+	var fc spatial.FeatureCollection
+	assert.Nil(t, ReadBlocks(&buf, &fc))
+	assert.Equal(t, map[string]interface{}{
+		"int32":    int64(-3),
+		"uint":     uint64(1 << 63),
+		"double":   0.25,
+		"float":    float32(0.5),
+		"yes":      true,
+		"no":       false,
+		"null":     nil,
+		"bytes":    []byte{0, 255},
+		"list":     []interface{}{"a", "", "b"},
+		"numbers":  []interface{}{int64(1), int64(-2)},
+		"floats":   []interface{}{float32(1.5)},
+		"blobs":    []interface{}{[]byte{1}, []byte{}},
+		"bools":    []interface{}{true, false},
+		"empty":    []interface{}{},
+		"mixed":    `[1,"a"]`,
+		"withnull": `[1,null]`,
+		"object":   `{"a":1}`,
+		"value":    []interface{}{"a", "b"},
+		"valuemix": `[1,"b"]`,
+	}, fc.Features[0].Props)
+
+	fs[0].Props = map[string]interface{}{"struct": struct{}{}}
+	assert.NotNil(t, WriteBlock(&buf, fs, nil))
+}
+
+func TestInvalidTags(t *testing.T) {
+	for _, tag := range []fileformat.Tag{
+		{Type: fileformat.Tag_INT, Value: []byte{1, 2}},
+		{Type: fileformat.Tag_FLOAT, Value: []byte{1, 2, 3, 4, 5}},
+		{Type: fileformat.Tag_BOOL, Value: []byte{2}},
+		{Type: fileformat.Tag_NULL, Value: []byte{1}},
+		{Type: fileformat.Tag_LIST},
+		{Type: fileformat.Tag_LIST, Value: []byte{byte(fileformat.Tag_LIST)}},
+		{Type: fileformat.Tag_LIST, Value: []byte{byte(fileformat.Tag_UINT), 1, 2}},
+		{Type: fileformat.Tag_LIST, Value: []byte{byte(fileformat.Tag_STRING), 5, 'a'}},
+		{Type: fileformat.Tag_LIST, Value: []byte{byte(fileformat.Tag_NULL), 1}},
+		{Type: 100},
+	} {
+		_, _, err := fileformat.KeyValue(&tag)
+		assert.NotNil(t, err, "%v", tag)
+	}
+}
+
+func TestReadNewerFileVersion(t *testing.T) {
+	_, err := ReadFileHeader(bytes.NewReader([]byte("SPAT\x04\x00\x00\x00")))
+	assert.EqualError(t, err, "unsupported file version 4, versions up to 3 are supported")
+}
+
 func TestBlockHeaderEncoding(t *testing.T) {
 	var (
 		buf bytes.Buffer
@@ -87,7 +182,11 @@ func TestBlockHeaderEncoding(t *testing.T) {
 	const headerLength = 8 // TODO: consider exporting this
 	// Compare buffer size with size written in header.
 	assert.Equal(t, buf.Len()-headerLength, int(binary.LittleEndian.Uint32(buf.Bytes()[:4])))
-	assert.Equal(t, "00000000", fmt.Sprintf("%x", buf.Bytes()[4:8]))
+	assert.Equal(t, "01000000", fmt.Sprintf("%x", buf.Bytes()[4:8]))
+
+	hd, err := readBlockHeader(&buf)
+	assert.Nil(t, err)
+	assert.Equal(t, BlockInfo{Summarized: true, Count: 1, BBox: spatial.BBox{SW: spatial.Point{1, 2}, NE: spatial.Point{1, 2}}}, hd.summary)
 }
 
 func TestInvalidBlockSize(t *testing.T) {
@@ -99,6 +198,31 @@ func TestInvalidBlockSize(t *testing.T) {
 	assert.NotNil(t, err)
 }
 
+func TestInvalidCompression(t *testing.T) {
+	var (
+		buf bytes.Buffer
+		fs  = []spatial.Feature{{Geometry: spatial.MustNewGeom(spatial.Point{X: 1, Y: 2})}}
+	)
+	_, _, err := writeBlock(&buf, fs, nil, geomOptions{}, CompressionZstd)
+	assert.Nil(t, err)
+	blk := buf.Bytes()
+
+	// unknown algorithm
+	blk[6] = 200
+	_, err = readBlockBody(bytes.NewReader(blk))
+	assert.EqualError(t, err, "compression unknown (200) is not supported")
+
+	// body doesn't match the algorithm
+	blk[6] = uint8(CompressionGzip)
+	_, err = readBlockBody(bytes.NewReader(blk))
+	assert.NotNil(t, err)
+
+	// truncated body
+	blk[6] = uint8(CompressionZstd)
+	_, err = readBlockBody(bytes.NewReader(blk[:len(blk)-3]))
+	assert.NotNil(t, err)
+}
+
 func TestWeirdFiles(t *testing.T) {
 	var fls = []struct {
 		buf       string
@@ -0,0 +1,185 @@
+package spaten
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+
+	"github.com/thomersch/grandine/lib/spaten/fileformat"
+	"github.com/thomersch/grandine/lib/spatial"
+)
+
+// Metadata describes the data of a whole file. It is stored in a block directly after the file
+// header, see ReadFileHeader.
+type Metadata struct {
+	// SRID of the coordinates, e.g. "4326", like spatial.FeatureCollection.SRID.
+	SRID string
+	// Tool which has created the file, e.g. "grandine-spatialize".
+	Tool string
+	// Attribution of the source data, e.g. "© OpenStreetMap contributors".
+	Attribution string
+	// Schema lists the properties of the features and their types. It is optional, see
+	// InferSchema.
+	Schema []Property
+
+	// HasStats reports whether Count and BBox are known. They are unknown if the file has been
+	// written with EncodeChunk to a writer which can't seek, or before version 3.
+	HasStats bool
+	// Count is the number of features and BBox the bbox of their geometries. They are computed
+	// while writing, values set in Codec.Metadata are ignored.
+	Count uint64
+	BBox  spatial.BBox
+}
+
+// Property describes a feature property in the schema of a file.
+type Property struct {
+	Name string
+	Type fileformat.Tag_ValueType
+}
+
+// The metadata block starts with the number of features and their bbox, which have a fixed size,
+// so they can be updated after all features have been written. They are followed by a Meta
+// message, whose tags contain the other fields.
+const (
+	metadataStatsSize        = 8 + 4*8
+	flagStats         uint16 = 1
+	schemaKeyPrefix          = "@schema:"
+)
+
+func writeMetadata(w io.Writer, md Metadata) (int, error) {
+	var tags []*fileformat.Tag
+	for _, kv := range [][2]string{{"@srid", md.SRID}, {"@tool", md.Tool}, {"@attribution", md.Attribution}} {
+		if len(kv[1]) != 0 {
+			tags = append(tags, &fileformat.Tag{Key: kv[0], Value: []byte(kv[1])})
+		}
+	}
+	for _, p := range md.Schema {
+		tags = append(tags, &fileformat.Tag{Key: schemaKeyPrefix + p.Name, Value: []byte(p.Type.String())})
+	}
+	metaBuf, err := proto.Marshal(&fileformat.Meta{Tags: tags})
+	if err != nil {
+		return 0, err
+	}
+
+	buf := make([]byte, blockHeaderSize, blockHeaderSize+metadataStatsSize+len(metaBuf))
+	binary.LittleEndian.PutUint32(buf[:4], uint32(metadataStatsSize+len(metaBuf)))
+	buf[7] = messageTypeMetadata
+	buf = append(appendStats(buf, md), metaBuf...)
+	return w.Write(buf)
+}
+
+// appendStats appends the flags, which are part of the block header, and the stats.
+func appendStats(buf []byte, md Metadata) []byte {
+	if md.HasStats {
+		binary.LittleEndian.PutUint16(buf[4:6], flagStats)
+	}
+	var sbuf = make([]byte, metadataStatsSize)
+	binary.LittleEndian.PutUint64(sbuf[0:8], md.Count)
+	for i, f := range []float64{md.BBox.SW.X, md.BBox.SW.Y, md.BBox.NE.X, md.BBox.NE.Y} {
+		binary.LittleEndian.PutUint64(sbuf[8+i*8:], math.Float64bits(f))
+	}
+	return append(buf, sbuf...)
+}
+
+// readMetadata reads the metadata block, which follows the file header.
+func readMetadata(r io.Reader) (Metadata, int, error) {
+	var md Metadata
+	hd, err := readBlockHeader(r)
+	if err == io.EOF {
+		return md, 0, errors.New("missing metadata")
+	}
+	if err != nil {
+		return md, 0, err
+	}
+	if hd.messageType != messageTypeMetadata || hd.bodyLen < metadataStatsSize {
+		return md, 0, errors.New("invalid metadata block")
+	}
+	var buf = make([]byte, hd.bodyLen)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return md, 0, fmt.Errorf("could not read metadata: %v", err)
+	}
+
+	if hd.flags&flagStats != 0 {
+		md.HasStats = true
+		md.Count = binary.LittleEndian.Uint64(buf[0:8])
+		var f [4]float64
+		for i := range f {
+			f[i] = math.Float64frombits(binary.LittleEndian.Uint64(buf[8+i*8:]))
+		}
+		md.BBox = spatial.BBox{SW: spatial.Point{X: f[0], Y: f[1]}, NE: spatial.Point{X: f[2], Y: f[3]}}
+	}
+
+	var meta fileformat.Meta
+	if err := meta.Unmarshal(buf[metadataStatsSize:]); err != nil {
+		return md, 0, fmt.Errorf("could not read metadata: %v", err)
+	}
+	for _, tag := range meta.GetTags() {
+		val := string(tag.GetValue())
+		switch {
+		case tag.GetKey() == "@srid":
+			md.SRID = val
+		case tag.GetKey() == "@tool":
+			md.Tool = val
+		case tag.GetKey() == "@attribution":
+			md.Attribution = val
+		case strings.HasPrefix(tag.GetKey(), schemaKeyPrefix):
+			typ, ok := fileformat.Tag_ValueType_value[val]
+			if !ok {
+				return md, 0, fmt.Errorf("unknown type %q in schema", val)
+			}
+			md.Schema = append(md.Schema, Property{
+				Name: strings.TrimPrefix(tag.GetKey(), schemaKeyPrefix),
+				Type: fileformat.Tag_ValueType(typ),
+			})
+		}
+	}
+	return md, blockHeaderSize + int(hd.bodyLen), nil
+}
+
+// InferSchema returns the properties of the features with the type of their values, sorted by
+// name. Null values are ignored, unless all values of a property are null. If a property has
+// values of different types, numbers are described as DOUBLE and others as STRING.
+func InferSchema(fs []spatial.Feature) ([]Property, error) {
+	var types = map[string]fileformat.Tag_ValueType{}
+	for _, ft := range fs {
+		for k, v := range ft.Props {
+			_, typ, err := fileformat.ValueType(v)
+			if err != nil {
+				return nil, fmt.Errorf("property %s: %v", k, err)
+			}
+			prev, ok := types[k]
+			if !ok || prev == fileformat.Tag_NULL {
+				types[k] = typ
+				continue
+			}
+			if typ != prev && typ != fileformat.Tag_NULL {
+				if isNumber(prev) && isNumber(typ) {
+					types[k] = fileformat.Tag_DOUBLE
+				} else {
+					types[k] = fileformat.Tag_STRING
+				}
+			}
+		}
+	}
+
+	var schema = make([]Property, 0, len(types))
+	for k, typ := range types {
+		schema = append(schema, Property{Name: k, Type: typ})
+	}
+	sort.Slice(schema, func(i, j int) bool { return schema[i].Name < schema[j].Name })
+	return schema, nil
+}
+
+func isNumber(typ fileformat.Tag_ValueType) bool {
+	switch typ {
+	case fileformat.Tag_INT, fileformat.Tag_UINT, fileformat.Tag_DOUBLE, fileformat.Tag_FLOAT:
+		return true
+	}
+	return false
+}
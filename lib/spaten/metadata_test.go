@@ -0,0 +1,131 @@
+package spaten
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thomersch/grandine/lib/spaten/fileformat"
+	"github.com/thomersch/grandine/lib/spatial"
+)
+
+func TestMetadata(t *testing.T) {
+	fc := gridFeatures(1500)
+	fc.SRID = "4326"
+	schema, err := InferSchema(fc.Features)
+	assert.Nil(t, err)
+	assert.Equal(t, []Property{{Name: "id", Type: fileformat.Tag_STRING}}, schema)
+
+	var (
+		buf bytes.Buffer
+		c   = Codec{BlockIndex: true, Metadata: Metadata{Tool: "test", Attribution: "© contributors", Schema: schema}}
+	)
+	assert.Nil(t, c.Encode(&buf, &fc))
+	var bb = emptyBBox
+	for _, ft := range fc.Features {
+		bb.ExtendWith(ft.Geometry.BBox())
+	}
+
+	hd, err := ReadFileHeader(bytes.NewReader(buf.Bytes()))
+	assert.Nil(t, err)
+	assert.Equal(t, version, hd.Version)
+	assert.Equal(t, Metadata{
+		SRID:        "4326",
+		Tool:        "test",
+		Attribution: "© contributors",
+		Schema:      schema,
+		HasStats:    true,
+		Count:       1500,
+		BBox:        bb,
+	}, hd.Metadata)
+
+	var read spatial.FeatureCollection
+	assert.Nil(t, c.Decode(bytes.NewReader(buf.Bytes()), &read))
+	assert.Equal(t, "4326", read.SRID)
+	assert.Len(t, read.Features, 1500)
+
+	chunks, err := c.ChunkedDecodeBBox(bytes.NewReader(buf.Bytes()), spatial.BBox{SW: spatial.Point{X: 0, Y: 0}, NE: spatial.Point{X: 1, Y: 1}})
+	assert.Nil(t, err)
+	assert.True(t, chunks.Next())
+	var chunk spatial.FeatureCollection
+	assert.Nil(t, chunks.Scan(&chunk))
+	assert.Equal(t, "4326", chunk.SRID)
+}
+
+func TestMetadataStreamed(t *testing.T) {
+	fc := gridFeatures(1500)
+	f, err := ioutil.TempFile("", "spatenmeta")
+	assert.Nil(t, err)
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	// the stats are rewritten, if the writer can seek
+	var c = Codec{Metadata: Metadata{SRID: "3857", Tool: "test"}}
+	assert.Nil(t, c.EncodeChunk(f, &spatial.FeatureCollection{Features: fc.Features[:1200], SRID: "4326"}))
+	assert.Nil(t, c.EncodeChunk(f, &spatial.FeatureCollection{Features: fc.Features[1200:]}))
+	assert.Nil(t, c.Close(f))
+
+	_, err = f.Seek(0, 0)
+	assert.Nil(t, err)
+	hd, err := ReadFileHeader(f)
+	assert.Nil(t, err)
+	assert.Equal(t, "3857", hd.Metadata.SRID)
+	assert.True(t, hd.Metadata.HasStats)
+	assert.Equal(t, uint64(1500), hd.Metadata.Count)
+	var bb = emptyBBox
+	for _, ft := range fc.Features {
+		bb.ExtendWith(ft.Geometry.BBox())
+	}
+	assert.Equal(t, bb, hd.Metadata.BBox)
+
+	_, err = f.Seek(0, 0)
+	assert.Nil(t, err)
+	var read spatial.FeatureCollection
+	assert.Nil(t, c.Decode(f, &read))
+	assert.Len(t, read.Features, 1500)
+
+	// otherwise they are unknown
+	var buf bytes.Buffer
+	c = Codec{}
+	assert.Nil(t, c.EncodeChunk(&buf, &spatial.FeatureCollection{Features: fc.Features, SRID: "4326"}))
+	assert.Nil(t, c.Close(&buf))
+	hd, err = ReadFileHeader(bytes.NewReader(buf.Bytes()))
+	assert.Nil(t, err)
+	assert.Equal(t, Metadata{SRID: "4326"}, hd.Metadata)
+}
+
+func TestInferSchema(t *testing.T) {
+	schema, err := InferSchema([]spatial.Feature{
+		{Props: map[string]interface{}{"name": "a", "n": 1, "x": nil, "mixed": 1, "list": []string{"a"}}},
+		{Props: map[string]interface{}{"name": nil, "n": 1.5, "x": nil, "mixed": "b", "flag": true}},
+		{Props: map[string]interface{}{"n": uint64(2), "f": float32(1)}},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []Property{
+		{Name: "f", Type: fileformat.Tag_FLOAT},
+		{Name: "flag", Type: fileformat.Tag_BOOL},
+		{Name: "list", Type: fileformat.Tag_LIST},
+		{Name: "mixed", Type: fileformat.Tag_STRING},
+		{Name: "n", Type: fileformat.Tag_DOUBLE},
+		{Name: "name", Type: fileformat.Tag_STRING},
+		{Name: "x", Type: fileformat.Tag_NULL},
+	}, schema)
+
+	_, err = InferSchema([]spatial.Feature{{Props: map[string]interface{}{"ch": make(chan int)}}})
+	assert.NotNil(t, err)
+}
+
+func TestInvalidMetadata(t *testing.T) {
+	for _, buf := range [][]byte{
+		[]byte("SPAT\x03\x00\x00\x00"),
+		// block index instead of metadata
+		[]byte("SPAT\x03\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x01"),
+		// stats are missing
+		[]byte("SPAT\x03\x00\x00\x00\x08\x00\x00\x00\x00\x00\x00\x02\x00\x00\x00\x00\x00\x00\x00\x00"),
+	} {
+		_, err := ReadFileHeader(bytes.NewReader(buf))
+		assert.NotNil(t, err, "%q", buf)
+	}
+}
@@ -0,0 +1,201 @@
+package spatial
+
+import (
+	"math"
+	"sort"
+)
+
+// SplitAntimeridian splits lines and polygons which cross the antimeridian into multi
+// geometries, whose parts lie on either side of it. Coordinates are WGS84 longitude/latitude and
+// a segment crosses the antimeridian if its ends are more than 180° of longitude apart, e.g. from
+// 179 to -179. The new vertices at ±180 get interpolated Z and M ordinates. Points, geometries
+// which don't cross the antimeridian and polygons which enclose a pole are returned unchanged.
+func (g Geom) SplitAntimeridian() Geom {
+	switch g.typ {
+	case GeomTypeLineString, GeomTypePolygon:
+		if !g.crossesAntimeridian() {
+			return g
+		}
+		ug, ok := g.unwrapLongitudes()
+		if !ok {
+			return g
+		}
+		pieces := ug.splitWorlds()
+		if len(pieces) == 0 {
+			return g
+		}
+		if len(pieces) == 1 {
+			return pieces[0]
+		}
+		return assembleMulti(g.typ, pieces)
+	case GeomTypeMultiLineString, GeomTypeMultiPolygon:
+		if !g.crossesAntimeridian() {
+			return g
+		}
+		var pieces []Geom
+		for _, m := range g.members() {
+			sm := m.SplitAntimeridian()
+			pieces = append(pieces, sm.members()...)
+		}
+		return assembleMulti(g.typ, pieces)
+	case GeomTypeGeometryCollection:
+		var gc = make(GeomCollection, 0, len(g.g.(GeomCollection)))
+		for _, m := range g.g.(GeomCollection) {
+			gc = append(gc, m.SplitAntimeridian())
+		}
+		return Geom{typ: g.typ, g: gc}
+	}
+	return g
+}
+
+// assembleMulti combines lines or polygons into a MultiLineString or MultiPolygon.
+func assembleMulti(typ GeomType, pieces []Geom) Geom {
+	var mg Geom
+	switch typ {
+	case GeomTypeLineString, GeomTypeMultiLineString:
+		var ml MultiLine
+		for _, p := range pieces {
+			ml = append(ml, p.g.(Line))
+		}
+		mg = Geom{typ: GeomTypeMultiLineString, g: ml}
+	default:
+		var mp MultiPolygon
+		for _, p := range pieces {
+			mp = append(mp, p.g.(Polygon))
+		}
+		mg = Geom{typ: GeomTypeMultiPolygon, g: mp}
+	}
+	mg.joinOrdinates(pieces)
+	return mg
+}
+
+func (g *Geom) crossesAntimeridian() bool {
+	parts, closed := g.parts()
+	for _, part := range parts {
+		for i := range part {
+			next := i + 1
+			if next == len(part) {
+				if !closed {
+					break
+				}
+				next = 0
+			}
+			if math.Abs(part[next].X-part[i].X) > 180 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// unwrapLongitudes returns a copy of a line or polygon whose longitudes are shifted by multiples
+// of 360°, so no segment is longer than 180°. The result is false for rings which enclose a pole,
+// as they can't be unwrapped.
+func (g *Geom) unwrapLongitudes() (Geom, bool) {
+	ug := g.Copy()
+	switch gm := ug.g.(type) {
+	case Line:
+		unwrapLine(gm)
+	case Polygon:
+		for n, ring := range gm {
+			unwrapLine(ring)
+			if math.Abs(ring[len(ring)-1].X-ring[0].X) > 180 {
+				return ug, false
+			}
+			if n == 0 {
+				continue
+			}
+			// move holes next to the outer ring
+			shift := 360 * math.Round((gm[0][0].X-ring[0].X)/360)
+			for i := range ring {
+				ring[i].X += shift
+			}
+		}
+	}
+	return ug, true
+}
+
+func unwrapLine(ln Line) {
+	for i := 1; i < len(ln); i++ {
+		ln[i].X -= 360 * math.Round((ln[i].X-ln[i-1].X)/360)
+	}
+}
+
+// splitWorlds clips an unwrapped geometry into the ranges from -180 to 180 plus multiples of 360
+// and moves the pieces back into the regular range.
+func (g *Geom) splitWorlds() []Geom {
+	var (
+		bb     = g.BBox()
+		first  = math.Floor((bb.SW.X + 180) / 360)
+		last   = math.Ceil((bb.NE.X - 180) / 360)
+		pieces []Geom
+	)
+	for k := first; k <= last; k++ {
+		var (
+			shift = 360 * k
+			// extended vertically, so horizontal lines are not clipped away
+			world = BBox{SW: Point{-180 + shift, bb.SW.Y - 1}, NE: Point{180 + shift, bb.NE.Y + 1}}
+		)
+		for _, p := range g.ClipToBBox(world) {
+			if degenerate(p) {
+				continue
+			}
+			if shift != 0 {
+				p.Project(func(pt Point) Point { return Point{pt.X - shift, pt.Y} })
+			}
+			pieces = append(pieces, p)
+		}
+	}
+	return pieces
+}
+
+// degenerate reports whether a clipped line or polygon has collapsed, e.g. where it touches the
+// clipping bbox.
+func degenerate(g Geom) bool {
+	switch gm := g.g.(type) {
+	case Line:
+		return len(gm) < 2
+	case Polygon:
+		return len(gm) == 0 || len(gm[0]) < 3
+	}
+	return true
+}
+
+// WrappedBBox returns the smallest bbox of the geometry, considering that longitudes wrap
+// around at the antimeridian. It is the same as BBox, unless the geometry has parts on both
+// sides of the antimeridian, e.g. after SplitAntimeridian. Then the bbox is wrapped, see
+// BBox.Wrapped.
+func (g *Geom) WrappedBBox() BBox {
+	bb := g.BBox()
+	if bb.NE.X-bb.SW.X <= 180 {
+		return bb
+	}
+
+	// find the largest gap between the longitude ranges of the parts
+	var ranges [][2]float64
+	for _, m := range g.members() {
+		parts, _ := m.parts()
+		for _, part := range parts {
+			pb := part.BBox()
+			ranges = append(ranges, [2]float64{pb.SW.X, pb.NE.X})
+		}
+	}
+	if len(ranges) == 0 {
+		return bb
+	}
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i][0] < ranges[j][0] })
+	var (
+		// the gap across the antimeridian belongs to the regular bbox
+		maxGap = ranges[0][0] + 360 - bb.NE.X
+		end    = ranges[0][1]
+		west   = bb.SW.X
+		east   = bb.NE.X
+	)
+	for _, r := range ranges[1:] {
+		if gap := r[0] - end; gap > maxGap {
+			maxGap, west, east = gap, r[0], end
+		}
+		end = math.Max(end, r[1])
+	}
+	return BBox{SW: Point{west, bb.SW.Y}, NE: Point{east, bb.NE.Y}}
+}
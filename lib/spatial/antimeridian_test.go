@@ -0,0 +1,110 @@
+package spatial
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitAntimeridian(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		geom     Geom
+		expected Geom
+	}{
+		{"point", MustNewGeom(Point{179, 0}), MustNewGeom(Point{179, 0})},
+		{"regular line", MustNewGeom(Line{{-170, 0}, {-10, 10}, {170, 10}}), MustNewGeom(Line{{-170, 0}, {-10, 10}, {170, 10}})},
+		{
+			"line",
+			MustNewGeom(Line{{170, 0}, {-170, 10}}),
+			MustNewGeom(MultiLine{{{170, 0}, {180, 5}}, {{-180, 5}, {-170, 10}}}),
+		},
+		{
+			"line crossing twice",
+			MustNewGeom(Line{{170, 0}, {-170, 0}, {-170, 10}, {170, 10}}),
+			MustNewGeom(MultiLine{{{170, 0}, {180, 0}}, {{180, 10}, {170, 10}}, {{-180, 0}, {-170, 0}, {-170, 10}, {-180, 10}}}),
+		},
+		{
+			"multi line",
+			MustNewGeom(MultiLine{{{0, 0}, {1, 1}}, {{-175, 0}, {175, 0}}}),
+			MustNewGeom(MultiLine{{{0, 0}, {1, 1}}, {{180, 0}, {175, 0}}, {{-175, 0}, {-180, 0}}}),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, tc.geom.SplitAntimeridian())
+		})
+	}
+}
+
+func TestSplitAntimeridianPolygon(t *testing.T) {
+	fiji := MustNewGeom(Polygon{
+		{{177, -18}, {-179, -18}, {-179, -16}, {177, -16}},
+		{{-179.5, -17.5}, {-179.2, -17.5}, {-179.2, -17}, {-179.5, -17}},
+	})
+	assert.Nil(t, fiji.SetZ([]float64{0, 4, 4, 0, 1, 1, 1, 1}))
+
+	sg := fiji.SplitAntimeridian()
+	assert.Equal(t, GeomTypeMultiPolygon, sg.Typ())
+	mp := sg.MustMultiPolygon()
+	assert.Len(t, mp, 2)
+
+	var east, west Polygon
+	for _, poly := range mp {
+		if poly.BBox().SW.X > 0 {
+			east = poly
+		} else {
+			west = poly
+		}
+	}
+	assert.Equal(t, BBox{Point{177, -18}, Point{180, -16}}, east.BBox())
+	assert.Equal(t, BBox{Point{-180, -18}, Point{-179, -16}}, west.BBox())
+	// the hole stays in the western part
+	assert.Len(t, west, 2)
+	assert.Len(t, east, 1)
+
+	// ordinates are interpolated at the antimeridian
+	assert.Len(t, sg.Z(), sg.vertexCount())
+	for i, pt := range append(mp[0][0], mp[1][0]...) {
+		if pt.X == 180 || pt.X == -180 {
+			assert.Equal(t, 3.0, sg.Z()[i])
+		}
+	}
+
+	assert.Equal(t, BBox{Point{177, -18}, Point{-179, -16}}, sg.WrappedBBox())
+
+	// Antarctica encloses the south pole, so it can't be split
+	antarctica := MustNewGeom(Polygon{{{-180, -90}, {180, -90}, {180, -70}, {90, -65}, {0, -70}, {-90, -65}, {-180, -70}}})
+	assert.Equal(t, antarctica, antarctica.SplitAntimeridian())
+}
+
+func TestWrappedBBox(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		geom     Geom
+		expected BBox
+	}{
+		{"line", MustNewGeom(Line{{-10, 0}, {10, 5}}), BBox{Point{-10, 0}, Point{10, 5}}},
+		{"wide line", MustNewGeom(Line{{-170, 0}, {170, 5}}), BBox{Point{-170, 0}, Point{170, 5}}},
+		{"wrapped points", MustNewGeom(MultiPoint{{178, 0}, {-179, 1}, {179, 2}}), BBox{Point{178, 0}, Point{-179, 2}}},
+		{"spread points", MustNewGeom(MultiPoint{{-100, 0}, {0, 1}, {100, 2}}), BBox{Point{-100, 0}, Point{100, 2}}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			bb := tc.geom.WrappedBBox()
+			assert.Equal(t, tc.expected, bb)
+			assert.Equal(t, tc.expected.SW.X > tc.expected.NE.X, bb.Wrapped())
+		})
+	}
+}
+
+func TestBBoxUnwrap(t *testing.T) {
+	bb := BBox{Point{170, -10}, Point{-170, 10}}
+	assert.True(t, bb.Wrapped())
+	assert.Equal(t, []BBox{{Point{170, -10}, Point{180, 10}}, {Point{-180, -10}, Point{-170, 10}}}, bb.Unwrap())
+	assert.True(t, Point{175, 0}.InBBox(bb))
+	assert.True(t, Point{-175, 0}.InBBox(bb))
+	assert.False(t, Point{0, 0}.InBBox(bb))
+
+	bb = BBox{Point{-170, -10}, Point{170, 10}}
+	assert.False(t, bb.Wrapped())
+	assert.Equal(t, []BBox{bb}, bb.Unwrap())
+}
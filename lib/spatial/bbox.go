@@ -2,10 +2,30 @@ package spatial
 
 import "math"
 
+// BBox is a bounding box. If SW.X is greater than NE.X, the bbox crosses the antimeridian, see
+// Wrapped.
 type BBox struct {
 	SW, NE Point
 }
 
+// Wrapped reports whether the bbox crosses the antimeridian, e.g. SW.X = 170 and NE.X = -170
+// denote a range of 20° of longitude.
+func (b BBox) Wrapped() bool {
+	return b.SW.X > b.NE.X
+}
+
+// Unwrap splits a bbox which crosses the antimeridian into its parts east and west of it. Other
+// bboxes are returned unchanged.
+func (b BBox) Unwrap() []BBox {
+	if !b.Wrapped() {
+		return []BBox{b}
+	}
+	return []BBox{
+		{SW: b.SW, NE: Point{180, b.NE.Y}},
+		{SW: Point{-180, b.SW.Y}, NE: b.NE},
+	}
+}
+
 func (b1 *BBox) ExtendWith(b2 BBox) {
 	b1.SW = Point{math.Min(b1.SW.X, b2.SW.X), math.Min(b1.SW.Y, b2.SW.Y)}
 	b1.NE = Point{math.Max(b1.NE.X, b2.NE.X), math.Max(b1.NE.Y, b2.NE.Y)}
@@ -0,0 +1,344 @@
+package spatial
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// CapStyle defines the shape at the ends of buffered lines.
+type CapStyle uint8
+
+const (
+	CapRound CapStyle = iota
+	CapFlat
+	CapSquare
+)
+
+// JoinStyle defines the shape at the outer side of corners of buffered lines and polygons.
+type JoinStyle uint8
+
+const (
+	JoinRound JoinStyle = iota
+	JoinMitre
+)
+
+// BufferOptions control the shape of buffers. The zero value creates round caps and joins.
+type BufferOptions struct {
+	Cap  CapStyle
+	Join JoinStyle
+	// MitreLimit is the maximum distance of a mitre join from the vertex, relative to the buffer
+	// distance. Corners which would exceed it are bevelled. Defaults to 5.
+	MitreLimit float64
+	// QuadrantSegments is the number of segments which approximate a quarter circle. Defaults to 8.
+	QuadrantSegments int
+	// Geodesic interprets the distance as meters and the coordinates as WGS84 longitude/latitude
+	// (EPSG:4326). The buffer is calculated in a local equirectangular projection, whose scale
+	// is set at the center of the geometry. Distortion grows with the distance from the center,
+	// so the result is only accurate for geometries and buffer distances of less than a few
+	// hundred kilometers.
+	Geodesic bool
+}
+
+func (o BufferOptions) withDefaults() BufferOptions {
+	if o.MitreLimit <= 0 {
+		o.MitreLimit = 5
+	}
+	if o.QuadrantSegments <= 0 {
+		o.QuadrantSegments = 8
+	}
+	return o
+}
+
+// Buffer returns the area within distance of g. A negative distance shrinks polygons, for points
+// and lines it results in an empty geometry. The result is a Polygon, a MultiPolygon or empty,
+// like the results of the overlay operations.
+func (g Geom) Buffer(distance float64, opts BufferOptions) (Geom, error) {
+	if g.g == nil {
+		return Geom{}, nil
+	}
+	if math.IsNaN(distance) || math.IsInf(distance, 0) {
+		return Geom{}, errors.New("buffer distance must be finite")
+	}
+	opts = opts.withDefaults()
+
+	if opts.Geodesic {
+		lp := newLocalProjection(g.BBox())
+		pg := g.Copy()
+		pg.Project(lp.forward)
+		opts.Geodesic = false
+		res, err := pg.Buffer(distance, opts)
+		if err != nil || res.g == nil {
+			return res, err
+		}
+		res.Project(lp.inverse)
+		return res, nil
+	}
+
+	polys, err := g.bufferPolygons(distance, opts)
+	if err != nil {
+		return Geom{}, err
+	}
+	return polygonsToGeom(polys), nil
+}
+
+func (g Geom) bufferPolygons(d float64, opts BufferOptions) ([]Polygon, error) {
+	var pieces []overlayRing
+	switch gm := g.g.(type) {
+	case *Point:
+		if d > 0 {
+			pieces = bufferPoint(*gm, d, opts)
+		}
+	case Line:
+		if d > 0 {
+			pieces = bufferLine(gm, d, false, opts)
+		}
+	case MultiPoint:
+		if d > 0 {
+			for _, pt := range gm {
+				pieces = append(pieces, bufferPoint(pt, d, opts)...)
+			}
+		}
+	case MultiLine:
+		if d > 0 {
+			for _, ln := range gm {
+				pieces = append(pieces, bufferLine(ln, d, false, opts)...)
+			}
+		}
+	case Polygon:
+		return bufferPolygon(gm, d, opts)
+	case MultiPolygon:
+		for _, poly := range gm {
+			polys, err := bufferPolygon(poly, d, opts)
+			if err != nil {
+				return nil, err
+			}
+			pieces = appendPolygonRings(pieces, polys...)
+		}
+	case GeomCollection:
+		for _, m := range gm {
+			polys, err := m.bufferPolygons(d, opts)
+			if err != nil {
+				return nil, err
+			}
+			pieces = appendPolygonRings(pieces, polys...)
+		}
+	default:
+		return nil, fmt.Errorf("cannot buffer %v", g.typ)
+	}
+	// The pieces overlap, with fillPositive every area covered by at least one of them is kept.
+	return overlayPolygons(pieces, nil, fillPositive, opUnion)
+}
+
+func bufferPoint(pt Point, d float64, opts BufferOptions) []overlayRing {
+	switch opts.Cap {
+	case CapFlat:
+		return nil
+	case CapSquare:
+		return bufferPiece(Line{{pt.X - d, pt.Y - d}, {pt.X - d, pt.Y + d}, {pt.X + d, pt.Y + d}, {pt.X + d, pt.Y - d}})
+	}
+	return bufferPiece(circle(pt, d, opts.QuadrantSegments))
+}
+
+func bufferPiece(ring Line) []overlayRing {
+	return []overlayRing{{ring: ring, sign: 1}}
+}
+
+// bufferPolygon grows or shrinks a polygon by the buffer of its rings.
+func bufferPolygon(poly Polygon, d float64, opts BufferOptions) ([]Polygon, error) {
+	if len(poly) == 0 {
+		return nil, nil
+	}
+	var (
+		rings  = appendPolygonRings(nil, poly)
+		pieces []overlayRing
+	)
+	if d == 0 {
+		return overlayPolygons(rings, nil, fillPositive, opUnion)
+	}
+	for _, ring := range poly {
+		pieces = append(pieces, bufferLine(ring, math.Abs(d), true, opts)...)
+	}
+	if d > 0 {
+		return overlayPolygons(rings, pieces, fillPositive, opUnion)
+	}
+	return overlayPolygons(rings, pieces, fillPositive, opDifference)
+}
+
+// bufferLine returns the pieces which make up the buffer of a line: rectangles around every
+// segment, joins at the vertices and caps at the ends. If closed is true, the line is treated
+// as a ring.
+func bufferLine(ln Line, d float64, closed bool, opts BufferOptions) []overlayRing {
+	// remove repeated points, they don't have a direction
+	var pts = make(Line, 0, len(ln))
+	for _, pt := range ln {
+		if len(pts) == 0 || pts[len(pts)-1] != pt {
+			pts = append(pts, pt)
+		}
+	}
+	if closed && len(pts) > 1 && pts[0] == pts[len(pts)-1] {
+		pts = pts[:len(pts)-1]
+	}
+	switch {
+	case len(pts) == 0:
+		return nil
+	case len(pts) == 1:
+		if closed {
+			return bufferPiece(circle(pts[0], d, opts.QuadrantSegments))
+		}
+		return bufferPoint(pts[0], d, opts)
+	}
+
+	var segs = pts.Segments()
+	if closed && len(pts) > 2 {
+		segs = append(segs, Segment{pts[len(pts)-1], pts[0]})
+	}
+
+	var pieces = make([]overlayRing, 0, 2*len(segs)+2)
+	for _, seg := range segs {
+		n := seg.normal()
+		pieces = append(pieces, bufferPiece(Line{
+			seg[0].offset(n, d), seg[1].offset(n, d), seg[1].offset(n, -d), seg[0].offset(n, -d),
+		})...)
+	}
+	for i := range segs {
+		if i == len(segs)-1 && !closed {
+			break
+		}
+		pieces = append(pieces, bufferJoin(segs[i], segs[(i+1)%len(segs)], d, opts)...)
+	}
+	if !closed {
+		pieces = append(pieces, bufferCap(segs[0][0], segs[0].normal(), d, -1, opts)...)
+		pieces = append(pieces, bufferCap(segs[len(segs)-1][1], segs[len(segs)-1].normal(), d, 1, opts)...)
+	}
+	return pieces
+}
+
+// bufferJoin creates the piece which fills the gap on the outer side of the vertex between
+// s1 and s2.
+func bufferJoin(s1, s2 Segment, d float64, opts BufferOptions) []overlayRing {
+	var (
+		v      = s1[1]
+		n1, n2 = s1.normal(), s2.normal()
+		cross  = n1.X*n2.Y - n1.Y*n2.X
+	)
+	if cross == 0 && n1 == n2 {
+		return nil // straight continuation
+	}
+	// The normals point to the left, for a left turn the outer side is on the right.
+	side := 1.0
+	if cross > 0 {
+		side = -1
+	}
+	var (
+		p1  = v.offset(n1, side*d)
+		p2  = v.offset(n2, side*d)
+		dot = n1.X*n2.X + n1.Y*n2.Y
+	)
+	if opts.Join == JoinRound {
+		var (
+			start = math.Atan2(side*n1.Y, side*n1.X)
+			sweep = math.Atan2(side*n2.Y, side*n2.X) - start
+		)
+		// take the shorter way around, which is always the outer side
+		switch {
+		case cross == 0:
+			sweep = -math.Pi // the line reverses, so the join is a cap
+		case sweep > math.Pi:
+			sweep -= 2 * math.Pi
+		case sweep < -math.Pi:
+			sweep += 2 * math.Pi
+		}
+		ring := Line{v, p1}
+		ring = append(ring, arc(v, d, start, sweep, opts.QuadrantSegments)...)
+		return bufferPiece(append(ring, p2))
+	}
+	if 1+dot > 0 && math.Sqrt(2/(1+dot)) <= opts.MitreLimit {
+		mitre := v.offset(Point{n1.X + n2.X, n1.Y + n2.Y}, side*d/(1+dot))
+		return bufferPiece(Line{v, p1, mitre, p2})
+	}
+	if cross == 0 {
+		return nil // the line reverses, there is no corner to bevel
+	}
+	return bufferPiece(Line{v, p1, p2})
+}
+
+// bufferCap creates the end of a line at pt. dir is -1 for the start and 1 for the end.
+func bufferCap(pt Point, n Point, d, dir float64, opts BufferOptions) []overlayRing {
+	switch opts.Cap {
+	case CapFlat:
+		return nil
+	case CapSquare:
+		// the direction of the line is the normal rotated clockwise
+		ext := Point{pt.X + dir*n.Y*d, pt.Y - dir*n.X*d}
+		return bufferPiece(Line{pt.offset(n, d), ext.offset(n, d), ext.offset(n, -d), pt.offset(n, -d)})
+	}
+	// The half circle ends exactly at the corners of the segment rectangle, so they share an edge.
+	ring := Line{pt.offset(n, d)}
+	ring = append(ring, arc(pt, d, math.Atan2(n.Y, n.X), -dir*math.Pi, opts.QuadrantSegments)...)
+	return bufferPiece(append(ring, pt.offset(n, -d)))
+}
+
+// arc returns the points between start and start+sweep (in radians) on a circle, excluding both
+// ends.
+func arc(center Point, r, start, sweep float64, quadSegs int) Line {
+	var (
+		steps = int(math.Ceil(math.Abs(sweep) / (math.Pi / 2) * float64(quadSegs)))
+		l     = make(Line, 0, steps)
+	)
+	for i := 1; i < steps; i++ {
+		a := start + sweep*float64(i)/float64(steps)
+		l = append(l, Point{center.X + r*math.Cos(a), center.Y + r*math.Sin(a)})
+	}
+	return l
+}
+
+func circle(center Point, r float64, quadSegs int) Line {
+	var (
+		n = 4 * quadSegs
+		l = make(Line, 0, n)
+	)
+	for i := 0; i < n; i++ {
+		a := 2 * math.Pi * float64(i) / float64(n)
+		l = append(l, Point{center.X + r*math.Cos(a), center.Y + r*math.Sin(a)})
+	}
+	return l
+}
+
+// normal returns the unit vector which is perpendicular to the segment, pointing to the left.
+func (s Segment) normal() Point {
+	var (
+		dx = s[1].X - s[0].X
+		dy = s[1].Y - s[0].Y
+		l  = math.Hypot(dx, dy)
+	)
+	return Point{-dy / l, dx / l}
+}
+
+func (p Point) offset(n Point, d float64) Point {
+	return Point{p.X + n.X*d, p.Y + n.Y*d}
+}
+
+// localProjection is an equirectangular projection in meters, centered on a bounding box.
+type localProjection struct {
+	center Point
+	scaleX float64
+	scaleY float64
+}
+
+func newLocalProjection(bb BBox) localProjection {
+	center := Point{(bb.SW.X + bb.NE.X) / 2, (bb.SW.Y + bb.NE.Y) / 2}
+	return localProjection{
+		center: center,
+		scaleX: degToRad(1) * earthRadiusMeters * math.Cos(degToRad(center.Y)),
+		scaleY: degToRad(1) * earthRadiusMeters,
+	}
+}
+
+func (lp localProjection) forward(p Point) Point {
+	return Point{(p.X - lp.center.X) * lp.scaleX, (p.Y - lp.center.Y) * lp.scaleY}
+}
+
+func (lp localProjection) inverse(p Point) Point {
+	return Point{p.X/lp.scaleX + lp.center.X, p.Y/lp.scaleY + lp.center.Y}
+}
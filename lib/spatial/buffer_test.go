@@ -0,0 +1,68 @@
+package spatial
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuffer(t *testing.T) {
+	var (
+		lshape   = MustNewGeom(Line{{0, 0}, {10, 0}, {10, 10}})
+		sq       = MustNewGeom(Polygon{square(0, 0, 10)})
+		mitre    = BufferOptions{Join: JoinMitre}
+		flatMitr = BufferOptions{Join: JoinMitre, Cap: CapFlat}
+		// 32-gon, which approximates the unit circle
+		circleArea = 16 * math.Sin(math.Pi/16)
+	)
+	for _, tc := range []struct {
+		name string
+		geom Geom
+		d    float64
+		opts BufferOptions
+		typ  GeomType
+		area float64
+	}{
+		{"point round", MustNewGeom(Point{1, 1}), 1, BufferOptions{}, GeomTypePolygon, circleArea},
+		{"point square", MustNewGeom(Point{1, 1}), 1, BufferOptions{Cap: CapSquare}, GeomTypePolygon, 4},
+		{"point flat", MustNewGeom(Point{1, 1}), 1, BufferOptions{Cap: CapFlat}, GeomTypeEmpty, 0},
+		{"point negative", MustNewGeom(Point{1, 1}), -1, BufferOptions{}, GeomTypeEmpty, 0},
+		{"line round", MustNewGeom(Line{{0, 0}, {10, 0}}), 1, BufferOptions{}, GeomTypePolygon, 20 + circleArea},
+		{"line flat", MustNewGeom(Line{{0, 0}, {10, 0}}), 1, BufferOptions{Cap: CapFlat}, GeomTypePolygon, 20},
+		{"line square", MustNewGeom(Line{{0, 0}, {10, 0}}), 1, BufferOptions{Cap: CapSquare}, GeomTypePolygon, 24},
+		{"line mitre", lshape, 1, flatMitr, GeomTypePolygon, 40},
+		{"line bevel", lshape, 1, BufferOptions{Join: JoinMitre, Cap: CapFlat, MitreLimit: 1}, GeomTypePolygon, 39.5},
+		{"polygon grow", sq, 1, mitre, GeomTypePolygon, 144},
+		{"polygon shrink", sq, -1, mitre, GeomTypePolygon, 64},
+		{"polygon shrink round", sq, -1, BufferOptions{}, GeomTypePolygon, 64},
+		{"polygon shrink to nothing", sq, -5, mitre, GeomTypeEmpty, 0},
+		{"polygon zero", sq, 0, mitre, GeomTypePolygon, 100},
+		{"polygon hole", MustNewGeom(Polygon{square(0, 0, 10), square(4, 4, 2)}), 0.5, mitre, GeomTypePolygon, 120},
+		{"polygon hole closes", MustNewGeom(Polygon{square(0, 0, 10), square(4, 4, 2)}), 1, mitre, GeomTypePolygon, 144},
+		{"multipoint merge", MustNewGeom(MultiPoint{{0, 0}, {1, 0}}), 1, BufferOptions{Cap: CapSquare}, GeomTypePolygon, 6},
+		{"multipoint separate", MustNewGeom(MultiPoint{{0, 0}, {10, 0}}), 1, BufferOptions{Cap: CapSquare}, GeomTypeMultiPolygon, 8},
+		{"multipolygon", MustNewGeom(MultiPolygon{{square(0, 0, 10)}, {square(20, 0, 10)}}), -1, mitre, GeomTypeMultiPolygon, 128},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := tc.geom.Buffer(tc.d, tc.opts)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.typ, res.Typ())
+			assert.InDelta(t, tc.area, overlayArea(t, res), 1e-6)
+		})
+	}
+}
+
+func TestBufferGeodesic(t *testing.T) {
+	center := Point{13.4, 52.5}
+	res, err := MustNewGeom(center).Buffer(1000, BufferOptions{Geodesic: true})
+	assert.Nil(t, err)
+	for _, pt := range res.MustPolygon()[0] {
+		assert.InDelta(t, 1000, center.HaversineDistance(&pt), 2)
+	}
+}
+
+func TestBufferInvalid(t *testing.T) {
+	_, err := MustNewGeom(Point{1, 1}).Buffer(math.NaN(), BufferOptions{})
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,67 @@
+package spatial
+
+import "strings"
+
+// GeomCollection is a heterogeneous set of geometries which are treated as a single geometry.
+type GeomCollection []Geom
+
+func (gc GeomCollection) Project(proj ConvertFunc) {
+	for _, g := range gc {
+		g.Project(proj)
+	}
+}
+
+func (gc GeomCollection) Copy() Projectable {
+	var ngc = make(GeomCollection, 0, len(gc))
+	for _, g := range gc {
+		ngc = append(ngc, g.Copy())
+	}
+	return ngc
+}
+
+func (gc GeomCollection) String() string {
+	var parts = make([]string, 0, len(gc))
+	for _, g := range gc {
+		parts = append(parts, g.String())
+	}
+	return "(" + strings.Join(parts, ", ") + ")"
+}
+
+// BBox returns the bbox of all member geometries, which is zero if all of them are empty.
+func (gc GeomCollection) BBox() BBox {
+	var (
+		bb    BBox
+		empty = true
+	)
+	for _, g := range gc {
+		if g.IsEmpty() {
+			continue
+		}
+		if empty {
+			bb, empty = g.BBox(), false
+			continue
+		}
+		bb.ExtendWith(g.BBox())
+	}
+	return bb
+}
+
+// ClipToBBox clips all member geometries and returns the remaining parts as one GeomCollection.
+func (gc GeomCollection) ClipToBBox(b BBox) []Geom {
+	var clipped GeomCollection
+	for _, g := range gc {
+		clipped = append(clipped, g.ClipToBBox(b)...)
+	}
+	if len(clipped) == 0 {
+		return []Geom{}
+	}
+	return []Geom{MustNewGeom(clipped)}
+}
+
+func (gc GeomCollection) Simplify(e float64) GeomCollection {
+	var sgc = make(GeomCollection, 0, len(gc))
+	for _, g := range gc {
+		sgc = append(sgc, g.Simplify(e))
+	}
+	return sgc
+}
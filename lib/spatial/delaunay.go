@@ -0,0 +1,359 @@
+package spatial
+
+import (
+	"math"
+	"sort"
+)
+
+// delaunay is a Delaunay triangulation, calculated with the sweep-hull algorithm of the
+// Delaunator library by Vladimir Agafonkin.
+//
+// Triangles are stored as triplets of point indices, halfedges[e] is the opposite halfedge
+// in the adjacent triangle or -1 if e is on the convex hull.
+type delaunay struct {
+	points    []Point
+	triangles []int
+	halfedges []int
+	hull      []int
+
+	hullPrev  []int
+	hullNext  []int
+	hullTri   []int
+	hullHash  []int
+	hullStart int
+	center    Point
+	edgeStack []int
+}
+
+// triangulate calculates the Delaunay triangulation of pts. If all points are collinear, no
+// triangles are returned and the hull contains the points ordered along the line.
+func triangulate(pts []Point) *delaunay {
+	n := len(pts)
+	maxTriangles := 2*n - 5
+	if maxTriangles < 0 {
+		maxTriangles = 0
+	}
+	d := &delaunay{
+		points:    pts,
+		triangles: make([]int, 0, maxTriangles*3),
+		halfedges: make([]int, 0, maxTriangles*3),
+		hullPrev:  make([]int, n),
+		hullNext:  make([]int, n),
+		hullTri:   make([]int, n),
+		hullHash:  make([]int, int(math.Ceil(math.Sqrt(float64(n))))),
+	}
+	if n == 0 {
+		return d
+	}
+	d.run()
+	return d
+}
+
+func (d *delaunay) run() {
+	var (
+		pts = d.points
+		n   = len(pts)
+		bb  = Line(pts).BBox()
+		c   = Point{(bb.SW.X + bb.NE.X) / 2, (bb.SW.Y + bb.NE.Y) / 2}
+	)
+
+	// The seed triangle consists of the point closest to the center, its nearest neighbour and
+	// the point which forms the smallest circumcircle with both of them.
+	i0, i1, i2 := -1, -1, -1
+	minDist := math.Inf(1)
+	for i, pt := range pts {
+		if dd := sqDist(c, pt); dd < minDist {
+			i0, minDist = i, dd
+		}
+	}
+	minDist = math.Inf(1)
+	for i, pt := range pts {
+		if i == i0 {
+			continue
+		}
+		if dd := sqDist(pts[i0], pt); dd < minDist && dd > 0 {
+			i1, minDist = i, dd
+		}
+	}
+	minRadius := math.Inf(1)
+	if i1 != -1 {
+		for i, pt := range pts {
+			if i == i0 || i == i1 {
+				continue
+			}
+			if r := circumradius(pts[i0], pts[i1], pt); r < minRadius {
+				i2, minRadius = i, r
+			}
+		}
+	}
+	if math.IsInf(minRadius, 1) {
+		d.collinearHull()
+		return
+	}
+	if orient(pts[i0], pts[i1], pts[i2]) {
+		i1, i2 = i2, i1
+	}
+
+	d.center = circumcenter(pts[i0], pts[i1], pts[i2])
+	var (
+		ids   = make([]int, n)
+		dists = make([]float64, n)
+	)
+	for i, pt := range pts {
+		ids[i] = i
+		dists[i] = sqDist(pt, d.center)
+	}
+	sort.Slice(ids, func(a, b int) bool { return dists[ids[a]] < dists[ids[b]] })
+
+	for i := range d.hullHash {
+		d.hullHash[i] = -1
+	}
+	d.hullStart = i0
+	hullSize := 3
+	d.hullNext[i0], d.hullPrev[i2] = i1, i1
+	d.hullNext[i1], d.hullPrev[i0] = i2, i2
+	d.hullNext[i2], d.hullPrev[i1] = i0, i0
+	d.hullTri[i0], d.hullTri[i1], d.hullTri[i2] = 0, 1, 2
+	d.hullHash[d.hashKey(pts[i0])] = i0
+	d.hullHash[d.hashKey(pts[i1])] = i1
+	d.hullHash[d.hashKey(pts[i2])] = i2
+	d.addTriangle(i0, i1, i2, -1, -1, -1)
+
+	var prev Point
+	for k, i := range ids {
+		pt := pts[i]
+		// skip near-duplicate points
+		if k > 0 && math.Abs(pt.X-prev.X) <= delaunayEpsilon && math.Abs(pt.Y-prev.Y) <= delaunayEpsilon {
+			continue
+		}
+		prev = pt
+		if i == i0 || i == i1 || i == i2 {
+			continue
+		}
+
+		// find a visible edge on the convex hull using the edge hash
+		var start int
+		for j, key := 0, d.hashKey(pt); j < len(d.hullHash); j++ {
+			start = d.hullHash[(key+j)%len(d.hullHash)]
+			if start != -1 && start != d.hullNext[start] {
+				break
+			}
+		}
+		start = d.hullPrev[start]
+		e := start
+		for q := d.hullNext[e]; !orient(pt, pts[e], pts[q]); q = d.hullNext[e] {
+			e = q
+			if e == start {
+				e = -1
+				break
+			}
+		}
+		if e == -1 {
+			continue // likely a near-duplicate point
+		}
+
+		// add the first triangle from the point and flip until the Delaunay condition holds
+		t := d.addTriangle(e, i, d.hullNext[e], -1, -1, d.hullTri[e])
+		d.hullTri[i] = d.legalize(t + 2)
+		d.hullTri[e] = t
+		hullSize++
+
+		// walk forward through the hull, adding more triangles
+		nx := d.hullNext[e]
+		for q := d.hullNext[nx]; orient(pt, pts[nx], pts[q]); q = d.hullNext[nx] {
+			t = d.addTriangle(nx, i, q, d.hullTri[i], -1, d.hullTri[nx])
+			d.hullTri[i] = d.legalize(t + 2)
+			d.hullNext[nx] = nx // mark as removed
+			hullSize--
+			nx = q
+		}
+		// walk backward from the other side
+		if e == start {
+			for q := d.hullPrev[e]; orient(pt, pts[q], pts[e]); q = d.hullPrev[e] {
+				t = d.addTriangle(q, i, e, -1, d.hullTri[e], d.hullTri[q])
+				d.legalize(t + 2)
+				d.hullTri[q] = t
+				d.hullNext[e] = e
+				hullSize--
+				e = q
+			}
+		}
+
+		d.hullStart = e
+		d.hullPrev[i] = e
+		d.hullNext[e] = i
+		d.hullPrev[nx] = i
+		d.hullNext[i] = nx
+		d.hullHash[d.hashKey(pt)] = i
+		d.hullHash[d.hashKey(pts[e])] = e
+	}
+
+	d.hull = make([]int, 0, hullSize)
+	for i, e := 0, d.hullStart; i < hullSize; i++ {
+		d.hull = append(d.hull, e)
+		e = d.hullNext[e]
+	}
+}
+
+// collinearHull orders the points along the line on which they all lie.
+func (d *delaunay) collinearHull() {
+	var (
+		ids   = make([]int, len(d.points))
+		dists = make([]float64, len(d.points))
+		p0    = d.points[0]
+	)
+	for i, pt := range d.points {
+		ids[i] = i
+		dists[i] = pt.X - p0.X
+		if dists[i] == 0 {
+			dists[i] = pt.Y - p0.Y
+		}
+	}
+	sort.Slice(ids, func(a, b int) bool { return dists[ids[a]] < dists[ids[b]] })
+	for k, i := range ids {
+		if k == 0 || dists[i] > dists[ids[k-1]] {
+			d.hull = append(d.hull, i)
+		}
+	}
+}
+
+func (d *delaunay) hashKey(pt Point) int {
+	return int(math.Floor(pseudoAngle(pt.X-d.center.X, pt.Y-d.center.Y)*float64(len(d.hullHash)))) % len(d.hullHash)
+}
+
+func (d *delaunay) legalize(a int) int {
+	var ar int
+	for {
+		b := d.halfedges[a]
+		a0 := a - a%3
+		ar = a0 + (a+2)%3
+		if b == -1 {
+			// convex hull edge
+			if len(d.edgeStack) == 0 {
+				break
+			}
+			a = d.popEdge()
+			continue
+		}
+
+		var (
+			b0 = b - b%3
+			al = a0 + (a+1)%3
+			bl = b0 + (b+2)%3
+			p0 = d.triangles[ar]
+			pr = d.triangles[a]
+			pl = d.triangles[al]
+			p1 = d.triangles[bl]
+		)
+		if !inCircle(d.points[p0], d.points[pr], d.points[pl], d.points[p1]) {
+			if len(d.edgeStack) == 0 {
+				break
+			}
+			a = d.popEdge()
+			continue
+		}
+
+		d.triangles[a] = p1
+		d.triangles[b] = p0
+		hbl := d.halfedges[bl]
+		if hbl == -1 {
+			// the edge was swapped on the other side of the hull, fix the reference
+			e := d.hullStart
+			for {
+				if d.hullTri[e] == bl {
+					d.hullTri[e] = a
+					break
+				}
+				e = d.hullPrev[e]
+				if e == d.hullStart {
+					break
+				}
+			}
+		}
+		d.link(a, hbl)
+		d.link(b, d.halfedges[ar])
+		d.link(ar, bl)
+		d.edgeStack = append(d.edgeStack, b0+(b+1)%3)
+	}
+	return ar
+}
+
+func (d *delaunay) popEdge() int {
+	e := d.edgeStack[len(d.edgeStack)-1]
+	d.edgeStack = d.edgeStack[:len(d.edgeStack)-1]
+	return e
+}
+
+func (d *delaunay) link(a, b int) {
+	d.halfedges[a] = b
+	if b != -1 {
+		d.halfedges[b] = a
+	}
+}
+
+func (d *delaunay) addTriangle(i0, i1, i2, a, b, c int) int {
+	t := len(d.triangles)
+	d.triangles = append(d.triangles, i0, i1, i2)
+	d.halfedges = append(d.halfedges, -1, -1, -1)
+	d.link(t, a)
+	d.link(t+1, b)
+	d.link(t+2, c)
+	return t
+}
+
+// delaunayEpsilon is used to detect duplicate points in the triangulation.
+var delaunayEpsilon = math.Pow(2, -52)
+
+// pseudoAngle is monotonic to the angle of the vector, in the range of 0 to 1.
+func pseudoAngle(dx, dy float64) float64 {
+	if dx == 0 && dy == 0 {
+		return 0
+	}
+	p := dx / (math.Abs(dx) + math.Abs(dy))
+	if dy > 0 {
+		return (3 - p) / 4
+	}
+	return (1 + p) / 4
+}
+
+func sqDist(a, b Point) float64 {
+	dx, dy := a.X-b.X, a.Y-b.Y
+	return dx*dx + dy*dy
+}
+
+func orient(p, q, r Point) bool {
+	return (q.Y-p.Y)*(r.X-q.X)-(q.X-p.X)*(r.Y-q.Y) < 0
+}
+
+func inCircle(a, b, c, p Point) bool {
+	var (
+		dx = a.X - p.X
+		dy = a.Y - p.Y
+		ex = b.X - p.X
+		ey = b.Y - p.Y
+		fx = c.X - p.X
+		fy = c.Y - p.Y
+		ap = dx*dx + dy*dy
+		bp = ex*ex + ey*ey
+		cp = fx*fx + fy*fy
+	)
+	return dx*(ey*cp-bp*fy)-dy*(ex*cp-bp*fx)+ap*(ex*fy-ey*fx) < 0
+}
+
+func circumradius(a, b, c Point) float64 {
+	cc := circumcenter(a, b, c)
+	return sqDist(a, cc)
+}
+
+func circumcenter(a, b, c Point) Point {
+	var (
+		dx = b.X - a.X
+		dy = b.Y - a.Y
+		ex = c.X - a.X
+		ey = c.Y - a.Y
+		bl = dx*dx + dy*dy
+		cl = ex*ex + ey*ey
+		dd = 0.5 / (dx*ey - dy*ex)
+	)
+	return Point{a.X + (ey*bl-dy*cl)*dd, a.Y + (dx*cl-ex*bl)*dd}
+}
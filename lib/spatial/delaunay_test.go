@@ -0,0 +1,47 @@
+package spatial
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTriangulate(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	var pts []Point
+	for i := 0; i < 300; i++ {
+		pts = append(pts, Point{r.Float64() * 100, r.Float64() * 100})
+	}
+	// duplicates and grid points, which have cocircular points
+	pts = append(pts, pts[0], pts[1])
+	for x := 0; x < 5; x++ {
+		for y := 0; y < 5; y++ {
+			pts = append(pts, Point{float64(x) * 25, float64(y) * 25})
+		}
+	}
+
+	d := triangulate(pts)
+	unique := len(pts) - 2
+	assert.Equal(t, 2*unique-2-len(d.hull), len(d.triangles)/3)
+	for e, opp := range d.halfedges {
+		if opp != -1 {
+			assert.Equal(t, e, d.halfedges[opp])
+		}
+	}
+	// no point is located inside of the circumcircle of a triangle
+	for tr := 0; tr < len(d.triangles); tr += 3 {
+		a, b, c := pts[d.triangles[tr]], pts[d.triangles[tr+1]], pts[d.triangles[tr+2]]
+		center := circumcenter(a, b, c)
+		radius := sqDist(a, center)
+		for _, pt := range pts {
+			assert.True(t, sqDist(pt, center) >= radius*(1-1e-9))
+		}
+	}
+}
+
+func TestTriangulateCollinear(t *testing.T) {
+	d := triangulate([]Point{{2, 2}, {0, 0}, {1, 1}, {1, 1}})
+	assert.Empty(t, d.triangles)
+	assert.Equal(t, []int{1, 2, 0}, d.hull)
+}
@@ -0,0 +1,170 @@
+package spatial
+
+import "math"
+
+// splitFunc returns the vertices which divide the segment from a to b into parts of at most
+// maxLength, excluding a and b, and their relative positions along the segment.
+type splitFunc func(a, b Point, maxLength float64) ([]Point, []float64)
+
+// Densify returns a copy of the geometry in which no segment is longer than maxSegmentLength, in
+// units of the coordinates. Longer segments are divided into parts of equal length, Z and M
+// ordinates of the new vertices are interpolated. Points and non-positive lengths are left
+// unchanged.
+func (g Geom) Densify(maxSegmentLength float64) Geom {
+	return g.densify(maxSegmentLength, splitPlanar)
+}
+
+// DensifyGeodesic is like Densify for WGS84 longitude/latitude coordinates. maxSegmentLength is
+// given in meters and the new vertices are placed on the great circle between the ends of the
+// segment, so long lines like flight routes follow the shortest path on the earth's surface.
+// Segments between antipodal points are left unchanged, as their great circle is ambiguous.
+func (g Geom) DensifyGeodesic(maxSegmentLength float64) Geom {
+	return g.densify(maxSegmentLength, splitGreatCircle)
+}
+
+func (g Geom) densify(maxLength float64, split splitFunc) Geom {
+	if !(maxLength > 0) || math.IsInf(maxLength, 1) {
+		return g.Copy()
+	}
+
+	var offset int
+	// densifyPart densifies the next part of the geometry and its ordinates.
+	densifyPart := func(part Line, closed bool, dg *Geom) Line {
+		var (
+			out Line
+			n   = len(part)
+		)
+		for i, pt := range part {
+			out = append(out, pt)
+			if g.z != nil {
+				dg.z = append(dg.z, g.z[offset+i])
+			}
+			if g.m != nil {
+				dg.m = append(dg.m, g.m[offset+i])
+			}
+
+			next := i + 1
+			if next == n {
+				if !closed || n < 3 {
+					continue
+				}
+				next = 0
+			}
+			pts, pos := split(pt, part[next], maxLength)
+			out = append(out, pts...)
+			for _, t := range pos {
+				if g.z != nil {
+					dg.z = append(dg.z, g.z[offset+i]+t*(g.z[offset+next]-g.z[offset+i]))
+				}
+				if g.m != nil {
+					dg.m = append(dg.m, g.m[offset+i]+t*(g.m[offset+next]-g.m[offset+i]))
+				}
+			}
+		}
+		offset += n
+		return out
+	}
+	densifyPoly := func(poly Polygon, dg *Geom) Polygon {
+		var dp = make(Polygon, 0, len(poly))
+		for _, ring := range poly {
+			dp = append(dp, densifyPart(ring, true, dg))
+		}
+		return dp
+	}
+
+	var dg = Geom{typ: g.typ}
+	switch gm := g.g.(type) {
+	case Line:
+		dg.g = densifyPart(gm, false, &dg)
+	case Polygon:
+		dg.g = densifyPoly(gm, &dg)
+	case MultiLine:
+		var ml = make(MultiLine, 0, len(gm))
+		for _, ln := range gm {
+			ml = append(ml, densifyPart(ln, false, &dg))
+		}
+		dg.g = ml
+	case MultiPolygon:
+		var mp = make(MultiPolygon, 0, len(gm))
+		for _, poly := range gm {
+			mp = append(mp, densifyPoly(poly, &dg))
+		}
+		dg.g = mp
+	case GeomCollection:
+		var gc = make(GeomCollection, 0, len(gm))
+		for _, m := range gm {
+			gc = append(gc, m.densify(maxLength, split))
+		}
+		dg.g = gc
+	default:
+		return g.Copy()
+	}
+	return dg
+}
+
+func splitPlanar(a, b Point, maxLength float64) ([]Point, []float64) {
+	n := math.Ceil(math.Hypot(b.X-a.X, b.Y-a.Y) / maxLength)
+	if !(n > 1) {
+		return nil, nil
+	}
+	var (
+		pts = make([]Point, 0, int(n)-1)
+		pos = make([]float64, 0, int(n)-1)
+	)
+	for k := 1.0; k < n; k++ {
+		t := k / n
+		pts = append(pts, Point{a.X + t*(b.X-a.X), a.Y + t*(b.Y-a.Y)})
+		pos = append(pos, t)
+	}
+	return pts, pos
+}
+
+// splitGreatCircle interpolates along the great circle on a sphere. The number of parts is
+// determined from the distance on the ellipsoid, widened by one percent, as the parts on the
+// ellipsoid are not exactly of equal length.
+func splitGreatCircle(a, b Point, maxLength float64) ([]Point, []float64) {
+	if a == b {
+		return nil, nil
+	}
+	var (
+		va    = unitVector(a)
+		vb    = unitVector(b)
+		cross = [3]float64{va[1]*vb[2] - va[2]*vb[1], va[2]*vb[0] - va[0]*vb[2], va[0]*vb[1] - va[1]*vb[0]}
+		sinO  = math.Sqrt(cross[0]*cross[0] + cross[1]*cross[1] + cross[2]*cross[2])
+		omega = math.Atan2(sinO, va[0]*vb[0]+va[1]*vb[1]+va[2]*vb[2])
+	)
+	if sinO < 1e-12 {
+		return nil, nil // identical or antipodal
+	}
+	n := math.Ceil(a.GeodesicDistance(&b) * 1.01 / maxLength)
+	if !(n > 1) {
+		return nil, nil
+	}
+
+	var (
+		pts = make([]Point, 0, int(n)-1)
+		pos = make([]float64, 0, int(n)-1)
+	)
+	for k := 1.0; k < n; k++ {
+		var (
+			t  = k / n
+			s1 = math.Sin((1-t)*omega) / sinO
+			s2 = math.Sin(t*omega) / sinO
+			x  = s1*va[0] + s2*vb[0]
+			y  = s1*va[1] + s2*vb[1]
+			z  = s1*va[2] + s2*vb[2]
+		)
+		pts = append(pts, Point{radToDeg(math.Atan2(y, x)), radToDeg(math.Atan2(z, math.Hypot(x, y)))})
+		pos = append(pos, t)
+	}
+	return pts, pos
+}
+
+// unitVector converts longitude and latitude into a point on the unit sphere.
+func unitVector(p Point) [3]float64 {
+	var (
+		lon = degToRad(p.X)
+		lat = degToRad(p.Y)
+	)
+	return [3]float64{math.Cos(lat) * math.Cos(lon), math.Cos(lat) * math.Sin(lon), math.Sin(lat)}
+}
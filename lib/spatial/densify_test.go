@@ -0,0 +1,85 @@
+package spatial
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDensify(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		geom     Geom
+		max      float64
+		expected Geom
+	}{
+		{"point", MustNewGeom(Point{1, 1}), 1, MustNewGeom(Point{1, 1})},
+		{"short line", MustNewGeom(Line{{0, 0}, {1, 0}}), 2, MustNewGeom(Line{{0, 0}, {1, 0}})},
+		{"line", MustNewGeom(Line{{0, 0}, {3, 0}, {3, 1}}), 1, MustNewGeom(Line{{0, 0}, {1, 0}, {2, 0}, {3, 0}, {3, 1}})},
+		{"uneven", MustNewGeom(Line{{0, 0}, {0, 5}}), 1.5, MustNewGeom(Line{{0, 0}, {0, 1.25}, {0, 2.5}, {0, 3.75}, {0, 5}})},
+		{
+			"polygon closing segment",
+			MustNewGeom(Polygon{{{0, 0}, {2, 0}, {0, 2}}}),
+			1.5,
+			MustNewGeom(Polygon{{{0, 0}, {1, 0}, {2, 0}, {1, 1}, {0, 2}, {0, 1}}}),
+		},
+		{
+			"multi line",
+			MustNewGeom(MultiLine{{{0, 0}, {2, 0}}, {{5, 5}, {5, 6}}}),
+			1,
+			MustNewGeom(MultiLine{{{0, 0}, {1, 0}, {2, 0}}, {{5, 5}, {5, 6}}}),
+		},
+		{"no length", MustNewGeom(Line{{0, 0}, {3, 0}}), 0, MustNewGeom(Line{{0, 0}, {3, 0}})},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, tc.geom.Densify(tc.max))
+		})
+	}
+}
+
+func TestDensifyOrdinates(t *testing.T) {
+	g := MustNewGeom(MultiPolygon{{{{0, 0}, {2, 0}, {2, 2}, {0, 2}}}, {{{5, 5}, {6, 5}, {6, 6}}}})
+	assert.Nil(t, g.SetZ([]float64{0, 2, 4, 6, 1, 1, 1}))
+
+	dg := g.Densify(1)
+	assert.Equal(t, LayoutXYZ, dg.Layout())
+	assert.Equal(t, []float64{0, 1, 2, 3, 4, 5, 6, 3, 1, 1, 1, 1}, dg.Z())
+	assert.Len(t, dg.MustMultiPolygon()[0][0], 8)
+	// the source geometry is not modified
+	assert.Len(t, g.Z(), 7)
+}
+
+func TestDensifyGeodesic(t *testing.T) {
+	var (
+		frankfurt = Point{8.5706, 50.0333}
+		newYork   = Point{-73.7789, 40.6397}
+		route     = MustNewGeom(Line{frankfurt, newYork})
+		dg        = route.DensifyGeodesic(100000)
+		ln        = dg.MustLineString()
+	)
+	assert.True(t, len(ln) > 60)
+	assert.Equal(t, frankfurt, ln[0])
+	assert.Equal(t, newYork, ln[len(ln)-1])
+	// the great circle on the sphere deviates slightly from the geodesic on the ellipsoid
+	assert.InDelta(t, route.Length(), dg.Length(), 10)
+
+	var maxLat float64
+	for i := 1; i < len(ln); i++ {
+		assert.True(t, ln[i-1].GeodesicDistance(&ln[i]) <= 100000)
+		maxLat = math.Max(maxLat, ln[i].Y)
+	}
+	// the great circle goes far to the north of both cities
+	assert.True(t, maxLat > 52)
+
+	// segments across the antimeridian take the short way
+	dg = MustNewGeom(Line{{170, 0}, {-170, 0}}).DensifyGeodesic(600000)
+	ln = dg.MustLineString()
+	assert.Len(t, ln, 5)
+	assert.InDelta(t, 180, math.Abs(ln[2].X), 1e-9)
+
+	// antipodal points are not connected by a unique great circle
+	dg = MustNewGeom(Line{{0, 0}, {180, 0}}).DensifyGeodesic(1000)
+	ln = dg.MustLineString()
+	assert.Len(t, ln, 2)
+}
@@ -0,0 +1,228 @@
+package spatial
+
+import "math"
+
+// Parameters of the WGS84 ellipsoid.
+const (
+	wgs84A = 6378137
+	wgs84F = 1 / 298.257223563
+	wgs84B = wgs84A * (1 - wgs84F)
+)
+
+var (
+	wgs84E2 = wgs84F * (2 - wgs84F) // first eccentricity squared
+	wgs84E  = math.Sqrt(wgs84E2)
+
+	// authalicQP is q at the pole, which is needed to convert latitudes into authalic latitudes.
+	authalicQP = authalicQ(1)
+	// authalicR2 is the square of the radius of the sphere with the same surface as the ellipsoid.
+	authalicR2 = wgs84A * wgs84A * authalicQP / 2
+)
+
+// GeodesicDistance returns the length of the shortest path between the points on the WGS84
+// ellipsoid in meters, calculated with Vincenty's inverse formula. Coordinates are longitude
+// and latitude. For nearly antipodal points, where the formula doesn't converge, the
+// distance on a sphere is returned.
+func (p *Point) GeodesicDistance(p2 *Point) float64 {
+	if *p == *p2 {
+		return 0
+	}
+	var (
+		l          = normalizeLon(degToRad(p2.X - p.X))
+		u1         = math.Atan((1 - wgs84F) * math.Tan(degToRad(p.Y)))
+		u2         = math.Atan((1 - wgs84F) * math.Tan(degToRad(p2.Y)))
+		sinU1      = math.Sin(u1)
+		cosU1      = math.Cos(u1)
+		sinU2      = math.Sin(u2)
+		cosU2      = math.Cos(u2)
+		lambda     = l
+		sinSigma   float64
+		cosSigma   float64
+		sigma      float64
+		cosSqAlpha float64
+		cos2SigmaM float64
+		converged  bool
+	)
+	for i := 0; i < 200; i++ {
+		sinLambda, cosLambda := math.Sin(lambda), math.Cos(lambda)
+		sinSigma = math.Hypot(cosU2*sinLambda, cosU1*sinU2-sinU1*cosU2*cosLambda)
+		if sinSigma == 0 {
+			return 0
+		}
+		cosSigma = sinU1*sinU2 + cosU1*cosU2*cosLambda
+		sigma = math.Atan2(sinSigma, cosSigma)
+		sinAlpha := cosU1 * cosU2 * sinLambda / sinSigma
+		cosSqAlpha = 1 - sinAlpha*sinAlpha
+		cos2SigmaM = 0 // on the equator
+		if cosSqAlpha != 0 {
+			cos2SigmaM = cosSigma - 2*sinU1*sinU2/cosSqAlpha
+		}
+		c := wgs84F / 16 * cosSqAlpha * (4 + wgs84F*(4-3*cosSqAlpha))
+		prev := lambda
+		lambda = l + (1-c)*wgs84F*sinAlpha*(sigma+c*sinSigma*(cos2SigmaM+c*cosSigma*(-1+2*cos2SigmaM*cos2SigmaM)))
+		if math.Abs(lambda-prev) < 1e-12 {
+			converged = true
+			break
+		}
+	}
+	if !converged {
+		return p.HaversineDistance(p2)
+	}
+
+	var (
+		uSq        = cosSqAlpha * (wgs84A*wgs84A - wgs84B*wgs84B) / (wgs84B * wgs84B)
+		a          = 1 + uSq/16384*(4096+uSq*(-768+uSq*(320-175*uSq)))
+		b          = uSq / 1024 * (256 + uSq*(-128+uSq*(74-47*uSq)))
+		deltaSigma = b * sinSigma * (cos2SigmaM + b/4*(cosSigma*(-1+2*cos2SigmaM*cos2SigmaM)-
+			b/6*cos2SigmaM*(-3+4*sinSigma*sinSigma)*(-3+4*cos2SigmaM*cos2SigmaM)))
+	)
+	return wgs84B * a * (sigma - deltaSigma)
+}
+
+// Length returns the geodesic length of all lines of the geometry in meters, on the WGS84
+// ellipsoid. Coordinates are longitude and latitude. Points and polygons have no length, see
+// Perimeter for the latter.
+func (g Geom) Length() float64 {
+	switch gm := g.g.(type) {
+	case Line:
+		return geodesicLength(gm, false)
+	case MultiLine:
+		var l float64
+		for _, ln := range gm {
+			l += geodesicLength(ln, false)
+		}
+		return l
+	case GeomCollection:
+		var l float64
+		for _, m := range gm {
+			l += m.Length()
+		}
+		return l
+	}
+	return 0
+}
+
+// Perimeter returns the geodesic length of all rings of the polygons in the geometry in
+// meters, including holes. See Length for the conventions.
+func (g Geom) Perimeter() float64 {
+	switch gm := g.g.(type) {
+	case Polygon, MultiPolygon:
+		var (
+			l        float64
+			parts, _ = g.parts()
+		)
+		for _, ring := range parts {
+			l += geodesicLength(ring, true)
+		}
+		return l
+	case GeomCollection:
+		var l float64
+		for _, m := range gm {
+			l += m.Perimeter()
+		}
+		return l
+	}
+	return 0
+}
+
+// Area returns the area of the polygons in the geometry in square meters, on the WGS84
+// ellipsoid. The area of holes is subtracted. Coordinates are longitude and latitude.
+//
+// Rings are mapped onto the sphere with the same surface as the ellipsoid by using authalic
+// latitudes, which preserves areas. Their edges are great circles on this sphere, which only
+// differ noticeably from geodesics on the ellipsoid for edges that are hundreds of kilometers
+// long.
+func (g Geom) Area() float64 {
+	switch gm := g.g.(type) {
+	case Polygon:
+		return geodesicPolygonArea(gm)
+	case MultiPolygon:
+		var a float64
+		for _, poly := range gm {
+			a += geodesicPolygonArea(poly)
+		}
+		return a
+	case GeomCollection:
+		var a float64
+		for _, m := range gm {
+			a += m.Area()
+		}
+		return a
+	}
+	return 0
+}
+
+func geodesicLength(ln Line, closed bool) float64 {
+	var l float64
+	for i := 1; i < len(ln); i++ {
+		l += ln[i-1].GeodesicDistance(&ln[i])
+	}
+	if closed && len(ln) > 2 {
+		l += ln[len(ln)-1].GeodesicDistance(&ln[0])
+	}
+	return l
+}
+
+func geodesicPolygonArea(poly Polygon) float64 {
+	if len(poly) == 0 {
+		return 0
+	}
+	a := geodesicRingArea(poly[0])
+	for _, hole := range poly[1:] {
+		a -= geodesicRingArea(hole)
+	}
+	return math.Max(a, 0)
+}
+
+// geodesicRingArea sums up the spherical excess of the areas between the edges and the
+// equator. If the ring encloses a pole, this is the area of the remaining hemisphere.
+func geodesicRingArea(ring Line) float64 {
+	if len(ring) < 3 {
+		return 0
+	}
+	var (
+		excess   float64
+		lonSum   float64
+		prev     = ring[len(ring)-1]
+		prevTanB = math.Tan(authalicLat(degToRad(prev.Y)) / 2)
+	)
+	for _, pt := range ring {
+		var (
+			dLon = normalizeLon(degToRad(pt.X - prev.X))
+			tanB = math.Tan(authalicLat(degToRad(pt.Y)) / 2)
+		)
+		excess += 2 * math.Atan2(math.Tan(dLon/2)*(prevTanB+tanB), 1+prevTanB*tanB)
+		lonSum += dLon
+		prev, prevTanB = pt, tanB
+	}
+	area := math.Abs(excess) * authalicR2
+	if math.Abs(lonSum) > math.Pi {
+		area = 2*math.Pi*authalicR2 - area
+	}
+	return area
+}
+
+// authalicQ is the q function used for authalic latitudes (Snyder, Map Projections: A Working
+// Manual, 3-12), given the sine of the latitude.
+func authalicQ(sinLat float64) float64 {
+	return (1 - wgs84E2) * (sinLat/(1-wgs84E2*sinLat*sinLat) -
+		1/(2*wgs84E)*math.Log((1-wgs84E*sinLat)/(1+wgs84E*sinLat)))
+}
+
+// authalicLat converts a geodetic latitude into the latitude on the sphere with the same
+// surface, both in radians.
+func authalicLat(lat float64) float64 {
+	return math.Asin(math.Max(-1, math.Min(1, authalicQ(math.Sin(lat))/authalicQP)))
+}
+
+// normalizeLon wraps a longitude difference in radians into the range of -π to π, so that
+// edges crossing the antimeridian take the short way.
+func normalizeLon(d float64) float64 {
+	for d > math.Pi {
+		d -= 2 * math.Pi
+	}
+	for d < -math.Pi {
+		d += 2 * math.Pi
+	}
+	return d
+}
@@ -0,0 +1,121 @@
+package spatial
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// dms converts degrees, minutes and seconds into decimal degrees.
+func dms(d, m, s float64) float64 {
+	if d < 0 {
+		return d - m/60 - s/3600
+	}
+	return d + m/60 + s/3600
+}
+
+func TestGeodesicDistance(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		p1, p2 Point
+		dist   float64
+	}{
+		// Vincenty's example from Flinders Peak to Buninyong
+		{"flinders peak", Point{dms(144, 25, 29.52440), dms(-37, 57, 3.72030)}, Point{dms(143, 55, 35.38390), dms(-37, 39, 10.15610)}, 54972.271},
+		{"equator", Point{0, 0}, Point{1, 0}, 111319.491},
+		{"meridian quadrant", Point{10, 0}, Point{10, 90}, 10001965.729},
+		{"antimeridian", Point{179.5, 0}, Point{-179.5, 0}, 111319.491},
+		{"same point", Point{5, 5}, Point{5, 5}, 0},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.InDelta(t, tc.dist, tc.p1.GeodesicDistance(&tc.p2), 0.001)
+		})
+	}
+
+	t.Run("antipodal", func(t *testing.T) {
+		p1, p2 := Point{0, 0}, Point{179.9, 0.1}
+		assert.InDelta(t, 2e7, p1.GeodesicDistance(&p2), 5e4)
+	})
+}
+
+// degreeCell returns a cell between the latitudes, with edges along the parallels, which are
+// densified so that they are close to the parallels.
+func degreeCell(lon, lat1, lat2 float64) Line {
+	var ring Line
+	for i := 0; i <= 100; i++ {
+		ring = append(ring, Point{lon + float64(i)/100, lat1})
+	}
+	for i := 100; i >= 0; i-- {
+		ring = append(ring, Point{lon + float64(i)/100, lat2})
+	}
+	return ring
+}
+
+// bandArea is the exact area of the band between two latitudes, one degree wide.
+func bandArea(lat1, lat2 float64) float64 {
+	return degToRad(1) * authalicR2 * (math.Sin(authalicLat(degToRad(lat2))) - math.Sin(authalicLat(degToRad(lat1))))
+}
+
+func TestGeodesicArea(t *testing.T) {
+	t.Run("equator", func(t *testing.T) {
+		g := MustNewGeom(Polygon{degreeCell(0, 0, 1)})
+		assert.InDelta(t, bandArea(0, 1), g.Area(), 1e4)
+		assert.InDelta(t, 12308.8e6, g.Area(), 1e6)
+	})
+
+	t.Run("high latitude", func(t *testing.T) {
+		g := MustNewGeom(Polygon{degreeCell(20, 70, 71)})
+		assert.InDelta(t, bandArea(70, 71), g.Area(), 1e4)
+	})
+
+	t.Run("hole and winding", func(t *testing.T) {
+		var (
+			outer = degreeCell(0, 0, 1)
+			inner = degreeCell(0, 0.25, 0.75)
+		)
+		inner.Reverse()
+		g := MustNewGeom(MultiPolygon{{outer, inner}, {degreeCell(3, -1, 0)}})
+		assert.InDelta(t, 2*bandArea(0, 1)-bandArea(0.25, 0.75), g.Area(), 1e4)
+	})
+
+	t.Run("antimeridian", func(t *testing.T) {
+		ring := degreeCell(179.5, 10, 11)
+		for i := range ring {
+			if ring[i].X > 180 {
+				ring[i].X -= 360
+			}
+		}
+		assert.InDelta(t, bandArea(10, 11), MustNewGeom(Polygon{ring}).Area(), 1e4)
+	})
+
+	t.Run("pole", func(t *testing.T) {
+		// the edges are not exactly on the parallel, so the cap is slightly smaller
+		var ring Line
+		for lon := -180.0; lon < 180; lon++ {
+			ring = append(ring, Point{lon, 89})
+		}
+		assert.InDelta(t, 360*bandArea(89, 90), MustNewGeom(Polygon{ring}).Area(), 1e7)
+	})
+
+	t.Run("no area", func(t *testing.T) {
+		assert.Equal(t, 0.0, MustNewGeom(Line{{0, 0}, {1, 1}, {1, 0}}).Area())
+		assert.Equal(t, 0.0, MustNewGeom(Point{1, 1}).Area())
+	})
+}
+
+func TestGeodesicLength(t *testing.T) {
+	var (
+		ln   = MustNewGeom(Line{{0, 0}, {1, 0}, {1, 1}})
+		poly = MustNewGeom(Polygon{{{0, 0}, {1, 0}, {1, 1}, {0, 1}}})
+	)
+	assert.InDelta(t, 111319.491+110574.389, ln.Length(), 0.01)
+	assert.Equal(t, 0.0, ln.Perimeter())
+	assert.Equal(t, 0.0, poly.Length())
+	assert.InDelta(t, 2*110574.389+111319.491+111302.649, poly.Perimeter(), 1)
+
+	gc := MustNewGeom(GeomCollection{ln, poly, MustNewGeom(Point{5, 5})})
+	assert.Equal(t, ln.Length(), gc.Length())
+	assert.Equal(t, poly.Perimeter(), gc.Perimeter())
+	assert.Equal(t, poly.Area(), gc.Area())
+}
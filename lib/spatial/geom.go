@@ -19,11 +19,15 @@ var (
 type GeomType uint32
 
 const (
-	GeomTypeEmpty      GeomType = 0
-	GeomTypePoint      GeomType = 1
-	GeomTypeLineString GeomType = 2
-	GeomTypePolygon    GeomType = 3
-	GeomTypeInvalid
+	GeomTypeEmpty              GeomType = 0
+	GeomTypePoint              GeomType = 1
+	GeomTypeLineString         GeomType = 2
+	GeomTypePolygon            GeomType = 3
+	GeomTypeMultiPoint         GeomType = 4
+	GeomTypeMultiLineString    GeomType = 5
+	GeomTypeMultiPolygon       GeomType = 6
+	GeomTypeGeometryCollection GeomType = 7
+	GeomTypeInvalid            GeomType = 255
 )
 
 func (g GeomType) String() string {
@@ -36,6 +40,14 @@ func (g GeomType) String() string {
 		return "LineString"
 	case 3:
 		return "Polygon"
+	case 4:
+		return "MultiPoint"
+	case 5:
+		return "MultiLineString"
+	case 6:
+		return "MultiPolygon"
+	case 7:
+		return "GeometryCollection"
 	}
 	return "Invalid"
 }
@@ -43,6 +55,10 @@ func (g GeomType) String() string {
 type Geom struct {
 	typ GeomType
 	g   Projectable
+	// optional Z and M ordinates, one per vertex (see Z())
+	z, m []float64
+	// unclosed are the rings which haven't been closed when decoding, see Validate
+	unclosed []ValidationError
 }
 
 func MustNewGeom(g interface{}) Geom {
@@ -79,6 +95,26 @@ func NewGeom(g interface{}) (Geom, error) {
 		return Geom{typ: GeomTypePolygon, g: Polygon(geom)}, nil
 	case Polygon:
 		return Geom{typ: GeomTypePolygon, g: g.(Projectable)}, nil
+
+	// Multi Point
+	case MultiPoint:
+		return Geom{typ: GeomTypeMultiPoint, g: geom}, nil
+
+	// Multi Line String
+	case MultiLine:
+		return Geom{typ: GeomTypeMultiLineString, g: geom}, nil
+
+	// Multi Polygon
+	case []Polygon:
+		return Geom{typ: GeomTypeMultiPolygon, g: MultiPolygon(geom)}, nil
+	case MultiPolygon:
+		return Geom{typ: GeomTypeMultiPolygon, g: geom}, nil
+
+	// Geometry Collection
+	case []Geom:
+		return Geom{typ: GeomTypeGeometryCollection, g: GeomCollection(geom)}, nil
+	case GeomCollection:
+		return Geom{typ: GeomTypeGeometryCollection, g: geom}, nil
 	default:
 		return Geom{}, fmt.Errorf("unknown input geom type: %T", g)
 	}
@@ -86,7 +122,8 @@ func NewGeom(g interface{}) (Geom, error) {
 
 type geoJSONGeom struct {
 	Type        string          `json:"type"`
-	Coordinates json.RawMessage `json:"coordinates"`
+	Coordinates json.RawMessage `json:"coordinates,omitempty"`
+	Geometries  json.RawMessage `json:"geometries,omitempty"`
 }
 
 func (g Geom) String() string {
@@ -109,11 +146,17 @@ func (g *Geom) UnmarshalJSON(buf []byte) error {
 	if err != nil {
 		return err
 	}
+	if strings.ToLower(wg.Type) == "geometrycollection" {
+		return g.UnmarshalJSONCoords(wg.Type, wg.Geometries)
+	}
 	return g.UnmarshalJSONCoords(wg.Type, wg.Coordinates)
 }
 
+// UnmarshalJSONCoords decodes the coordinates member of a GeoJSON geometry. For
+// GeometryCollections inner has to contain the geometries member instead.
 func (g *Geom) UnmarshalJSONCoords(typ string, inner json.RawMessage) error {
 	var err error
+	g.unclosed = nil
 	switch strings.ToLower(typ) {
 	case "":
 		g.typ = GeomTypeEmpty
@@ -137,21 +180,66 @@ func (g *Geom) UnmarshalJSONCoords(typ string, inner json.RawMessage) error {
 		if err = json.Unmarshal(inner, &poly); err != nil {
 			return err
 		}
-		for nring := range poly {
-			// remove last element from every ring as it is unnecessary
-			poly[nring] = poly[nring][:len(poly[nring])-1]
+		g.g, g.unclosed = geoJSONToPolygon(poly, 0, nil)
+	case "multipoint":
+		g.typ = GeomTypeMultiPoint
+		var mp MultiPoint
+		if err = json.Unmarshal(inner, &mp); err != nil {
+			return err
+		}
+		g.g = mp
+	case "multilinestring":
+		g.typ = GeomTypeMultiLineString
+		var ml MultiLine
+		if err = json.Unmarshal(inner, &ml); err != nil {
+			return err
+		}
+		g.g = ml
+	case "multipolygon":
+		g.typ = GeomTypeMultiPolygon
+		var mp MultiPolygon
+		if err = json.Unmarshal(inner, &mp); err != nil {
+			return err
+		}
+		for npoly := range mp {
+			mp[npoly], g.unclosed = geoJSONToPolygon(mp[npoly], npoly, g.unclosed)
 		}
-		poly.FixWinding() // GeoJSON winding is not reliable, so let's fix it
-		g.g = poly
+		g.g = mp
+	case "geometrycollection":
+		g.typ = GeomTypeGeometryCollection
+		var gc GeomCollection
+		if err = json.Unmarshal(inner, &gc); err != nil {
+			return err
+		}
+		g.g = gc
+		return nil
 	default:
 		return fmt.Errorf("unsupported geometry type: %s", typ)
 	}
+	if g.typ == GeomTypeEmpty {
+		return nil
+	}
+	closedRings := g.typ == GeomTypePolygon || g.typ == GeomTypeMultiPolygon
+	if err = g.readGeoJSONOrdinates(inner, closedRings); err != nil {
+		return err
+	}
+	g.fixWinding() // GeoJSON winding is not reliable, so let's fix it
 	return nil
 }
 
 func (g Geom) MarshalJSON() ([]byte, error) {
 	var wg geoJSONGeom
 
+	if g.z != nil && g.typ != GeomTypeGeometryCollection {
+		var err error
+		wg.Type = g.typ.String()
+		wg.Coordinates, err = json.Marshal(g.geoJSONCoordinates())
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(&wg)
+	}
+
 	switch g.typ {
 	case GeomTypePoint:
 		wg.Type = "Point"
@@ -176,17 +264,54 @@ func (g Geom) MarshalJSON() ([]byte, error) {
 	case GeomTypePolygon:
 		wg.Type = "Polygon"
 		poly, err := g.Polygon()
-		pCopy := make(Polygon, len(poly))
-		copy(pCopy, poly)
 		if err != nil {
 			return nil, err
 		}
-		for ringN := range pCopy {
-			pCopy[ringN] = append(pCopy[ringN], pCopy[ringN][0])
+		wg.Coordinates, err = json.Marshal(polygonToGeoJSON(poly))
+		if err != nil {
+			return nil, err
+		}
+	case GeomTypeMultiPoint:
+		wg.Type = "MultiPoint"
+		mp, err := g.MultiPoint()
+		if err != nil {
+			return nil, err
+		}
+		wg.Coordinates, err = json.Marshal(mp)
+		if err != nil {
+			return nil, err
+		}
+	case GeomTypeMultiLineString:
+		wg.Type = "MultiLineString"
+		ml, err := g.MultiLineString()
+		if err != nil {
+			return nil, err
+		}
+		wg.Coordinates, err = json.Marshal(ml)
+		if err != nil {
+			return nil, err
+		}
+	case GeomTypeMultiPolygon:
+		wg.Type = "MultiPolygon"
+		mp, err := g.MultiPolygon()
+		if err != nil {
+			return nil, err
+		}
+		var mpCopy = make(MultiPolygon, 0, len(mp))
+		for _, poly := range mp {
+			mpCopy = append(mpCopy, polygonToGeoJSON(poly))
+		}
+		wg.Coordinates, err = json.Marshal(mpCopy)
+		if err != nil {
+			return nil, err
+		}
+	case GeomTypeGeometryCollection:
+		wg.Type = "GeometryCollection"
+		gc, err := g.GeometryCollection()
+		if err != nil {
+			return nil, err
 		}
-		// Rewind in case something got lost along the way.
-		pCopy.FixWinding()
-		wg.Coordinates, err = json.Marshal(pCopy)
+		wg.Geometries, err = json.Marshal(gc)
 		if err != nil {
 			return nil, err
 		}
@@ -194,66 +319,62 @@ func (g Geom) MarshalJSON() ([]byte, error) {
 	return json.Marshal(&wg)
 }
 
-func (g *Geom) UnmarshalWKB(r io.Reader) error {
-	var wkbEndianness uint8
-	//TODO: read the byte directly
-	err := binary.Read(r, endianness, &wkbEndianness)
-	if err != nil {
-		return err
-	}
-	if wkbEndianness != 1 {
-		return errors.New("only little endian is supported")
+// geoJSONToPolygon converts GeoJSON polygon rings into the internal representation. Rings
+// which aren't closed are appended to unclosed, part is the index of the polygon in a multi
+// polygon.
+func geoJSONToPolygon(poly Polygon, part int, unclosed []ValidationError) (Polygon, []ValidationError) {
+	for nring := range poly {
+		// remove last element from every ring as it is unnecessary
+		ring, closed := openRing(poly[nring])
+		if !closed {
+			unclosed = append(unclosed, ringNotClosed(ring, part, nring))
+		}
+		poly[nring] = ring
 	}
+	return poly, unclosed
+}
 
-	var (
-		npg interface{}
-		ng  Geom
-	)
-	gt, err := wkbReadHeader(r)
-	if err != nil {
-		return err
-	}
-	switch gt {
-	case GeomTypePoint:
-		npg, err = wkbReadPoint(r)
-	case GeomTypeLineString:
-		npg, err = wkbReadLineString(r)
-	case GeomTypePolygon:
-		npg, err = wkbReadPolygon(r)
-	default:
-		return fmt.Errorf("unsupported GeomType: %v", gt)
-	}
-	if err != nil {
-		return err
+// polygonToGeoJSON returns a copy of the polygon with closed rings, as required by GeoJSON.
+func polygonToGeoJSON(poly Polygon) Polygon {
+	pCopy := make(Polygon, len(poly))
+	copy(pCopy, poly)
+	for ringN := range pCopy {
+		pCopy[ringN] = append(pCopy[ringN], pCopy[ringN][0])
 	}
-	ng, err = NewGeom(npg)
+	// Rewind in case something got lost along the way.
+	pCopy.FixWinding()
+	return pCopy
+}
+
+func (g *Geom) UnmarshalWKB(r io.Reader) error {
+	ng, err := GeomFromWKB(r)
 	if err != nil {
 		return err
 	}
-	g.typ = ng.typ
-	g.g = ng.g
+	*g = ng
 	return nil
 }
 
+// MarshalWKB encodes the geometry as WKB. Geometries with Z or M ordinates use the ISO type codes.
 // TODO: maybe MarshalWKB could take an io.Writer instead of returning a buffer?
 func (g Geom) MarshalWKB() ([]byte, error) {
 	if endianness != binary.LittleEndian {
 		return nil, errors.New("only little endian is supported")
 	}
-	var (
-		buf     bytes.Buffer
-		typeBuf = make([]byte, 4)
-	)
-	_, err := buf.Write([]byte{1}) // little endian
+	var buf bytes.Buffer
+	_, err := buf.Write([]byte{wkbLittleEndian})
 	if err != nil {
 		return nil, err
 	}
-	endianness.PutUint32(typeBuf, uint32(g.Typ()))
-	_, err = buf.Write(typeBuf)
+	err = wkbWriteHeader(&buf, g.Typ(), g.Layout())
 	if err != nil {
 		return nil, err
 	}
 
+	var ord *wkbOrdinates
+	if g.Layout() != LayoutXY {
+		ord = &wkbOrdinates{layout: g.Layout(), z: g.z, m: g.m}
+	}
 	switch g.Typ() {
 	case GeomTypePoint:
 		var p *Point
@@ -261,21 +382,26 @@ func (g Geom) MarshalWKB() ([]byte, error) {
 		if err != nil {
 			return nil, err
 		}
-		err = wkbWritePoint(&buf, *p)
+		if err = wkbWritePoint(&buf, *p); err != nil {
+			return nil, err
+		}
+		err = ord.writeNext(&buf)
 	case GeomTypeLineString:
 		var ls Line
 		ls, err = g.LineString()
 		if err != nil {
 			return nil, err
 		}
-		err = wkbWriteLineString(&buf, ls)
+		err = wkbWriteLineString(&buf, ls, ord)
 	case GeomTypePolygon:
 		var poly Polygon
 		poly, err = g.Polygon()
 		if err != nil {
 			return nil, err
 		}
-		err = wkbWritePolygon(&buf, poly)
+		err = wkbWritePolygon(&buf, poly, ord)
+	case GeomTypeMultiPoint, GeomTypeMultiLineString, GeomTypeMultiPolygon, GeomTypeGeometryCollection:
+		err = wkbWriteMembers(&buf, g.members())
 	default:
 		return nil, fmt.Errorf("unsupported GeomType: %v", g.Typ())
 	}
@@ -336,24 +462,131 @@ func (g *Geom) MustPolygon() Polygon {
 	return p
 }
 
+func (g *Geom) MultiPoint() (MultiPoint, error) {
+	geom, ok := g.g.(MultiPoint)
+	if !ok {
+		return nil, errors.New("geometry is not a MultiPoint")
+	}
+	return geom, nil
+}
+
+func (g *Geom) MustMultiPoint() MultiPoint {
+	mp, err := g.MultiPoint()
+	if err != nil {
+		panic(err)
+	}
+	return mp
+}
+
+func (g *Geom) MultiLineString() (MultiLine, error) {
+	geom, ok := g.g.(MultiLine)
+	if !ok {
+		return nil, errors.New("geometry is not a MultiLineString")
+	}
+	return geom, nil
+}
+
+func (g *Geom) MustMultiLineString() MultiLine {
+	ml, err := g.MultiLineString()
+	if err != nil {
+		panic(err)
+	}
+	return ml
+}
+
+func (g *Geom) MultiPolygon() (MultiPolygon, error) {
+	geom, ok := g.g.(MultiPolygon)
+	if !ok {
+		return nil, errors.New("geometry is not a MultiPolygon")
+	}
+	return geom, nil
+}
+
+func (g *Geom) MustMultiPolygon() MultiPolygon {
+	mp, err := g.MultiPolygon()
+	if err != nil {
+		panic(err)
+	}
+	return mp
+}
+
+func (g *Geom) GeometryCollection() (GeomCollection, error) {
+	geom, ok := g.g.(GeomCollection)
+	if !ok {
+		return nil, errors.New("geometry is not a GeometryCollection")
+	}
+	return geom, nil
+}
+
+func (g *Geom) MustGeometryCollection() GeomCollection {
+	gc, err := g.GeometryCollection()
+	if err != nil {
+		panic(err)
+	}
+	return gc
+}
+
+// BBox returns the bbox of the geometry. Empty geometries have a zero bbox, see IsEmpty.
 func (g *Geom) BBox() BBox {
 	switch gm := g.g.(type) {
+	case nil:
+		return BBox{}
 	case *Point:
 		return BBox{*gm, *gm}
 	case Line:
 		return gm.BBox()
 	case Polygon:
-		var bboxPoints Line
-		for _, ring := range gm {
-			bb := ring.BBox()
-			bboxPoints = append(bboxPoints, bb.SW, bb.NE)
-		}
-		return bboxPoints.BBox()
+		return gm.BBox()
+	case MultiPoint:
+		return gm.BBox()
+	case MultiLine:
+		return gm.BBox()
+	case MultiPolygon:
+		return gm.BBox()
+	case GeomCollection:
+		return gm.BBox()
 	default:
 		panic("unimplemented type")
 	}
 }
 
+// IsEmpty reports whether the geometry has no coordinates, e.g. an empty Geom or a MultiPolygon
+// without polygons.
+func (g *Geom) IsEmpty() bool {
+	switch gm := g.g.(type) {
+	case nil:
+		return true
+	case Line:
+		return len(gm) == 0
+	case Polygon:
+		return len(gm) == 0 || len(gm[0]) == 0
+	case MultiPoint:
+		return len(gm) == 0
+	case MultiLine:
+		for _, ln := range gm {
+			if len(ln) > 0 {
+				return false
+			}
+		}
+		return true
+	case MultiPolygon:
+		for _, poly := range gm {
+			if len(poly) > 0 && len(poly[0]) > 0 {
+				return false
+			}
+		}
+		return true
+	case GeomCollection:
+		for _, m := range gm {
+			if !m.IsEmpty() {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
 func (g *Geom) Overlaps(bbox BBox) bool {
 	return g.BBox().Overlaps(bbox)
 }
@@ -362,12 +595,35 @@ type simplifiable interface {
 	Simplify(e float64) interface{}
 }
 
+// Simplify returns a copy of the geometry, simplified with Douglas-Peucker. The tolerance e is a
+// distance in the units of the coordinates. Rings that collapse to less than three points are
+// removed, see Polygon.Simplify.
 func (g Geom) Simplify(e float64) Geom {
+	var sg Geom
 	switch gm := g.g.(type) {
 	case Line:
+		sg = Geom{typ: g.typ, g: gm.Simplify(e)}
+	case Polygon:
+		sg = Geom{typ: g.typ, g: gm.Simplify(e)}
+	case MultiLine:
+		sg = Geom{typ: g.typ, g: gm.Simplify(e)}
+	case MultiPolygon:
+		sg = Geom{typ: g.typ, g: gm.Simplify(e)}
+	case GeomCollection:
 		return Geom{typ: g.typ, g: gm.Simplify(e)}
+	default:
+		return g
+	}
+	sg.inheritOrdinates(g)
+	return sg
+}
+
+// SimplifyWith is like Simplify, but uses the given algorithm.
+func (g Geom) SimplifyWith(e float64, method SimplifyMethod) Geom {
+	if method == SimplifyDouglasPeucker {
+		return g.Simplify(e)
 	}
-	return g
+	return g.simplifyVW(e, method == SimplifyTopology)
 }
 
 type Clippable interface {
@@ -376,17 +632,27 @@ type Clippable interface {
 }
 
 // Clips a geometry and returns a cropped copy. Returns a slice, because clip might result in multiple sub-Geoms.
+// Z and M ordinates are kept, vertices on the bbox edges get interpolated ones.
 func (g *Geom) ClipToBBox(bbox BBox) []Geom {
-	if gm, ok := g.g.(Clippable); ok {
-		return gm.ClipToBBox(bbox)
+	gm, ok := g.g.(Clippable)
+	if !ok {
+		panic("internal geometry needs to fulfill Clippable interface")
+	}
+	clipped := gm.ClipToBBox(bbox)
+	if g.Layout() != LayoutXY {
+		for i := range clipped {
+			clipped[i].inheritOrdinates(*g)
+		}
 	}
-	panic("internal geometry needs to fulfill Clippable interface")
+	return clipped
 }
 
 func (g *Geom) Copy() Geom {
 	return Geom{
 		typ: g.typ,
 		g:   g.g.Copy(),
+		z:   copyOrdinates(g.z),
+		m:   copyOrdinates(g.m),
 	}
 }
 
@@ -65,7 +65,7 @@ func TestUnmarshalWKBEOF(t *testing.T) {
 	var buf []byte
 	fmt.Sscanf("09000000000000000000f03f00000000000000400000000000000840000000000000104000000000000014400000000000001040", "%x", &buf)
 
-	_, err := wkbReadLineString(bytes.NewReader(buf))
+	_, err := wkbReadLineString(bytes.NewReader(buf), nil)
 	assert.Equal(t, io.EOF, err)
 }
 
@@ -197,7 +197,7 @@ func BenchmarkWKBMarshalRawPoly(b *testing.B) {
 	b.ResetTimer()
 
 	for i := 0; i < b.N; i++ {
-		wkbWritePolygon(&buf, poly)
+		wkbWritePolygon(&buf, poly, nil)
 	}
 }
 
@@ -212,7 +212,7 @@ func BenchmarkWKBUnmarshalPoint(b *testing.B) {
 
 	for i := 0; i < b.N; i++ {
 		r.Reset(rawPt)
-		wkbReadPoint(r)
+		wkbReadPoint(r, nil)
 	}
 }
 
@@ -227,7 +227,7 @@ func BenchmarkWKBUnmarshalLineString(b *testing.B) {
 
 	for i := 0; i < b.N; i++ {
 		r.Reset(rawLine)
-		wkbReadLineString(r)
+		wkbReadLineString(r, nil)
 	}
 }
 
@@ -242,7 +242,7 @@ func BenchmarkWKBUnmarshalPoly(b *testing.B) {
 
 	for i := 0; i < b.N; i++ {
 		r.Reset(rawPoly)
-		wkbReadPolygon(r)
+		wkbReadPolygon(r, nil)
 	}
 }
 
@@ -0,0 +1,238 @@
+package spatial
+
+import (
+	"container/heap"
+	"math"
+	"sort"
+)
+
+// ConvexHull returns the smallest convex polygon which contains all vertices of g. If the
+// vertices are collinear, the result is a line, if there is only a single distinct vertex, it
+// is a point. The hull of an empty geometry is empty.
+func (g Geom) ConvexHull() Geom {
+	return convexHull(g.appendVertices(nil))
+}
+
+// ConcaveHull returns a polygon which contains all vertices of g and follows their outline more
+// closely than the convex hull. It is calculated as chi-shape: Starting with the Delaunay
+// triangulation of the vertices, the longest edges on the border are removed, as long as they
+// are longer than the threshold and the polygon stays simple.
+//
+// ratio controls the threshold relative to the range of edge lengths in the triangulation: 0
+// creates the most concave hull, 1 results in the convex hull. Degenerate inputs are handled
+// like in ConvexHull.
+func (g Geom) ConcaveHull(ratio float64) Geom {
+	return concaveHull(g.appendVertices(nil), ratio)
+}
+
+// ConvexHull returns the convex hull of all features, see Geom.ConvexHull.
+func ConvexHull(fts []Feature) Geom {
+	return convexHull(featureVertices(fts))
+}
+
+// ConcaveHull returns the concave hull of all features, see Geom.ConcaveHull.
+func ConcaveHull(fts []Feature, ratio float64) Geom {
+	return concaveHull(featureVertices(fts), ratio)
+}
+
+func featureVertices(fts []Feature) []Point {
+	var pts []Point
+	for i := range fts {
+		pts = fts[i].Geometry.appendVertices(pts)
+	}
+	return pts
+}
+
+func (g *Geom) appendVertices(pts []Point) []Point {
+	if gc, ok := g.g.(GeomCollection); ok {
+		for i := range gc {
+			pts = gc[i].appendVertices(pts)
+		}
+		return pts
+	}
+	parts, _ := g.parts()
+	for _, part := range parts {
+		pts = append(pts, part...)
+	}
+	return pts
+}
+
+// convexHull calculates the hull using Andrew's monotone chain algorithm.
+func convexHull(pts []Point) Geom {
+	pts = append([]Point(nil), pts...)
+	sort.Slice(pts, func(i, j int) bool {
+		if pts[i].X != pts[j].X {
+			return pts[i].X < pts[j].X
+		}
+		return pts[i].Y < pts[j].Y
+	})
+	var uniq = pts[:0]
+	for _, pt := range pts {
+		if len(uniq) == 0 || uniq[len(uniq)-1] != pt {
+			uniq = append(uniq, pt)
+		}
+	}
+	pts = uniq
+
+	switch len(pts) {
+	case 0:
+		return Geom{}
+	case 1:
+		return MustNewGeom(pts[0])
+	}
+
+	// lower and upper part of the hull, both are counter-clockwise
+	var hull Line
+	for _, pt := range pts {
+		for len(hull) >= 2 && cross(hull[len(hull)-2], hull[len(hull)-1], pt) <= 0 {
+			hull = hull[:len(hull)-1]
+		}
+		hull = append(hull, pt)
+	}
+	lower := len(hull) + 1
+	for i := len(pts) - 2; i >= 0; i-- {
+		for len(hull) >= lower && cross(hull[len(hull)-2], hull[len(hull)-1], pts[i]) <= 0 {
+			hull = hull[:len(hull)-1]
+		}
+		hull = append(hull, pts[i])
+	}
+	hull = hull[:len(hull)-1] // the last point is the first one
+
+	if len(hull) < 3 {
+		return MustNewGeom(Line{pts[0], pts[len(pts)-1]})
+	}
+	return MustNewGeom(Polygon{hull})
+}
+
+// cross returns the z component of the cross product of a->b and a->c, which is positive if
+// the points are in counter-clockwise order.
+func cross(a, b, c Point) float64 {
+	return (b.X-a.X)*(c.Y-a.Y) - (b.Y-a.Y)*(c.X-a.X)
+}
+
+func concaveHull(pts []Point, ratio float64) Geom {
+	if ratio >= 1 {
+		return convexHull(pts)
+	}
+	d := triangulate(pts)
+	if len(d.triangles) == 0 {
+		return convexHull(pts)
+	}
+
+	var (
+		nTri       = len(d.triangles) / 3
+		removed    = make([]bool, nTri)
+		onBoundary = make([]bool, len(pts))
+		minLen     = math.Inf(1)
+		maxLen     float64
+		border     borderEdges
+	)
+	for e := range d.triangles {
+		l := d.edgeLength(e)
+		minLen = math.Min(minLen, l)
+		maxLen = math.Max(maxLen, l)
+	}
+	threshold := minLen + math.Max(ratio, 0)*(maxLen-minLen)
+
+	for _, i := range d.hull {
+		onBoundary[i] = true
+	}
+	for e, opp := range d.halfedges {
+		if opp == -1 {
+			border = append(border, borderEdge{e, d.edgeLength(e)})
+		}
+	}
+	heap.Init(&border)
+
+	remaining := nTri
+	for border.Len() > 0 && remaining > 1 {
+		be := heap.Pop(&border).(borderEdge)
+		if be.length <= threshold {
+			break
+		}
+		t := be.edge / 3
+		if removed[t] {
+			continue
+		}
+		// Removing the triangle is only allowed if its third vertex is not on the border yet,
+		// otherwise the polygon would be split or touch itself.
+		third := d.triangles[prevHalfedge(be.edge)]
+		if onBoundary[third] {
+			continue
+		}
+		removed[t] = true
+		remaining--
+		onBoundary[third] = true
+		for _, e := range []int{nextHalfedge(be.edge), prevHalfedge(be.edge)} {
+			if opp := d.halfedges[e]; opp != -1 {
+				heap.Push(&border, borderEdge{opp, d.edgeLength(opp)})
+			}
+		}
+	}
+
+	// Trace the border of the remaining triangles.
+	var (
+		outgoing = map[int]int{} // start vertex -> border halfedge
+		first    = -1
+	)
+	for e := range d.triangles {
+		if removed[e/3] {
+			continue
+		}
+		if opp := d.halfedges[e]; opp == -1 || removed[opp/3] {
+			outgoing[d.triangles[e]] = e
+			if first == -1 {
+				first = e
+			}
+		}
+	}
+	var ring Line
+	for e := first; ; {
+		ring = append(ring, pts[d.triangles[e]])
+		e = outgoing[d.triangles[nextHalfedge(e)]]
+		if e == first || len(ring) > len(outgoing) {
+			break
+		}
+	}
+	if !ring.Clockwise() {
+		ring.Reverse()
+	}
+	return MustNewGeom(Polygon{ring})
+}
+
+func (d *delaunay) edgeLength(e int) float64 {
+	return math.Sqrt(sqDist(d.points[d.triangles[e]], d.points[d.triangles[nextHalfedge(e)]]))
+}
+
+func nextHalfedge(e int) int {
+	if e%3 == 2 {
+		return e - 2
+	}
+	return e + 1
+}
+
+func prevHalfedge(e int) int {
+	if e%3 == 0 {
+		return e + 2
+	}
+	return e - 1
+}
+
+type borderEdge struct {
+	edge   int
+	length float64
+}
+
+// borderEdges is a max-heap of edges, ordered by their length.
+type borderEdges []borderEdge
+
+func (b borderEdges) Len() int            { return len(b) }
+func (b borderEdges) Less(i, j int) bool  { return b[i].length > b[j].length }
+func (b borderEdges) Swap(i, j int)       { b[i], b[j] = b[j], b[i] }
+func (b *borderEdges) Push(x interface{}) { *b = append(*b, x.(borderEdge)) }
+func (b *borderEdges) Pop() interface{} {
+	old := *b
+	e := old[len(old)-1]
+	*b = old[:len(old)-1]
+	return e
+}
@@ -0,0 +1,126 @@
+package spatial
+
+import (
+	"encoding/json"
+	"math"
+	"math/rand"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConvexHull(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		geom Geom
+		hull Geom
+	}{
+		{"empty", Geom{}, Geom{}},
+		{"point", MustNewGeom(MultiPoint{{1, 1}, {1, 1}}), MustNewGeom(Point{1, 1})},
+		{"collinear", MustNewGeom(MultiPoint{{1, 1}, {3, 3}, {2, 2}}), MustNewGeom(Line{{1, 1}, {3, 3}})},
+		{
+			"square with inner points",
+			MustNewGeom(MultiPoint{{0, 0}, {1, 1}, {0, 2}, {1, 0}, {2, 2}, {2, 0}, {0, 1}}),
+			MustNewGeom(Polygon{{{0, 0}, {2, 0}, {2, 2}, {0, 2}}}),
+		},
+		{
+			"collection",
+			MustNewGeom(GeomCollection{MustNewGeom(Point{0, 0}), MustNewGeom(Line{{2, 0}, {1, 3}})}),
+			MustNewGeom(Polygon{{{0, 0}, {2, 0}, {1, 3}}}),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.hull, tc.geom.ConvexHull())
+		})
+	}
+}
+
+// uShape returns a grid of points, which forms a U.
+func uShape() []Point {
+	var pts []Point
+	for x := 0; x <= 10; x++ {
+		for y := 0; y <= 10; y++ {
+			if x > 2 && x < 8 && y > 2 {
+				continue
+			}
+			pts = append(pts, Point{float64(x), float64(y)})
+		}
+	}
+	return pts
+}
+
+func TestConcaveHull(t *testing.T) {
+	pts := uShape()
+	g := MustNewGeom(MultiPoint(pts))
+
+	convex := g.ConvexHull()
+	assert.InDelta(t, 100, overlayArea(t, convex), 1e-9)
+	assert.Equal(t, convex, g.ConcaveHull(1))
+
+	concave := g.ConcaveHull(0)
+	assert.Equal(t, GeomTypePolygon, concave.Typ())
+	// the gap of the U has a size of 6x8
+	assert.InDelta(t, 100-6*8, overlayArea(t, concave), 1e-9)
+	assert.True(t, concave.MustPolygon()[0].Clockwise())
+	// every point is covered by the hull
+	for _, pt := range pts {
+		assert.NotEqual(t, -1, concave.MustPolygon()[0].locate(pt))
+	}
+
+	// features are treated as one point set
+	var fts []Feature
+	for _, pt := range pts {
+		fts = append(fts, Feature{Geometry: MustNewGeom(pt)})
+	}
+	assert.Equal(t, concave, ConcaveHull(fts, 0))
+	assert.Equal(t, convex, ConvexHull(fts))
+
+	// degenerate inputs fall back to the convex hull
+	assert.Equal(t, MustNewGeom(Line{{0, 0}, {2, 2}}), MustNewGeom(Line{{0, 0}, {1, 1}, {2, 2}}).ConcaveHull(0))
+}
+
+func TestConcaveHullRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	var pts MultiPoint
+	for i := 0; i < 2000; i++ {
+		// points in an annulus
+		a, d := r.Float64()*2*math.Pi, 5+r.Float64()*5
+		pts = append(pts, Point{d * math.Cos(a), d * math.Sin(a)})
+	}
+	g := MustNewGeom(pts)
+
+	var prevArea = math.Inf(1)
+	for _, ratio := range []float64{1, 0.5, 0.2, 0.05, 0} {
+		hull := g.ConcaveHull(ratio)
+		ring := hull.MustPolygon()[0]
+		assert.True(t, ring.Clockwise())
+		// the ring is simple, so it doesn't visit any vertex twice
+		seen := map[Point]bool{}
+		for _, pt := range ring {
+			assert.False(t, seen[pt])
+			seen[pt] = true
+		}
+		for _, pt := range pts {
+			assert.NotEqual(t, -1, ring.locate(pt))
+		}
+		area := overlayArea(t, hull)
+		assert.True(t, area <= prevArea)
+		prevArea = area
+	}
+}
+
+func TestHullFixture(t *testing.T) {
+	f, err := os.Open("../geojson/testdata/multipolygon.geojson")
+	assert.Nil(t, err)
+	defer f.Close()
+	var fc FeatureCollection
+	assert.Nil(t, json.NewDecoder(f).Decode(&fc))
+
+	convex := ConvexHull(fc.Features)
+	concave := ConcaveHull(fc.Features, 0.1)
+	covered, err := concave.Difference(convex)
+	assert.Nil(t, err)
+	assert.Equal(t, GeomTypeEmpty, covered.Typ())
+	assert.True(t, overlayArea(t, concave) < overlayArea(t, convex))
+}
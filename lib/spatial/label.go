@@ -0,0 +1,318 @@
+package spatial
+
+import (
+	"container/heap"
+	"math"
+	"sort"
+)
+
+// Centroid returns the center of mass of the geometry as point. Only the components with the
+// highest dimension are taken into account: polygons are weighted by their area, lines by
+// their length. The centroid of concave polygons can be located outside of them, see
+// PointOnSurface for a point which is always inside. The centroid of an empty geometry is
+// empty.
+func (g Geom) Centroid() Geom {
+	var c centroid
+	c.add(g)
+	switch {
+	case c.area != 0:
+		return MustNewGeom(Point{c.areaSum.X / c.area, c.areaSum.Y / c.area})
+	case c.length != 0:
+		return MustNewGeom(Point{c.lineSum.X / c.length, c.lineSum.Y / c.length})
+	case c.points != 0:
+		return MustNewGeom(Point{c.ptSum.X / float64(c.points), c.ptSum.Y / float64(c.points)})
+	}
+	return Geom{}
+}
+
+// centroid accumulates the weighted centers of all components. Degenerate polygons and lines
+// are also added as lines and points, so that their centroid can be calculated.
+type centroid struct {
+	area, length float64
+	points       int
+
+	areaSum, lineSum, ptSum Point
+}
+
+func (c *centroid) add(g Geom) {
+	if gc, ok := g.g.(GeomCollection); ok {
+		for _, m := range gc {
+			c.add(m)
+		}
+		return
+	}
+	parts, closed := g.parts()
+	for n, part := range parts {
+		if closed {
+			c.addRing(part, g.isHole(n))
+		}
+		c.addLine(part, closed)
+		for _, pt := range part {
+			c.ptSum.X += pt.X
+			c.ptSum.Y += pt.Y
+			c.points++
+		}
+	}
+}
+
+// isHole reports whether the n-th part of a polygonal geometry is a hole.
+func (g *Geom) isHole(n int) bool {
+	switch gm := g.g.(type) {
+	case Polygon:
+		return n > 0
+	case MultiPolygon:
+		for _, poly := range gm {
+			if n < len(poly) {
+				return n > 0
+			}
+			n -= len(poly)
+		}
+	}
+	return false
+}
+
+func (c *centroid) addRing(ring Line, hole bool) {
+	// the center of a triangle fan from the first point, weighted by the signed areas
+	var (
+		area float64
+		sum  Point
+	)
+	for i := 1; i+1 < len(ring); i++ {
+		a := cross(ring[0], ring[i], ring[i+1])
+		area += a
+		sum.X += a * (ring[0].X + ring[i].X + ring[i+1].X) / 3
+		sum.Y += a * (ring[0].Y + ring[i].Y + ring[i+1].Y) / 3
+	}
+	// holes are subtracted, independent of their winding
+	if (area < 0) != hole {
+		area, sum = -area, Point{-sum.X, -sum.Y}
+	}
+	c.area += area
+	c.areaSum.X += sum.X
+	c.areaSum.Y += sum.Y
+}
+
+func (c *centroid) addLine(ln Line, closed bool) {
+	segs := ln.Segments()
+	if closed && len(ln) > 2 {
+		segs = ln.SegmentsWithClosing()
+	}
+	for _, seg := range segs {
+		l := math.Hypot(seg[1].X-seg[0].X, seg[1].Y-seg[0].Y)
+		c.length += l
+		c.lineSum.X += l * (seg[0].X + seg[1].X) / 2
+		c.lineSum.Y += l * (seg[0].Y + seg[1].Y) / 2
+	}
+}
+
+// PointOnSurface returns a point which is guaranteed to be located on the geometry. For
+// polygons, it is the center of the widest interval on a horizontal line through the middle
+// of the polygon, for lines and points the vertex which is closest to the centroid. The
+// result of an empty geometry is empty.
+func (g Geom) PointOnSurface() Geom {
+	if polys := g.polygons(); len(polys) > 0 {
+		var (
+			best  Point
+			width = -1.0
+		)
+		for _, poly := range polys {
+			if pt, w := interiorPoint(poly); w > width {
+				best, width = pt, w
+			}
+		}
+		if width > 0 {
+			return MustNewGeom(best)
+		}
+	}
+
+	c := g.Centroid()
+	if c.Typ() == GeomTypeEmpty {
+		return c
+	}
+	var (
+		center  = *c.MustPoint()
+		best    Point
+		minDist = math.Inf(1)
+	)
+	for _, pt := range g.appendVertices(nil) {
+		if d := sqDist(pt, center); d < minDist {
+			best, minDist = pt, d
+		}
+	}
+	return MustNewGeom(best)
+}
+
+// polygons returns all polygons of the geometry, including the members of collections.
+func (g *Geom) polygons() []Polygon {
+	switch gm := g.g.(type) {
+	case Polygon:
+		return []Polygon{gm}
+	case MultiPolygon:
+		return gm
+	case GeomCollection:
+		var polys []Polygon
+		for i := range gm {
+			polys = append(polys, gm[i].polygons()...)
+		}
+		return polys
+	}
+	return nil
+}
+
+// interiorPoint intersects the polygon with a horizontal line, which doesn't touch any vertex,
+// and returns the center of the widest interval inside of the polygon and its width.
+func interiorPoint(poly Polygon) (Point, float64) {
+	if len(poly) == 0 || len(poly[0]) < 3 {
+		return Point{}, 0
+	}
+	var (
+		bb      = poly.BBox()
+		centerY = (bb.SW.Y + bb.NE.Y) / 2
+		loY     = bb.SW.Y
+		hiY     = bb.NE.Y
+	)
+	for _, ring := range poly {
+		for _, pt := range ring {
+			if pt.Y <= centerY && pt.Y > loY {
+				loY = pt.Y
+			} else if pt.Y > centerY && pt.Y < hiY {
+				hiY = pt.Y
+			}
+		}
+	}
+	scanY := (loY + hiY) / 2
+
+	var xs []float64
+	for _, ring := range poly {
+		for _, seg := range ring.SegmentsWithClosing() {
+			a, b := seg[0], seg[1]
+			if (a.Y > scanY) != (b.Y > scanY) {
+				xs = append(xs, a.X+(scanY-a.Y)*(b.X-a.X)/(b.Y-a.Y))
+			}
+		}
+	}
+	sort.Float64s(xs)
+
+	var (
+		best  Point
+		width float64
+	)
+	for i := 0; i+1 < len(xs); i += 2 {
+		if w := xs[i+1] - xs[i]; w > width {
+			best, width = Point{(xs[i] + xs[i+1]) / 2, scanY}, w
+		}
+	}
+	return best, width
+}
+
+// PoleOfInaccessibility returns the point inside of the polygons of the geometry, which is
+// farthest away from their outline, which makes it a good position for labels. It is
+// calculated with the polylabel algorithm by Mapbox, up to the given precision in the units of
+// the coordinates. If precision is not positive, a thousandth of the larger side of the bbox
+// is used. For geometries without polygons, PointOnSurface is returned.
+func (g Geom) PoleOfInaccessibility(precision float64) Geom {
+	polys := g.polygons()
+	var rings []Line
+	for _, poly := range polys {
+		rings = append(rings, poly...)
+	}
+	if len(rings) == 0 || len(rings[0]) < 3 {
+		return g.PointOnSurface()
+	}
+
+	bb := rings[0].BBox()
+	for _, ring := range rings[1:] {
+		bb.ExtendWith(ring.BBox())
+	}
+	var (
+		width    = bb.NE.X - bb.SW.X
+		height   = bb.NE.Y - bb.SW.Y
+		cellSize = math.Min(width, height)
+	)
+	if precision <= 0 {
+		precision = math.Max(width, height) / 1000
+	}
+	if cellSize == 0 {
+		return g.PointOnSurface()
+	}
+
+	var (
+		h     = cellSize / 2
+		cells labelCells
+	)
+	for x := bb.SW.X; x < bb.NE.X; x += cellSize {
+		for y := bb.SW.Y; y < bb.NE.Y; y += cellSize {
+			cells = append(cells, newLabelCell(Point{x + h, y + h}, h, rings))
+		}
+	}
+	heap.Init(&cells)
+
+	// The first guess is the best interior point, which is better than the centroid for
+	// concave polygons.
+	pos := g.PointOnSurface()
+	best := newLabelCell(*pos.MustPoint(), 0, rings)
+
+	for cells.Len() > 0 {
+		c := heap.Pop(&cells).(labelCell)
+		if c.dist > best.dist {
+			best = c
+		}
+		// skip cells which can't contain a better solution
+		if c.max-best.dist <= precision {
+			continue
+		}
+		h = c.h / 2
+		for _, d := range []Point{{-h, -h}, {h, -h}, {-h, h}, {h, h}} {
+			heap.Push(&cells, newLabelCell(Point{c.center.X + d.X, c.center.Y + d.Y}, h, rings))
+		}
+	}
+	return MustNewGeom(best.center)
+}
+
+type labelCell struct {
+	center Point
+	h      float64 // half of the cell size
+	dist   float64 // distance from the center to the outline, negative if outside
+	max    float64 // maximum distance to the outline of any point in the cell
+}
+
+func newLabelCell(center Point, h float64, rings []Line) labelCell {
+	d := outlineDistance(center, rings)
+	return labelCell{center: center, h: h, dist: d, max: d + h*math.Sqrt2}
+}
+
+// outlineDistance returns the distance of the point to the nearest ring, which is negative if
+// the point is outside of the polygons. Rings are combined using the even-odd rule.
+func outlineDistance(pt Point, rings []Line) float64 {
+	var (
+		inside  bool
+		minDist = math.Inf(1)
+	)
+	for _, ring := range rings {
+		for _, seg := range ring.SegmentsWithClosing() {
+			a, b := seg[0], seg[1]
+			if (a.Y > pt.Y) != (b.Y > pt.Y) && pt.X < (b.X-a.X)*(pt.Y-a.Y)/(b.Y-a.Y)+a.X {
+				inside = !inside
+			}
+			minDist = math.Min(minDist, seg.DistanceToPt(pt))
+		}
+	}
+	if !inside {
+		return -minDist
+	}
+	return minDist
+}
+
+// labelCells is a max-heap of cells, ordered by their potential maximum distance.
+type labelCells []labelCell
+
+func (c labelCells) Len() int            { return len(c) }
+func (c labelCells) Less(i, j int) bool  { return c[i].max > c[j].max }
+func (c labelCells) Swap(i, j int)       { c[i], c[j] = c[j], c[i] }
+func (c *labelCells) Push(x interface{}) { *c = append(*c, x.(labelCell)) }
+func (c *labelCells) Pop() interface{} {
+	old := *c
+	cell := old[len(old)-1]
+	*c = old[:len(old)-1]
+	return cell
+}
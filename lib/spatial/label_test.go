@@ -0,0 +1,83 @@
+package spatial
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// uPolygon has the shape of a U, its centroid is located in the gap.
+var uPolygon = MustNewGeom(Polygon{{{0, 0}, {6, 0}, {6, 6}, {4, 6}, {4, 2}, {2, 2}, {2, 6}, {0, 6}}})
+
+func TestCentroid(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		geom     Geom
+		centroid Geom
+	}{
+		{"empty", Geom{}, Geom{}},
+		{"point", MustNewGeom(Point{1, 2}), MustNewGeom(Point{1, 2})},
+		{"multi point", MustNewGeom(MultiPoint{{0, 0}, {2, 0}, {4, 3}}), MustNewGeom(Point{2, 1})},
+		{"line", MustNewGeom(Line{{0, 0}, {2, 0}, {2, 1}}), MustNewGeom(Point{4.0 / 3, 1.0 / 6})},
+		{"square", MustNewGeom(Polygon{square(0, 0, 2)}), MustNewGeom(Point{1, 1})},
+		{"u shape", uPolygon, MustNewGeom(Point{3, 76.0 / 28})},
+		{
+			"hole",
+			MustNewGeom(Polygon{{{0, 0}, {4, 0}, {4, 4}, {0, 4}}, square(2, 0, 2)}),
+			MustNewGeom(Point{(16*2 - 4*3) / 12.0, (16*2 - 4*1) / 12.0}),
+		},
+		{
+			"multi polygon",
+			MustNewGeom(MultiPolygon{{square(0, 0, 2)}, {square(4, 0, 2)}}),
+			MustNewGeom(Point{3, 1}),
+		},
+		{
+			"collection uses highest dimension",
+			MustNewGeom(GeomCollection{MustNewGeom(Point{10, 10}), MustNewGeom(Polygon{square(0, 0, 2)})}),
+			MustNewGeom(Point{1, 1}),
+		},
+		{"degenerate polygon", MustNewGeom(Polygon{{{0, 0}, {2, 0}, {4, 0}}}), MustNewGeom(Point{2, 0})},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			c := tc.geom.Centroid()
+			assert.Equal(t, tc.centroid.Typ(), c.Typ())
+			if c.Typ() == GeomTypePoint {
+				assert.InDelta(t, tc.centroid.MustPoint().X, c.MustPoint().X, 1e-9)
+				assert.InDelta(t, tc.centroid.MustPoint().Y, c.MustPoint().Y, 1e-9)
+			}
+		})
+	}
+}
+
+func TestPointOnSurface(t *testing.T) {
+	pt := uPolygon.PointOnSurface()
+	assert.Equal(t, MustNewGeom(Point{1, 4}), pt)
+
+	c := uPolygon.Centroid()
+	assert.Equal(t, -1, uPolygon.MustPolygon()[0].locate(*c.MustPoint()))
+
+	// the larger polygon is preferred
+	mp := MustNewGeom(MultiPolygon{{square(0, 0, 1)}, {square(5, 5, 3)}})
+	assert.Equal(t, MustNewGeom(Point{6.5, 6.5}), mp.PointOnSurface())
+
+	ln := MustNewGeom(Line{{0, 0}, {1, 5}, {2, 0}})
+	assert.Equal(t, MustNewGeom(Point{1, 5}), ln.PointOnSurface())
+
+	assert.Equal(t, Geom{}, Geom{}.PointOnSurface())
+}
+
+func TestPoleOfInaccessibility(t *testing.T) {
+	pole := uPolygon.PoleOfInaccessibility(0.001)
+	pt := *pole.MustPoint()
+	assert.InDelta(t, 1.1716, outlineDistance(pt, uPolygon.MustPolygon()), 0.002)
+
+	// the hole pushes the pole to the right
+	withHole := MustNewGeom(Polygon{{{0, 0}, {10, 0}, {10, 10}, {0, 10}}, {{1, 1}, {1, 9}, {5, 9}, {5, 1}}})
+	pole = withHole.PoleOfInaccessibility(0.01)
+	pt = *pole.MustPoint()
+	assert.InDelta(t, 7.5, pt.X, 0.05)
+	assert.InDelta(t, 2.5, outlineDistance(pt, withHole.MustPolygon()), 0.01)
+
+	ln := MustNewGeom(Line{{0, 0}, {1, 5}, {2, 0}})
+	assert.Equal(t, ln.PointOnSurface(), ln.PoleOfInaccessibility(1))
+}
@@ -84,6 +84,9 @@ func (l Line) Intersections(segments []Segment) []Point {
 
 func (l Line) BBox() BBox {
 	var bb BBox
+	if len(l) == 0 {
+		return bb
+	}
 	bb.SW.X = l[0].X
 	bb.SW.Y = l[0].Y
 	bb.NE.X = bb.SW.X
@@ -2,7 +2,9 @@ package spatial
 
 import "sort"
 
-// MergeFeatures aggregates features that have the same properties, if possible.
+// MergeFeatures aggregates features that have the same properties, if possible. Line strings
+// which share an end point are concatenated and polygons are dissolved into a single feature,
+// see Dissolve. Merged features only keep their ID if all parts have the same one.
 func MergeFeatures(fts []Feature) []Feature {
 	if len(fts) == 1 {
 		return fts
@@ -24,11 +26,153 @@ func MergeFeatures(fts []Feature) []Feature {
 	}
 
 	for _, bucket := range buckets {
-		out = append(out, bucket...)
+		out = append(out, dissolveBucket(bucket)...)
 	}
 	return out
 }
 
+// dissolveBucket unions the polygonal features of a bucket, which have the same properties. If
+// the union fails, e.g. because of invalid geometries, the features are kept as they are.
+func dissolveBucket(fts []Feature) []Feature {
+	var (
+		polys   []Geom
+		polyFts []Feature
+		other   []Feature
+	)
+	for _, ft := range fts {
+		if isPolygonal(ft.Geometry) {
+			polys = append(polys, ft.Geometry)
+			polyFts = append(polyFts, ft)
+			continue
+		}
+		other = append(other, ft)
+	}
+	if len(polys) < 2 {
+		return fts
+	}
+	g, err := unionPolygons(polys)
+	if err != nil {
+		return fts
+	}
+	if g.Typ() == GeomTypeEmpty {
+		return other
+	}
+	return append(other, Feature{ID: commonID(polyFts), Props: fts[0].Props, Geometry: g})
+}
+
+// Dissolve unions the polygons of features which have equal values for all of the given keys,
+// resulting in one feature per distinct combination of values. These features only keep the
+// given keys as properties. If no keys are given, features need to have equal properties,
+// which are kept. The ID is only kept if all features of a group have the same one. Features
+// which are not polygonal are returned unchanged.
+func Dissolve(fts []Feature, keys ...string) ([]Feature, error) {
+	var (
+		out    []Feature
+		groups [][]Feature
+		slots  []int // position of each group in out
+	)
+Outer:
+	for _, ft := range fts {
+		if !isPolygonal(ft.Geometry) {
+			out = append(out, ft)
+			continue
+		}
+		for gID, group := range groups {
+			if sameValues(group[0].Props, ft.Props, keys) {
+				groups[gID] = append(group, ft)
+				continue Outer
+			}
+		}
+		groups = append(groups, []Feature{ft})
+		slots = append(slots, len(out))
+		out = append(out, Feature{})
+	}
+
+	for gID, group := range groups {
+		var geoms = make([]Geom, 0, len(group))
+		for _, ft := range group {
+			geoms = append(geoms, ft.Geometry)
+		}
+		g := group[0].Geometry
+		if len(geoms) > 1 {
+			var err error
+			if g, err = unionPolygons(geoms); err != nil {
+				return nil, err
+			}
+		}
+
+		props := group[0].Props
+		if len(keys) > 0 {
+			props = map[string]interface{}{}
+			for _, k := range keys {
+				if v, ok := group[0].Props[k]; ok {
+					props[k] = v
+				}
+			}
+		}
+		out[slots[gID]] = Feature{ID: commonID(group), Props: props, Geometry: g}
+	}
+
+	// Groups whose union is empty are removed.
+	var res = out[:0]
+	for _, ft := range out {
+		if ft.Geometry.Typ() != GeomTypeEmpty {
+			res = append(res, ft)
+		}
+	}
+	return res, nil
+}
+
+// commonID returns the ID of the features if all of them have the same one, otherwise 0.
+func commonID(fts []Feature) uint64 {
+	id := fts[0].ID
+	for _, ft := range fts[1:] {
+		if ft.ID != id {
+			return 0
+		}
+	}
+	return id
+}
+
+func isPolygonal(g Geom) bool {
+	return g.typ == GeomTypePolygon || g.typ == GeomTypeMultiPolygon
+}
+
+// unionPolygons calculates the union of all geometries in one pass.
+func unionPolygons(geoms []Geom) (Geom, error) {
+	var rings []overlayRing
+	for _, g := range geoms {
+		r, err := g.overlayRings()
+		if err != nil {
+			return Geom{}, err
+		}
+		rings = append(rings, r...)
+	}
+	polys, err := overlayPolygons(rings, nil, fillPositive, opUnion)
+	if err != nil {
+		return Geom{}, err
+	}
+	res := polygonsToGeom(polys)
+	res.inheritOrdinates(geoms...)
+	return res, nil
+}
+
+// sameValues reports whether both property sets have the same values for keys, or are equal if
+// keys is empty.
+func sameValues(p1, p2 map[string]interface{}, keys []string) bool {
+	if len(keys) == 0 {
+		return equalProps(p1, p2)
+	}
+	for _, k := range keys {
+		v1, ok1 := p1[k]
+		v2, ok2 := p2[k]
+		if ok1 != ok2 || !equalValues(v1, v2) {
+			return false
+		}
+	}
+	return true
+}
+
 func tagBuckets(fts []Feature) [][]Feature {
 	var buckets [][]Feature
 
@@ -79,14 +223,19 @@ func searchAndMerge(fts []Feature) []Feature {
 			if ignore.Has(i) || i == refID {
 				continue
 			}
-			if ft.Geometry.typ != fts[refID].Geometry.typ {
+			if ft.Geometry.typ != fts[refID].Geometry.typ || ft.Geometry.Layout() != fts[refID].Geometry.Layout() {
 				continue
 			}
 			switch ft.Geometry.typ {
 			case GeomTypeLineString:
 				l, merged := mergeLines(fts[refID].Geometry.g.(Line), ft.Geometry.g.(Line))
 				if merged {
+					src := []Geom{fts[refID].Geometry, ft.Geometry}
 					fts[refID].Geometry.set(l)
+					fts[refID].Geometry.inheritOrdinates(src...)
+					if fts[refID].ID != ft.ID {
+						fts[refID].ID = 0
+					}
 					ignore.Add(i)
 				}
 			}
@@ -121,7 +270,7 @@ func equalProps(p1, p2 map[string]interface{}) bool {
 		if v2, ok := p2[k]; !ok {
 			return false
 		} else {
-			if v1 != v2 {
+			if !equalValues(v1, v2) {
 				return false
 			}
 		}
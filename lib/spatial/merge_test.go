@@ -2,6 +2,7 @@ package spatial
 
 import (
 	"encoding/json"
+	"math"
 	"os"
 	"testing"
 
@@ -84,3 +85,157 @@ func TestIgnoreList(t *testing.T) {
 	assert.True(t, il.Has(1))
 	assert.False(t, il.Has(4))
 }
+
+func TestMergePolygons(t *testing.T) {
+	var (
+		props = map[string]interface{}{"landuse": "forest"}
+		fts   = []Feature{
+			{Props: props, Geometry: MustNewGeom(Polygon{{{0, 0}, {1, 0}, {1, 1}, {0, 1}}})},
+			{Props: props, Geometry: MustNewGeom(Polygon{{{1, 0}, {2, 0}, {2, 1}, {1, 1}}})},
+			{Props: props, Geometry: MustNewGeom(Line{{0, 0}, {2, 2}})},
+			{Props: map[string]interface{}{"landuse": "meadow"}, Geometry: MustNewGeom(Polygon{{{2, 0}, {3, 0}, {3, 1}, {2, 1}}})},
+		}
+		merged = MergeFeatures(fts)
+	)
+	assert.Len(t, merged, 3)
+	assert.Equal(t, Feature{Props: props, Geometry: MustNewGeom(Polygon{{{0, 0}, {2, 0}, {2, 1}, {0, 1}}})}, normalized(merged[1]))
+}
+
+func TestMergeFeatureIDs(t *testing.T) {
+	var (
+		props = map[string]interface{}{"building": "yes"}
+		fts   = []Feature{
+			{ID: 1, Props: props, Geometry: MustNewGeom(Polygon{{{0, 0}, {1, 0}, {1, 1}, {0, 1}}})},
+			{ID: 2, Props: props, Geometry: MustNewGeom(Polygon{{{1, 0}, {2, 0}, {2, 1}, {1, 1}}})},
+			{ID: 2, Props: props, Geometry: MustNewGeom(Polygon{{{2, 0}, {3, 0}, {3, 1}, {2, 1}}})},
+		}
+		merged = MergeFeatures(fts)
+	)
+	// features with different IDs are dissolved as well, the ID is dropped
+	assert.Len(t, merged, 1)
+	assert.Equal(t, Feature{Props: props, Geometry: MustNewGeom(Polygon{{{0, 0}, {3, 0}, {3, 1}, {0, 1}}})}, normalized(merged[0]))
+
+	// parts of the same feature keep its ID
+	merged = MergeFeatures([]Feature{
+		{ID: 2, Props: props, Geometry: MustNewGeom(Polygon{{{1, 0}, {2, 0}, {2, 1}, {1, 1}}})},
+		{ID: 2, Props: props, Geometry: MustNewGeom(Polygon{{{2, 0}, {3, 0}, {3, 1}, {2, 1}}})},
+	})
+	assert.Len(t, merged, 1)
+	assert.Equal(t, uint64(2), merged[0].ID)
+
+	// lines of different ways are concatenated
+	road := map[string]interface{}{"highway": "primary"}
+	merged = MergeFeatures([]Feature{
+		{ID: 10, Props: road, Geometry: MustNewGeom(Line{{0, 0}, {1, 0}})},
+		{ID: 11, Props: road, Geometry: MustNewGeom(Line{{1, 0}, {2, 1}})},
+		{ID: 12, Props: road, Geometry: MustNewGeom(Line{{5, 5}, {6, 5}})},
+	})
+	assert.Len(t, merged, 2)
+	assert.Equal(t, Feature{Props: road, Geometry: MustNewGeom(Line{{0, 0}, {1, 0}, {2, 1}})}, merged[0])
+	assert.Equal(t, uint64(12), merged[1].ID)
+
+	// dissolving features with different IDs drops the ID
+	dissolved, err := Dissolve([]Feature{
+		{ID: 1, Props: props, Geometry: MustNewGeom(Polygon{{{0, 0}, {1, 0}, {1, 1}, {0, 1}}})},
+		{ID: 2, Props: props, Geometry: MustNewGeom(Polygon{{{1, 0}, {2, 0}, {2, 1}, {1, 1}}})},
+		{ID: 3, Props: map[string]interface{}{}, Geometry: MustNewGeom(Polygon{{{5, 0}, {6, 0}, {6, 1}, {5, 1}}})},
+	})
+	assert.Nil(t, err)
+	assert.Len(t, dissolved, 2)
+	assert.Equal(t, uint64(0), dissolved[0].ID)
+	assert.Equal(t, uint64(3), dissolved[1].ID)
+}
+
+func TestMergeListProps(t *testing.T) {
+	var (
+		fts = []Feature{
+			{Props: map[string]interface{}{"ref": []interface{}{"A1", "E40"}}, Geometry: MustNewGeom(Line{{0, 0}, {1, 0}})},
+			{Props: map[string]interface{}{"ref": []interface{}{"A1", "E40"}}, Geometry: MustNewGeom(Line{{1, 0}, {2, 0}})},
+			{Props: map[string]interface{}{"ref": []interface{}{"A2"}}, Geometry: MustNewGeom(Line{{2, 0}, {3, 0}})},
+		}
+		merged = MergeFeatures(fts)
+	)
+	assert.Len(t, merged, 2)
+	assert.Equal(t, MustNewGeom(Line{{0, 0}, {1, 0}, {2, 0}}), merged[0].Geometry)
+}
+
+func TestDissolve(t *testing.T) {
+	var (
+		sq = func(x, y float64) Geom {
+			return MustNewGeom(Polygon{{{x, y}, {x + 1, y}, {x + 1, y + 1}, {x, y + 1}}})
+		}
+		fts = []Feature{
+			{Props: map[string]interface{}{"admin": "A", "name": "a1"}, Geometry: sq(0, 0)},
+			{Props: map[string]interface{}{"admin": "B", "name": "b1"}, Geometry: sq(5, 5)},
+			{Props: map[string]interface{}{"admin": "A", "name": "a2"}, Geometry: sq(1, 0)},
+			{Props: map[string]interface{}{"admin": "A", "name": "a3"}, Geometry: sq(3, 0)},
+			{Props: map[string]interface{}{"admin": "A"}, Geometry: MustNewGeom(Point{0, 0})},
+			{Props: map[string]interface{}{"name": "none"}, Geometry: sq(0, 1)},
+		}
+	)
+
+	t.Run("by key", func(t *testing.T) {
+		res, err := Dissolve(fts, "admin")
+		assert.Nil(t, err)
+		assert.Len(t, res, 4)
+
+		assert.Equal(t, map[string]interface{}{"admin": "A"}, res[0].Props)
+		assert.Equal(t, GeomTypeMultiPolygon, res[0].Geometry.Typ())
+		var area float64
+		for _, poly := range res[0].Geometry.MustMultiPolygon() {
+			area += poly[0].Area() / 2
+		}
+		assert.Equal(t, 3.0, area)
+
+		assert.Equal(t, map[string]interface{}{"admin": "B"}, res[1].Props)
+		assert.Equal(t, sq(5, 5), res[1].Geometry)
+		assert.Equal(t, GeomTypePoint, res[2].Geometry.Typ())
+		assert.Equal(t, map[string]interface{}{}, res[3].Props)
+	})
+
+	t.Run("by all properties", func(t *testing.T) {
+		res, err := Dissolve(fts)
+		assert.Nil(t, err)
+		assert.Len(t, res, len(fts))
+	})
+
+	t.Run("hole", func(t *testing.T) {
+		var ring []Feature
+		for x := 0.0; x < 3; x++ {
+			for y := 0.0; y < 3; y++ {
+				if x != 1 || y != 1 {
+					ring = append(ring, Feature{Props: map[string]interface{}{}, Geometry: sq(x, y)})
+				}
+			}
+		}
+		res, err := Dissolve(ring)
+		assert.Nil(t, err)
+		assert.Len(t, res, 1)
+		poly := res[0].Geometry.MustPolygon()
+		assert.Len(t, poly, 2)
+		assert.Equal(t, 16.0, poly[0].Area()+poly[1].Area())
+	})
+
+	t.Run("invalid geometry", func(t *testing.T) {
+		_, err := Dissolve([]Feature{
+			{Geometry: MustNewGeom(Polygon{{{0, 0}, {1, 0}, {1, 1}, {0, 1}}})},
+			{Geometry: MustNewGeom(Polygon{{{0, 0}, {1, 0}, {1, math.NaN()}}})},
+		})
+		assert.NotNil(t, err)
+	})
+}
+
+// normalized returns a copy of the feature with polygon rings starting at their lowest point.
+func normalized(ft Feature) Feature {
+	poly := ft.Geometry.MustPolygon()
+	for n, ring := range poly {
+		var start int
+		for i, pt := range ring {
+			if pt.Y < ring[start].Y || (pt.Y == ring[start].Y && pt.X < ring[start].X) {
+				start = i
+			}
+		}
+		poly[n] = append(append(Line{}, ring[start:]...), ring[:start]...)
+	}
+	return Feature{ID: ft.ID, Props: ft.Props, Geometry: MustNewGeom(poly)}
+}
@@ -0,0 +1,14 @@
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd
+
+package spatial
+
+import "io/ioutil"
+
+// mmapFile reads the whole file, because memory mapping is not available.
+func mmapFile(path string) ([]byte, func() error, error) {
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	return buf, func() error { return nil }, nil
+}
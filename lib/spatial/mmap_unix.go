@@ -0,0 +1,30 @@
+// +build darwin dragonfly freebsd linux netbsd openbsd
+
+package spatial
+
+import (
+	"os"
+	"syscall"
+)
+
+// mmapFile maps the file read-only into memory.
+func mmapFile(path string) ([]byte, func() error, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, nil, err
+	}
+	if fi.Size() == 0 {
+		return nil, func() error { return nil }, nil
+	}
+	buf, err := syscall.Mmap(int(f.Fd()), 0, int(fi.Size()), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, nil, err
+	}
+	return buf, func() error { return syscall.Munmap(buf) }, nil
+}
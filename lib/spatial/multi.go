@@ -0,0 +1,182 @@
+package spatial
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MultiPoint is a set of points which are treated as a single geometry.
+type MultiPoint []Point
+
+func (mp MultiPoint) Project(proj ConvertFunc) {
+	for i := range mp {
+		mp[i] = proj(mp[i])
+	}
+}
+
+func (mp MultiPoint) Copy() Projectable {
+	return append(mp[:0:0], mp...)
+}
+
+func (mp MultiPoint) String() string {
+	var parts = make([]string, 0, len(mp))
+	for _, pt := range mp {
+		parts = append(parts, fmt.Sprintf("%v", pt))
+	}
+	return "(" + strings.Join(parts, ", ") + ")"
+}
+
+// BBox returns the bbox of all points, which is zero if there are none.
+func (mp MultiPoint) BBox() BBox {
+	return Line(mp).BBox()
+}
+
+// ClipToBBox returns a MultiPoint which only contains the points inside the bbox.
+func (mp MultiPoint) ClipToBBox(b BBox) []Geom {
+	var clipped MultiPoint
+	for _, pt := range mp {
+		if pt.InBBox(b) {
+			clipped = append(clipped, pt)
+		}
+	}
+	if len(clipped) == 0 {
+		return []Geom{}
+	}
+	return []Geom{MustNewGeom(clipped)}
+}
+
+// MultiLine is a set of line strings which are treated as a single geometry.
+type MultiLine []Line
+
+func (ml MultiLine) Project(proj ConvertFunc) {
+	for _, ln := range ml {
+		ln.Project(proj)
+	}
+}
+
+func (ml MultiLine) Copy() Projectable {
+	var nml = make(MultiLine, 0, len(ml))
+	for _, ln := range ml {
+		nml = append(nml, ln.Copy().(Line))
+	}
+	return nml
+}
+
+func (ml MultiLine) String() string {
+	var parts = make([]string, 0, len(ml))
+	for _, ln := range ml {
+		parts = append(parts, "("+ln.String()+")")
+	}
+	return "(" + strings.Join(parts, ", ") + ")"
+}
+
+// BBox returns the bbox of all line strings, which is zero if there are none.
+func (ml MultiLine) BBox() BBox {
+	var (
+		bb    BBox
+		empty = true
+	)
+	for _, ln := range ml {
+		if len(ln) == 0 {
+			continue
+		}
+		if empty {
+			bb, empty = ln.BBox(), false
+			continue
+		}
+		bb.ExtendWith(ln.BBox())
+	}
+	return bb
+}
+
+// ClipToBBox clips all line strings and returns the remaining parts as one MultiLine.
+func (ml MultiLine) ClipToBBox(b BBox) []Geom {
+	var clipped MultiLine
+	for _, ln := range ml {
+		for _, g := range ln.ClipToBBox(b) {
+			clipped = append(clipped, g.MustLineString())
+		}
+	}
+	if len(clipped) == 0 {
+		return []Geom{}
+	}
+	return []Geom{MustNewGeom(clipped)}
+}
+
+func (ml MultiLine) Simplify(e float64) MultiLine {
+	var sml = make(MultiLine, 0, len(ml))
+	for _, ln := range ml {
+		sml = append(sml, ln.Simplify(e))
+	}
+	return sml
+}
+
+// MultiPolygon is a set of polygons which are treated as a single geometry.
+type MultiPolygon []Polygon
+
+func (mp MultiPolygon) Project(proj ConvertFunc) {
+	for _, poly := range mp {
+		poly.Project(proj)
+	}
+}
+
+func (mp MultiPolygon) Copy() Projectable {
+	var nmp = make(MultiPolygon, 0, len(mp))
+	for _, poly := range mp {
+		nmp = append(nmp, poly.Copy().(Polygon))
+	}
+	return nmp
+}
+
+func (mp MultiPolygon) String() string {
+	var parts = make([]string, 0, len(mp))
+	for _, poly := range mp {
+		parts = append(parts, poly.String())
+	}
+	return "(" + strings.Join(parts, ", ") + ")"
+}
+
+// BBox returns the bbox of all polygons, which is zero if there are none.
+func (mp MultiPolygon) BBox() BBox {
+	var (
+		bb    BBox
+		empty = true
+	)
+	for _, poly := range mp {
+		if len(poly) == 0 || len(poly[0]) == 0 {
+			continue
+		}
+		if empty {
+			bb, empty = poly.BBox(), false
+			continue
+		}
+		bb.ExtendWith(poly.BBox())
+	}
+	return bb
+}
+
+// ClipToBBox clips all polygons and returns the remaining parts as one MultiPolygon.
+func (mp MultiPolygon) ClipToBBox(b BBox) []Geom {
+	var clipped MultiPolygon
+	for _, poly := range mp {
+		for _, g := range poly.ClipToBBox(b) {
+			if p := g.MustPolygon(); len(p) > 0 {
+				clipped = append(clipped, p)
+			}
+		}
+	}
+	if len(clipped) == 0 {
+		return []Geom{}
+	}
+	return []Geom{MustNewGeom(clipped)}
+}
+
+func (mp MultiPolygon) Simplify(e float64) MultiPolygon {
+	var smp = make(MultiPolygon, 0, len(mp))
+	for _, poly := range mp {
+		if sp := poly.Simplify(e); len(sp) > 0 {
+			smp = append(smp, sp)
+		}
+	}
+	return smp
+}
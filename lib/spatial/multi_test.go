@@ -0,0 +1,151 @@
+package spatial
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/twpayne/go-geom/encoding/wkb"
+)
+
+func TestMultiWKBRoundtrip(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		geom interface{}
+	}{
+		{"multipoint", MultiPoint{{1, 2}, {3, 4}}},
+		{"multilinestring", MultiLine{{{1, 2}, {3, 4}}, {{5, 6}, {7, 8}, {9, 9}}}},
+		{"multipolygon", MultiPolygon{
+			{{{0, 0}, {0, 10}, {10, 10}, {10, 0}}, {{2, 2}, {4, 2}, {4, 4}, {2, 4}}},
+			{{{20, 20}, {20, 30}, {30, 30}}},
+		}},
+		{"geometrycollection", GeomCollection{
+			MustNewGeom(Point{1, 2}),
+			MustNewGeom(Line{{1, 2}, {3, 4}}),
+			MustNewGeom(MultiPoint{{5, 6}}),
+		}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			g := MustNewGeom(tc.geom)
+			buf, err := g.MarshalWKB()
+			assert.Nil(t, err)
+
+			// test against third party implementation
+			_, err = wkb.Unmarshal(buf)
+			assert.Nil(t, err)
+
+			rg, err := GeomFromWKB(bytes.NewReader(buf))
+			assert.Nil(t, err)
+			assert.Equal(t, g, rg)
+
+			var ug Geom
+			err = ug.UnmarshalWKB(bytes.NewReader(buf))
+			assert.Nil(t, err)
+			assert.Equal(t, g, ug)
+		})
+	}
+}
+
+func TestMultiWKBMixedMembers(t *testing.T) {
+	g := MustNewGeom(GeomCollection{MustNewGeom(Line{{1, 2}, {3, 4}})})
+	buf, err := g.MarshalWKB()
+	assert.Nil(t, err)
+	buf[1] = byte(GeomTypeMultiPoint) // pretend to be a MultiPoint which contains a LineString
+
+	_, err = GeomFromWKB(bytes.NewReader(buf))
+	assert.NotNil(t, err)
+}
+
+func TestMultiGeoJSON(t *testing.T) {
+	for _, tc := range []struct {
+		in  string
+		typ GeomType
+	}{
+		{`{"type":"MultiPoint","coordinates":[[1,2],[3,4]]}`, GeomTypeMultiPoint},
+		{`{"type":"MultiLineString","coordinates":[[[1,2],[3,4]],[[5,6],[7,8]]]}`, GeomTypeMultiLineString},
+		{`{"type":"MultiPolygon","coordinates":[[[[0,0],[10,0],[10,10],[0,10],[0,0]]],[[[20,20],[30,20],[30,30],[20,20]]]]}`, GeomTypeMultiPolygon},
+		{`{"type":"GeometryCollection","geometries":[{"type":"Point","coordinates":[1,2]},{"type":"LineString","coordinates":[[1,2],[3,4]]}]}`, GeomTypeGeometryCollection},
+	} {
+		t.Run(tc.typ.String(), func(t *testing.T) {
+			var g Geom
+			err := json.Unmarshal([]byte(tc.in), &g)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.typ, g.Typ())
+
+			buf, err := json.Marshal(g)
+			assert.Nil(t, err)
+			assert.JSONEq(t, tc.in, string(buf))
+		})
+	}
+}
+
+func TestMultiBBox(t *testing.T) {
+	g := MustNewGeom(MultiPolygon{
+		{{{0, 0}, {0, 10}, {10, 10}}},
+		{{{20, -5}, {20, 30}, {30, 30}}},
+	})
+	assert.Equal(t, BBox{SW: Point{0, -5}, NE: Point{30, 30}}, g.BBox())
+
+	gc := MustNewGeom(GeomCollection{MustNewGeom(Point{-1, -1}), g})
+	assert.Equal(t, BBox{SW: Point{-1, -5}, NE: Point{30, 30}}, gc.BBox())
+}
+
+func TestMultiBBoxEmpty(t *testing.T) {
+	for _, s := range []string{
+		`{"type":"MultiPoint","coordinates":[]}`,
+		`{"type":"MultiLineString","coordinates":[]}`,
+		`{"type":"MultiPolygon","coordinates":[]}`,
+		`{"type":"GeometryCollection","geometries":[]}`,
+		`{"type":"GeometryCollection","geometries":[{"type":"MultiPolygon","coordinates":[]}]}`,
+	} {
+		var g Geom
+		assert.Nil(t, json.Unmarshal([]byte(s), &g), s)
+		assert.True(t, g.IsEmpty(), s)
+		assert.Equal(t, BBox{}, g.BBox(), s)
+	}
+	assert.True(t, (&Geom{}).IsEmpty())
+	assert.Equal(t, BBox{}, (&Geom{}).BBox())
+
+	// empty members don't extend the bbox
+	g := MustNewGeom(GeomCollection{
+		MustNewGeom(MultiPolygon{}),
+		MustNewGeom(MultiLine{{}, {{1, 2}, {3, 4}}}),
+	})
+	assert.False(t, g.IsEmpty())
+	assert.Equal(t, BBox{SW: Point{1, 2}, NE: Point{3, 4}}, g.BBox())
+
+	// all polygons can be simplified away
+	sg := MustNewGeom(MultiPolygon{{{{0, 0}, {1, 0.01}, {2, 0}}}}).Simplify(1)
+	assert.Equal(t, BBox{}, sg.BBox())
+}
+
+func TestMultiClipToBBox(t *testing.T) {
+	bb := BBox{SW: Point{0, 0}, NE: Point{10, 10}}
+
+	mp := MustNewGeom(MultiPoint{{1, 1}, {20, 20}, {5, 5}})
+	assert.Equal(t, []Geom{MustNewGeom(MultiPoint{{1, 1}, {5, 5}})}, mp.ClipToBBox(bb))
+
+	outside := MustNewGeom(MultiPoint{{20, 20}})
+	assert.Equal(t, []Geom{}, outside.ClipToBBox(bb))
+
+	ml := MustNewGeom(MultiLine{{{1, 1}, {2, 2}}, {{20, 20}, {30, 30}}})
+	clipped := ml.ClipToBBox(bb)
+	assert.Len(t, clipped, 1)
+	assert.Equal(t, GeomTypeMultiLineString, clipped[0].Typ())
+	assert.Len(t, clipped[0].MustMultiLineString(), 1)
+}
+
+func TestMultiProject(t *testing.T) {
+	g := MustNewGeom(MultiLine{{{1, 1}, {2, 2}}, {{3, 3}, {4, 4}}})
+	ng := g.Copy()
+	ng.Project(func(p Point) Point { return Point{p.X * 2, p.Y * 2} })
+	assert.Equal(t, MultiLine{{{2, 2}, {4, 4}}, {{6, 6}, {8, 8}}}, ng.MustMultiLineString())
+	assert.Equal(t, MultiLine{{{1, 1}, {2, 2}}, {{3, 3}, {4, 4}}}, g.MustMultiLineString())
+}
+
+func TestMultiSimplify(t *testing.T) {
+	g := MustNewGeom(MultiLine{{{0, 0}, {1, 0.1}, {2, 0}}, {{0, 0}, {1, 5}, {2, 0}}})
+	sg := g.Simplify(1)
+	assert.Equal(t, MultiLine{{{0, 0}, {2, 0}}, {{0, 0}, {1, 5}, {2, 0}}}, sg.MustMultiLineString())
+}
@@ -0,0 +1,158 @@
+package spatial
+
+import (
+	"math"
+	"sort"
+)
+
+// maxGeodesicDistance is larger than the distance between any two points on earth.
+const maxGeodesicDistance = 2.1e7
+
+// GeodesicDistanceTo returns the distance in meters between the point and the nearest part of
+// the geometry, which is zero if the point lies inside of a polygon. Coordinates are longitude
+// and latitude. The nearest part is determined in an equirectangular projection around the
+// point, which is precise for distances up to a few hundred kilometers.
+func (g Geom) GeodesicDistanceTo(pt Point) float64 {
+	switch gm := g.g.(type) {
+	case nil:
+		return math.Inf(1)
+	case GeomCollection:
+		var d = math.Inf(1)
+		for _, m := range gm {
+			d = math.Min(d, m.GeodesicDistanceTo(pt))
+		}
+		return d
+	}
+
+	for _, poly := range g.polygons() {
+		if outlineDistance(pt, poly) >= 0 {
+			return 0
+		}
+	}
+
+	var (
+		scale   = math.Cos(degToRad(pt.Y))
+		nearest Point
+		minDist = math.Inf(1)
+	)
+	parts, closed := g.parts()
+	for _, part := range parts {
+		if len(part) == 1 {
+			if d := sqDist(scalePt(part[0], scale), scalePt(pt, scale)); d < minDist {
+				minDist, nearest = d, part[0]
+			}
+			continue
+		}
+		segs := part.Segments()
+		if closed {
+			segs = part.SegmentsWithClosing()
+		}
+		for _, seg := range segs {
+			np := nearestOnSegment(seg, pt, scale)
+			if d := sqDist(scalePt(np, scale), scalePt(pt, scale)); d < minDist {
+				minDist, nearest = d, np
+			}
+		}
+	}
+	if math.IsInf(minDist, 1) {
+		return minDist
+	}
+	return pt.GeodesicDistance(&nearest)
+}
+
+func scalePt(pt Point, scale float64) Point {
+	return Point{pt.X * scale, pt.Y}
+}
+
+// nearestOnSegment returns the point of the segment which is closest to pt, after scaling the
+// x coordinates.
+func nearestOnSegment(seg Segment, pt Point, scale float64) Point {
+	var (
+		dx    = (seg[1].X - seg[0].X) * scale
+		dy    = seg[1].Y - seg[0].Y
+		lenSq = dx*dx + dy*dy
+	)
+	if lenSq == 0 {
+		return seg[0]
+	}
+	t := ((pt.X-seg[0].X)*scale*dx + (pt.Y-seg[0].Y)*dy) / lenSq
+	t = math.Max(0, math.Min(1, t))
+	return Point{seg[0].X + t*(seg[1].X-seg[0].X), seg[0].Y + t*(seg[1].Y-seg[0].Y)}
+}
+
+// distanceBBox returns a bbox in longitude and latitude which contains all points that are at
+// most meters away from pt. Bboxes which would cross the antimeridian are extended to the full
+// range of longitudes.
+func distanceBBox(pt Point, meters float64) BBox {
+	var (
+		// angular distance on a sphere with the polar radius, widened by one percent to cover
+		// the flattening of the ellipsoid
+		delta  = meters / wgs84B * 1.01
+		dLat   = radToDeg(delta)
+		bb     = BBox{SW: Point{-180, pt.Y - dLat}, NE: Point{180, pt.Y + dLat}}
+		cosLat = math.Cos(degToRad(pt.Y))
+	)
+	if bb.SW.Y <= -90 || bb.NE.Y >= 90 || math.Sin(delta) >= cosLat {
+		bb.SW.Y = math.Max(bb.SW.Y, -90)
+		bb.NE.Y = math.Min(bb.NE.Y, 90)
+		return bb
+	}
+	dLon := radToDeg(math.Asin(math.Sin(delta) / cosLat))
+	if pt.X-dLon > -180 && pt.X+dLon < 180 {
+		bb.SW.X, bb.NE.X = pt.X-dLon, pt.X+dLon
+	}
+	return bb
+}
+
+type featureDistance struct {
+	ft   Feature
+	dist float64
+}
+
+// withinDistance returns all features which are at most meters away, ordered by distance.
+func withinDistance(f Filterable, pt Point, meters float64) []featureDistance {
+	var res []featureDistance
+	for _, ft := range f.Filter(distanceBBox(pt, meters)) {
+		if d := ft.Geometry.GeodesicDistanceTo(pt); d <= meters {
+			res = append(res, featureDistance{ft: ft, dist: d})
+		}
+	}
+	sort.SliceStable(res, func(i, j int) bool { return res[i].dist < res[j].dist })
+	return res
+}
+
+// WithinDistance returns all features which are at most meters away from the point, ordered by
+// their distance. Distances are calculated on the WGS84 ellipsoid to the nearest part of the
+// geometries, see Geom.GeodesicDistanceTo.
+func (rt *RTreeCollection) WithinDistance(pt Point, meters float64) []Feature {
+	var fts []Feature
+	for _, fd := range withinDistance(rt, pt, meters) {
+		fts = append(fts, fd.ft)
+	}
+	return fts
+}
+
+// NearestNeighbours returns the k features which are closest to the point, ordered by their
+// distance. Distances are calculated like in WithinDistance.
+func (rt *RTreeCollection) NearestNeighbours(pt Point, k int) []Feature {
+	if k <= 0 {
+		return nil
+	}
+	// The search radius grows until enough features are found. All features within the radius
+	// are found by the search, so the first k of them are the nearest ones.
+	var res []featureDistance
+	for r := 1000.0; ; r *= 4 {
+		res = withinDistance(rt, pt, r)
+		if len(res) >= k || r > maxGeodesicDistance {
+			break
+		}
+	}
+	if len(res) > k {
+		res = res[:k]
+	}
+	var fts = make([]Feature, 0, len(res))
+	for _, fd := range res {
+		fts = append(fts, fd.ft)
+	}
+	return fts
+}
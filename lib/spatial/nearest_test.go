@@ -0,0 +1,162 @@
+package spatial
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGeodesicDistanceTo(t *testing.T) {
+	var (
+		pt   = Point{13.4, 52.5}
+		east = Point{13.5, 52.5}
+		d    = pt.GeodesicDistance(&east)
+		poly = Polygon{{{13, 52}, {14, 52}, {14, 53}, {13, 53}}, {{13.3, 52.4}, {13.5, 52.4}, {13.5, 52.6}, {13.3, 52.6}}}
+	)
+
+	for name, tc := range map[string]struct {
+		g        Geom
+		expected float64
+	}{
+		"point":              {MustNewGeom(east), d},
+		"same point":         {MustNewGeom(pt), 0},
+		"line through":       {MustNewGeom(Line{{13.3, 52.5}, {13.5, 52.5}}), 0},
+		"line perpendicular": {MustNewGeom(Line{{13.5, 52}, {13.5, 53}}), d},
+		"inside polygon":     {MustNewGeom(Polygon{poly[0]}), 0},
+		"inside hole":        {MustNewGeom(poly), pt.GeodesicDistance(&Point{13.3, 52.5})},
+		"multi point":        {MustNewGeom(MultiPoint{{15, 52.5}, east}), d},
+		"collection":         {MustNewGeom(GeomCollection{MustNewGeom(Point{15, 52.5}), MustNewGeom(Line{{13.5, 52}, {13.5, 53}})}), d},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.InDelta(t, tc.expected, tc.g.GeodesicDistanceTo(pt), 0.01)
+		})
+	}
+	assert.True(t, math.IsInf(Geom{}.GeodesicDistanceTo(pt), 1))
+}
+
+func TestDistanceBBox(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	for i := 0; i < 1000; i++ {
+		var (
+			pt     = Point{rnd.Float64()*360 - 180, rnd.Float64()*178 - 89}
+			meters = math.Pow(10, rnd.Float64()*7)
+			bb     = distanceBBox(pt, meters)
+		)
+		// points on the circle around pt must be inside the bbox
+		for bearing := 0.0; bearing < 360; bearing += 5 {
+			p := destination(pt, bearing, meters*0.999)
+			if p.X < -180 || p.X > 180 {
+				continue
+			}
+			assert.True(t, p.X >= bb.SW.X && p.X <= bb.NE.X && p.Y >= bb.SW.Y && p.Y <= bb.NE.Y,
+				"%v not in %v for %v and %vm", p, bb, pt, meters)
+		}
+	}
+}
+
+// destination returns the point in the given distance and bearing on a sphere with the
+// equatorial radius, which is slightly farther than on the ellipsoid.
+func destination(pt Point, bearing, meters float64) Point {
+	var (
+		delta = meters / wgs84A
+		lat1  = degToRad(pt.Y)
+		b     = degToRad(bearing)
+		lat2  = math.Asin(math.Sin(lat1)*math.Cos(delta) + math.Cos(lat1)*math.Sin(delta)*math.Cos(b))
+		dLon  = math.Atan2(math.Sin(b)*math.Sin(delta)*math.Cos(lat1), math.Cos(delta)-math.Sin(lat1)*math.Sin(lat2))
+	)
+	return Point{pt.X + radToDeg(dLon), radToDeg(lat2)}
+}
+
+func randomFeatures(rnd *rand.Rand, n int) []Feature {
+	var fts []Feature
+	for i := 0; i < n; i++ {
+		var (
+			x, y = rnd.Float64()*10 + 5, rnd.Float64()*10 + 45
+			g    Geom
+		)
+		switch i % 3 {
+		case 0:
+			g = MustNewGeom(Point{x, y})
+		case 1:
+			g = MustNewGeom(Line{{x, y}, {x + rnd.Float64()*0.1, y + rnd.Float64()*0.1}})
+		case 2:
+			g = MustNewGeom(Polygon{{{x, y}, {x, y + 0.05}, {x + 0.05, y + 0.05}, {x + 0.05, y}}})
+		}
+		fts = append(fts, Feature{Props: map[string]interface{}{"id": i}, Geometry: g})
+	}
+	return fts
+}
+
+func TestRTreeCollectionNearest(t *testing.T) {
+	var (
+		rnd = rand.New(rand.NewSource(2))
+		fts = randomFeatures(rnd, 2000)
+	)
+
+	bulk := BulkLoadRTreeCollection(fts[:1500])
+	for _, ft := range fts[1500:] {
+		bulk.Add(ft)
+	}
+	for name, rt := range map[string]*RTreeCollection{
+		"insert": NewRTreeCollection(fts...),
+		"bulk":   bulk,
+	} {
+		t.Run(name, func(t *testing.T) {
+			for i := 0; i < 20; i++ {
+				var (
+					pt    = Point{rnd.Float64()*12 + 4, rnd.Float64()*12 + 44}
+					dists = map[int]float64{}
+					ids   []int
+				)
+				for _, ft := range fts {
+					id := ft.Props["id"].(int)
+					dists[id] = ft.Geometry.GeodesicDistanceTo(pt)
+					ids = append(ids, id)
+				}
+				sort.Slice(ids, func(a, b int) bool { return dists[ids[a]] < dists[ids[b]] })
+
+				nearest := rt.NearestNeighbours(pt, 10)
+				assert.Len(t, nearest, 10)
+				for n, ft := range nearest {
+					assert.Equal(t, dists[ids[n]], dists[ft.Props["id"].(int)])
+				}
+
+				radius := dists[ids[25]]
+				within := rt.WithinDistance(pt, radius)
+				assert.Len(t, within, 26)
+				for n, ft := range within {
+					assert.Equal(t, dists[ids[n]], dists[ft.Props["id"].(int)])
+				}
+			}
+		})
+	}
+}
+
+func TestRTreeCollectionNearestAll(t *testing.T) {
+	rt := NewRTreeCollection(
+		Feature{Geometry: MustNewGeom(Point{-170, -80})},
+		Feature{Geometry: MustNewGeom(Point{170, 80})},
+	)
+	assert.Len(t, rt.NearestNeighbours(Point{0, 0}, 5), 2)
+	assert.Len(t, rt.NearestNeighbours(Point{0, 0}, 0), 0)
+	assert.Len(t, rt.WithinDistance(Point{0, 0}, 100), 0)
+}
+
+func TestBulkLoadRTreeCollectionFilter(t *testing.T) {
+	var (
+		rnd  = rand.New(rand.NewSource(3))
+		fts  = randomFeatures(rnd, 1000)
+		rt   = BulkLoadRTreeCollection(fts)
+		bbox = BBox{Point{7, 47}, Point{9, 49}}
+	)
+	var expected int
+	for _, ft := range fts {
+		if bboxIntersects(ft.Geometry.BBox(), bbox) {
+			expected++
+		}
+	}
+	assert.Len(t, rt.Filter(bbox), expected)
+}
@@ -0,0 +1,521 @@
+package spatial
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// Layout describes which ordinates a geometry carries in addition to X and Y.
+type Layout uint8
+
+const (
+	LayoutXY Layout = iota
+	LayoutXYZ
+	LayoutXYM
+	LayoutXYZM
+)
+
+func (l Layout) HasZ() bool {
+	return l == LayoutXYZ || l == LayoutXYZM
+}
+
+func (l Layout) HasM() bool {
+	return l == LayoutXYM || l == LayoutXYZM
+}
+
+func (l Layout) String() string {
+	switch l {
+	case LayoutXYZ:
+		return "XYZ"
+	case LayoutXYM:
+		return "XYM"
+	case LayoutXYZM:
+		return "XYZM"
+	}
+	return "XY"
+}
+
+func newLayout(hasZ, hasM bool) Layout {
+	switch {
+	case hasZ && hasM:
+		return LayoutXYZM
+	case hasZ:
+		return LayoutXYZ
+	case hasM:
+		return LayoutXYM
+	}
+	return LayoutXY
+}
+
+// Layout returns which ordinates are present in the geometry. Members of a GeometryCollection
+// carry their own ordinates, so the collection itself is always LayoutXY.
+func (g *Geom) Layout() Layout {
+	return newLayout(g.z != nil, g.m != nil)
+}
+
+// Z returns the Z ordinates (e.g. elevation) of all vertices, in the order they are traversed
+// (polygon rings are not closed). Returns nil if the geometry has no Z ordinates.
+func (g *Geom) Z() []float64 {
+	return g.z
+}
+
+// M returns the measured ordinates of all vertices, analogous to Z.
+func (g *Geom) M() []float64 {
+	return g.m
+}
+
+// SetZ attaches Z ordinates to the geometry, one per vertex. Passing nil removes them.
+func (g *Geom) SetZ(z []float64) error {
+	if err := g.checkOrdinates(z); err != nil {
+		return err
+	}
+	g.z = z
+	return nil
+}
+
+// SetM attaches measured ordinates to the geometry, one per vertex. Passing nil removes them.
+func (g *Geom) SetM(m []float64) error {
+	if err := g.checkOrdinates(m); err != nil {
+		return err
+	}
+	g.m = m
+	return nil
+}
+
+func (g *Geom) checkOrdinates(ord []float64) error {
+	if ord == nil {
+		return nil
+	}
+	if g.typ == GeomTypeGeometryCollection {
+		return errors.New("ordinates of a GeometryCollection have to be set on its members")
+	}
+	if n := g.vertexCount(); len(ord) != n {
+		return fmt.Errorf("geometry has %v vertices, but %v ordinates were given", n, len(ord))
+	}
+	return nil
+}
+
+// parts returns the vertices of a geometry grouped by connected parts. Rings are returned unclosed,
+// closed reports whether parts are rings.
+func (g *Geom) parts() (parts []Line, closed bool) {
+	switch gm := g.g.(type) {
+	case *Point:
+		return []Line{{*gm}}, false
+	case Line:
+		return []Line{gm}, false
+	case Polygon:
+		return gm, true
+	case MultiPoint:
+		for _, pt := range gm {
+			parts = append(parts, Line{pt})
+		}
+		return parts, false
+	case MultiLine:
+		return gm, false
+	case MultiPolygon:
+		for _, poly := range gm {
+			parts = append(parts, poly...)
+		}
+		return parts, true
+	}
+	return nil, false
+}
+
+func (g *Geom) vertexCount() int {
+	var (
+		n        int
+		parts, _ = g.parts()
+	)
+	for _, part := range parts {
+		n += len(part)
+	}
+	return n
+}
+
+// members returns the parts of a multi geometry as single geometries, including their ordinates.
+func (g *Geom) members() []Geom {
+	var (
+		members []Geom
+		offset  int
+	)
+	add := func(m Geom) {
+		n := m.vertexCount()
+		if g.z != nil {
+			m.z = g.z[offset : offset+n]
+		}
+		if g.m != nil {
+			m.m = g.m[offset : offset+n]
+		}
+		offset += n
+		members = append(members, m)
+	}
+
+	switch gm := g.g.(type) {
+	case MultiPoint:
+		for _, pt := range gm {
+			add(MustNewGeom(pt))
+		}
+	case MultiLine:
+		for _, ln := range gm {
+			add(MustNewGeom(ln))
+		}
+	case MultiPolygon:
+		for _, poly := range gm {
+			add(MustNewGeom(poly))
+		}
+	case GeomCollection:
+		return gm
+	default:
+		return []Geom{*g}
+	}
+	return members
+}
+
+// joinOrdinates concatenates the ordinates of members, which have been assembled into g.
+func (g *Geom) joinOrdinates(members []Geom) {
+	var hasZ, hasM = true, true
+	for _, m := range members {
+		hasZ = hasZ && m.z != nil
+		hasM = hasM && m.m != nil
+	}
+	g.z, g.m = nil, nil
+	for _, m := range members {
+		if hasZ {
+			g.z = append(g.z, m.z...)
+		}
+		if hasM {
+			g.m = append(g.m, m.m...)
+		}
+	}
+}
+
+func copyOrdinates(ord []float64) []float64 {
+	if ord == nil {
+		return nil
+	}
+	return append(ord[:0:0], ord...)
+}
+
+// inheritOrdinates sets the ordinates of g by looking them up in src. This is needed for
+// operations which work in two dimensions only. Vertices which exist in src keep their values,
+// new vertices (e.g. created by clipping) are interpolated along the nearest segment of src.
+func (g *Geom) inheritOrdinates(src ...Geom) {
+	var hasZ, hasM = true, true
+	for _, s := range src {
+		hasZ = hasZ && s.z != nil
+		hasM = hasM && s.m != nil
+	}
+	if !hasZ && !hasM {
+		return
+	}
+	if gc, ok := g.g.(GeomCollection); ok {
+		for i := range gc {
+			gc[i].inheritOrdinates(src...)
+		}
+		return
+	}
+
+	var (
+		known    = map[Point]int{}
+		vertices Line
+		zs, ms   []float64
+		segs     []Segment
+		segIdx   [][2]int // vertex indices of the segment ends
+	)
+	for _, s := range src {
+		parts, closed := s.parts()
+		for _, part := range parts {
+			base := len(vertices)
+			for i, pt := range part {
+				if _, ok := known[pt]; !ok {
+					known[pt] = base + i
+				}
+				if i+1 < len(part) {
+					segs = append(segs, Segment{pt, part[i+1]})
+					segIdx = append(segIdx, [2]int{base + i, base + i + 1})
+				}
+			}
+			if closed && len(part) > 2 {
+				segs = append(segs, Segment{part[len(part)-1], part[0]})
+				segIdx = append(segIdx, [2]int{base + len(part) - 1, base})
+			}
+			vertices = append(vertices, part...)
+		}
+		if hasZ {
+			zs = append(zs, s.z...)
+		}
+		if hasM {
+			ms = append(ms, s.m...)
+		}
+	}
+
+	var (
+		parts, _ = g.parts()
+		nz, nm   []float64
+	)
+	for _, part := range parts {
+		for _, pt := range part {
+			var (
+				i0, i1 int
+				t      float64
+			)
+			if pos, ok := known[pt]; ok {
+				i0, i1 = pos, pos
+			} else {
+				i0, i1, t = nearestSegment(pt, segs, segIdx, vertices)
+			}
+			if hasZ {
+				nz = append(nz, zs[i0]+t*(zs[i1]-zs[i0]))
+			}
+			if hasM {
+				nm = append(nm, ms[i0]+t*(ms[i1]-ms[i0]))
+			}
+		}
+	}
+	g.z, g.m = nz, nm
+}
+
+// nearestSegment determines the vertex indices of the segment which is closest to pt and the
+// relative position of pt along it.
+func nearestSegment(pt Point, segs []Segment, segIdx [][2]int, vertices Line) (i0, i1 int, t float64) {
+	var minDist = math.Inf(1)
+	if len(segs) == 0 {
+		// there are only isolated points, so take the closest one
+		for i, v := range vertices {
+			if d := math.Hypot(v.X-pt.X, v.Y-pt.Y); d < minDist {
+				minDist, i0 = d, i
+			}
+		}
+		return i0, i0, 0
+	}
+
+	var best int
+	for i, seg := range segs {
+		if d := seg.DistanceToPt(pt); d < minDist {
+			minDist, best = d, i
+		}
+	}
+	return segIdx[best][0], segIdx[best][1], segs[best].position(pt)
+}
+
+// position returns the relative position (0 to 1) of the projection of p onto the segment.
+func (s Segment) position(p Point) float64 {
+	var (
+		dx    = s[1].X - s[0].X
+		dy    = s[1].Y - s[0].Y
+		lenSq = dx*dx + dy*dy
+	)
+	if lenSq == 0 {
+		return 0
+	}
+	return math.Max(0, math.Min(1, ((p.X-s[0].X)*dx+(p.Y-s[0].Y)*dy)/lenSq))
+}
+
+// fixWinding corrects the ring orientation of polygonal geometries, keeping ordinates in sync.
+func (g *Geom) fixWinding() {
+	var polys []Polygon
+	switch gm := g.g.(type) {
+	case Polygon:
+		polys = []Polygon{gm}
+	case MultiPolygon:
+		polys = gm
+	default:
+		return
+	}
+
+	var offset int
+	for _, poly := range polys {
+		wrong := poly.wrongWinding()
+		for n, ring := range poly {
+			if wrong[n] {
+				ring.Reverse()
+				if g.z != nil {
+					reverseFloats(g.z[offset : offset+len(ring)])
+				}
+				if g.m != nil {
+					reverseFloats(g.m[offset : offset+len(ring)])
+				}
+			}
+			offset += len(ring)
+		}
+	}
+}
+
+func reverseFloats(f []float64) {
+	for i := len(f)/2 - 1; i >= 0; i-- {
+		opp := len(f) - 1 - i
+		f[i], f[opp] = f[opp], f[i]
+	}
+}
+
+// geoJSONHasOrdinates checks cheaply whether the first position of GeoJSON coordinates has more
+// than two values. Positions are assumed to have the same number of values throughout a geometry.
+func geoJSONHasOrdinates(inner json.RawMessage) bool {
+	var commas int
+	for _, c := range inner {
+		switch c {
+		case '[':
+			commas = 0
+		case ',':
+			commas++
+		case ']':
+			return commas >= 2
+		}
+	}
+	return false
+}
+
+// geoJSONRingClosed reports whether the last position of a ring repeats the first one.
+func geoJSONRingClosed(ring []interface{}) bool {
+	if len(ring) < 2 {
+		return false
+	}
+	first, ok1 := ring[0].([]interface{})
+	last, ok2 := ring[len(ring)-1].([]interface{})
+	if !ok1 || !ok2 || len(first) < 2 || len(last) < 2 {
+		return false
+	}
+	return first[0] == last[0] && first[1] == last[1]
+}
+
+// readGeoJSONOrdinates extracts the third (Z) and fourth (M) values of all positions. closedRings
+// needs to be set for polygonal geometries, as their last position is not part of the internal
+// representation.
+func (g *Geom) readGeoJSONOrdinates(inner json.RawMessage, closedRings bool) error {
+	if !geoJSONHasOrdinates(inner) {
+		return nil
+	}
+	var raw interface{}
+	if err := json.Unmarshal(inner, &raw); err != nil {
+		return err
+	}
+
+	var (
+		positions [][]interface{}
+		walk      func(v interface{})
+	)
+	walk = func(v interface{}) {
+		arr, ok := v.([]interface{})
+		if !ok || len(arr) == 0 {
+			return
+		}
+		if _, isPos := arr[0].(float64); isPos {
+			positions = append(positions, arr)
+			return
+		}
+		if inner, ok := arr[0].([]interface{}); ok && len(inner) > 0 && closedRings {
+			if _, isRing := inner[0].(float64); isRing && geoJSONRingClosed(arr) {
+				arr = arr[:len(arr)-1]
+			}
+		}
+		for _, elem := range arr {
+			walk(elem)
+		}
+	}
+	walk(raw)
+
+	var hasZ, hasM = true, true
+	for _, pos := range positions {
+		hasZ = hasZ && len(pos) >= 3
+		hasM = hasM && len(pos) >= 4
+	}
+	var z, m []float64
+	for _, pos := range positions {
+		if hasZ {
+			v, ok := pos[2].(float64)
+			if !ok {
+				return fmt.Errorf("invalid Z value %v", pos[2])
+			}
+			z = append(z, v)
+		}
+		if hasM {
+			v, ok := pos[3].(float64)
+			if !ok {
+				return fmt.Errorf("invalid M value %v", pos[3])
+			}
+			m = append(m, v)
+		}
+	}
+	if err := g.SetZ(z); err != nil {
+		return err
+	}
+	return g.SetM(m)
+}
+
+// geoJSONPosition is a GeoJSON position with an arbitrary number of values.
+type geoJSONPosition []float64
+
+func (p geoJSONPosition) MarshalJSON() ([]byte, error) {
+	var b = make([]byte, 1, 50)
+	b[0] = '['
+	for i, v := range p {
+		if i > 0 {
+			b = append(b, ',')
+		}
+		b = strconv.AppendFloat(b, v, 'f', -1, 64)
+	}
+	return append(b, ']'), nil
+}
+
+// geoJSONCoordinates returns the GeoJSON coordinates of g including Z and M. As GeoJSON positions
+// can only carry M after Z, M is omitted if there is no Z.
+func (g *Geom) geoJSONCoordinates() interface{} {
+	var (
+		pc  = g.Copy()
+		pos int
+	)
+	pc.fixWinding() // writes a copy, so the original stays unchanged
+	position := func(pt Point) geoJSONPosition {
+		p := geoJSONPosition{pt.X, pt.Y}
+		if pc.z != nil {
+			p = append(p, pc.z[pos])
+			if pc.m != nil {
+				p = append(p, pc.m[pos])
+			}
+		}
+		pos++
+		return p
+	}
+	line := func(ln Line) []geoJSONPosition {
+		var ps = make([]geoJSONPosition, 0, len(ln))
+		for _, pt := range ln {
+			ps = append(ps, position(pt))
+		}
+		return ps
+	}
+	polygon := func(poly Polygon) [][]geoJSONPosition {
+		var rings = make([][]geoJSONPosition, 0, len(poly))
+		for _, ring := range poly {
+			ps := line(ring)
+			rings = append(rings, append(ps, ps[0]))
+		}
+		return rings
+	}
+
+	switch gm := pc.g.(type) {
+	case *Point:
+		return position(*gm)
+	case Line:
+		return line(gm)
+	case Polygon:
+		return polygon(gm)
+	case MultiPoint:
+		return line(Line(gm))
+	case MultiLine:
+		var lines = make([][]geoJSONPosition, 0, len(gm))
+		for _, ln := range gm {
+			lines = append(lines, line(ln))
+		}
+		return lines
+	case MultiPolygon:
+		var polys = make([][][]geoJSONPosition, 0, len(gm))
+		for _, poly := range gm {
+			polys = append(polys, polygon(poly))
+		}
+		return polys
+	}
+	return nil
+}
@@ -0,0 +1,178 @@
+package spatial
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/twpayne/go-geom"
+	"github.com/twpayne/go-geom/encoding/wkb"
+)
+
+func geomWithOrdinates(t *testing.T, g interface{}, z, m []float64) Geom {
+	ng := MustNewGeom(g)
+	assert.Nil(t, ng.SetZ(z))
+	assert.Nil(t, ng.SetM(m))
+	return ng
+}
+
+func TestOrdinatesWKBRoundtrip(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		geom   Geom
+		layout geom.Layout
+	}{
+		{"pointz", geomWithOrdinates(t, Point{1, 2}, []float64{3}, nil), geom.XYZ},
+		{"pointm", geomWithOrdinates(t, Point{1, 2}, nil, []float64{4}), geom.XYM},
+		{"linestringzm", geomWithOrdinates(t, Line{{1, 2}, {3, 4}}, []float64{5, 6}, []float64{7, 8}), geom.XYZM},
+		{"polygonz", geomWithOrdinates(t, Polygon{{{0, 0}, {0, 10}, {10, 10}, {10, 0}}}, []float64{1, 2, 3, 4}, nil), geom.XYZ},
+		{"multipolygonz", geomWithOrdinates(t, MultiPolygon{
+			{{{0, 0}, {0, 10}, {10, 10}}},
+			{{{20, 20}, {20, 30}, {30, 30}}},
+		}, []float64{1, 2, 3, 4, 5, 6}, nil), geom.XYZ},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			buf, err := tc.geom.MarshalWKB()
+			assert.Nil(t, err)
+
+			// test against third party implementation
+			tg, err := wkb.Unmarshal(buf)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.layout, tg.Layout())
+
+			rg, err := GeomFromWKB(bytes.NewReader(buf))
+			assert.Nil(t, err)
+			assert.Equal(t, tc.geom, rg)
+		})
+	}
+}
+
+func TestOrdinatesWKBPolygonClosing(t *testing.T) {
+	g := geomWithOrdinates(t, Polygon{{{0, 0}, {0, 10}, {10, 10}}}, []float64{1, 2, 3}, nil)
+	buf, err := g.MarshalWKB()
+	assert.Nil(t, err)
+
+	tg, err := wkb.Unmarshal(buf)
+	assert.Nil(t, err)
+	ring := tg.(*geom.Polygon).LinearRing(0).Coords()
+	assert.Len(t, ring, 4)
+	assert.Equal(t, geom.Coord{0, 0, 1}, ring[3])
+}
+
+func TestOrdinatesEWKB(t *testing.T) {
+	// SELECT ST_AsEWKB('SRID=4326;POINT Z(1 2 3)'::geometry)
+	buf, err := hex.DecodeString("01010000a0e6100000000000000000f03f00000000000000400000000000000840")
+	assert.Nil(t, err)
+
+	g, err := GeomFromWKB(bytes.NewReader(buf))
+	assert.Nil(t, err)
+	assert.Equal(t, Point{1, 2}, *g.MustPoint())
+	assert.Equal(t, LayoutXYZ, g.Layout())
+	assert.Equal(t, []float64{3}, g.Z())
+}
+
+func TestOrdinatesGeoJSON(t *testing.T) {
+	for _, tc := range []struct {
+		in     string
+		layout Layout
+		z      []float64
+	}{
+		{`{"type":"Point","coordinates":[1,2,3]}`, LayoutXYZ, []float64{3}},
+		{`{"type":"Point","coordinates":[1,2,3,4]}`, LayoutXYZM, []float64{3}},
+		{`{"type":"LineString","coordinates":[[1,2,3],[3,4,5]]}`, LayoutXYZ, []float64{3, 5}},
+		{`{"type":"Polygon","coordinates":[[[0,0,1],[10,0,2],[10,10,3],[0,10,4],[0,0,1]]]}`, LayoutXYZ, nil},
+		{`{"type":"MultiPoint","coordinates":[[1,2,3],[3,4,5]]}`, LayoutXYZ, []float64{3, 5}},
+		{`{"type":"MultiPolygon","coordinates":[[[[0,0,1],[10,0,2],[10,10,3],[0,0,1]]],[[[20,20,5],[30,20,6],[30,30,7],[20,20,5]]]]}`, LayoutXYZ, nil},
+		{`{"type":"LineString","coordinates":[[1,2],[3,4]]}`, LayoutXY, nil},
+	} {
+		t.Run(tc.in, func(t *testing.T) {
+			var g Geom
+			err := json.Unmarshal([]byte(tc.in), &g)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.layout, g.Layout())
+			if tc.z != nil {
+				assert.Equal(t, tc.z, g.Z())
+			}
+
+			buf, err := json.Marshal(g)
+			assert.Nil(t, err)
+			assert.JSONEq(t, tc.in, string(buf))
+		})
+	}
+}
+
+func TestOrdinatesGeoJSONWinding(t *testing.T) {
+	// counter-clockwise ring, which is reversed internally
+	var g Geom
+	err := json.Unmarshal([]byte(`{"type":"Polygon","coordinates":[[[0,0,1],[0,10,2],[10,10,3],[0,0,1]]]}`), &g)
+	assert.Nil(t, err)
+	poly := g.MustPolygon()
+	for i, pt := range poly[0] {
+		switch pt {
+		case Point{0, 0}:
+			assert.Equal(t, 1.0, g.Z()[i])
+		case Point{0, 10}:
+			assert.Equal(t, 2.0, g.Z()[i])
+		case Point{10, 10}:
+			assert.Equal(t, 3.0, g.Z()[i])
+		}
+	}
+}
+
+func TestOrdinatesGeoJSONInvalid(t *testing.T) {
+	for _, in := range []string{
+		`{"type":"Point","coordinates":[1,2,null]}`,
+		`{"type":"LineString","coordinates":[[1,2,3,"m"],[3,4,5,6]]}`,
+	} {
+		var g Geom
+		assert.NotNil(t, json.Unmarshal([]byte(in), &g), in)
+	}
+}
+
+func TestOrdinatesClipInterpolation(t *testing.T) {
+	g := geomWithOrdinates(t, Line{{0, 5}, {20, 5}}, []float64{100, 200}, []float64{0, 1})
+	clipped := g.ClipToBBox(BBox{SW: Point{0, 0}, NE: Point{10, 10}})
+	assert.Len(t, clipped, 1)
+	assert.Equal(t, Line{{0, 5}, {10, 5}}, clipped[0].MustLineString())
+	assert.Equal(t, []float64{100, 150}, clipped[0].Z())
+	assert.Equal(t, []float64{0, 0.5}, clipped[0].M())
+
+	mp := geomWithOrdinates(t, MultiPoint{{1, 1}, {20, 20}, {5, 5}}, []float64{1, 2, 3}, nil)
+	clipped = mp.ClipToBBox(BBox{SW: Point{0, 0}, NE: Point{10, 10}})
+	assert.Len(t, clipped, 1)
+	assert.Equal(t, []float64{1, 3}, clipped[0].Z())
+}
+
+func TestOrdinatesSimplify(t *testing.T) {
+	g := geomWithOrdinates(t, Line{{0, 0}, {1, 0.1}, {2, 0}}, []float64{1, 2, 3}, nil)
+	sg := g.Simplify(1)
+	assert.Equal(t, []float64{1, 3}, sg.Z())
+}
+
+func TestOrdinatesCopy(t *testing.T) {
+	g := geomWithOrdinates(t, Line{{0, 0}, {1, 1}}, []float64{1, 2}, nil)
+	c := g.Copy()
+	c.Z()[0] = 5
+	assert.Equal(t, []float64{1, 2}, g.Z())
+}
+
+func TestOrdinatesSetInvalid(t *testing.T) {
+	g := MustNewGeom(Line{{0, 0}, {1, 1}})
+	assert.NotNil(t, g.SetZ([]float64{1}))
+	assert.Equal(t, LayoutXY, g.Layout())
+
+	gc := MustNewGeom(GeomCollection{g})
+	assert.NotNil(t, gc.SetZ([]float64{1, 2}))
+}
+
+func TestOrdinatesMergeLines(t *testing.T) {
+	fts := []Feature{
+		{Geometry: geomWithOrdinates(t, Line{{0, 0}, {1, 1}}, []float64{1, 2}, nil)},
+		{Geometry: geomWithOrdinates(t, Line{{1, 1}, {2, 2}}, []float64{2, 3}, nil)},
+	}
+	merged := MergeFeatures(fts)
+	assert.Len(t, merged, 1)
+	assert.Equal(t, []float64{1, 2, 3}, merged[0].Geometry.Z())
+}
@@ -0,0 +1,310 @@
+package spatial
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Union returns the area covered by either g or other. Both geometries need to be polygonal
+// (Polygon or MultiPolygon), empty geometries are treated as an empty area.
+//
+// The result is a Polygon if the area is connected, a MultiPolygon if it consists of multiple
+// parts and an empty Geom if nothing remains. Overlay operations are implemented in Go, so they
+// are available regardless of whether GEOS is used for clipping.
+func (g Geom) Union(other Geom) (Geom, error) {
+	return g.overlay(other, opUnion)
+}
+
+// Intersection returns the area covered by both g and other. See Union for details.
+func (g Geom) Intersection(other Geom) (Geom, error) {
+	return g.overlay(other, opIntersection)
+}
+
+// Difference returns the area of g which is not covered by other. See Union for details.
+func (g Geom) Difference(other Geom) (Geom, error) {
+	return g.overlay(other, opDifference)
+}
+
+// SymDifference returns the area covered by exactly one of g and other. See Union for details.
+func (g Geom) SymDifference(other Geom) (Geom, error) {
+	return g.overlay(other, opSymDifference)
+}
+
+func (g Geom) overlay(other Geom, op func(inA, inB bool) bool) (Geom, error) {
+	a, err := g.overlayRings()
+	if err != nil {
+		return Geom{}, err
+	}
+	b, err := other.overlayRings()
+	if err != nil {
+		return Geom{}, err
+	}
+	polys, err := overlayPolygons(a, b, fillPositive, op)
+	if err != nil {
+		return Geom{}, err
+	}
+	res := polygonsToGeom(polys)
+	if g.Layout() == other.Layout() {
+		res.inheritOrdinates(g, other)
+	}
+	return res, nil
+}
+
+func opUnion(inA, inB bool) bool         { return inA || inB }
+func opIntersection(inA, inB bool) bool  { return inA && inB }
+func opDifference(inA, inB bool) bool    { return inA && !inB }
+func opSymDifference(inA, inB bool) bool { return inA != inB }
+
+// overlayRings returns the rings of polygonal geometries.
+func (g Geom) overlayRings() ([]overlayRing, error) {
+	switch gm := g.g.(type) {
+	case nil:
+		return nil, nil
+	case Polygon:
+		return appendPolygonRings(nil, gm), nil
+	case MultiPolygon:
+		return appendPolygonRings(nil, gm...), nil
+	}
+	return nil, fmt.Errorf("overlay operations are only supported for polygons, got %v", g.typ)
+}
+
+func appendPolygonRings(rings []overlayRing, polys ...Polygon) []overlayRing {
+	for _, poly := range polys {
+		for n, ring := range poly {
+			sign := 1
+			if n > 0 {
+				sign = -1
+			}
+			rings = append(rings, overlayRing{ring: ring, sign: sign})
+		}
+	}
+	return rings
+}
+
+func polygonsToGeom(polys []Polygon) Geom {
+	switch len(polys) {
+	case 0:
+		return Geom{}
+	case 1:
+		return MustNewGeom(polys[0])
+	}
+	return MustNewGeom(MultiPolygon(polys))
+}
+
+// overlayPolygons calculates the area for which op returns true and builds polygons from it.
+func overlayPolygons(a, b []overlayRing, rule fillRule, op func(inA, inB bool) bool) ([]Polygon, error) {
+	var (
+		norm  = newOverlayNormalizer(a, b)
+		segsA []*sweepSegment
+		segsB []*sweepSegment
+		err   error
+	)
+	if segsA, err = resolveRings(norm.rings(a), rule); err != nil {
+		return nil, err
+	}
+	if segsB, err = resolveRings(norm.rings(b), rule); err != nil {
+		return nil, err
+	}
+	segs, err := combineSegments(segsA, segsB, op)
+	if err != nil {
+		return nil, err
+	}
+	rings := chainSegments(segs)
+	for _, ring := range rings {
+		norm.restore(ring)
+	}
+	return polygonsFromRings(rings), nil
+}
+
+// splitRing splits a ring which visits a vertex more than once into rings which don't and
+// removes their collinear points, except for junctions. This happens if parts of the result
+// touch at a single point, e.g. two polygons which share a vertex or a hole which touches its
+// outer ring.
+func splitRing(ring Line, junctions map[Point]bool) []Line {
+	var (
+		rings []Line
+		seen  = make(map[Point]int, len(ring))
+		cur   = make(Line, 0, len(ring))
+	)
+	for _, pt := range ring {
+		i, ok := seen[pt]
+		if !ok {
+			seen[pt] = len(cur)
+			cur = append(cur, pt)
+			continue
+		}
+		// the part since the previous visit is a ring of its own
+		rings = append(rings, removeCollinear(cur[i:], junctions))
+		for _, p := range cur[i+1:] {
+			delete(seen, p)
+		}
+		cur = cur[:i+1]
+	}
+	return append(rings, removeCollinear(cur, junctions))
+}
+
+// overlayNormalizer moves coordinates into the range of -1 to 1, as the overlay algorithm
+// works with a fixed epsilon. Input vertices are restored exactly.
+type overlayNormalizer struct {
+	center Point
+	scale  float64
+	orig   map[Point]Point
+}
+
+func newOverlayNormalizer(ringSets ...[]overlayRing) overlayNormalizer {
+	var (
+		bb    BBox
+		first = true
+	)
+	for _, rings := range ringSets {
+		for _, r := range rings {
+			if len(r.ring) == 0 {
+				continue
+			}
+			if first {
+				bb = r.ring.BBox()
+				first = false
+				continue
+			}
+			bb.ExtendWith(r.ring.BBox())
+		}
+	}
+	n := overlayNormalizer{
+		center: Point{(bb.SW.X + bb.NE.X) / 2, (bb.SW.Y + bb.NE.Y) / 2},
+		scale:  math.Max(bb.NE.X-bb.SW.X, bb.NE.Y-bb.SW.Y) / 2,
+		orig:   map[Point]Point{},
+	}
+	if n.scale == 0 {
+		n.scale = 1
+	}
+	return n
+}
+
+func (n overlayNormalizer) rings(rings []overlayRing) []overlayRing {
+	var nr = make([]overlayRing, 0, len(rings))
+	for _, r := range rings {
+		var ring = make(Line, 0, len(r.ring))
+		for _, pt := range r.ring {
+			np := Point{(pt.X - n.center.X) / n.scale, (pt.Y - n.center.Y) / n.scale}
+			if _, ok := n.orig[np]; !ok {
+				n.orig[np] = pt
+			}
+			ring = append(ring, np)
+		}
+		nr = append(nr, overlayRing{ring: ring, sign: r.sign})
+	}
+	return nr
+}
+
+func (n overlayNormalizer) restore(ring Line) {
+	for i, pt := range ring {
+		if op, ok := n.orig[pt]; ok {
+			ring[i] = op
+			continue
+		}
+		ring[i] = Point{pt.X*n.scale + n.center.X, pt.Y*n.scale + n.center.Y}
+	}
+}
+
+// polygonsFromRings builds polygons from rings, which are not ordered and don't tell whether
+// they are holes or outer rings. Rings which are located inside an odd number of other rings
+// are holes of the smallest ring surrounding them.
+func polygonsFromRings(rings []Line) []Polygon {
+	var valid = rings[:0]
+	for _, ring := range rings {
+		if len(ring) >= 3 && ring.Area() != 0 {
+			valid = append(valid, ring)
+		}
+	}
+	rings = valid
+	// Sorting by size ensures that the parent of a ring is always processed before its children.
+	sort.SliceStable(rings, func(i, j int) bool {
+		return math.Abs(rings[i].Area()) > math.Abs(rings[j].Area())
+	})
+
+	var (
+		polys  []Polygon
+		depth  = make([]int, len(rings))
+		polyOf = make([]int, len(rings)) // index of the polygon an outer ring belongs to
+		bboxes = make([]BBox, len(rings))
+	)
+	for i, ring := range rings {
+		bboxes[i] = ring.BBox()
+		parent := -1
+		for j := i - 1; j >= 0; j-- {
+			if bboxes[i].FullyIn(bboxes[j]) && rings[j].containsRing(ring) {
+				parent = j
+				break
+			}
+		}
+		if parent >= 0 {
+			depth[i] = depth[parent] + 1
+		}
+		if depth[i]%2 == 0 {
+			polyOf[i] = len(polys)
+			polys = append(polys, Polygon{ring})
+			continue
+		}
+		polys[polyOf[parent]] = append(polys[polyOf[parent]], ring)
+	}
+	// The structure is known, so the winding can be set without FixWinding's inside tests.
+	for _, poly := range polys {
+		for n, ring := range poly {
+			if ring.Clockwise() != (n == 0) {
+				ring.Reverse()
+			}
+		}
+	}
+	return polys
+}
+
+// containsRing reports whether inner is located inside of the ring l. The rings must not cross,
+// but they may touch.
+func (l Line) containsRing(inner Line) bool {
+	for _, pt := range inner {
+		switch l.locate(pt) {
+		case 1:
+			return true
+		case -1:
+			return false
+		}
+	}
+	// All vertices are on the boundary, so compare the centers of the edges.
+	for _, seg := range inner.SegmentsWithClosing() {
+		mid := Point{(seg[0].X + seg[1].X) / 2, (seg[0].Y + seg[1].Y) / 2}
+		switch l.locate(mid) {
+		case 1:
+			return true
+		case -1:
+			return false
+		}
+	}
+	return false
+}
+
+// locate determines if pt is inside of the ring l (1), on its boundary (0) or outside (-1).
+func (l Line) locate(pt Point) int {
+	var inside bool
+	for i := range l {
+		a, b := l[i], l[(i+1)%len(l)]
+		if onSegment(pt, a, b) {
+			return 0
+		}
+		if (a.Y > pt.Y) != (b.Y > pt.Y) && pt.X < (b.X-a.X)*(pt.Y-a.Y)/(b.Y-a.Y)+a.X {
+			inside = !inside
+		}
+	}
+	if inside {
+		return 1
+	}
+	return -1
+}
+
+func onSegment(pt, a, b Point) bool {
+	if (pt.X < a.X && pt.X < b.X) || (pt.X > a.X && pt.X > b.X) ||
+		(pt.Y < a.Y && pt.Y < b.Y) || (pt.Y > a.Y && pt.Y > b.Y) {
+		return false
+	}
+	return (b.X-a.X)*(pt.Y-a.Y)-(pt.X-a.X)*(b.Y-a.Y) == 0
+}
@@ -0,0 +1,713 @@
+package spatial
+
+import (
+	"container/heap"
+	"errors"
+	"math"
+)
+
+// The overlay is calculated with a sweep line, which annotates every segment with the fill
+// state on both of its sides (above and below). This follows the approach of the PolyBool
+// library by Sean Connelly: In a first pass all self intersections of a polygon are resolved,
+// in a second pass the segments of both polygons are combined. Afterwards, the segments which
+// separate filled from empty areas in the result are selected and chained into rings.
+//
+// Coordinates are normalized to roughly [-1, 1] before, so that a fixed epsilon can be used.
+
+const overlayEpsilon = 1e-10
+
+type fillRule uint8
+
+const (
+	// fillPositive fills every area which has a positive winding number. The orientation of
+	// rings is normalized, so outer rings always count +1 and holes -1. This results in
+	// overlapping polygons being merged.
+	fillPositive fillRule = iota
+	// fillEvenOdd fills every area which is enclosed by an odd number of rings.
+	fillEvenOdd
+)
+
+func (r fillRule) filled(wind int) bool {
+	if r == fillEvenOdd {
+		return wind%2 != 0
+	}
+	return wind > 0
+}
+
+// overlayRing is an input ring, sign is +1 for outer rings and -1 for holes.
+type overlayRing struct {
+	ring Line
+	sign int
+}
+
+type sideFill struct {
+	above, below bool
+}
+
+type sweepSegment struct {
+	start, end Point
+	wind       int // change of the winding number from below to above
+	windAbove  int
+	fill       sideFill
+	other      *sideFill // fill of the other polygon
+}
+
+type sweepEvent struct {
+	isStart bool
+	pt      Point
+	seg     *sweepSegment
+	primary bool
+	other   *sweepEvent
+	status  *statusNode
+	removed bool
+	seq     int
+}
+
+type statusNode struct {
+	ev         *sweepEvent
+	prev, next *statusNode
+}
+
+type eventQueue []*sweepEvent
+
+func (q eventQueue) Len() int { return len(q) }
+func (q eventQueue) Less(i, j int) bool {
+	if c := eventCompare(q[i], q[j]); c != 0 {
+		return c < 0
+	}
+	return q[i].seq < q[j].seq
+}
+func (q eventQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *eventQueue) Push(x interface{}) { *q = append(*q, x.(*sweepEvent)) }
+func (q *eventQueue) Pop() interface{} {
+	old := *q
+	ev := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return ev
+}
+
+func eventCompare(e1, e2 *sweepEvent) int {
+	if c := pointsCompare(e1.pt, e2.pt); c != 0 {
+		return c
+	}
+	if pointsSame(e1.other.pt, e2.other.pt) {
+		return 0
+	}
+	if e1.isStart != e2.isStart {
+		// end events come first
+		if e1.isStart {
+			return 1
+		}
+		return -1
+	}
+	var left, right = e2.pt, e2.other.pt
+	if !e2.isStart {
+		left, right = right, left
+	}
+	if pointAboveOrOnLine(e1.other.pt, left, right) {
+		return 1
+	}
+	return -1
+}
+
+type sweepLine struct {
+	queue  eventQueue
+	seq    int
+	status statusNode // sentinel, status.next is the top-most segment
+	self   bool
+}
+
+func (s *sweepLine) push(ev *sweepEvent) {
+	s.seq++
+	ev.seq = s.seq
+	heap.Push(&s.queue, ev)
+}
+
+// pop returns the next event which hasn't been removed or nil if the queue is empty.
+func (s *sweepLine) pop() *sweepEvent {
+	for s.queue.Len() > 0 {
+		ev := heap.Pop(&s.queue).(*sweepEvent)
+		if !ev.removed {
+			return ev
+		}
+	}
+	return nil
+}
+
+func (s *sweepLine) peek() *sweepEvent {
+	for s.queue.Len() > 0 {
+		if ev := s.queue[0]; !ev.removed {
+			return ev
+		}
+		heap.Pop(&s.queue)
+	}
+	return nil
+}
+
+func (s *sweepLine) addSegment(seg *sweepSegment, primary bool) *sweepEvent {
+	start := &sweepEvent{isStart: true, pt: seg.start, seg: seg, primary: primary}
+	end := &sweepEvent{pt: seg.end, seg: seg, primary: primary, other: start}
+	start.other = end
+	s.push(start)
+	s.push(end)
+	return start
+}
+
+// updateEnd moves the end of the segment of the start event ev backwards.
+func (s *sweepLine) updateEnd(ev *sweepEvent, end Point) {
+	old := ev.other
+	old.removed = true
+	ev.seg.end = end
+	ne := &sweepEvent{pt: end, seg: ev.seg, primary: ev.primary, other: ev, status: old.status}
+	ev.other = ne
+	s.push(ne)
+}
+
+// divide splits the segment of ev at pt.
+func (s *sweepLine) divide(ev *sweepEvent, pt Point) {
+	// Due to the epsilon, pt might not be located between start and end in sweep order, which
+	// would result in a segment that ends before it starts.
+	if pointsCompare(ev.seg.start, pt) >= 0 || pointsCompare(pt, ev.seg.end) >= 0 {
+		return
+	}
+	ns := &sweepSegment{start: pt, end: ev.seg.end, wind: ev.seg.wind, fill: ev.seg.fill}
+	s.updateEnd(ev, pt)
+	s.addSegment(ns, ev.primary)
+}
+
+// statusCompare returns 1 if ev1 is above ev2, -1 otherwise.
+func statusCompare(ev1, ev2 *sweepEvent) int {
+	var (
+		a1, a2 = ev1.seg.start, ev1.seg.end
+		b1, b2 = ev2.seg.start, ev2.seg.end
+	)
+	if pointsCollinear(a1, b1, b2) {
+		if pointsCollinear(a2, b1, b2) {
+			return 1
+		}
+		if pointAboveOrOnLine(a2, b1, b2) {
+			return 1
+		}
+		return -1
+	}
+	if pointAboveOrOnLine(a1, b1, b2) {
+		return 1
+	}
+	return -1
+}
+
+// checkIntersection divides ev1 and ev2 where they intersect. If both segments are equal,
+// ev2 is returned.
+func (s *sweepLine) checkIntersection(ev1, ev2 *sweepEvent) *sweepEvent {
+	var (
+		a1, a2 = ev1.seg.start, ev1.seg.end
+		b1, b2 = ev2.seg.start, ev2.seg.end
+	)
+	i, ok := linesIntersect(a1, a2, b1, b2)
+	if !ok {
+		// segments are parallel or coincident
+		if !pointsCollinear(a1, a2, b1) {
+			return nil
+		}
+		if pointsSame(a1, b2) || pointsSame(a2, b1) {
+			return nil // segments only touch
+		}
+		var (
+			a1EquB1 = pointsSame(a1, b1)
+			a2EquB2 = pointsSame(a2, b2)
+		)
+		if a1EquB1 && a2EquB2 {
+			return ev2
+		}
+		var (
+			a1Between = !a1EquB1 && pointBetween(a1, b1, b2)
+			a2Between = !a2EquB2 && pointBetween(a2, b1, b2)
+		)
+		if a1EquB1 {
+			if a2Between {
+				s.divide(ev2, a2)
+			} else {
+				s.divide(ev1, b2)
+			}
+			return ev2
+		} else if a1Between {
+			if !a2EquB2 {
+				if a2Between {
+					s.divide(ev2, a2)
+				} else {
+					s.divide(ev1, b2)
+				}
+			}
+			s.divide(ev2, a1)
+		}
+		return nil
+	}
+
+	if i.alongA == 0 {
+		switch i.alongB {
+		case -1:
+			s.divide(ev1, b1)
+		case 0:
+			s.divide(ev1, i.pt)
+		case 1:
+			s.divide(ev1, b2)
+		}
+	}
+	if i.alongB == 0 {
+		switch i.alongA {
+		case -1:
+			s.divide(ev2, a1)
+		case 0:
+			s.divide(ev2, i.pt)
+		case 1:
+			s.divide(ev2, a2)
+		}
+	}
+	return nil
+}
+
+var errOverlayZeroLength = errors.New("overlay failed, zero-length segment detected")
+
+func (s *sweepLine) run() ([]*sweepSegment, error) {
+	var segments []*sweepSegment
+	for {
+		ev := s.pop()
+		if ev == nil {
+			break
+		}
+
+		if ev.isStart {
+			// find the segments directly above and below
+			var (
+				prev = &s.status
+				here = s.status.next
+			)
+			for here != nil && statusCompare(ev, here.ev) <= 0 {
+				prev, here = here, here.next
+			}
+			var above, below *sweepEvent
+			if prev != &s.status {
+				above = prev.ev
+			}
+			if here != nil {
+				below = here.ev
+			}
+
+			var eve *sweepEvent
+			if above != nil {
+				eve = s.checkIntersection(ev, above)
+			}
+			if eve == nil && below != nil {
+				eve = s.checkIntersection(ev, below)
+			}
+			if eve != nil {
+				// ev and eve are equal, the information of ev is merged into eve
+				if s.self {
+					eve.seg.wind += ev.seg.wind
+					eve.seg.windAbove += ev.seg.wind
+				} else {
+					fill := ev.seg.fill
+					eve.seg.other = &fill
+				}
+				ev.other.removed = true
+				continue
+			}
+			if next := s.peek(); next != nil && eventLess(next, ev) {
+				// something was inserted before this event, process it first
+				s.push(ev)
+				continue
+			}
+
+			if s.self {
+				var windBelow int
+				if below != nil {
+					windBelow = below.seg.windAbove
+				}
+				ev.seg.windAbove = windBelow + ev.seg.wind
+			} else if ev.seg.other == nil {
+				var inside bool
+				if below != nil {
+					if ev.primary == below.primary {
+						inside = below.seg.other.above
+					} else {
+						inside = below.seg.fill.above
+					}
+				}
+				ev.seg.other = &sideFill{above: inside, below: inside}
+			}
+
+			node := &statusNode{ev: ev, prev: prev, next: here}
+			prev.next = node
+			if here != nil {
+				here.prev = node
+			}
+			ev.other.status = node
+			continue
+		}
+
+		st := ev.status
+		if st == nil {
+			return nil, errOverlayZeroLength
+		}
+		if st.prev != &s.status && st.next != nil {
+			s.checkIntersection(st.prev.ev, st.next.ev)
+		}
+		st.prev.next = st.next
+		if st.next != nil {
+			st.next.prev = st.prev
+		}
+
+		if !s.self && !ev.primary {
+			// fill always refers to the primary polygon
+			ev.seg.fill, *ev.seg.other = *ev.seg.other, ev.seg.fill
+		}
+		segments = append(segments, ev.seg)
+	}
+	return segments, nil
+}
+
+func eventLess(e1, e2 *sweepEvent) bool {
+	if c := eventCompare(e1, e2); c != 0 {
+		return c < 0
+	}
+	return e1.seq < e2.seq
+}
+
+// resolveRings resolves all self intersections of the rings and returns the segments which
+// separate filled from empty areas.
+func resolveRings(rings []overlayRing, rule fillRule) ([]*sweepSegment, error) {
+	s := sweepLine{self: true}
+	for _, r := range rings {
+		dir := r.sign
+		if rule == fillPositive && r.ring.Area() < 0 {
+			dir = -dir
+		}
+		for i := range r.ring {
+			var (
+				pt1 = r.ring[i]
+				pt2 = r.ring[(i+1)%len(r.ring)]
+			)
+			switch pointsCompare(pt1, pt2) {
+			case 0:
+				continue
+			case -1:
+				// Area is positive for counter-clockwise rings. Travelling forward on them,
+				// the inside is above.
+				s.addSegment(&sweepSegment{start: pt1, end: pt2, wind: dir}, true)
+			case 1:
+				s.addSegment(&sweepSegment{start: pt2, end: pt1, wind: -dir}, true)
+			}
+		}
+	}
+	segs, err := s.run()
+	if err != nil {
+		return nil, err
+	}
+	var res = segs[:0]
+	for _, seg := range segs {
+		seg.fill = sideFill{above: rule.filled(seg.windAbove), below: rule.filled(seg.windAbove - seg.wind)}
+		if seg.fill.above != seg.fill.below {
+			res = append(res, seg)
+		}
+	}
+	return res, nil
+}
+
+// combineSegments calculates the fill of the other polygon for the resolved segments of a
+// and b and returns the segments which are on the border of the result of op. They are directed,
+// so that the filled area is on their left.
+func combineSegments(a, b []*sweepSegment, op func(inA, inB bool) bool) ([]Segment, error) {
+	var s sweepLine
+	for _, seg := range a {
+		s.addSegment(&sweepSegment{start: seg.start, end: seg.end, fill: seg.fill}, true)
+	}
+	for _, seg := range b {
+		s.addSegment(&sweepSegment{start: seg.start, end: seg.end, fill: seg.fill}, false)
+	}
+	segs, err := s.run()
+	if err != nil {
+		return nil, err
+	}
+	var res []Segment
+	for _, seg := range segs {
+		var (
+			above = op(seg.fill.above, seg.other.above)
+			below = op(seg.fill.below, seg.other.below)
+		)
+		switch {
+		case above && !below:
+			res = append(res, Segment{seg.start, seg.end})
+		case below && !above:
+			res = append(res, Segment{seg.end, seg.start})
+		}
+	}
+	return res, nil
+}
+
+// chainSegments connects the directed segments to closed rings. At vertices where several
+// rings meet, the ring continues with the segment which turns left most sharply, so it follows
+// the border of the filled area it is on and rings never cross each other. Rings which visit a
+// vertex twice are split, see splitRing.
+func chainSegments(segs []Segment) []Line {
+	var (
+		nodes    overlayNodes
+		from, to = make([]int, len(segs)), make([]int, len(segs))
+		out      = map[int][]int{}
+		degree   = map[int]int{}
+		used     = make([]bool, len(segs))
+		rings    []Line
+	)
+	for i, seg := range segs {
+		from[i], to[i] = nodes.index(seg[0]), nodes.index(seg[1])
+		if from[i] == to[i] {
+			used[i] = true
+			continue
+		}
+		out[from[i]] = append(out[from[i]], i)
+		degree[from[i]]++
+		degree[to[i]]++
+	}
+	// Vertices where rings touch are kept, even if they are collinear, so the rings share them.
+	var junctions = map[Point]bool{}
+	for n, d := range degree {
+		if d > 2 {
+			junctions[nodes.pts[n]] = true
+		}
+	}
+
+	for first := range segs {
+		if used[first] {
+			continue
+		}
+		var (
+			ring   = Line{nodes.pts[from[first]]}
+			cur    = first
+			closed bool
+		)
+		for {
+			used[cur] = true
+			v := to[cur]
+			if v == from[first] {
+				closed = true
+				break
+			}
+			ring = append(ring, nodes.pts[v])
+			if cur = nodes.nextSegment(v, from[cur], out[v], to, used); cur < 0 {
+				break
+			}
+		}
+		if closed && len(ring) > 2 {
+			rings = append(rings, splitRing(ring, junctions)...)
+		}
+	}
+	return rings
+}
+
+// overlayNodes merges points which are the same within the epsilon into nodes. Points are
+// looked up in a grid with the size of the epsilon, so only the neighbouring cells need to be
+// compared.
+type overlayNodes struct {
+	pts   []Point
+	cells map[[2]int64][]int
+}
+
+func (n *overlayNodes) index(pt Point) int {
+	if n.cells == nil {
+		n.cells = map[[2]int64][]int{}
+	}
+	cx, cy := int64(math.Floor(pt.X/overlayEpsilon)), int64(math.Floor(pt.Y/overlayEpsilon))
+	for dx := int64(-1); dx <= 1; dx++ {
+		for dy := int64(-1); dy <= 1; dy++ {
+			for _, i := range n.cells[[2]int64{cx + dx, cy + dy}] {
+				if pointsSame(n.pts[i], pt) {
+					return i
+				}
+			}
+		}
+	}
+	n.pts = append(n.pts, pt)
+	n.cells[[2]int64{cx, cy}] = append(n.cells[[2]int64{cx, cy}], len(n.pts)-1)
+	return len(n.pts) - 1
+}
+
+// nextSegment returns the unused segment of candidates which is the first one clockwise from
+// the segment v -> prev, the one the ring has arrived on, or -1 if there is none.
+func (n *overlayNodes) nextSegment(v, prev int, candidates, to []int, used []bool) int {
+	var (
+		back = n.angle(v, prev)
+		best = -1
+		min  float64
+	)
+	for _, c := range candidates {
+		if used[c] {
+			continue
+		}
+		d := back - n.angle(v, to[c])
+		if d <= 0 {
+			d += 2 * math.Pi
+		}
+		if best < 0 || d < min {
+			best, min = c, d
+		}
+	}
+	return best
+}
+
+func (n *overlayNodes) angle(from, to int) float64 {
+	return math.Atan2(n.pts[to].Y-n.pts[from].Y, n.pts[to].X-n.pts[from].X)
+}
+
+// removeCollinear removes the points of a ring which are located on a straight line between
+// their neighbours, except for the points in keep. The points of spikes are always removed.
+func removeCollinear(ring Line, keep map[Point]bool) Line {
+	var removable = func(p1, p2, p3 Point) bool {
+		if !pointsCollinear(p1, p2, p3) {
+			return false
+		}
+		// p2 is the tip of a spike, if p1 and p3 are on the same side of it
+		spike := (p1.X-p2.X)*(p3.X-p2.X)+(p1.Y-p2.Y)*(p3.Y-p2.Y) > 0
+		return spike || !keep[p2]
+	}
+	var res = make(Line, 0, len(ring))
+	for _, pt := range ring {
+		for len(res) > 1 && removable(res[len(res)-2], res[len(res)-1], pt) {
+			res = res[:len(res)-1]
+		}
+		res = append(res, pt)
+	}
+	// the seam
+	for len(res) > 2 && removable(res[len(res)-2], res[len(res)-1], res[0]) {
+		res = res[:len(res)-1]
+	}
+	for len(res) > 2 && removable(res[len(res)-1], res[0], res[1]) {
+		res = res[1:]
+	}
+	return res
+}
+
+func pointsSame(p1, p2 Point) bool {
+	return math.Abs(p1.X-p2.X) < overlayEpsilon && math.Abs(p1.Y-p2.Y) < overlayEpsilon
+}
+
+func pointsCompare(p1, p2 Point) int {
+	if math.Abs(p1.X-p2.X) < overlayEpsilon {
+		if math.Abs(p1.Y-p2.Y) < overlayEpsilon {
+			return 0
+		}
+		if p1.Y < p2.Y {
+			return -1
+		}
+		return 1
+	}
+	if p1.X < p2.X {
+		return -1
+	}
+	return 1
+}
+
+// The following predicates compare distances rather than plain cross products with the
+// epsilon, as otherwise short segments would always be considered parallel.
+
+func pointAboveOrOnLine(pt, left, right Point) bool {
+	var (
+		dx = right.X - left.X
+		dy = right.Y - left.Y
+	)
+	cross := dx*(pt.Y-left.Y) - dy*(pt.X-left.X)
+	return cross >= 0 || cross*cross <= overlayEpsilon*overlayEpsilon*(dx*dx+dy*dy)
+}
+
+// pointBetween reports whether p, which must be collinear, is located between left and right,
+// excluding both ends.
+func pointBetween(p, left, right Point) bool {
+	var (
+		dx     = right.X - left.X
+		dy     = right.Y - left.Y
+		length = math.Sqrt(dx*dx + dy*dy)
+		along  = ((p.X-left.X)*dx + (p.Y-left.Y)*dy) / length
+	)
+	return along >= overlayEpsilon && along-length <= -overlayEpsilon
+}
+
+func pointsCollinear(p1, p2, p3 Point) bool {
+	var (
+		dx1 = p1.X - p2.X
+		dy1 = p1.Y - p2.Y
+		dx2 = p2.X - p3.X
+		dy2 = p2.Y - p3.Y
+	)
+	return crossBelowEpsilon(dx1*dy2-dx2*dy1, dx1, dy1, dx2, dy2)
+}
+
+// crossBelowEpsilon reports whether the cross product of two vectors, divided by the length of
+// the longer one, is smaller than the epsilon.
+func crossBelowEpsilon(cross, dx1, dy1, dx2, dy2 float64) bool {
+	var (
+		l1 = dx1*dx1 + dy1*dy1
+		l2 = dx2*dx2 + dy2*dy2
+	)
+	if l2 > l1 {
+		l1 = l2
+	}
+	return cross*cross < overlayEpsilon*overlayEpsilon*l1
+}
+
+type lineIntersection struct {
+	pt Point
+	// position of the intersection along the segments: -2 before the start, -1 at the start,
+	// 0 between start and end, 1 at the end, 2 after the end
+	alongA, alongB int
+}
+
+// linesIntersect calculates the intersection of the lines through a and b. If they are
+// parallel, false is returned.
+func linesIntersect(a0, a1, b0, b1 Point) (lineIntersection, bool) {
+	var (
+		adx = a1.X - a0.X
+		ady = a1.Y - a0.Y
+		bdx = b1.X - b0.X
+		bdy = b1.Y - b0.Y
+		axb = adx*bdy - ady*bdx
+	)
+	if crossBelowEpsilon(axb, adx, ady, bdx, bdy) {
+		return lineIntersection{}, false
+	}
+	var (
+		dx = a0.X - b0.X
+		dy = a0.Y - b0.Y
+		a  = (bdx*dy - bdy*dx) / axb
+		b  = (adx*dy - ady*dx) / axb
+	)
+	var (
+		pt = Point{a0.X + a*adx, a0.Y + a*ady}
+		i  = lineIntersection{pt: pt, alongA: alongSegment(a), alongB: alongSegment(b)}
+	)
+	// Dividing a segment very close to one of its ends would create a zero-length segment.
+	switch {
+	case i.alongA == 0 && pointsSame(pt, a0):
+		i.alongA = -1
+	case i.alongA == 0 && pointsSame(pt, a1):
+		i.alongA = 1
+	}
+	switch {
+	case i.alongB == 0 && pointsSame(pt, b0):
+		i.alongB = -1
+	case i.alongB == 0 && pointsSame(pt, b1):
+		i.alongB = 1
+	}
+	return i, true
+}
+
+func alongSegment(v float64) int {
+	switch {
+	case v <= -overlayEpsilon:
+		return -2
+	case v < overlayEpsilon:
+		return -1
+	case v-1 <= -overlayEpsilon:
+		return 0
+	case v-1 < overlayEpsilon:
+		return 1
+	}
+	return 2
+}
@@ -0,0 +1,220 @@
+package spatial
+
+import (
+	"encoding/json"
+	"math"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func square(x, y, size float64) Line {
+	return Line{{x, y}, {x, y + size}, {x + size, y + size}, {x + size, y}}
+}
+
+// overlayArea calculates the planar area, holes are subtracted.
+func overlayArea(t *testing.T, g Geom) float64 {
+	var area float64
+	for _, poly := range overlayResultPolygons(t, g) {
+		area += math.Abs(poly[0].Area()) / 2
+		for _, hole := range poly[1:] {
+			area -= math.Abs(hole.Area()) / 2
+		}
+	}
+	return area
+}
+
+func overlayResultPolygons(t *testing.T, g Geom) []Polygon {
+	switch g.Typ() {
+	case GeomTypeEmpty:
+		return nil
+	case GeomTypePolygon:
+		return []Polygon{g.MustPolygon()}
+	case GeomTypeMultiPolygon:
+		return g.MustMultiPolygon()
+	}
+	t.Fatalf("unexpected type %v", g.Typ())
+	return nil
+}
+
+func assertWinding(t *testing.T, g Geom) {
+	for _, poly := range overlayResultPolygons(t, g) {
+		assert.True(t, poly[0].Clockwise(), "outer ring has wrong winding")
+		for _, hole := range poly[1:] {
+			assert.False(t, hole.Clockwise(), "hole has wrong winding")
+		}
+	}
+}
+
+func TestOverlay(t *testing.T) {
+	var (
+		a        = MustNewGeom(Polygon{square(0, 0, 2)})
+		b        = MustNewGeom(Polygon{square(1, 1, 2)})
+		adjacent = MustNewGeom(Polygon{square(2, 0, 2)})
+		disjoint = MustNewGeom(Polygon{square(10, 10, 1)})
+		inner    = MustNewGeom(Polygon{square(0.5, 0.5, 1)})
+		withHole = MustNewGeom(Polygon{square(0, 0, 4), square(1, 1, 2)})
+		multi    = MustNewGeom(MultiPolygon{{square(0, 0, 2)}, {square(10, 10, 1)}})
+	)
+	for _, tc := range []struct {
+		name  string
+		fn    func(Geom, Geom) (Geom, error)
+		a, b  Geom
+		typ   GeomType
+		parts int
+		area  float64
+	}{
+		{"union overlapping", Geom.Union, a, b, GeomTypePolygon, 1, 7},
+		{"union adjacent", Geom.Union, a, adjacent, GeomTypePolygon, 1, 8},
+		{"union disjoint", Geom.Union, a, disjoint, GeomTypeMultiPolygon, 2, 5},
+		{"union empty", Geom.Union, a, Geom{}, GeomTypePolygon, 1, 4},
+		{"union fills hole", Geom.Union, withHole, MustNewGeom(Polygon{square(1, 1, 2)}), GeomTypePolygon, 1, 16},
+		{"intersection overlapping", Geom.Intersection, a, b, GeomTypePolygon, 1, 1},
+		{"intersection disjoint", Geom.Intersection, a, disjoint, GeomTypeEmpty, 0, 0},
+		{"intersection multi", Geom.Intersection, multi, MustNewGeom(Polygon{square(1, 1, 10)}), GeomTypeMultiPolygon, 2, 2},
+		{"intersection hole", Geom.Intersection, withHole, a, GeomTypePolygon, 1, 3},
+		{"difference hole", Geom.Difference, a, inner, GeomTypePolygon, 1, 3},
+		{"difference overlapping", Geom.Difference, a, b, GeomTypePolygon, 1, 3},
+		{"difference everything", Geom.Difference, inner, a, GeomTypeEmpty, 0, 0},
+		{"symdifference", Geom.SymDifference, a, b, GeomTypeMultiPolygon, 2, 6},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := tc.fn(tc.a, tc.b)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.typ, res.Typ())
+			if tc.typ == GeomTypeMultiPolygon {
+				assert.Len(t, res.MustMultiPolygon(), tc.parts)
+			}
+			assert.InDelta(t, tc.area, overlayArea(t, res), 1e-9)
+			assertWinding(t, res)
+		})
+	}
+}
+
+func TestOverlayHoleStructure(t *testing.T) {
+	res, err := MustNewGeom(Polygon{square(0, 0, 4)}).Difference(MustNewGeom(Polygon{square(1, 1, 2)}))
+	assert.Nil(t, err)
+	poly := res.MustPolygon()
+	assert.Len(t, poly, 2)
+	assert.InDelta(t, 16, math.Abs(poly[0].Area())/2, 1e-9)
+
+	// an island in a lake in an island
+	res, err = MustNewGeom(Polygon{square(0, 0, 4), square(1, 1, 2)}).Union(MustNewGeom(Polygon{square(1.5, 1.5, 1)}))
+	assert.Nil(t, err)
+	mp := res.MustMultiPolygon()
+	assert.Len(t, mp, 2)
+	var rings int
+	for _, poly := range mp {
+		rings += len(poly)
+	}
+	assert.Equal(t, 3, rings)
+}
+
+func TestOverlayInvalidType(t *testing.T) {
+	_, err := MustNewGeom(Polygon{square(0, 0, 1)}).Union(MustNewGeom(Line{{0, 0}, {1, 1}}))
+	assert.NotNil(t, err)
+	_, err = MustNewGeom(Point{1, 1}).Intersection(MustNewGeom(Polygon{square(0, 0, 1)}))
+	assert.NotNil(t, err)
+}
+
+func TestOverlayOrdinates(t *testing.T) {
+	a := geomWithOrdinates(t, Polygon{square(0, 0, 2)}, []float64{1, 1, 1, 1}, nil)
+	b := geomWithOrdinates(t, Polygon{square(1, 1, 2)}, []float64{1, 1, 1, 1}, nil)
+	res, err := a.Union(b)
+	assert.Nil(t, err)
+	assert.Equal(t, LayoutXYZ, res.Layout())
+	for _, z := range res.Z() {
+		assert.Equal(t, 1.0, z)
+	}
+}
+
+func TestOverlayFixtureIdentities(t *testing.T) {
+	for _, fn := range []string{
+		"testfiles/polygon_with_holes.geojson",
+		"../geojson/testdata/multipolygon.geojson",
+	} {
+		t.Run(fn, func(t *testing.T) {
+			f, err := os.Open(fn)
+			assert.Nil(t, err)
+			defer f.Close()
+			var fc FeatureCollection
+			assert.Nil(t, json.NewDecoder(f).Decode(&fc))
+
+			a := fc.Features[0].Geometry
+			bb := a.BBox()
+			b := a.Copy()
+			b.Project(func(p Point) Point {
+				return Point{p.X + (bb.NE.X-bb.SW.X)/7, p.Y + (bb.NE.Y-bb.SW.Y)/5}
+			})
+
+			var areas = map[string]float64{}
+			for name, fn := range map[string]func(Geom, Geom) (Geom, error){
+				"union": Geom.Union, "intersection": Geom.Intersection,
+				"difference": Geom.Difference, "symdifference": Geom.SymDifference,
+			} {
+				res, err := fn(a, b)
+				assert.Nil(t, err)
+				assertWinding(t, res)
+				areas[name] = overlayArea(t, res)
+			}
+			aArea, bArea := overlayArea(t, a), overlayArea(t, b)
+			assert.True(t, areas["intersection"] > 0)
+			assert.InDelta(t, aArea+bArea, areas["union"]+areas["intersection"], 1e-6*aArea)
+			assert.InDelta(t, aArea-areas["intersection"], areas["difference"], 1e-6*aArea)
+			assert.InDelta(t, areas["union"]-areas["intersection"], areas["symdifference"], 1e-6*aArea)
+		})
+	}
+}
+
+// TestOverlayTouchingVertex checks that parts of the result, which touch at a single point, are
+// returned as separate rings.
+func TestOverlayTouchingVertex(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		fn   func(Geom, Geom) (Geom, error)
+		a, b string
+		// area of the result, calculated from the areas of a, b and their intersection
+		area func(a, b, inter float64) float64
+	}{
+		{
+			"union", Geom.Union,
+			"POLYGON((7 8,4 10,0 11,1 6,5 5,7 8))", "POLYGON((11 10,6 13,4 10,6 9,11 10))",
+			func(a, b, inter float64) float64 { return a + b - inter },
+		},
+		{
+			"difference", Geom.Difference,
+			"POLYGON((3 9,3 13,-1 11,-1 8,2 6,3 9))", "POLYGON((5 10,6 12,4 14,2 13,2 10,-1 8,1 7,4 5,7 7,5 10))",
+			func(a, b, inter float64) float64 { return a - inter },
+		},
+		{
+			// a vertex of a lies on an edge of b
+			"symdifference", Geom.SymDifference,
+			"POLYGON((3 8,1 6,1 7,-1 3,-1 2,3 8))", "POLYGON((0 8,2 4,4 3,0 8))",
+			func(a, b, inter float64) float64 { return a + b - 2*inter },
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var a, b Geom
+			assert.Nil(t, a.UnmarshalWKT(tc.a))
+			assert.Nil(t, b.UnmarshalWKT(tc.b))
+			res, err := tc.fn(a, b)
+			assert.Nil(t, err)
+			assert.Empty(t, res.Validate())
+			assertWinding(t, res)
+			for _, poly := range overlayResultPolygons(t, res) {
+				for _, ring := range poly {
+					var seen = map[Point]bool{}
+					for _, pt := range ring {
+						assert.False(t, seen[pt], "%v is visited twice", pt)
+						seen[pt] = true
+					}
+				}
+			}
+
+			inter, err := a.Intersection(b)
+			assert.Nil(t, err)
+			assert.InDelta(t, tc.area(overlayArea(t, a), overlayArea(t, b), overlayArea(t, inter)), overlayArea(t, res), 1e-9)
+		})
+	}
+}
@@ -0,0 +1,215 @@
+package spatial
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+const (
+	packedIndexCookie     = "SPIX"
+	packedIndexVersion    = 0
+	packedIndexHeaderSize = 24
+	packedIndexNodeSize   = 16
+)
+
+// PackedIndex is an immutable R-tree, which is bulk loaded using the Sort-Tile-Recursive
+// algorithm. Instead of features it stores a reference for each item, e.g. its position in a
+// file. The tree is kept in a flat byte slice, so it can be written to disk and memory mapped
+// back without decoding, see OpenPackedIndex.
+//
+// The serialized form consists of a header ("SPIX", version, node size and item count), the
+// bboxes of all items and nodes as four float64 and the references of items respectively the
+// position of the first child of nodes as uint64, all little endian.
+type PackedIndex struct {
+	buf      []byte
+	nodeSize int
+	numItems int
+	levels   []int
+	refsOff  int
+
+	close func() error
+}
+
+// NewPackedIndex builds an index for items with the given bboxes and references.
+func NewPackedIndex(boxes []BBox, refs []uint64) (*PackedIndex, error) {
+	if len(boxes) != len(refs) {
+		return nil, errors.New("number of bboxes and references differs")
+	}
+	var (
+		t        = newSTRTree(boxes, packedIndexNodeSize)
+		numNodes = len(t.boxes)
+		buf      = make([]byte, packedIndexHeaderSize+numNodes*40)
+		refsOff  = packedIndexHeaderSize + numNodes*32
+	)
+	copy(buf, packedIndexCookie)
+	binary.LittleEndian.PutUint32(buf[4:], packedIndexVersion)
+	binary.LittleEndian.PutUint32(buf[8:], packedIndexNodeSize)
+	binary.LittleEndian.PutUint64(buf[16:], uint64(len(boxes)))
+	for i, bb := range t.boxes {
+		o := packedIndexHeaderSize + i*32
+		binary.LittleEndian.PutUint64(buf[o:], math.Float64bits(bb.SW.X))
+		binary.LittleEndian.PutUint64(buf[o+8:], math.Float64bits(bb.SW.Y))
+		binary.LittleEndian.PutUint64(buf[o+16:], math.Float64bits(bb.NE.X))
+		binary.LittleEndian.PutUint64(buf[o+24:], math.Float64bits(bb.NE.Y))
+
+		ref := uint64(t.indices[i])
+		if i < len(boxes) {
+			ref = refs[t.indices[i]]
+		}
+		binary.LittleEndian.PutUint64(buf[refsOff+i*8:], ref)
+	}
+	return ReadPackedIndex(buf)
+}
+
+// ReadPackedIndex uses buf, which contains a serialized index, without copying it.
+func ReadPackedIndex(buf []byte) (*PackedIndex, error) {
+	if len(buf) < packedIndexHeaderSize || string(buf[:4]) != packedIndexCookie {
+		return nil, errors.New("invalid index: missing header")
+	}
+	if v := binary.LittleEndian.Uint32(buf[4:]); v > packedIndexVersion {
+		return nil, fmt.Errorf("unsupported index version %d", v)
+	}
+	var (
+		nodeSize = int(binary.LittleEndian.Uint32(buf[8:]))
+		numItems = binary.LittleEndian.Uint64(buf[16:])
+	)
+	if nodeSize < 2 || numItems > uint64(len(buf)) {
+		return nil, errors.New("invalid index: corrupt header")
+	}
+	ix := &PackedIndex{
+		buf:      buf,
+		nodeSize: nodeSize,
+		numItems: int(numItems),
+		levels:   packedLevels(int(numItems), nodeSize),
+	}
+	var numNodes int
+	if len(ix.levels) > 0 {
+		numNodes = ix.levels[len(ix.levels)-1]
+	}
+	if len(buf) != packedIndexHeaderSize+numNodes*40 {
+		return nil, fmt.Errorf("invalid index: expected %d bytes, got %d", packedIndexHeaderSize+numNodes*40, len(buf))
+	}
+	ix.refsOff = packedIndexHeaderSize + numNodes*32
+	return ix, nil
+}
+
+// packedLevels returns the end position of every level of a tree with n items, in the same
+// way as newSTRTree builds it.
+func packedLevels(n, nodeSize int) []int {
+	if n == 0 {
+		return nil
+	}
+	var (
+		levels = []int{n}
+		count  = n
+		end    = n
+	)
+	for {
+		count = (count + nodeSize - 1) / nodeSize
+		end += count
+		levels = append(levels, end)
+		if count == 1 {
+			return levels
+		}
+	}
+}
+
+// WriteTo writes the serialized index.
+func (ix *PackedIndex) WriteTo(w io.Writer) (int64, error) {
+	n, err := w.Write(ix.buf)
+	return int64(n), err
+}
+
+// Len returns the number of items.
+func (ix *PackedIndex) Len() int {
+	return ix.numItems
+}
+
+// BBox returns the bbox of all items.
+func (ix *PackedIndex) BBox() BBox {
+	if ix.numItems == 0 {
+		return BBox{}
+	}
+	return ix.box(ix.levels[len(ix.levels)-1] - 1)
+}
+
+func (ix *PackedIndex) box(i int) BBox {
+	o := packedIndexHeaderSize + i*32
+	return BBox{
+		SW: Point{ix.float(o), ix.float(o + 8)},
+		NE: Point{ix.float(o + 16), ix.float(o + 24)},
+	}
+}
+
+func (ix *PackedIndex) float(o int) float64 {
+	return math.Float64frombits(binary.LittleEndian.Uint64(ix.buf[o:]))
+}
+
+func (ix *PackedIndex) ref(i int) uint64 {
+	return binary.LittleEndian.Uint64(ix.buf[ix.refsOff+i*8:])
+}
+
+// Search calls fn with the reference of every item whose bbox intersects with bbox.
+func (ix *PackedIndex) Search(bbox BBox, fn func(ref uint64)) {
+	if ix.numItems == 0 {
+		return
+	}
+	type entry struct{ pos, level int }
+	var (
+		top   = len(ix.levels) - 1
+		stack = []entry{{pos: ix.levels[top] - 1, level: top}}
+	)
+	for len(stack) > 0 {
+		e := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		var (
+			first = int(ix.ref(e.pos))
+			last  = first + ix.nodeSize
+		)
+		if end := ix.levels[e.level-1]; last > end {
+			last = end
+		}
+		for c := first; c < last; c++ {
+			if !bboxIntersects(ix.box(c), bbox) {
+				continue
+			}
+			if e.level == 1 {
+				fn(ix.ref(c))
+				continue
+			}
+			stack = append(stack, entry{pos: c, level: e.level - 1})
+		}
+	}
+}
+
+// Close releases the memory mapping of an index which has been opened with OpenPackedIndex.
+func (ix *PackedIndex) Close() error {
+	if ix.close == nil {
+		return nil
+	}
+	err := ix.close()
+	ix.close = nil
+	ix.buf = nil
+	return err
+}
+
+// OpenPackedIndex memory maps an index file, so only the parts of the tree which are needed
+// by queries are read from disk. On platforms without mmap the file is read into memory. The
+// index must be closed after use.
+func OpenPackedIndex(path string) (*PackedIndex, error) {
+	buf, unmap, err := mmapFile(path)
+	if err != nil {
+		return nil, err
+	}
+	ix, err := ReadPackedIndex(buf)
+	if err != nil {
+		unmap()
+		return nil, err
+	}
+	ix.close = unmap
+	return ix, nil
+}
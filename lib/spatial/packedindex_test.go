@@ -0,0 +1,90 @@
+package spatial
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPackedIndex(t *testing.T) {
+	var (
+		rnd   = rand.New(rand.NewSource(1))
+		boxes []BBox
+		refs  []uint64
+	)
+	for i := 0; i < 3000; i++ {
+		x, y := rnd.Float64()*360-180, rnd.Float64()*180-90
+		boxes = append(boxes, BBox{Point{x, y}, Point{x + rnd.Float64(), y + rnd.Float64()}})
+		refs = append(refs, uint64(i)*7+1)
+	}
+
+	dir, err := ioutil.TempDir("", "packedindex")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	for _, n := range []int{0, 1, 16, 17, 3000} {
+		ix, err := NewPackedIndex(boxes[:n], refs[:n])
+		assert.Nil(t, err)
+		assert.Equal(t, n, ix.Len())
+
+		var buf bytes.Buffer
+		_, err = ix.WriteTo(&buf)
+		assert.Nil(t, err)
+		path := filepath.Join(dir, "index")
+		assert.Nil(t, ioutil.WriteFile(path, buf.Bytes(), 0644))
+		mix, err := OpenPackedIndex(path)
+		assert.Nil(t, err)
+
+		if n > 0 {
+			var bb = boxes[0]
+			for _, b := range boxes[1:n] {
+				bb.ExtendWith(b)
+			}
+			assert.Equal(t, bb, mix.BBox())
+		}
+
+		for i := 0; i < 50; i++ {
+			x, y := rnd.Float64()*360-180, rnd.Float64()*180-90
+			q := BBox{Point{x, y}, Point{x + rnd.Float64()*20, y + rnd.Float64()*20}}
+
+			var expected []uint64
+			for j, bb := range boxes[:n] {
+				if bboxIntersects(bb, q) {
+					expected = append(expected, refs[j])
+				}
+			}
+			for _, idx := range []*PackedIndex{ix, mix} {
+				var found []uint64
+				idx.Search(q, func(ref uint64) { found = append(found, ref) })
+				sort.Slice(found, func(a, b int) bool { return found[a] < found[b] })
+				assert.Equal(t, expected, found)
+			}
+		}
+		assert.Nil(t, mix.Close())
+	}
+}
+
+func TestReadPackedIndexInvalid(t *testing.T) {
+	ix, err := NewPackedIndex([]BBox{{Point{1, 2}, Point{3, 4}}}, []uint64{5})
+	assert.Nil(t, err)
+	var buf bytes.Buffer
+	ix.WriteTo(&buf)
+
+	_, err = ReadPackedIndex(buf.Bytes()[:buf.Len()-1])
+	assert.NotNil(t, err)
+	_, err = ReadPackedIndex([]byte("SPAT"))
+	assert.NotNil(t, err)
+	_, err = NewPackedIndex([]BBox{{}}, nil)
+	assert.NotNil(t, err)
+
+	corrupt := append([]byte{}, buf.Bytes()...)
+	corrupt[16] = 0xff
+	_, err = ReadPackedIndex(corrupt)
+	assert.NotNil(t, err)
+}
@@ -16,6 +16,10 @@ func (p *Point) Project(proj ConvertFunc) {
 }
 
 func (p Point) InBBox(b BBox) bool {
+	if b.Wrapped() {
+		return (b.SW.X <= p.X || b.NE.X >= p.X) &&
+			b.SW.Y <= p.Y && b.NE.Y >= p.Y
+	}
 	return b.SW.X <= p.X && b.NE.X >= p.X &&
 		b.SW.Y <= p.Y && b.NE.Y >= p.Y
 }
@@ -126,6 +130,10 @@ func degToRad(deg float64) float64 {
 	return deg * math.Pi / 180
 }
 
+func radToDeg(rad float64) float64 {
+	return rad * 180 / math.Pi
+}
+
 func round(v float64) float64 {
 	if v < 0 {
 		return math.Ceil(v - 0.5)
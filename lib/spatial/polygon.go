@@ -23,6 +23,17 @@ func (p Polygon) String() string {
 	return p.string()
 }
 
+func (p Polygon) BBox() BBox {
+	if len(p) == 0 {
+		return BBox{}
+	}
+	var bb = p[0].BBox()
+	for _, ring := range p[1:] {
+		bb.ExtendWith(ring.BBox())
+	}
+	return bb
+}
+
 func (p Polygon) ClipToBBox(bbox BBox) []Geom {
 	// Speed-ups for common cases to eliminate the need for calling geos.
 	if len(p) == 1 && len(p[0].Intersections(bbox.Segments())) == 0 {
@@ -39,6 +50,23 @@ func (p Polygon) ClipToBBox(bbox BBox) []Geom {
 	return p.clipToBBox(bbox)
 }
 
+// Simplify returns a simplified copy of the Polygon. Holes that collapse to less than three points
+// are removed, if the outer ring collapses, the result is empty.
+func (p Polygon) Simplify(e float64) Polygon {
+	var sp = make(Polygon, 0, len(p))
+	for n, ring := range p {
+		sr := ring.Simplify(e)
+		if len(sr) < 3 {
+			if n == 0 {
+				return nil
+			}
+			continue
+		}
+		sp = append(sp, sr)
+	}
+	return sp
+}
+
 func (p Polygon) Rewind() {
 	for _, ring := range p {
 		ring.Reverse()
@@ -46,12 +74,20 @@ func (p Polygon) Rewind() {
 }
 
 func (p Polygon) FixWinding() {
+	for n, wrong := range p.wrongWinding() {
+		if wrong {
+			p[n].Reverse()
+		}
+	}
+}
+
+// wrongWinding reports for every ring whether it needs to be reversed.
+func (p Polygon) wrongWinding() []bool {
+	var wrong = make([]bool, len(p))
 	for n, ring := range p {
 		if n == 0 {
 			// First ring must be outer and therefore clockwise.
-			if !ring.Clockwise() {
-				ring.Reverse()
-			}
+			wrong[n] = !ring.Clockwise()
 			continue
 		}
 		// Compare in how many rings the point is located.
@@ -65,10 +101,9 @@ func (p Polygon) FixWinding() {
 				inrings++
 			}
 		}
-		if (inrings%2 == 0 && !ring.Clockwise()) || (inrings%2 == 1 && ring.Clockwise()) {
-			ring.Reverse()
-		}
+		wrong[n] = (inrings%2 == 0 && !ring.Clockwise()) || (inrings%2 == 1 && ring.Clockwise())
 	}
+	return wrong
 }
 
 func (p Polygon) ValidTopology() bool {
@@ -0,0 +1,550 @@
+package spatial
+
+import (
+	"math"
+	"sort"
+)
+
+// relateEpsilon is the distance, relative to the magnitude of the coordinates, up to which a
+// point is considered to be located on an edge. This compensates the rounding of computed
+// intersection points.
+const relateEpsilon = 1e-12
+
+// Locations of points relative to a geometry, which are the rows and columns of the
+// IntersectionMatrix.
+const (
+	locInterior = iota
+	locBoundary
+	locExterior
+)
+
+// IntersectionMatrix is a Dimensionally Extended 9-Intersection Model (DE-9IM) matrix, which
+// describes the relationship between two geometries. Rows are the interior, boundary and
+// exterior of the first geometry, columns the ones of the second geometry. Every entry is the
+// dimension of the intersection of both sets: -1 if they don't intersect, 0 for points, 1 for
+// lines and 2 for areas.
+type IntersectionMatrix [3][3]int
+
+// String returns the matrix in the usual notation, e.g. "FF2F11212", with F for empty
+// intersections.
+func (im IntersectionMatrix) String() string {
+	var buf = make([]byte, 0, 9)
+	for _, row := range im {
+		for _, dim := range row {
+			if dim < 0 {
+				buf = append(buf, 'F')
+				continue
+			}
+			buf = append(buf, byte('0'+dim))
+		}
+	}
+	return string(buf)
+}
+
+// Matches reports whether the matrix matches a pattern of nine characters, in the order of
+// String. The characters are T (intersecting), F (not intersecting), * (anything) or a
+// dimension (0, 1, 2).
+func (im IntersectionMatrix) Matches(pattern string) bool {
+	if len(pattern) != 9 {
+		return false
+	}
+	for i := 0; i < 9; i++ {
+		dim := im[i/3][i%3]
+		switch c := pattern[i]; c {
+		case '*':
+		case 'T', 't':
+			if dim < 0 {
+				return false
+			}
+		case 'F', 'f':
+			if dim >= 0 {
+				return false
+			}
+		case '0', '1', '2':
+			if dim != int(c-'0') {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func (im *IntersectionMatrix) set(locA, locB, dim int) {
+	if im[locA][locB] < dim {
+		im[locA][locB] = dim
+	}
+}
+
+// Relate calculates the DE-9IM matrix of g and other. Geometries of all types can be related,
+// collections are treated as the union of their members, where the interior of polygons takes
+// precedence over their boundaries and over lines. The boundary of lines are their end points,
+// if they are shared by an odd number of lines (mod-2 rule); closed lines have no boundary.
+//
+// Geometries need to be valid, see Validate. Points closer to an edge than a fraction of the
+// magnitude of the coordinates are considered to be located on it.
+func (g Geom) Relate(other Geom) IntersectionMatrix {
+	im, _, _ := relate(g, other)
+	return im
+}
+
+// relate returns the matrix and the dimensions of both geometries.
+func relate(g, other Geom) (im IntersectionMatrix, dimA, dimB int) {
+	var (
+		a, b = newRelateGeom(g), newRelateGeom(other)
+		tol  = math.Max(a.magnitude(), b.magnitude()) * relateEpsilon
+	)
+	a.prepare(tol)
+	b.prepare(tol)
+
+	for i := range im {
+		for j := range im[i] {
+			im[i][j] = -1
+		}
+	}
+	im[locExterior][locExterior] = 2
+
+	splitsA, splitsB, nodes := nodeEdges(a.edges, b.edges)
+	a.splitAtPoints(splitsA, b.points)
+	b.splitAtPoints(splitsB, a.points)
+	relateEdges(&im, a, b, splitsA, false)
+	relateEdges(&im, b, a, splitsB, true)
+
+	nodes = append(nodes, a.vertices...)
+	nodes = append(nodes, b.vertices...)
+	var seen = make(map[Point]bool, len(nodes))
+	for _, pt := range nodes {
+		if seen[pt] {
+			continue
+		}
+		seen[pt] = true
+		locA, _, _ := a.locate(pt)
+		locB, _, _ := b.locate(pt)
+		if locA != locExterior || locB != locExterior {
+			im.set(locA, locB, 0)
+		}
+	}
+	return im, a.dim, b.dim
+}
+
+// Intersects reports whether the geometries share at least one point.
+func (g Geom) Intersects(other Geom) bool {
+	return !g.Disjoint(other)
+}
+
+// Disjoint reports whether the geometries have no point in common.
+func (g Geom) Disjoint(other Geom) bool {
+	return g.Relate(other).Matches("FF*FF****")
+}
+
+// Contains reports whether no point of other is located in the exterior of g and at least one
+// point of the interior of other is located in the interior of g. Geometries don't contain
+// their boundary, see Covers.
+func (g Geom) Contains(other Geom) bool {
+	return g.Relate(other).Matches("T*****FF*")
+}
+
+// Within reports whether g is contained by other, see Contains.
+func (g Geom) Within(other Geom) bool {
+	return other.Contains(g)
+}
+
+// Covers reports whether no point of other is located in the exterior of g. Unlike Contains,
+// this is also true if other is located on the boundary of g.
+func (g Geom) Covers(other Geom) bool {
+	im := g.Relate(other)
+	return im.Matches("T*****FF*") || im.Matches("*T****FF*") ||
+		im.Matches("***T**FF*") || im.Matches("****T*FF*")
+}
+
+// CoveredBy reports whether g is covered by other, see Covers.
+func (g Geom) CoveredBy(other Geom) bool {
+	return other.Covers(g)
+}
+
+// Touches reports whether the geometries have at least one point in common, but their
+// interiors don't intersect.
+func (g Geom) Touches(other Geom) bool {
+	im := g.Relate(other)
+	return im.Matches("FT*******") || im.Matches("F**T*****") || im.Matches("F***T****")
+}
+
+// Crosses reports whether the interiors of the geometries intersect in a set with a lower
+// dimension than the higher dimension of both, e.g. two lines which intersect in a point or a
+// line which is partly located inside of a polygon. Geometries of the same dimension other
+// than lines don't cross.
+func (g Geom) Crosses(other Geom) bool {
+	im, dimA, dimB := relate(g, other)
+	switch {
+	case dimA == 1 && dimB == 1:
+		return im.Matches("0********")
+	case dimA < dimB:
+		return im.Matches("T*T******")
+	case dimA > dimB:
+		return im.Matches("T*****T**")
+	}
+	return false
+}
+
+// relateEdge is a segment of a line or of a polygon ring.
+type relateEdge struct {
+	seg  Segment
+	ring bool
+	poly int // index of the polygon, for ring edges
+	// interiorLeft is set for ring edges, if the interior of the polygon is located left of
+	// the edge, looking from its first to its second point.
+	interiorLeft bool
+}
+
+// relateGeom is a geometry split into its components, so that points can be located.
+type relateGeom struct {
+	dim      int
+	edges    []relateEdge
+	vertices []Point
+	points   map[Point]bool
+	lineEnds map[Point]int // number of non-closed lines ending at a point
+	polys    int
+
+	tol    float64
+	minY   float64
+	height float64 // of a strip
+	strips [][]int // edges which cover the Y range of a strip
+}
+
+func newRelateGeom(g Geom) *relateGeom {
+	r := &relateGeom{dim: -1, points: map[Point]bool{}, lineEnds: map[Point]int{}}
+	r.add(g)
+	return r
+}
+
+func (r *relateGeom) add(g Geom) {
+	switch gm := g.g.(type) {
+	case *Point:
+		r.addPoint(*gm)
+	case MultiPoint:
+		for _, pt := range gm {
+			r.addPoint(pt)
+		}
+	case Line:
+		r.addLine(gm)
+	case MultiLine:
+		for _, ln := range gm {
+			r.addLine(ln)
+		}
+	case Polygon:
+		r.addPolygon(gm)
+	case MultiPolygon:
+		for _, poly := range gm {
+			r.addPolygon(poly)
+		}
+	case GeomCollection:
+		for _, m := range gm {
+			r.add(m)
+		}
+	}
+}
+
+func (r *relateGeom) addPoint(pt Point) {
+	r.points[pt] = true
+	r.vertices = append(r.vertices, pt)
+	if r.dim < 0 {
+		r.dim = 0
+	}
+}
+
+func (r *relateGeom) addLine(ln Line) {
+	ln = withoutDuplicates(ln, false)
+	switch len(ln) {
+	case 0:
+		return
+	case 1:
+		r.addPoint(ln[0])
+		return
+	}
+	for _, seg := range ln.Segments() {
+		r.edges = append(r.edges, relateEdge{seg: seg})
+	}
+	r.vertices = append(r.vertices, ln...)
+	if ln[0] != ln[len(ln)-1] {
+		r.lineEnds[ln[0]]++
+		r.lineEnds[ln[len(ln)-1]]++
+	}
+	if r.dim < 1 {
+		r.dim = 1
+	}
+}
+
+func (r *relateGeom) addPolygon(poly Polygon) {
+	for n, ring := range poly {
+		ring = withoutDuplicates(ring, true)
+		if len(ring) < 3 {
+			continue
+		}
+		interiorLeft := (ring.Area() > 0) == (n == 0)
+		for _, seg := range ring.SegmentsWithClosing() {
+			r.edges = append(r.edges, relateEdge{seg: seg, ring: true, poly: r.polys, interiorLeft: interiorLeft})
+		}
+		r.vertices = append(r.vertices, ring...)
+		r.dim = 2
+	}
+	r.polys++
+}
+
+func (r *relateGeom) magnitude() float64 {
+	var m float64
+	for _, pt := range r.vertices {
+		m = math.Max(m, math.Max(math.Abs(pt.X), math.Abs(pt.Y)))
+	}
+	return m
+}
+
+// prepare builds an index of horizontal strips, so that only the edges of one strip need to be
+// checked when locating a point. Edges are added to all strips which are within tol of them.
+func (r *relateGeom) prepare(tol float64) {
+	r.tol = tol
+	if len(r.edges) == 0 {
+		return
+	}
+	var (
+		minY = math.Inf(1)
+		maxY = math.Inf(-1)
+	)
+	for _, e := range r.edges {
+		minY = math.Min(minY, math.Min(e.seg[0].Y, e.seg[1].Y))
+		maxY = math.Max(maxY, math.Max(e.seg[0].Y, e.seg[1].Y))
+	}
+	r.minY = minY - tol
+	n := int(math.Sqrt(float64(len(r.edges)))) + 1
+	r.height = (maxY + tol - r.minY) / float64(n)
+	if r.height == 0 {
+		n = 1
+	}
+	r.strips = make([][]int, n)
+	for i, e := range r.edges {
+		from := r.strip(math.Min(e.seg[0].Y, e.seg[1].Y) - tol)
+		to := r.strip(math.Max(e.seg[0].Y, e.seg[1].Y) + tol)
+		for s := from; s <= to; s++ {
+			r.strips[s] = append(r.strips[s], i)
+		}
+	}
+}
+
+func (r *relateGeom) strip(y float64) int {
+	if r.height == 0 {
+		return 0
+	}
+	s := int((y - r.minY) / r.height)
+	if s < 0 {
+		return 0
+	}
+	if s >= len(r.strips) {
+		return len(r.strips) - 1
+	}
+	return s
+}
+
+// locate returns the location of the point relative to the geometry and relative to its
+// polygons only. If the point is located on the boundary of a polygon, one of the ring edges
+// it is located on is returned as well.
+func (r *relateGeom) locate(pt Point) (loc, areaLoc int, on *relateEdge) {
+	var (
+		onLine   bool
+		inside   map[int]bool // parity of ray crossings per polygon
+		boundary map[int]bool // polygons on whose boundary the point is located
+	)
+	if len(r.strips) != 0 && pt.Y >= r.minY && pt.Y <= r.minY+r.height*float64(len(r.strips)) {
+		for _, i := range r.strips[r.strip(pt.Y)] {
+			e := &r.edges[i]
+			if e.seg.DistanceToPt(pt) <= r.tol {
+				if !e.ring {
+					onLine = true
+					continue
+				}
+				if boundary == nil {
+					boundary = map[int]bool{}
+				}
+				boundary[e.poly] = true
+				on = e
+				continue
+			}
+			a, b := e.seg[0], e.seg[1]
+			if e.ring && (a.Y > pt.Y) != (b.Y > pt.Y) && pt.X < (b.X-a.X)*(pt.Y-a.Y)/(b.Y-a.Y)+a.X {
+				if inside == nil {
+					inside = map[int]bool{}
+				}
+				inside[e.poly] = !inside[e.poly]
+			}
+		}
+	}
+
+	areaLoc = locExterior
+	for poly, in := range inside {
+		if in && !boundary[poly] {
+			return locInterior, locInterior, nil
+		}
+	}
+	if len(boundary) != 0 {
+		return locBoundary, locBoundary, on
+	}
+	if r.lineEnds[pt]%2 == 1 {
+		return locBoundary, areaLoc, nil
+	}
+	if onLine || r.points[pt] || r.lineEnds[pt] != 0 {
+		return locInterior, areaLoc, nil
+	}
+	return locExterior, areaLoc, nil
+}
+
+// nodeEdges calculates the points at which the edges of a have to be split, so that they
+// either don't intersect the edges of b or are located on them, and vice versa. It also returns
+// all intersection points. Edges are sorted by their minimum X, as in selfIntersections.
+func nodeEdges(a, b []relateEdge) (splitsA, splitsB [][]Point, nodes []Point) {
+	type sortedEdge struct {
+		seg        Segment
+		idx        int
+		fromA      bool
+		minX, maxX float64
+	}
+	var sorted = make([]sortedEdge, 0, len(a)+len(b))
+	for i, e := range a {
+		sorted = append(sorted, sortedEdge{e.seg, i, true, math.Min(e.seg[0].X, e.seg[1].X), math.Max(e.seg[0].X, e.seg[1].X)})
+	}
+	for i, e := range b {
+		sorted = append(sorted, sortedEdge{e.seg, i, false, math.Min(e.seg[0].X, e.seg[1].X), math.Max(e.seg[0].X, e.seg[1].X)})
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].minX < sorted[j].minX })
+
+	splitsA = make([][]Point, len(a))
+	splitsB = make([][]Point, len(b))
+	for i, s := range sorted {
+		for _, o := range sorted[i+1:] {
+			if o.minX > s.maxX {
+				break
+			}
+			if s.fromA == o.fromA {
+				continue
+			}
+			ea, eb := s, o
+			if !s.fromA {
+				ea, eb = o, s
+			}
+			pt, kind := segmentIntersection(ea.seg, eb.seg)
+			switch kind {
+			case intersectNone:
+				continue
+			case intersectOverlap:
+				// the overlapping part is delimited by end points of both segments
+				for _, p := range eb.seg {
+					if onSegment(p, ea.seg[0], ea.seg[1]) {
+						splitsA[ea.idx] = append(splitsA[ea.idx], p)
+						nodes = append(nodes, p)
+					}
+				}
+				for _, p := range ea.seg {
+					if onSegment(p, eb.seg[0], eb.seg[1]) {
+						splitsB[eb.idx] = append(splitsB[eb.idx], p)
+						nodes = append(nodes, p)
+					}
+				}
+			case intersectCross:
+				// segmentIntersection rounds the point, which would move it off the segments
+				pt = crossingPoint(ea.seg, eb.seg)
+				fallthrough
+			default:
+				splitsA[ea.idx] = append(splitsA[ea.idx], pt)
+				splitsB[eb.idx] = append(splitsB[eb.idx], pt)
+				nodes = append(nodes, pt)
+			}
+		}
+	}
+	return splitsA, splitsB, nodes
+}
+
+// splitAtPoints adds the points which are located on edges to their splits, so that the
+// location of the remaining parts of the edges can be determined by their centers.
+func (r *relateGeom) splitAtPoints(splits [][]Point, pts map[Point]bool) {
+	if len(r.strips) == 0 {
+		return
+	}
+	for pt := range pts {
+		for _, i := range r.strips[r.strip(pt.Y)] {
+			if r.edges[i].seg.DistanceToPt(pt) <= r.tol {
+				splits[i] = append(splits[i], pt)
+			}
+		}
+	}
+}
+
+// crossingPoint returns the intersection of two segments which cross each other.
+func crossingPoint(a, b Segment) Point {
+	var (
+		dA  = Point{a[1].X - a[0].X, a[1].Y - a[0].Y}
+		dB  = Point{b[1].X - b[0].X, b[1].Y - b[0].Y}
+		det = dA.X*dB.Y - dA.Y*dB.X
+		t   = ((b[0].X-a[0].X)*dB.Y - (b[0].Y-a[0].Y)*dB.X) / det
+	)
+	return Point{a[0].X + t*dA.X, a[0].Y + t*dA.Y}
+}
+
+// subSegments splits the segment at the given points.
+func subSegments(seg Segment, splits []Point) []Segment {
+	if len(splits) == 0 {
+		return []Segment{seg}
+	}
+	sort.Slice(splits, func(i, j int) bool { return sqDist(seg[0], splits[i]) < sqDist(seg[0], splits[j]) })
+	var (
+		segs []Segment
+		prev = seg[0]
+	)
+	for _, pt := range append(splits, seg[1]) {
+		if pt == prev || pt == seg[0] {
+			continue
+		}
+		segs = append(segs, Segment{prev, pt})
+		prev = pt
+	}
+	return segs
+}
+
+// relateEdges adds the intersections of the edges of x with the other geometry y to the matrix.
+// If swapped is set, x is the second geometry of the matrix.
+func relateEdges(im *IntersectionMatrix, x, y *relateGeom, splits [][]Point, swapped bool) {
+	set := func(locX, locY, dim int) {
+		if swapped {
+			locX, locY = locY, locX
+		}
+		im.set(locX, locY, dim)
+	}
+	for i, e := range x.edges {
+		for _, seg := range subSegments(e.seg, splits[i]) {
+			mid := Point{(seg[0].X + seg[1].X) / 2, (seg[0].Y + seg[1].Y) / 2}
+			loc, areaLoc, on := y.locate(mid)
+			if !e.ring {
+				set(locInterior, loc, 1)
+				continue
+			}
+			set(locBoundary, loc, 1)
+
+			// The areas on both sides of the edge tell how the interiors relate.
+			switch areaLoc {
+			case locInterior:
+				set(locInterior, locInterior, 2)
+				set(locExterior, locInterior, 2)
+			case locExterior:
+				set(locInterior, locExterior, 2)
+			case locBoundary:
+				var (
+					sameDir = (seg[1].X-seg[0].X)*(on.seg[1].X-on.seg[0].X)+(seg[1].Y-seg[0].Y)*(on.seg[1].Y-on.seg[0].Y) > 0
+					yLeft   = on.interiorLeft == sameDir
+				)
+				if e.interiorLeft == yLeft {
+					set(locInterior, locInterior, 2)
+				} else {
+					set(locInterior, locExterior, 2)
+					set(locExterior, locInterior, 2)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package spatial
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRelate(t *testing.T) {
+	var (
+		ccwSquare = Line{{1, 1}, {3, 1}, {3, 3}, {1, 3}}
+		withHole  = MustNewGeom(Polygon{square(0, 0, 10), square(2, 2, 4)})
+	)
+	for _, tc := range []struct {
+		name   string
+		a, b   Geom
+		matrix string
+	}{
+		{"overlapping polygons", MustNewGeom(Polygon{square(0, 0, 2)}), MustNewGeom(Polygon{square(1, 1, 2)}), "212101212"},
+		{"overlapping polygons with mixed winding", MustNewGeom(Polygon{square(0, 0, 2)}), MustNewGeom(Polygon{ccwSquare}), "212101212"},
+		{"adjacent polygons", MustNewGeom(Polygon{square(0, 0, 2)}), MustNewGeom(Polygon{square(2, 0, 2)}), "FF2F11212"},
+		{"polygons touching in corner", MustNewGeom(Polygon{square(0, 0, 2)}), MustNewGeom(Polygon{square(2, 2, 2)}), "FF2F01212"},
+		{"contained polygon", MustNewGeom(Polygon{square(0, 0, 4)}), MustNewGeom(Polygon{square(1, 1, 1)}), "212FF1FF2"},
+		{"contained polygon sharing edge", MustNewGeom(Polygon{square(0, 0, 4)}), MustNewGeom(Polygon{square(0, 0, 1)}), "212F11FF2"},
+		{"equal polygons", MustNewGeom(Polygon{square(0, 0, 2)}), MustNewGeom(Polygon{square(0, 0, 2)}), "2FFF1FFF2"},
+		{"disjoint polygons", MustNewGeom(Polygon{square(0, 0, 2)}), MustNewGeom(Polygon{square(5, 5, 2)}), "FF2FF1212"},
+		{"polygon in hole", withHole, MustNewGeom(Polygon{square(3, 3, 1)}), "FF2FF1212"},
+		{"polygon filling hole", withHole, MustNewGeom(Polygon{square(2, 2, 4)}), "FF2F112F2"},
+		{"multi polygon", MustNewGeom(MultiPolygon{{square(0, 0, 2)}, {square(5, 0, 2)}}), MustNewGeom(Polygon{square(5, 0, 2)}), "2F2F11FF2"},
+
+		{"line crossing polygon", MustNewGeom(Line{{-1, 1}, {3, 1}}), MustNewGeom(Polygon{square(0, 0, 2)}), "101FF0212"},
+		{"line in polygon", MustNewGeom(Line{{0.5, 1}, {1.5, 1}}), MustNewGeom(Polygon{square(0, 0, 2)}), "1FF0FF212"},
+		{"line on polygon boundary", MustNewGeom(Line{{0, 0}, {2, 0}}), MustNewGeom(Polygon{square(0, 0, 2)}), "F1FF0F212"},
+		{"line touching polygon", MustNewGeom(Line{{2, 1}, {4, 1}}), MustNewGeom(Polygon{square(0, 0, 2)}), "FF1F00212"},
+		{"crossing lines", MustNewGeom(Line{{0, 0}, {2, 2}}), MustNewGeom(Line{{0, 2}, {2, 0}}), "0F1FF0102"},
+		{"overlapping lines", MustNewGeom(Line{{0, 0}, {2, 0}}), MustNewGeom(Line{{1, 0}, {3, 0}}), "1010F0102"},
+		{"touching lines", MustNewGeom(Line{{0, 0}, {2, 0}}), MustNewGeom(Line{{2, 0}, {2, 2}}), "FF1F00102"},
+		{"closed line", MustNewGeom(Line{{0, 0}, {2, 0}, {2, 2}, {0, 0}}), MustNewGeom(Line{{0, 0}, {-1, -1}}), "F01FFF102"},
+		{"multi line boundary", MustNewGeom(MultiLine{{{0, 0}, {1, 0}}, {{1, 0}, {2, 0}}}), MustNewGeom(Point{1, 0}), "0F1FF0FF2"},
+
+		{"point in polygon", MustNewGeom(Point{1, 1}), MustNewGeom(Polygon{square(0, 0, 2)}), "0FFFFF212"},
+		{"point on polygon boundary", MustNewGeom(Point{2, 1}), MustNewGeom(Polygon{square(0, 0, 2)}), "F0FFFF212"},
+		{"point outside of polygon", MustNewGeom(Point{3, 1}), MustNewGeom(Polygon{square(0, 0, 2)}), "FF0FFF212"},
+		{"point in hole", MustNewGeom(Point{4, 4}), withHole, "FF0FFF212"},
+		{"point at line end", MustNewGeom(Point{0, 0}), MustNewGeom(Line{{0, 0}, {1, 1}}), "F0FFFF102"},
+		{"point on line", MustNewGeom(Point{0.5, 0.5}), MustNewGeom(Line{{0, 0}, {1, 1}}), "0FFFFF102"},
+		{"multi point", MustNewGeom(MultiPoint{{0, 0}, {5, 5}}), MustNewGeom(Point{0, 0}), "0F0FFFFF2"},
+
+		{"collection", MustNewGeom(GeomCollection{MustNewGeom(Polygon{square(0, 0, 2)}), MustNewGeom(Line{{1, 1}, {5, 1}})}), MustNewGeom(Point{1, 1}), "0F2FF1FF2"},
+		{"empty", Geom{}, MustNewGeom(Polygon{square(0, 0, 2)}), "FFFFFF212"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.matrix, tc.a.Relate(tc.b).String())
+			// the matrix of the reversed relation is transposed
+			var (
+				m  = tc.matrix
+				tr = string([]byte{m[0], m[3], m[6], m[1], m[4], m[7], m[2], m[5], m[8]})
+			)
+			assert.Equal(t, tr, tc.b.Relate(tc.a).String())
+		})
+	}
+}
+
+func TestRelateComputedIntersections(t *testing.T) {
+	// the intersection points can't be represented exactly
+	var (
+		a = MustNewGeom(Polygon{{{0.1, 0.1}, {0.7, 0.3}, {0.3, 0.9}}})
+		b = MustNewGeom(Polygon{{{0.5, 0.1}, {0.9, 0.7}, {0.2, 0.6}}})
+	)
+	assert.Equal(t, "212101212", a.Relate(b).String())
+	assert.Equal(t, "212101212", b.Relate(a).String())
+
+	ln := MustNewGeom(Line{{0.1, 0.2}, {0.7, 0.9}})
+	assert.True(t, ln.Crosses(a))
+	assert.True(t, ln.Crosses(MustNewGeom(Line{{0.3, 0.1}, {0.2, 0.8}})))
+}
+
+func TestIntersectionMatrixMatches(t *testing.T) {
+	im := MustNewGeom(Polygon{square(0, 0, 2)}).Relate(MustNewGeom(Polygon{square(1, 1, 2)}))
+	assert.True(t, im.Matches("T*T***T**"))
+	assert.True(t, im.Matches("2121012*2"))
+	assert.False(t, im.Matches("F********"))
+	assert.False(t, im.Matches("1********"))
+	assert.False(t, im.Matches("T*T"))
+	assert.False(t, im.Matches("X********"))
+}
+
+func TestPredicates(t *testing.T) {
+	var (
+		big      = MustNewGeom(Polygon{square(0, 0, 4)})
+		small    = MustNewGeom(Polygon{square(1, 1, 1)})
+		corner   = MustNewGeom(Polygon{square(0, 0, 1)})
+		adjacent = MustNewGeom(Polygon{square(4, 0, 1)})
+		far      = MustNewGeom(Polygon{square(10, 10, 1)})
+		crossing = MustNewGeom(Line{{-1, 2}, {5, 2}})
+		edge     = MustNewGeom(Line{{0, 0}, {0, 4}})
+		pt       = MustNewGeom(Point{2, 2})
+	)
+
+	assert.True(t, big.Intersects(small))
+	assert.True(t, big.Intersects(adjacent))
+	assert.False(t, big.Intersects(far))
+	assert.True(t, big.Disjoint(far))
+	assert.True(t, big.Disjoint(Geom{}))
+
+	assert.True(t, big.Contains(small))
+	assert.True(t, big.Contains(corner))
+	assert.True(t, big.Contains(pt))
+	assert.False(t, big.Contains(edge))
+	assert.False(t, big.Contains(crossing))
+	assert.False(t, small.Contains(big))
+	assert.False(t, big.Contains(Geom{}))
+
+	assert.True(t, small.Within(big))
+	assert.True(t, pt.Within(big))
+	assert.False(t, big.Within(small))
+
+	assert.True(t, big.Covers(edge))
+	assert.True(t, big.Covers(corner))
+	assert.False(t, big.Covers(crossing))
+	assert.True(t, edge.CoveredBy(big))
+
+	assert.True(t, big.Touches(adjacent))
+	assert.True(t, edge.Touches(big))
+	assert.False(t, big.Touches(small))
+	assert.False(t, big.Touches(far))
+
+	assert.True(t, crossing.Crosses(big))
+	assert.True(t, big.Crosses(crossing))
+	assert.False(t, edge.Crosses(big))
+	assert.True(t, crossing.Crosses(MustNewGeom(Line{{2, 0}, {2, 4}})))
+	assert.False(t, crossing.Crosses(MustNewGeom(Line{{0, 2}, {2, 2}})))
+	assert.False(t, big.Crosses(small))
+	assert.True(t, MustNewGeom(MultiPoint{{2, 2}, {8, 8}}).Crosses(big))
+}
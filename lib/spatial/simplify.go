@@ -0,0 +1,350 @@
+package spatial
+
+import (
+	"container/heap"
+	"math"
+)
+
+// SimplifyMethod selects the algorithm which is used by Geom.SimplifyWith.
+type SimplifyMethod uint8
+
+const (
+	// SimplifyDouglasPeucker keeps the points which are farther away than the tolerance from the
+	// line between the other kept points (Ramer-Douglas-Peucker).
+	SimplifyDouglasPeucker SimplifyMethod = iota
+	// SimplifyVisvalingam removes the point that forms the smallest triangle with its neighbours
+	// until all triangles are larger than the square of the tolerance (Visvalingam-Whyatt).
+	SimplifyVisvalingam
+	// SimplifyTopology works like SimplifyVisvalingam, but never removes a point if the lines or
+	// rings of the geometry would intersect each other afterwards or if a ring would have less
+	// than three points.
+	SimplifyTopology
+)
+
+// simplifyVW simplifies all lines and rings of the geometry with the Visvalingam-Whyatt
+// algorithm. In topology mode, they are simplified together, so that they don't cross.
+func (g Geom) simplifyVW(e float64, topology bool) Geom {
+	var sg Geom
+	switch gm := g.g.(type) {
+	case Line:
+		sg = Geom{typ: g.typ, g: newVWSimplifier([]Line{gm}, false, topology).run(e * e)[0]}
+	case MultiLine:
+		sg = Geom{typ: g.typ, g: MultiLine(newVWSimplifier(gm, false, topology).run(e * e))}
+	case Polygon:
+		// collapsed polygons are empty, like in Polygon.Simplify
+		var sp Polygon
+		if mp := vwPolygons(MultiPolygon{gm}, e, topology); len(mp) > 0 {
+			sp = mp[0]
+		}
+		sg = Geom{typ: g.typ, g: sp}
+	case MultiPolygon:
+		sg = Geom{typ: g.typ, g: vwPolygons(gm, e, topology)}
+	case GeomCollection:
+		var sgc = make(GeomCollection, 0, len(gm))
+		for _, m := range gm {
+			sgc = append(sgc, m.simplifyVW(e, topology))
+		}
+		return Geom{typ: g.typ, g: sgc}
+	default:
+		return g
+	}
+	sg.inheritOrdinates(g)
+	return sg
+}
+
+func vwPolygons(mp MultiPolygon, e float64, topology bool) MultiPolygon {
+	var rings []Line
+	for _, poly := range mp {
+		rings = append(rings, poly...)
+	}
+	simplified := newVWSimplifier(rings, true, topology).run(e * e)
+
+	var smp = make(MultiPolygon, 0, len(mp))
+	for _, poly := range mp {
+		var sp = make(Polygon, 0, len(poly))
+		for n := range poly {
+			sr := simplified[0]
+			simplified = simplified[1:]
+			if len(sr) < 3 {
+				if n == 0 {
+					sp = nil
+					simplified = simplified[len(poly)-1:]
+					break
+				}
+				continue
+			}
+			sp = append(sp, sr)
+		}
+		if len(sp) > 0 {
+			smp = append(smp, sp)
+		}
+	}
+	return smp
+}
+
+// vwSimplifier stores the vertices of all parts as doubly linked lists, so that points can be
+// removed cheaply.
+type vwSimplifier struct {
+	pts        []Point
+	prev, next []int
+	part       []int
+	removed    []bool
+	version    []int
+
+	// start index, current and minimal number of points per part
+	starts   []int
+	counts   []int
+	minCount []int
+
+	queue vwQueue
+	grid  *segmentGrid // only used in topology mode
+}
+
+func newVWSimplifier(parts []Line, rings, topology bool) *vwSimplifier {
+	var s vwSimplifier
+	for np, part := range parts {
+		if topology {
+			// duplicate points would block the removal of their neighbours
+			part = withoutDuplicates(part, rings)
+		}
+		start := len(s.pts)
+		s.starts = append(s.starts, start)
+		s.counts = append(s.counts, len(part))
+		switch {
+		case rings && topology:
+			s.minCount = append(s.minCount, 3)
+		case !rings && topology && len(part) > 1 && part[0] == part[len(part)-1]:
+			s.minCount = append(s.minCount, 4)
+		default:
+			s.minCount = append(s.minCount, 2)
+		}
+		for i, pt := range part {
+			var prev, next = start + i - 1, start + i + 1
+			if i == 0 {
+				prev = -1
+				if rings {
+					prev = start + len(part) - 1
+				}
+			}
+			if i == len(part)-1 {
+				next = -1
+				if rings {
+					next = start
+				}
+			}
+			s.pts = append(s.pts, pt)
+			s.prev = append(s.prev, prev)
+			s.next = append(s.next, next)
+			s.part = append(s.part, np)
+		}
+	}
+	s.removed = make([]bool, len(s.pts))
+	s.version = make([]int, len(s.pts))
+	if topology {
+		s.grid = newSegmentGrid(s.pts)
+		for i := range s.pts {
+			s.grid.insert(i, s.segmentBBox(i))
+		}
+	}
+	return &s
+}
+
+// run removes points until all triangles have at least the given area and returns the
+// remaining points of every part.
+func (s *vwSimplifier) run(minArea float64) []Line {
+	for i := range s.pts {
+		s.queue = append(s.queue, vwItem{idx: i, area: s.area(i)})
+	}
+	heap.Init(&s.queue)
+
+	for s.queue.Len() > 0 {
+		it := heap.Pop(&s.queue).(vwItem)
+		if s.removed[it.idx] || it.version != s.version[it.idx] {
+			continue
+		}
+		if it.area >= minArea {
+			break
+		}
+		if s.counts[s.part[it.idx]] <= s.minCount[s.part[it.idx]] {
+			continue
+		}
+		if s.grid != nil && !s.removable(it.idx) {
+			continue
+		}
+		s.remove(it.idx)
+
+		// Neighbours get at least the area of the removed point, so that points are always
+		// removed in the order of their effective area.
+		for _, nb := range []int{s.prev[it.idx], s.next[it.idx]} {
+			if nb == -1 {
+				continue
+			}
+			s.version[nb]++
+			heap.Push(&s.queue, vwItem{idx: nb, area: math.Max(s.area(nb), it.area), version: s.version[nb]})
+		}
+	}
+
+	var res = make([]Line, 0, len(s.starts))
+	for np, start := range s.starts {
+		end := len(s.pts)
+		if np+1 < len(s.starts) {
+			end = s.starts[np+1]
+		}
+		var ln = make(Line, 0, s.counts[np])
+		for i := start; i < end; i++ {
+			if !s.removed[i] {
+				ln = append(ln, s.pts[i])
+			}
+		}
+		res = append(res, ln)
+	}
+	return res
+}
+
+// area returns the area of the triangle which the point forms with its neighbours. End points
+// of lines are never removed.
+func (s *vwSimplifier) area(i int) float64 {
+	p, n := s.prev[i], s.next[i]
+	if p == -1 || n == -1 {
+		return math.Inf(1)
+	}
+	return math.Abs(cross(s.pts[p], s.pts[i], s.pts[n])) / 2
+}
+
+func (s *vwSimplifier) remove(i int) {
+	p, n := s.prev[i], s.next[i]
+	s.next[p] = n
+	s.prev[n] = p
+	s.removed[i] = true
+	s.counts[s.part[i]]--
+	if s.grid != nil {
+		s.grid.insert(p, s.segmentBBox(p))
+	}
+}
+
+// segmentBBox returns the bbox of the segment which starts at i.
+func (s *vwSimplifier) segmentBBox(i int) BBox {
+	bb := BBox{SW: s.pts[i], NE: s.pts[i]}
+	if n := s.next[i]; n != -1 {
+		bb.ExtendWith(BBox{SW: s.pts[n], NE: s.pts[n]})
+	}
+	return bb
+}
+
+// removable checks whether removing the point keeps the topology: No other point may be located
+// inside of the triangle that is cut off and the new segment must not intersect other segments.
+func (s *vwSimplifier) removable(i int) bool {
+	var (
+		p, n = s.prev[i], s.next[i]
+		a, b = s.pts[p], s.pts[n]
+		tri  = Line{a, s.pts[i], b}
+		bb   = tri.BBox()
+		ok   = true
+	)
+	s.grid.query(bb, func(k int) bool {
+		if s.removed[k] {
+			return true
+		}
+		// Points of other rings at the same position as a or b already touched before.
+		if pt := s.pts[k]; k != i && pt != a && pt != b && tri.locate(pt) >= 0 {
+			ok = false
+			return false
+		}
+		kn := s.next[k]
+		if kn == -1 || k == p || k == i {
+			return true // the segments which are replaced
+		}
+		var (
+			seg       = Segment{s.pts[k], s.pts[kn]}
+			ipt, kind = segmentIntersection(Segment{a, b}, seg)
+		)
+		switch {
+		case kind == intersectNone:
+		case kind == intersectTouch && (ipt == a || ipt == b) && (ipt == seg[0] || ipt == seg[1]):
+			// touches in an existing shared vertex, adjacent segments always do
+		default:
+			ok = false
+		}
+		return ok
+	})
+	return ok
+}
+
+type vwItem struct {
+	idx     int
+	area    float64
+	version int
+}
+
+// vwQueue is a min-heap of points, ordered by their area.
+type vwQueue []vwItem
+
+func (q vwQueue) Len() int            { return len(q) }
+func (q vwQueue) Less(i, j int) bool  { return q[i].area < q[j].area }
+func (q vwQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *vwQueue) Push(x interface{}) { *q = append(*q, x.(vwItem)) }
+func (q *vwQueue) Pop() interface{} {
+	old := *q
+	it := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return it
+}
+
+// segmentGrid is a uniform grid, which stores the indices of segments in all cells that their
+// bbox covers. Entries are never removed, so callers need to check whether they are still
+// current.
+type segmentGrid struct {
+	origin   Point
+	cellSize float64
+	cells    map[[2]int][]int
+	seen     []int
+	stamp    int
+}
+
+func newSegmentGrid(pts []Point) *segmentGrid {
+	g := &segmentGrid{cells: map[[2]int][]int{}, seen: make([]int, len(pts)), cellSize: 1}
+	if len(pts) == 0 {
+		return g
+	}
+	bb := Line(pts).BBox()
+	g.origin = bb.SW
+	if size := math.Max(bb.NE.X-bb.SW.X, bb.NE.Y-bb.SW.Y) / math.Ceil(math.Sqrt(float64(len(pts)))); size > 0 {
+		g.cellSize = size
+	}
+	return g
+}
+
+func (g *segmentGrid) cell(pt Point) (int, int) {
+	return int(math.Floor((pt.X - g.origin.X) / g.cellSize)), int(math.Floor((pt.Y - g.origin.Y) / g.cellSize))
+}
+
+func (g *segmentGrid) insert(idx int, bb BBox) {
+	x0, y0 := g.cell(bb.SW)
+	x1, y1 := g.cell(bb.NE)
+	for x := x0; x <= x1; x++ {
+		for y := y0; y <= y1; y++ {
+			g.cells[[2]int{x, y}] = append(g.cells[[2]int{x, y}], idx)
+		}
+	}
+}
+
+// query calls fn once for every index in the cells which are covered by bb, until fn returns
+// false.
+func (g *segmentGrid) query(bb BBox, fn func(int) bool) {
+	g.stamp++
+	x0, y0 := g.cell(bb.SW)
+	x1, y1 := g.cell(bb.NE)
+	for x := x0; x <= x1; x++ {
+		for y := y0; y <= y1; y++ {
+			for _, idx := range g.cells[[2]int{x, y}] {
+				if g.seen[idx] == g.stamp {
+					continue
+				}
+				g.seen[idx] = g.stamp
+				if !fn(idx) {
+					return
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package spatial
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSimplifyVisvalingam(t *testing.T) {
+	t.Run("line", func(t *testing.T) {
+		g := MustNewGeom(Line{{0, 0}, {2.5, 0.5}, {5, 0}})
+		assert.Equal(t, g, g.SimplifyWith(1, SimplifyVisvalingam))
+		assert.Equal(t, MustNewGeom(Line{{0, 0}, {5, 0}}), g.SimplifyWith(2, SimplifyVisvalingam))
+	})
+
+	t.Run("ordinates", func(t *testing.T) {
+		g := geomWithOrdinates(t, Line{{0, 0}, {1, 0.1}, {2, 0}}, []float64{1, 2, 3}, nil)
+		sg := g.SimplifyWith(1, SimplifyVisvalingam)
+		assert.Equal(t, []float64{1, 3}, sg.Z())
+	})
+
+	t.Run("collapsing polygon", func(t *testing.T) {
+		g := MustNewGeom(Polygon{{{0, 0}, {100, 0}, {100, 1}, {0, 1}}})
+		assert.Equal(t, Geom{typ: GeomTypePolygon, g: Polygon(nil)}, g.SimplifyWith(10, SimplifyVisvalingam))
+	})
+}
+
+func TestSimplifyTopology(t *testing.T) {
+	t.Run("thin polygon", func(t *testing.T) {
+		g := MustNewGeom(Polygon{{{0, 0}, {100, 0}, {100, 1}, {0, 1}}})
+		sg := g.SimplifyWith(10, SimplifyTopology)
+		assert.Len(t, sg.MustPolygon()[0], 3)
+		assert.Empty(t, sg.Validate())
+	})
+
+	t.Run("hole in bump", func(t *testing.T) {
+		g := MustNewGeom(Polygon{
+			{{0, 0}, {10, 0}, {10, 10}, {5, 10.5}, {0, 10}},
+			{{4, 9}, {5, 10.2}, {6, 9}},
+		})
+		assert.Equal(t, MustNewGeom(Polygon{{{0, 0}, {10, 0}, {10, 10}, {0, 10}}}), g.SimplifyWith(2, SimplifyVisvalingam))
+		assert.Equal(t, g, g.SimplifyWith(2, SimplifyTopology))
+	})
+
+	t.Run("lines keep their sides", func(t *testing.T) {
+		g := MustNewGeom(MultiLine{
+			{{0, 0}, {5, -1}, {10, 0}},
+			{{4, -0.2}, {5, -0.5}, {6, -0.2}},
+		})
+		assert.Equal(t, MustNewGeom(MultiLine{{{0, 0}, {10, 0}}, {{4, -0.2}, {6, -0.2}}}), g.SimplifyWith(3, SimplifyVisvalingam))
+		assert.Equal(t, MustNewGeom(MultiLine{
+			{{0, 0}, {5, -1}, {10, 0}},
+			{{4, -0.2}, {6, -0.2}},
+		}), g.SimplifyWith(3, SimplifyTopology))
+	})
+
+	t.Run("shared vertex", func(t *testing.T) {
+		g := MustNewGeom(MultiPolygon{
+			{{{0, 0}, {5, 0.1}, {10, 0}, {10, 10}}},
+			{{{10, 0}, {20, 0}, {20, 10}, {15, 10.1}, {10, 10}}},
+		})
+		assert.Equal(t, MustNewGeom(MultiPolygon{
+			{{{0, 0}, {10, 0}, {10, 10}}},
+			{{{10, 0}, {20, 0}, {20, 10}, {10, 10}}},
+		}), g.SimplifyWith(1, SimplifyTopology))
+	})
+
+	t.Run("random rings stay valid", func(t *testing.T) {
+		var ring Line
+		for i := 0; i < 500; i++ {
+			a := float64(i) / 500 * 2 * math.Pi
+			r := 10 + float64(i%7)
+			ring = append(ring, Point{r * math.Cos(a), r * math.Sin(a)})
+		}
+		g := MustNewGeom(Polygon{ring, {{-1, -1}, {-1, 1}, {1, 1}, {1, -1}}})
+		assert.Empty(t, g.Validate())
+		sg := g.SimplifyWith(3, SimplifyTopology)
+		assert.Empty(t, sg.Validate())
+		assert.True(t, len(sg.MustPolygon()[0]) < len(ring))
+	})
+}
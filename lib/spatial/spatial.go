@@ -2,6 +2,7 @@ package spatial
 
 import (
 	"encoding/json"
+	"fmt"
 	"math"
 
 	"github.com/dhconnelly/rtreego"
@@ -17,6 +18,8 @@ type Filterable interface {
 
 // Feature is a data structure which holds geometry and tags/properties of a geographical feature.
 type Feature struct {
+	// ID is an optional identifier of the feature, 0 means that the feature has no ID.
+	ID       uint64                 `json:"-"`
 	Props    map[string]interface{} `json:"properties"`
 	Geometry Geom
 }
@@ -35,14 +38,29 @@ func (f *Feature) MarshalWKB() ([]byte, error) {
 	return f.Geometry.MarshalWKB()
 }
 
+// MarshalJSON encodes the feature as GeoJSON. Properties are encoded as described in
+// Value.MarshalJSON.
 func (f Feature) MarshalJSON() ([]byte, error) {
+	var props map[string]Value
+	if f.Props != nil {
+		props = make(map[string]Value, len(f.Props))
+		for k, v := range f.Props {
+			val, err := ValueOf(v)
+			if err != nil {
+				return nil, fmt.Errorf("property %s: %v", k, err)
+			}
+			props[k] = val
+		}
+	}
 	tfc := struct {
-		Type     string                 `json:"type"`
-		Props    map[string]interface{} `json:"properties"`
-		Geometry Geom                   `json:"geometry"`
+		Type     string           `json:"type"`
+		ID       uint64           `json:"id,omitempty"`
+		Props    map[string]Value `json:"properties"`
+		Geometry Geom             `json:"geometry"`
 	}{
 		Type:     "Feature",
-		Props:    f.Props,
+		ID:       f.ID,
+		Props:    props,
 		Geometry: f.Geometry,
 	}
 	return json.Marshal(tfc)
@@ -110,6 +128,10 @@ func (ft rtreeFeat) Bounds() *rtreego.Rect {
 // RTreeCollection is a FeatureCollection which is backed by a rtree.
 type RTreeCollection struct {
 	rt *rtreego.Rtree
+	// packed indexes the features of a bulk loaded collection, features which are added later
+	// are kept in rt.
+	packed      *strTree
+	packedFeats []Feature
 }
 
 func NewRTreeCollection(features ...Feature) *RTreeCollection {
@@ -124,12 +146,32 @@ func NewRTreeCollection(features ...Feature) *RTreeCollection {
 	}
 }
 
+// BulkLoadRTreeCollection builds the index using the Sort-Tile-Recursive algorithm, which is
+// considerably faster than NewRTreeCollection for large amounts of features and results in
+// a tree with very little overlap. Features can still be added afterwards.
+func BulkLoadRTreeCollection(features []Feature) *RTreeCollection {
+	var boxes = make([]BBox, len(features))
+	for i := range features {
+		boxes[i] = features[i].Geometry.BBox()
+	}
+	return &RTreeCollection{
+		rt:          rtreego.NewTree(2, 32, 64),
+		packed:      newSTRTree(boxes, 16),
+		packedFeats: features,
+	}
+}
+
 func (rt *RTreeCollection) Add(feature Feature) {
 	rt.rt.Insert(rtreeFeat(feature))
 }
 
 func (rt *RTreeCollection) Filter(bbox BBox) []Feature {
 	var fts []Feature
+	if rt.packed != nil {
+		rt.packed.search(bbox, func(i int) {
+			fts = append(fts, rt.packedFeats[i])
+		})
+	}
 	for _, ft := range rt.rt.SearchIntersect(bboxToRect(bbox)) {
 		fts = append(fts, Feature(ft.(rtreeFeat)))
 	}
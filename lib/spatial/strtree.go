@@ -0,0 +1,135 @@
+package spatial
+
+import (
+	"math"
+	"sort"
+)
+
+// strTree is a static R-tree, which is bulk loaded using the Sort-Tile-Recursive algorithm
+// (Leutenegger et al., 1997). All nodes are packed into flat slices, level by level, starting
+// with the items and ending with the root.
+type strTree struct {
+	nodeSize int
+	boxes    []BBox
+	// indices contains the original position for items and the position of the first child
+	// for nodes.
+	indices []int
+	// levels contains the end position of every level in boxes.
+	levels []int
+}
+
+func newSTRTree(boxes []BBox, nodeSize int) *strTree {
+	var (
+		n = len(boxes)
+		t = &strTree{
+			nodeSize: nodeSize,
+			boxes:    make([]BBox, n, n+n/(nodeSize-1)+1),
+			indices:  make([]int, n, n+n/(nodeSize-1)+1),
+		}
+	)
+	copy(t.boxes, boxes)
+	for i := range t.indices {
+		t.indices[i] = i
+	}
+	if n == 0 {
+		return t
+	}
+
+	start, end := 0, n
+	for {
+		t.levels = append(t.levels, end)
+		if end-start == 1 && len(t.levels) > 1 {
+			return t
+		}
+		t.sortTiles(start, end)
+		for i := start; i < end; i += nodeSize {
+			j := i + nodeSize
+			if j > end {
+				j = end
+			}
+			bb := t.boxes[i]
+			for _, cb := range t.boxes[i+1 : j] {
+				bb.ExtendWith(cb)
+			}
+			t.boxes = append(t.boxes, bb)
+			t.indices = append(t.indices, i)
+		}
+		start, end = end, len(t.boxes)
+	}
+}
+
+// sortTiles orders the entries between start and end into vertical slices, which are sorted
+// by their y coordinate, so that consecutive groups of nodeSize entries are close to each other.
+func (t *strTree) sortTiles(start, end int) {
+	var (
+		n      = end - start
+		slices = int(math.Ceil(math.Sqrt(math.Ceil(float64(n) / float64(t.nodeSize)))))
+		width  = slices * t.nodeSize
+	)
+	sort.Sort(strSorter{boxes: t.boxes[start:end], indices: t.indices[start:end], dim: 0})
+	for i := start; i < end; i += width {
+		j := i + width
+		if j > end {
+			j = end
+		}
+		sort.Sort(strSorter{boxes: t.boxes[i:j], indices: t.indices[i:j], dim: 1})
+	}
+}
+
+// search calls fn with the original position of every item which intersects the bbox.
+func (t *strTree) search(bb BBox, fn func(i int)) {
+	if len(t.levels) == 0 {
+		return
+	}
+	type entry struct{ pos, level int }
+	var (
+		top   = len(t.levels) - 1
+		stack = []entry{{pos: len(t.boxes) - 1, level: top}}
+	)
+	for len(stack) > 0 {
+		e := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		var (
+			first = t.indices[e.pos]
+			last  = first + t.nodeSize
+		)
+		if end := t.levels[e.level-1]; last > end {
+			last = end
+		}
+		for c := first; c < last; c++ {
+			if !bboxIntersects(t.boxes[c], bb) {
+				continue
+			}
+			if e.level == 1 {
+				fn(t.indices[c])
+				continue
+			}
+			stack = append(stack, entry{pos: c, level: e.level - 1})
+		}
+	}
+}
+
+func bboxIntersects(a, b BBox) bool {
+	return a.SW.X <= b.NE.X && a.NE.X >= b.SW.X && a.SW.Y <= b.NE.Y && a.NE.Y >= b.SW.Y
+}
+
+type strSorter struct {
+	boxes   []BBox
+	indices []int
+	dim     int
+}
+
+func (s strSorter) Len() int { return len(s.boxes) }
+
+func (s strSorter) Less(i, j int) bool {
+	if s.dim == 0 {
+		return s.boxes[i].SW.X+s.boxes[i].NE.X < s.boxes[j].SW.X+s.boxes[j].NE.X
+	}
+	return s.boxes[i].SW.Y+s.boxes[i].NE.Y < s.boxes[j].SW.Y+s.boxes[j].NE.Y
+}
+
+func (s strSorter) Swap(i, j int) {
+	s.boxes[i], s.boxes[j] = s.boxes[j], s.boxes[i]
+	s.indices[i], s.indices[j] = s.indices[j], s.indices[i]
+}
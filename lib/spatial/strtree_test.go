@@ -0,0 +1,47 @@
+package spatial
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSTRTreeSearch(t *testing.T) {
+	var (
+		rnd   = rand.New(rand.NewSource(1))
+		boxes []BBox
+	)
+	for i := 0; i < 5000; i++ {
+		x, y := rnd.Float64()*1000, rnd.Float64()*1000
+		boxes = append(boxes, BBox{Point{x, y}, Point{x + rnd.Float64()*20, y + rnd.Float64()*20}})
+	}
+
+	for _, n := range []int{0, 1, 2, 16, 17, 300, 5000} {
+		tree := newSTRTree(boxes[:n], 16)
+		for i := 0; i < 50; i++ {
+			x, y := rnd.Float64()*1000, rnd.Float64()*1000
+			q := BBox{Point{x, y}, Point{x + rnd.Float64()*100, y + rnd.Float64()*100}}
+
+			var expected, found []int
+			for j, bb := range boxes[:n] {
+				if bboxIntersects(bb, q) {
+					expected = append(expected, j)
+				}
+			}
+			tree.search(q, func(j int) { found = append(found, j) })
+			sort.Ints(found)
+			assert.Equal(t, expected, found, "%d items, query %v", n, q)
+		}
+	}
+}
+
+func TestSTRTreeSearchPoint(t *testing.T) {
+	tree := newSTRTree([]BBox{{Point{1, 1}, Point{1, 1}}, {Point{0, 0}, Point{2, 2}}}, 16)
+
+	var found []int
+	tree.search(BBox{Point{1, 1}, Point{1, 1}}, func(i int) { found = append(found, i) })
+	sort.Ints(found)
+	assert.Equal(t, []int{0, 1}, found)
+}
@@ -0,0 +1,670 @@
+package spatial
+
+import (
+	"math"
+	"sort"
+)
+
+// Triangulate splits the polygon into triangles. It returns the vertices of all rings, in the
+// order of the polygon, and the indices of the triangle corners into vertices, three per
+// triangle. Triangles are oriented counter-clockwise.
+//
+// The algorithm is a port of earcut by Mapbox (https://github.com/mapbox/earcut), which uses
+// ear clipping and handles holes, self-touching rings and, to some degree, invalid polygons.
+// Large polygons are sped up by indexing vertices along a z-order curve.
+func (p Polygon) Triangulate() (vertices []Point, indices []int) {
+	var holes []int
+	for n, ring := range p {
+		if n > 0 {
+			holes = append(holes, len(vertices))
+		}
+		vertices = append(vertices, ring...)
+	}
+	return vertices, earcut(vertices, holes)
+}
+
+// Triangulate triangulates all polygons of a Polygon or MultiPolygon, see Polygon.Triangulate.
+// The vertices are in the same order as the ordinates returned by Z and M. Other geometry
+// types have no triangles.
+func (g Geom) Triangulate() (vertices []Point, indices []int) {
+	switch g.typ {
+	case GeomTypePolygon, GeomTypeMultiPolygon:
+	default:
+		return nil, nil
+	}
+	for _, poly := range g.polygons() {
+		vs, is := poly.Triangulate()
+		for _, i := range is {
+			indices = append(indices, i+len(vertices))
+		}
+		vertices = append(vertices, vs...)
+	}
+	return vertices, indices
+}
+
+type earNode struct {
+	i            int // index of the vertex
+	x, y         float64
+	prev, next   *earNode
+	z            int32
+	prevZ, nextZ *earNode
+	steiner      bool // the node is a single point hole
+}
+
+func earcut(pts []Point, holes []int) []int {
+	var (
+		outerLen  = len(pts)
+		triangles []int
+	)
+	if len(holes) > 0 {
+		outerLen = holes[0]
+	}
+	outer := earLinkedList(pts, 0, outerLen, true)
+	if outer == nil || outer.next == outer.prev {
+		return triangles
+	}
+	if len(holes) > 0 {
+		outer = eliminateHoles(pts, holes, outer)
+	}
+
+	var minX, minY, invSize float64
+	if len(pts) > 80 {
+		// only the outer ring is relevant, as holes are inside of it
+		minX, minY = pts[0].X, pts[0].Y
+		maxX, maxY := minX, minY
+		for _, pt := range pts[1:outerLen] {
+			minX, minY = math.Min(minX, pt.X), math.Min(minY, pt.Y)
+			maxX, maxY = math.Max(maxX, pt.X), math.Max(maxY, pt.Y)
+		}
+		// minX, minY and invSize are used to map coordinates into the integer space of the
+		// z-order curve
+		if size := math.Max(maxX-minX, maxY-minY); size != 0 {
+			invSize = 32767 / size
+		}
+	}
+	return earcutLinked(outer, triangles, minX, minY, invSize, 0)
+}
+
+// earLinkedList creates a circular doubly linked list from the ring with the given winding.
+func earLinkedList(pts []Point, start, end int, clockwise bool) *earNode {
+	var last *earNode
+	if clockwise == (earSignedArea(pts, start, end) > 0) {
+		for i := start; i < end; i++ {
+			last = insertEarNode(i, pts[i], last)
+		}
+	} else {
+		for i := end - 1; i >= start; i-- {
+			last = insertEarNode(i, pts[i], last)
+		}
+	}
+	if last != nil && earEquals(last, last.next) {
+		removeEarNode(last)
+		last = last.next
+	}
+	return last
+}
+
+// filterEarPoints eliminates duplicate and collinear points.
+func filterEarPoints(start, end *earNode) *earNode {
+	if start == nil {
+		return start
+	}
+	if end == nil {
+		end = start
+	}
+	var p = start
+	for {
+		if !p.steiner && (earEquals(p, p.next) || earArea(p.prev, p, p.next) == 0) {
+			removeEarNode(p)
+			p = p.prev
+			end = p
+			if p == p.next {
+				break
+			}
+			continue
+		}
+		p = p.next
+		if p == end {
+			break
+		}
+	}
+	return end
+}
+
+// earcutLinked is the main ear slicing loop, which triangulates a polygon given as linked list.
+func earcutLinked(ear *earNode, triangles []int, minX, minY, invSize float64, pass int) []int {
+	if ear == nil {
+		return triangles
+	}
+	if pass == 0 && invSize != 0 {
+		indexCurve(ear, minX, minY, invSize)
+	}
+
+	var stop = ear
+	for ear.prev != ear.next {
+		prev, next := ear.prev, ear.next
+
+		var isEar bool
+		if invSize != 0 {
+			isEar = isEarHashed(ear, minX, minY, invSize)
+		} else {
+			isEar = isEarNode(ear)
+		}
+		if isEar {
+			triangles = append(triangles, prev.i, ear.i, next.i)
+			removeEarNode(ear)
+			// skipping the next vertex leads to less sliver triangles
+			ear = next.next
+			stop = next.next
+			continue
+		}
+
+		ear = next
+		if ear == stop {
+			// If no more ears can be cut, try to filter points and slice again. If this doesn't
+			// help, try to cure small local self intersections, as a last resort split the
+			// remaining polygon into two.
+			switch pass {
+			case 0:
+				triangles = earcutLinked(filterEarPoints(ear, nil), triangles, minX, minY, invSize, 1)
+			case 1:
+				var cured *earNode
+				cured, triangles = cureLocalIntersections(filterEarPoints(ear, nil), triangles)
+				triangles = earcutLinked(cured, triangles, minX, minY, invSize, 2)
+			case 2:
+				triangles = splitEarcut(ear, triangles, minX, minY, invSize)
+			}
+			break
+		}
+	}
+	return triangles
+}
+
+// isEarNode checks whether a polygon node forms a valid ear with its neighbours.
+func isEarNode(ear *earNode) bool {
+	a, b, c := ear.prev, ear, ear.next
+	if earArea(a, b, c) >= 0 {
+		return false // reflex, can't be an ear
+	}
+	// no other point may lie inside of the ear
+	for p := ear.next.next; p != ear.prev; p = p.next {
+		if pointInTriangle(a.x, a.y, b.x, b.y, c.x, c.y, p.x, p.y) && earArea(p.prev, p, p.next) >= 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// isEarHashed is like isEarNode, but only checks points whose z-order value is in the range of
+// the triangle's bbox.
+func isEarHashed(ear *earNode, minX, minY, invSize float64) bool {
+	a, b, c := ear.prev, ear, ear.next
+	if earArea(a, b, c) >= 0 {
+		return false
+	}
+	var (
+		minZ = zOrder(math.Min(a.x, math.Min(b.x, c.x)), math.Min(a.y, math.Min(b.y, c.y)), minX, minY, invSize)
+		maxZ = zOrder(math.Max(a.x, math.Max(b.x, c.x)), math.Max(a.y, math.Max(b.y, c.y)), minX, minY, invSize)
+		p    = ear.prevZ
+		n    = ear.nextZ
+	)
+	inside := func(p *earNode) bool {
+		return p != ear.prev && p != ear.next &&
+			pointInTriangle(a.x, a.y, b.x, b.y, c.x, c.y, p.x, p.y) && earArea(p.prev, p, p.next) >= 0
+	}
+	// look in both directions along the curve
+	for p != nil && p.z >= minZ && n != nil && n.z <= maxZ {
+		if inside(p) {
+			return false
+		}
+		p = p.prevZ
+		if inside(n) {
+			return false
+		}
+		n = n.nextZ
+	}
+	for ; p != nil && p.z >= minZ; p = p.prevZ {
+		if inside(p) {
+			return false
+		}
+	}
+	for ; n != nil && n.z <= maxZ; n = n.nextZ {
+		if inside(n) {
+			return false
+		}
+	}
+	return true
+}
+
+// cureLocalIntersections removes small self intersections by cutting off a triangle.
+func cureLocalIntersections(start *earNode, triangles []int) (*earNode, []int) {
+	var p = start
+	for {
+		a, b := p.prev, p.next.next
+		if !earEquals(a, b) && earIntersects(a, p, p.next, b) && locallyInside(a, b) && locallyInside(b, a) {
+			triangles = append(triangles, a.i, p.i, b.i)
+			removeEarNode(p)
+			removeEarNode(p.next)
+			p = b
+			start = b
+		}
+		p = p.next
+		if p == start {
+			break
+		}
+	}
+	return filterEarPoints(p, nil), triangles
+}
+
+// splitEarcut searches for a valid diagonal, which divides the polygon into two, and
+// triangulates both halves independently.
+func splitEarcut(start *earNode, triangles []int, minX, minY, invSize float64) []int {
+	var a = start
+	for {
+		for b := a.next.next; b != a.prev; b = b.next {
+			if a.i != b.i && isValidDiagonal(a, b) {
+				c := splitEarPolygon(a, b)
+				a = filterEarPoints(a, a.next)
+				c = filterEarPoints(c, c.next)
+				triangles = earcutLinked(a, triangles, minX, minY, invSize, 0)
+				return earcutLinked(c, triangles, minX, minY, invSize, 0)
+			}
+		}
+		a = a.next
+		if a == start {
+			return triangles
+		}
+	}
+}
+
+// eliminateHoles links every hole into the outer ring, which results in a single ring.
+func eliminateHoles(pts []Point, holes []int, outer *earNode) *earNode {
+	var queue []*earNode
+	for n, start := range holes {
+		end := len(pts)
+		if n < len(holes)-1 {
+			end = holes[n+1]
+		}
+		list := earLinkedList(pts, start, end, false)
+		if list == nil {
+			continue
+		}
+		if list == list.next {
+			list.steiner = true
+		}
+		queue = append(queue, leftmostEarNode(list))
+	}
+	sort.SliceStable(queue, func(i, j int) bool { return queue[i].x < queue[j].x })
+
+	// process holes from left to right
+	for _, hole := range queue {
+		outer = eliminateHole(hole, outer)
+	}
+	return outer
+}
+
+func eliminateHole(hole, outer *earNode) *earNode {
+	bridge := findHoleBridge(hole, outer)
+	if bridge == nil {
+		return outer
+	}
+	bridgeReverse := splitEarPolygon(bridge, hole)
+
+	// filter collinear points around the cuts
+	filtered := filterEarPoints(bridge, bridge.next)
+	filterEarPoints(bridgeReverse, bridgeReverse.next)
+	if outer == bridge {
+		return filtered
+	}
+	return outer
+}
+
+// findHoleBridge uses David Eberly's algorithm to find a vertex of the outer ring, which can be
+// connected with the leftmost point of the hole.
+func findHoleBridge(hole, outer *earNode) *earNode {
+	var (
+		p      = outer
+		hx, hy = hole.x, hole.y
+		qx     = math.Inf(-1)
+		m      *earNode
+	)
+	// find a segment intersected by a ray from the hole's leftmost point to the left, the
+	// segment's endpoint with the lesser x will be the potential connection point
+	for {
+		if hy <= p.y && hy >= p.next.y && p.next.y != p.y {
+			x := p.x + (hy-p.y)*(p.next.x-p.x)/(p.next.y-p.y)
+			if x <= hx && x > qx {
+				qx = x
+				if x == hx {
+					if hy == p.y {
+						return p
+					}
+					if hy == p.next.y {
+						return p.next
+					}
+				}
+				if p.x < p.next.x {
+					m = p
+				} else {
+					m = p.next
+				}
+			}
+		}
+		p = p.next
+		if p == outer {
+			break
+		}
+	}
+	if m == nil {
+		return nil
+	}
+	if hx == qx {
+		return m // the hole touches the outer segment
+	}
+
+	// Look for points inside of the triangle of the hole point, the segment intersection and
+	// the endpoint. If there are none, the endpoint is used, otherwise the point with the
+	// minimum angle to the ray.
+	var (
+		stop   = m
+		mx, my = m.x, m.y
+		tanMin = math.Inf(1)
+	)
+	p = m
+	for {
+		if hx >= p.x && p.x >= mx && hx != p.x {
+			var ax, cx = qx, hx
+			if hy < my {
+				ax, cx = hx, qx
+			}
+			if pointInTriangle(ax, hy, mx, my, cx, hy, p.x, p.y) {
+				tan := math.Abs(hy-p.y) / (hx - p.x)
+				if locallyInside(p, hole) &&
+					(tan < tanMin || (tan == tanMin && (p.x > m.x || (p.x == m.x && sectorContainsSector(m, p))))) {
+					m = p
+					tanMin = tan
+				}
+			}
+		}
+		p = p.next
+		if p == stop {
+			break
+		}
+	}
+	return m
+}
+
+// sectorContainsSector checks whether the sector in vertex m contains the sector in vertex p
+// in the same coordinates.
+func sectorContainsSector(m, p *earNode) bool {
+	return earArea(m.prev, m, p.prev) < 0 && earArea(p.next, m, m.next) < 0
+}
+
+// indexCurve links the nodes in the order of their z-order value.
+func indexCurve(start *earNode, minX, minY, invSize float64) {
+	var p = start
+	for {
+		if p.z == 0 {
+			p.z = zOrder(p.x, p.y, minX, minY, invSize)
+		}
+		p.prevZ = p.prev
+		p.nextZ = p.next
+		p = p.next
+		if p == start {
+			break
+		}
+	}
+	p.prevZ.nextZ = nil
+	p.prevZ = nil
+	sortLinked(p)
+}
+
+// sortLinked sorts the nodes by their z value, using Simon Tatham's linked list merge sort.
+func sortLinked(list *earNode) *earNode {
+	var inSize = 1
+	for {
+		var (
+			p         = list
+			tail      *earNode
+			numMerges int
+		)
+		list = nil
+		for p != nil {
+			numMerges++
+			var (
+				q     = p
+				pSize int
+			)
+			for i := 0; i < inSize; i++ {
+				pSize++
+				q = q.nextZ
+				if q == nil {
+					break
+				}
+			}
+			qSize := inSize
+			for pSize > 0 || (qSize > 0 && q != nil) {
+				var e *earNode
+				if pSize != 0 && (qSize == 0 || q == nil || p.z <= q.z) {
+					e = p
+					p = p.nextZ
+					pSize--
+				} else {
+					e = q
+					q = q.nextZ
+					qSize--
+				}
+				if tail != nil {
+					tail.nextZ = e
+				} else {
+					list = e
+				}
+				e.prevZ = tail
+				tail = e
+			}
+			p = q
+		}
+		tail.nextZ = nil
+		inSize *= 2
+		if numMerges <= 1 {
+			return list
+		}
+	}
+}
+
+// zOrder interleaves the bits of the coordinates, after mapping them into 15 bit integers.
+func zOrder(fx, fy, minX, minY, invSize float64) int32 {
+	var (
+		x = int32((fx - minX) * invSize)
+		y = int32((fy - minY) * invSize)
+	)
+	x = (x | (x << 8)) & 0x00FF00FF
+	x = (x | (x << 4)) & 0x0F0F0F0F
+	x = (x | (x << 2)) & 0x33333333
+	x = (x | (x << 1)) & 0x55555555
+
+	y = (y | (y << 8)) & 0x00FF00FF
+	y = (y | (y << 4)) & 0x0F0F0F0F
+	y = (y | (y << 2)) & 0x33333333
+	y = (y | (y << 1)) & 0x55555555
+
+	return x | (y << 1)
+}
+
+func leftmostEarNode(start *earNode) *earNode {
+	var p, leftmost = start, start
+	for {
+		if p.x < leftmost.x || (p.x == leftmost.x && p.y < leftmost.y) {
+			leftmost = p
+		}
+		p = p.next
+		if p == start {
+			return leftmost
+		}
+	}
+}
+
+func pointInTriangle(ax, ay, bx, by, cx, cy, px, py float64) bool {
+	return (cx-px)*(ay-py) >= (ax-px)*(cy-py) &&
+		(ax-px)*(by-py) >= (bx-px)*(ay-py) &&
+		(bx-px)*(cy-py) >= (cx-px)*(by-py)
+}
+
+// isValidDiagonal checks whether a diagonal between two nodes is inside of the polygon and
+// doesn't intersect any edge.
+func isValidDiagonal(a, b *earNode) bool {
+	if a.next.i == b.i || a.prev.i == b.i || intersectsEarPolygon(a, b) {
+		return false
+	}
+	if locallyInside(a, b) && locallyInside(b, a) && middleInside(a, b) &&
+		// doesn't create opposite-facing sectors
+		(earArea(a.prev, a, b.prev) != 0 || earArea(a, b.prev, b) != 0) {
+		return true
+	}
+	// special zero-length case
+	return earEquals(a, b) && earArea(a.prev, a, a.next) > 0 && earArea(b.prev, b, b.next) > 0
+}
+
+// earArea returns the signed area of a triangle, which is negative for counter-clockwise
+// triangles.
+func earArea(p, q, r *earNode) float64 {
+	return (q.y-p.y)*(r.x-q.x) - (q.x-p.x)*(r.y-q.y)
+}
+
+func earEquals(p1, p2 *earNode) bool {
+	return p1.x == p2.x && p1.y == p2.y
+}
+
+// earIntersects checks whether the segments p1-q1 and p2-q2 intersect.
+func earIntersects(p1, q1, p2, q2 *earNode) bool {
+	var (
+		o1 = sign(earArea(p1, q1, p2))
+		o2 = sign(earArea(p1, q1, q2))
+		o3 = sign(earArea(p2, q2, p1))
+		o4 = sign(earArea(p2, q2, q1))
+	)
+	if o1 != o2 && o3 != o4 {
+		return true
+	}
+	// collinear points on the other segment
+	return (o1 == 0 && earOnSegment(p1, p2, q1)) ||
+		(o2 == 0 && earOnSegment(p1, q2, q1)) ||
+		(o3 == 0 && earOnSegment(p2, p1, q2)) ||
+		(o4 == 0 && earOnSegment(p2, q1, q2))
+}
+
+// earOnSegment checks whether q lies on the segment p-r, given that the points are collinear.
+func earOnSegment(p, q, r *earNode) bool {
+	return q.x <= math.Max(p.x, r.x) && q.x >= math.Min(p.x, r.x) &&
+		q.y <= math.Max(p.y, r.y) && q.y >= math.Min(p.y, r.y)
+}
+
+func sign(v float64) int {
+	switch {
+	case v > 0:
+		return 1
+	case v < 0:
+		return -1
+	}
+	return 0
+}
+
+// intersectsEarPolygon checks whether the diagonal a-b intersects any edge of the polygon.
+func intersectsEarPolygon(a, b *earNode) bool {
+	var p = a
+	for {
+		if p.i != a.i && p.next.i != a.i && p.i != b.i && p.next.i != b.i && earIntersects(p, p.next, a, b) {
+			return true
+		}
+		p = p.next
+		if p == a {
+			return false
+		}
+	}
+}
+
+// locallyInside checks whether the diagonal a-b is locally inside of the polygon.
+func locallyInside(a, b *earNode) bool {
+	if earArea(a.prev, a, a.next) < 0 {
+		return earArea(a, b, a.next) >= 0 && earArea(a, a.prev, b) >= 0
+	}
+	return earArea(a, b, a.prev) < 0 || earArea(a, a.next, b) < 0
+}
+
+// middleInside checks whether the middle of the diagonal a-b is inside of the polygon.
+func middleInside(a, b *earNode) bool {
+	var (
+		p      = a
+		inside bool
+		px, py = (a.x + b.x) / 2, (a.y + b.y) / 2
+	)
+	for {
+		if (p.y > py) != (p.next.y > py) && p.next.y != p.y && px < (p.next.x-p.x)*(py-p.y)/(p.next.y-p.y)+p.x {
+			inside = !inside
+		}
+		p = p.next
+		if p == a {
+			return inside
+		}
+	}
+}
+
+// splitEarPolygon links a and b with a bridge. If a and b are in the same ring, the ring is
+// split into two, if they are in different rings, the rings are merged. It returns the copy of
+// b, which is part of the second ring.
+func splitEarPolygon(a, b *earNode) *earNode {
+	var (
+		a2 = &earNode{i: a.i, x: a.x, y: a.y}
+		b2 = &earNode{i: b.i, x: b.x, y: b.y}
+		an = a.next
+		bp = b.prev
+	)
+	a.next = b
+	b.prev = a
+
+	a2.next = an
+	an.prev = a2
+
+	b2.next = a2
+	a2.prev = b2
+
+	bp.next = b2
+	b2.prev = bp
+
+	return b2
+}
+
+// insertEarNode creates a node and inserts it after last.
+func insertEarNode(i int, pt Point, last *earNode) *earNode {
+	p := &earNode{i: i, x: pt.X, y: pt.Y}
+	if last == nil {
+		p.prev = p
+		p.next = p
+	} else {
+		p.next = last.next
+		p.prev = last
+		last.next.prev = p
+		last.next = p
+	}
+	return p
+}
+
+func removeEarNode(p *earNode) {
+	p.next.prev = p.prev
+	p.prev.next = p.next
+	if p.prevZ != nil {
+		p.prevZ.nextZ = p.nextZ
+	}
+	if p.nextZ != nil {
+		p.nextZ.prevZ = p.prevZ
+	}
+}
+
+// earSignedArea returns twice the signed area of a ring, positive for counter-clockwise rings.
+func earSignedArea(pts []Point, start, end int) float64 {
+	var sum float64
+	for i, j := start, end-1; i < end; j, i = i, i+1 {
+		sum += (pts[j].X - pts[i].X) * (pts[i].Y + pts[j].Y)
+	}
+	return sum
+}
@@ -0,0 +1,85 @@
+package spatial
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// triangleArea sums up the areas of the triangles and fails if any of them is clockwise.
+func triangleArea(t *testing.T, vertices []Point, indices []int) float64 {
+	var sum float64
+	for i := 0; i < len(indices); i += 3 {
+		a := Line{vertices[indices[i]], vertices[indices[i+1]], vertices[indices[i+2]]}.Area() / 2
+		assert.True(t, a >= 0, "triangle %d is clockwise", i/3)
+		sum += a
+	}
+	return sum
+}
+
+func polygonArea(poly Polygon) float64 {
+	var sum float64
+	for n, ring := range poly {
+		if n == 0 {
+			sum += math.Abs(ring.Area() / 2)
+		} else {
+			sum -= math.Abs(ring.Area() / 2)
+		}
+	}
+	return sum
+}
+
+func TestPolygonTriangulate(t *testing.T) {
+	var circle Line
+	for i := 0; i < 200; i++ {
+		a := 2 * math.Pi * float64(i) / 200
+		circle = append(circle, Point{10 * math.Cos(a), 10 * math.Sin(a)})
+	}
+
+	for _, tc := range []struct {
+		name      string
+		poly      Polygon
+		triangles int
+	}{
+		{"triangle", Polygon{{{0, 0}, {1, 0}, {0, 1}}}, 1},
+		{"square", Polygon{square(0, 0, 2)}, 2},
+		{"clockwise square", Polygon{{{0, 0}, {0, 2}, {2, 2}, {2, 0}}}, 2},
+		{"u shape", uPolygon.MustPolygon(), 6},
+		{"hole", Polygon{square(0, 0, 4), square(1, 1, 2)}, 8},
+		{"two holes", Polygon{square(0, 0, 6), square(1, 1, 1), square(4, 4, 1)}, 14},
+		{"hole touching outer ring", Polygon{square(0, 0, 4), {{0, 2}, {2, 1}, {2, 3}}}, 6},
+		{"collinear points", Polygon{{{0, 0}, {1, 0}, {2, 0}, {2, 2}, {0, 2}}}, 3},
+		{"circle", Polygon{circle}, 198},
+		{"circle with hole", Polygon{circle, square(-2, -2, 4)}, 204},
+		{"degenerate", Polygon{{{0, 0}, {1, 0}, {2, 0}}}, 0},
+		{"empty", Polygon{}, 0},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			vertices, indices := tc.poly.Triangulate()
+			assert.Len(t, indices, tc.triangles*3)
+			for _, i := range indices {
+				assert.True(t, i >= 0 && i < len(vertices))
+			}
+			if tc.triangles > 0 {
+				assert.InDelta(t, polygonArea(tc.poly), triangleArea(t, vertices, indices), 1e-9)
+			}
+		})
+	}
+}
+
+func TestGeomTriangulate(t *testing.T) {
+	g := MustNewGeom(MultiPolygon{{square(0, 0, 2)}, {square(4, 0, 2), square(4.5, 0.5, 1)}})
+	vertices, indices := g.Triangulate()
+	assert.Len(t, vertices, 12)
+	assert.Len(t, indices, 10*3)
+	assert.InDelta(t, 4+4-1, triangleArea(t, vertices, indices), 1e-9)
+	// indices of the second polygon refer to its own vertices
+	for _, i := range indices[6:] {
+		assert.True(t, i >= 4)
+	}
+
+	vertices, indices = MustNewGeom(Line{{0, 0}, {1, 1}}).Triangulate()
+	assert.Nil(t, vertices)
+	assert.Nil(t, indices)
+}
@@ -1,30 +1,132 @@
 package spatial
 
 import (
+	"bytes"
 	"encoding/binary"
+	"errors"
+	"fmt"
 	"io"
 	"math"
 )
 
+// TWKBOptions configure how geometries are encoded as TWKB (https://github.com/TWKB/Specification).
+type TWKBOptions struct {
+	// Precision is the number of decimal digits of X and Y which are kept, between -8 and 7.
+	// Negative values round to tens, hundreds, etc.
+	Precision int
+	// ZPrecision and MPrecision are the decimal digits of Z and M ordinates, between 0 and 7.
+	ZPrecision, MPrecision int
+	// BBox adds the bounding box of the geometry.
+	BBox bool
+	// Size adds the length of the encoded geometry, so readers can skip it without decoding.
+	Size bool
+	// IDs are stored alongside the members of multi geometries and collections, if set. The number
+	// of IDs has to match the number of members.
+	IDs []int64
+}
+
+// MarshalTWKB encodes the geometry as Tiny Well-known Binary. Coordinates are rounded to the precision
+// given in opts.
+func (g Geom) MarshalTWKB(opts TWKBOptions) ([]byte, error) {
+	var buf bytes.Buffer
+	err := twkbWriteGeom(&buf, g, opts)
+	return buf.Bytes(), err
+}
+
+func (g *Geom) UnmarshalTWKB(r io.Reader) error {
+	ng, err := GeomFromTWKB(r)
+	if err != nil {
+		return err
+	}
+	*g = ng
+	return nil
+}
+
+func GeomFromTWKB(r io.Reader) (Geom, error) {
+	g, _, err := ReadTWKB(r)
+	return g, err
+}
+
+// ReadTWKB decodes a TWKB geometry and additionally returns the member IDs, if the geometry has an
+// id list.
+func ReadTWKB(r io.Reader) (Geom, []int64, error) {
+	wr, ok := r.(combinedReader)
+	if !ok {
+		wr = &wrappedReader{r}
+	}
+	return twkbReadGeom(wr)
+}
+
 func twkbWriteHeader(w io.Writer, gt GeomType, precision int) error {
-	var buf = make([]byte, 2)
-	buf[0] = byte(precision<<4) ^ byte(gt)
+	return twkbHeader{typ: gt, precision: precision}.write(w)
+}
+
+func (hd twkbHeader) write(w io.Writer) error {
+	var buf = make([]byte, 2, 3)
+	buf[0] = byte(zigzag(hd.precision)<<4) ^ byte(hd.typ)
+	if hd.bbox {
+		buf[1] |= 1
+	}
+	if hd.size {
+		buf[1] |= 2
+	}
+	if hd.idList {
+		buf[1] |= 4
+	}
+	if hd.extendedPrecision {
+		buf[1] |= 8
+	}
+	if hd.emptyGeom {
+		buf[1] |= 16
+	}
+	if hd.extendedPrecision {
+		// BIT   USAGE
+		// 1     has Z
+		// 2     has M
+		// 3-5   Z precision
+		// 6-8   M precision
+		var ext byte
+		if hd.hasZ {
+			ext |= 1 | byte(hd.zPrecision&7)<<2
+		}
+		if hd.hasM {
+			ext |= 2 | byte(hd.mPrecision&7)<<5
+		}
+		buf = append(buf, ext)
+	}
 	_, err := w.Write(buf)
 	return err
 }
 
+// layout returns which ordinates are encoded in addition to X and Y.
+func (hd twkbHeader) layout() Layout {
+	return newLayout(hd.hasZ, hd.hasM)
+}
+
+// dims returns the number of encoded dimensions.
+func (hd twkbHeader) dims() int {
+	var n = 2
+	if hd.hasZ {
+		n++
+	}
+	if hd.hasM {
+		n++
+	}
+	return n
+}
+
 func twkbWritePoint(w io.Writer, p Point, previous Point, precision int) error {
 	var (
-		xi  = int(p.X * math.Pow10(precision))
-		yi  = int(p.Y * math.Pow10(precision))
-		xpi = int(previous.X * math.Pow10(precision))
-		ypi = int(previous.Y * math.Pow10(precision))
+		xi  = twkbScale(p.X, precision)
+		yi  = twkbScale(p.Y, precision)
+		xpi = twkbScale(previous.X, precision)
+		ypi = twkbScale(previous.Y, precision)
 
 		buf = make([]byte, 20) // up to 10 bytes per varint
 	)
 
-	dx := int64(xi - xpi)
-	dy := int64(yi - ypi)
+	dx := xi - xpi
+	dy := yi - ypi
 	bwx := binary.PutVarint(buf, dx)
 	bwy := binary.PutVarint(buf[bwx:], dy)
 
@@ -58,6 +160,13 @@ type twkbHeader struct {
 	precision int
 	// metadata attributes
 	bbox, size, idList, extendedPrecision, emptyGeom bool
+	// extended dimensions, only present if extendedPrecision is set
+	hasZ, hasM             bool
+	zPrecision, mPrecision int
+}
+
+func zigzag(nVal int) int {
+	return (nVal << 1) ^ (nVal >> 31)
 }
 
 func unzigzag(nVal int) int {
@@ -81,14 +190,23 @@ func twkbReadHeader(r io.Reader) (twkbHeader, error) {
 		by = make([]byte, 2)
 		hd twkbHeader
 	)
-	_, err := r.Read(by)
+	_, err := io.ReadFull(r, by)
 	hd.typ = GeomType(by[0] & 15)
-	hd.precision = int(by[0] >> 4)
+	hd.precision = unzigzag(int(by[0] >> 4))
 	hd.bbox = int(by[1])&1 == 1
 	hd.size = int(by[1])&2 == 2
 	hd.idList = int(by[1])&4 == 4
 	hd.extendedPrecision = int(by[1])&8 == 8
 	hd.emptyGeom = int(by[1])&16 == 16
+	if err != nil || !hd.extendedPrecision {
+		return hd, err
+	}
+
+	_, err = io.ReadFull(r, by[:1])
+	hd.hasZ = by[0]&1 == 1
+	hd.hasM = by[0]&2 == 2
+	hd.zPrecision = int(by[0]>>2) & 7
+	hd.mPrecision = int(by[0]>>5) & 7
 	return hd, err
 }
 
@@ -119,37 +237,470 @@ func twkbReadLineString(r io.Reader, precision int) ([]Point, error) {
 	if !ok {
 		wr = &wrappedReader{r}
 	}
-	npoints, err := binary.ReadUvarint(wr)
-	if err != nil {
-		return nil, err
+	d := twkbDecoder{r: wr, hd: twkbHeader{precision: precision}}
+	return d.points(false)
+}
+
+func twkbWriteLineString(w io.Writer, ls []Point, precision int) error {
+	e := twkbEncoder{w: w, hd: twkbHeader{precision: precision}}
+	return e.points(ls, false)
+}
+
+func twkbScale(v float64, precision int) int64 {
+	return int64(math.Round(v * math.Pow10(precision)))
+}
+
+func twkbIsMulti(gt GeomType) bool {
+	return gt >= GeomTypeMultiPoint && gt <= GeomTypeGeometryCollection
+}
+
+func twkbWriteGeom(w io.Writer, g Geom, opts TWKBOptions) error {
+	if opts.Precision < -8 || opts.Precision > 7 {
+		return fmt.Errorf("TWKB precision must be between -8 and 7, got %v", opts.Precision)
+	}
+	if opts.ZPrecision < 0 || opts.ZPrecision > 7 || opts.MPrecision < 0 || opts.MPrecision > 7 {
+		return errors.New("TWKB Z and M precision must be between 0 and 7")
 	}
 
 	var (
-		ls     = make([]Point, npoints)
-		lastPt Point
+		layout = g.Layout()
+		hd     = twkbHeader{
+			typ:               g.typ,
+			precision:         opts.Precision,
+			bbox:              opts.BBox,
+			size:              opts.Size,
+			idList:            opts.IDs != nil && twkbIsMulti(g.typ),
+			extendedPrecision: layout != LayoutXY,
+			hasZ:              layout.HasZ(),
+			hasM:              layout.HasM(),
+			zPrecision:        opts.ZPrecision,
+			mPrecision:        opts.MPrecision,
+		}
+		body bytes.Buffer
 	)
-	for i := 0; i < int(npoints); i++ {
-		lastPt, err = twkbReadPoint(wr, lastPt, precision)
-		if err != nil {
-			return ls, err
+	if g.typ == GeomTypeEmpty || g.g == nil {
+		// TWKB has no type for empty geometries, so use the most generic one
+		hd.typ = GeomTypeGeometryCollection
+		hd.emptyGeom = true
+		hd.bbox, hd.size, hd.idList = false, false, false
+		return hd.write(w)
+	}
+
+	if hd.bbox {
+		if err := twkbWriteBBox(&body, g, hd); err != nil {
+			return err
+		}
+	}
+	e := twkbEncoder{w: &body, hd: hd, z: g.z, m: g.m}
+	if err := e.geom(g, opts); err != nil {
+		return err
+	}
+
+	if err := hd.write(w); err != nil {
+		return err
+	}
+	if hd.size {
+		var buf = make([]byte, binary.MaxVarintLen64)
+		if _, err := w.Write(buf[:binary.PutUvarint(buf, uint64(body.Len()))]); err != nil {
+			return err
 		}
-		ls[i] = lastPt
 	}
-	return ls, nil
+	_, err := w.Write(body.Bytes())
+	return err
 }
 
-func twkbWriteLineString(w io.Writer, ls []Point, precision int) error {
-	buf := make([]byte, 10)
-	bWritten := binary.PutUvarint(buf, uint64(len(ls)))
-	_, err := w.Write(buf[:bWritten-1])
-	if err != nil {
+// twkbWriteBBox writes minimum and extent of every dimension.
+func twkbWriteBBox(w io.Writer, g Geom, hd twkbHeader) error {
+	var (
+		dims     = hd.dims()
+		min, max = make([]int64, dims), make([]int64, dims)
+		first    = true
+		visit    func(g Geom)
+	)
+	visit = func(g Geom) {
+		if gc, ok := g.g.(GeomCollection); ok {
+			for _, m := range gc {
+				visit(m)
+			}
+			return
+		}
+		var (
+			parts, _ = g.parts()
+			pos      int
+		)
+		for _, part := range parts {
+			for _, pt := range part {
+				vals := []int64{twkbScale(pt.X, hd.precision), twkbScale(pt.Y, hd.precision)}
+				if hd.hasZ {
+					var z float64
+					if g.z != nil {
+						z = g.z[pos]
+					}
+					vals = append(vals, twkbScale(z, hd.zPrecision))
+				}
+				if hd.hasM {
+					var m float64
+					if g.m != nil {
+						m = g.m[pos]
+					}
+					vals = append(vals, twkbScale(m, hd.mPrecision))
+				}
+				for i, v := range vals {
+					if first || v < min[i] {
+						min[i] = v
+					}
+					if first || v > max[i] {
+						max[i] = v
+					}
+				}
+				first = false
+				pos++
+			}
+		}
+	}
+	visit(g)
+
+	var buf = make([]byte, 2*dims*binary.MaxVarintLen64)
+	var n int
+	for i := range min {
+		n += binary.PutVarint(buf[n:], min[i])
+		n += binary.PutVarint(buf[n:], max[i]-min[i])
+	}
+	_, err := w.Write(buf[:n])
+	return err
+}
+
+// twkbEncoder writes coordinates as deltas to the previous vertex. The deltas continue across all
+// parts of a geometry.
+type twkbEncoder struct {
+	w    io.Writer
+	hd   twkbHeader
+	z, m []float64
+	pos  int      // index of the next vertex in z and m
+	prev [4]int64 // x, y, z, m of the previous vertex
+}
+
+func (e *twkbEncoder) varint(v int64) error {
+	var buf = make([]byte, binary.MaxVarintLen64)
+	_, err := e.w.Write(buf[:binary.PutVarint(buf, v)])
+	return err
+}
+
+func (e *twkbEncoder) uvarint(v int) error {
+	var buf = make([]byte, binary.MaxVarintLen64)
+	_, err := e.w.Write(buf[:binary.PutUvarint(buf, uint64(v))])
+	return err
+}
+
+// vertex writes a point and the ordinates at index i.
+func (e *twkbEncoder) vertex(p Point, i int) error {
+	var vals = [4]int64{twkbScale(p.X, e.hd.precision), twkbScale(p.Y, e.hd.precision)}
+	dims := 2
+	if e.hd.hasZ {
+		vals[dims] = twkbScale(e.z[i], e.hd.zPrecision)
+		dims++
+	}
+	if e.hd.hasM {
+		vals[dims] = twkbScale(e.m[i], e.hd.mPrecision)
+		dims++
+	}
+	for d := 0; d < dims; d++ {
+		if err := e.varint(vals[d] - e.prev[d]); err != nil {
+			return err
+		}
+		e.prev[d] = vals[d]
+	}
+	return nil
+}
+
+// points writes a point array. Rings are closed by repeating the first point.
+func (e *twkbEncoder) points(ln Line, closed bool) error {
+	var n = len(ln)
+	if closed {
+		n++
+	}
+	if err := e.uvarint(n); err != nil {
 		return err
 	}
-	var previous Point
-	for _, pt := range ls {
-		if err = twkbWritePoint(w, pt, previous, precision); err != nil {
+	start := e.pos
+	for _, pt := range ln {
+		if err := e.vertex(pt, e.pos); err != nil {
 			return err
 		}
+		e.pos++
+	}
+	if closed {
+		return e.vertex(ln[0], start)
 	}
 	return nil
 }
+
+func (e *twkbEncoder) polygon(poly Polygon) error {
+	if err := e.uvarint(len(poly)); err != nil {
+		return err
+	}
+	for _, ring := range poly {
+		if err := e.points(ring, true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *twkbEncoder) members(n int, ids []int64) error {
+	if err := e.uvarint(n); err != nil {
+		return err
+	}
+	if !e.hd.idList {
+		return nil
+	}
+	if len(ids) != n {
+		return fmt.Errorf("geometry has %v members, but %v IDs were given", n, len(ids))
+	}
+	for _, id := range ids {
+		if err := e.varint(id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *twkbEncoder) geom(g Geom, opts TWKBOptions) error {
+	switch gm := g.g.(type) {
+	case *Point:
+		return e.vertex(*gm, 0)
+	case Line:
+		return e.points(gm, false)
+	case Polygon:
+		return e.polygon(gm)
+	case MultiPoint:
+		if err := e.members(len(gm), opts.IDs); err != nil {
+			return err
+		}
+		for i, pt := range gm {
+			if err := e.vertex(pt, i); err != nil {
+				return err
+			}
+		}
+	case MultiLine:
+		if err := e.members(len(gm), opts.IDs); err != nil {
+			return err
+		}
+		for _, ln := range gm {
+			if err := e.points(ln, false); err != nil {
+				return err
+			}
+		}
+	case MultiPolygon:
+		if err := e.members(len(gm), opts.IDs); err != nil {
+			return err
+		}
+		for _, poly := range gm {
+			if err := e.polygon(poly); err != nil {
+				return err
+			}
+		}
+	case GeomCollection:
+		if err := e.members(len(gm), opts.IDs); err != nil {
+			return err
+		}
+		memberOpts := opts
+		memberOpts.IDs = nil
+		for _, m := range gm {
+			if err := twkbWriteGeom(e.w, m, memberOpts); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("unsupported GeomType: %v", g.typ)
+	}
+	return nil
+}
+
+// twkbMaxPrealloc limits allocations based on counts from the input, which might be corrupt.
+const twkbMaxPrealloc = 1024
+
+func twkbPrealloc(n uint64) int {
+	if n > twkbMaxPrealloc {
+		return twkbMaxPrealloc
+	}
+	return int(n)
+}
+
+type twkbDecoder struct {
+	r    combinedReader
+	hd   twkbHeader
+	prev [4]int64
+	z, m []float64
+}
+
+func (d *twkbDecoder) count() (uint64, error) {
+	return binary.ReadUvarint(d.r)
+}
+
+func (d *twkbDecoder) vertex() (Point, error) {
+	var dims = d.hd.dims()
+	for i := 0; i < dims; i++ {
+		delta, err := binary.ReadVarint(d.r)
+		if err != nil {
+			return Point{}, err
+		}
+		d.prev[i] += delta
+	}
+	var (
+		pt  = Point{float64(d.prev[0]) / math.Pow10(d.hd.precision), float64(d.prev[1]) / math.Pow10(d.hd.precision)}
+		dim = 2
+	)
+	if d.hd.hasZ {
+		d.z = append(d.z, float64(d.prev[dim])/math.Pow10(d.hd.zPrecision))
+		dim++
+	}
+	if d.hd.hasM {
+		d.m = append(d.m, float64(d.prev[dim])/math.Pow10(d.hd.mPrecision))
+	}
+	return pt, nil
+}
+
+// points reads a point array. The closing point of rings is removed, as the internal representation
+// doesn't repeat it.
+func (d *twkbDecoder) points(closed bool) (Line, error) {
+	n, err := d.count()
+	if err != nil {
+		return nil, err
+	}
+	var ln = make(Line, 0, twkbPrealloc(n))
+	for i := uint64(0); i < n; i++ {
+		pt, err := d.vertex()
+		if err != nil {
+			return ln, err
+		}
+		ln = append(ln, pt)
+	}
+	if closed && len(ln) > 1 && ln[0] == ln[len(ln)-1] {
+		ln = ln[:len(ln)-1]
+		if d.hd.hasZ {
+			d.z = d.z[:len(d.z)-1]
+		}
+		if d.hd.hasM {
+			d.m = d.m[:len(d.m)-1]
+		}
+	}
+	return ln, nil
+}
+
+func (d *twkbDecoder) polygon() (Polygon, error) {
+	n, err := d.count()
+	if err != nil {
+		return nil, err
+	}
+	var poly = make(Polygon, 0, twkbPrealloc(n))
+	for i := uint64(0); i < n; i++ {
+		ring, err := d.points(true)
+		if err != nil {
+			return poly, err
+		}
+		poly = append(poly, ring)
+	}
+	return poly, nil
+}
+
+// members reads the member count and the id list, if present.
+func (d *twkbDecoder) members() (uint64, []int64, error) {
+	n, err := d.count()
+	if err != nil || !d.hd.idList {
+		return n, nil, err
+	}
+	var ids = make([]int64, 0, twkbPrealloc(n))
+	for i := uint64(0); i < n; i++ {
+		id, err := binary.ReadVarint(d.r)
+		if err != nil {
+			return n, ids, err
+		}
+		ids = append(ids, id)
+	}
+	return n, ids, nil
+}
+
+func twkbReadGeom(r combinedReader) (Geom, []int64, error) {
+	var g Geom
+	hd, err := twkbReadHeader(r)
+	if err != nil {
+		return g, nil, err
+	}
+	if hd.size {
+		if _, err = binary.ReadUvarint(r); err != nil {
+			return g, nil, err
+		}
+	}
+	if hd.emptyGeom {
+		return Geom{typ: GeomTypeEmpty}, nil, nil
+	}
+	if hd.bbox {
+		// the bbox can be derived from the geometry, so it is skipped
+		for i := 0; i < 2*hd.dims(); i++ {
+			if _, err = binary.ReadVarint(r); err != nil {
+				return g, nil, err
+			}
+		}
+	}
+
+	var (
+		d   = twkbDecoder{r: r, hd: hd}
+		ids []int64
+		n   uint64
+	)
+	g.typ = hd.typ
+	switch hd.typ {
+	case GeomTypePoint:
+		var pt Point
+		pt, err = d.vertex()
+		g.g = &pt
+	case GeomTypeLineString:
+		g.g, err = d.points(false)
+	case GeomTypePolygon:
+		g.g, err = d.polygon()
+	case GeomTypeMultiPoint:
+		n, ids, err = d.members()
+		var mp = make(MultiPoint, 0, twkbPrealloc(n))
+		for i := uint64(0); i < n && err == nil; i++ {
+			var pt Point
+			pt, err = d.vertex()
+			mp = append(mp, pt)
+		}
+		g.g = mp
+	case GeomTypeMultiLineString:
+		n, ids, err = d.members()
+		var ml = make(MultiLine, 0, twkbPrealloc(n))
+		for i := uint64(0); i < n && err == nil; i++ {
+			var ln Line
+			ln, err = d.points(false)
+			ml = append(ml, ln)
+		}
+		g.g = ml
+	case GeomTypeMultiPolygon:
+		n, ids, err = d.members()
+		var mp = make(MultiPolygon, 0, twkbPrealloc(n))
+		for i := uint64(0); i < n && err == nil; i++ {
+			var poly Polygon
+			poly, err = d.polygon()
+			mp = append(mp, poly)
+		}
+		g.g = mp
+	case GeomTypeGeometryCollection:
+		n, ids, err = d.members()
+		var gc = make(GeomCollection, 0, twkbPrealloc(n))
+		for i := uint64(0); i < n && err == nil; i++ {
+			var m Geom
+			m, _, err = twkbReadGeom(r)
+			gc = append(gc, m)
+		}
+		g.g = gc
+	default:
+		return g, nil, fmt.Errorf("unsupported GeomType: %v", hd.typ)
+	}
+	if err != nil {
+		return g, ids, err
+	}
+	g.z, g.m = d.z, d.m
+	return g, ids, nil
+}
@@ -10,12 +10,38 @@ import (
 )
 
 func TestTWKBReadHeader(t *testing.T) {
-	buf, err := hex.DecodeString("24FF")
+	// all flags are set, so the extended dimensions byte follows
+	buf, err := hex.DecodeString("24FF00")
 	assert.Nil(t, err)
 	r := bytes.NewBuffer(buf)
 	hd, err := twkbReadHeader(r)
 	assert.Nil(t, err)
 	assert.True(t, hd.bbox)
+	assert.Equal(t, LayoutXY, hd.layout())
+}
+
+func TestTWKBHeaderExtendedDims(t *testing.T) {
+	w := &bytes.Buffer{}
+	orig := twkbHeader{typ: GeomTypePoint, precision: 5, extendedPrecision: true, hasZ: true, zPrecision: 2, hasM: true, mPrecision: 7}
+	assert.Nil(t, orig.write(w))
+	assert.Equal(t, 3, w.Len())
+
+	hd, err := twkbReadHeader(w)
+	assert.Nil(t, err)
+	assert.Equal(t, orig, hd)
+	assert.Equal(t, LayoutXYZM, hd.layout())
+}
+
+func TestTWKBOrdinate(t *testing.T) {
+	g := geomWithOrdinates(t, Line{{1, 2}, {3, 4}, {5, 6}}, []float64{12.5, 10.25, 10.25}, []float64{1, -2.125, 3})
+	buf, err := g.MarshalTWKB(TWKBOptions{Precision: 0, ZPrecision: 2, MPrecision: 1})
+	assert.Nil(t, err)
+
+	rg, err := GeomFromTWKB(bytes.NewReader(buf))
+	assert.Nil(t, err)
+	assert.Equal(t, []float64{12.5, 10.25, 10.25}, rg.Z())
+	// M is rounded to one digit
+	assert.Equal(t, []float64{1, -2.1, 3}, rg.M())
 }
 
 func TestTWKBWriteHeader(t *testing.T) {
@@ -92,3 +118,91 @@ func BenchmarkTWKBReadRawPoint(b *testing.B) {
 		twkbReadPoint(r, Point{}, 0)
 	}
 }
+
+func TestTWKBRoundtrip(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		geom Geom
+		opts TWKBOptions
+	}{
+		{"point", MustNewGeom(Point{1.5, -2.25}), TWKBOptions{Precision: 2}},
+		{"linestring", MustNewGeom(Line{{1, 2}, {3, 4}, {-5, 6}}), TWKBOptions{}},
+		{"polygon", MustNewGeom(Polygon{{{0, 0}, {0, 10}, {10, 10}, {10, 0}}, {{2, 2}, {4, 2}, {4, 4}, {2, 4}}}), TWKBOptions{BBox: true}},
+		{"multipoint", MustNewGeom(MultiPoint{{1, 2}, {3, 4}}), TWKBOptions{Size: true, IDs: []int64{7, -9}}},
+		{"multilinestring", MustNewGeom(MultiLine{{{1, 2}, {3, 4}}, {{5, 6}, {7, 8}, {9, 9}}}), TWKBOptions{BBox: true, Size: true}},
+		{"multipolygon", MustNewGeom(MultiPolygon{
+			{{{0, 0}, {0, 10}, {10, 10}, {10, 0}}},
+			{{{20, 20}, {20, 30}, {30, 30}}},
+		}), TWKBOptions{Precision: -1}},
+		{"geometrycollection", MustNewGeom(GeomCollection{
+			MustNewGeom(Point{1, 2}),
+			MustNewGeom(Line{{1, 2}, {3, 4}}),
+		}), TWKBOptions{Size: true, IDs: []int64{1, 2}}},
+		{"linestringzm", geomWithOrdinates(t, Line{{1, 2}, {3, 4}}, []float64{5.5, 6.25}, []float64{7, 8}), TWKBOptions{ZPrecision: 2, BBox: true}},
+		{"multipolygonz", geomWithOrdinates(t, MultiPolygon{
+			{{{0, 0}, {0, 10}, {10, 10}}},
+			{{{20, 20}, {20, 30}, {30, 30}}},
+		}, []float64{1, 2, 3, 4, 5, 6}, nil), TWKBOptions{}},
+		{"empty", Geom{typ: GeomTypeEmpty}, TWKBOptions{}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			buf, err := tc.geom.MarshalTWKB(tc.opts)
+			assert.Nil(t, err)
+
+			rg, ids, err := ReadTWKB(bytes.NewReader(buf))
+			assert.Nil(t, err)
+			assert.Equal(t, tc.geom, rg)
+			assert.Equal(t, tc.opts.IDs, ids)
+
+			var ug Geom
+			assert.Nil(t, ug.UnmarshalTWKB(bytes.NewReader(buf)))
+			assert.Equal(t, tc.geom, ug)
+		})
+	}
+}
+
+func TestTWKBEncoding(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		geom Geom
+		opts TWKBOptions
+		hex  string
+	}{
+		// same as TestTWKBReadLine
+		{"linestring", MustNewGeom(Line{{1, 1}, {5, 5}}), TWKBOptions{}, "02000202020808"},
+		// header, xmin, Δx, ymin, Δy, point
+		{"bbox", MustNewGeom(Point{1, 2}), TWKBOptions{BBox: true}, "0101020004000204"},
+		// header, size, point
+		{"size", MustNewGeom(Point{1, 2}), TWKBOptions{Size: true}, "0102020204"},
+		// header, member count, ids, points
+		{"idlist", MustNewGeom(MultiPoint{{1, 2}, {3, 4}}), TWKBOptions{IDs: []int64{10, 20}}, "040402142802040404"},
+		// precision is stored zigzag encoded in the upper 4 bits
+		{"precision", MustNewGeom(Point{1.5, 2.25}), TWKBOptions{Precision: 2}, "4100ac02c203"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			buf, err := tc.geom.MarshalTWKB(tc.opts)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.hex, hex.EncodeToString(buf))
+		})
+	}
+}
+
+func TestTWKBPrecisionRounding(t *testing.T) {
+	g := MustNewGeom(Line{{1.23456, 2.34567}, {3.45678, 4.56789}})
+	buf, err := g.MarshalTWKB(TWKBOptions{Precision: 3})
+	assert.Nil(t, err)
+
+	rg, err := GeomFromTWKB(bytes.NewReader(buf))
+	assert.Nil(t, err)
+	assert.Equal(t, Line{{1.235, 2.346}, {3.457, 4.568}}, rg.MustLineString())
+}
+
+func TestTWKBInvalidOptions(t *testing.T) {
+	g := MustNewGeom(Point{1, 2})
+	_, err := g.MarshalTWKB(TWKBOptions{Precision: 8})
+	assert.NotNil(t, err)
+
+	mp := MustNewGeom(MultiPoint{{1, 2}, {3, 4}})
+	_, err = mp.MarshalTWKB(TWKBOptions{IDs: []int64{1}})
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,437 @@
+package spatial
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// ValidationReason describes why a geometry is invalid.
+type ValidationReason uint8
+
+const (
+	// ReasonSelfIntersection means that a ring or line crosses itself or another ring of the
+	// same geometry. Rings may only touch other rings in single points.
+	ReasonSelfIntersection ValidationReason = iota + 1
+	// ReasonRingNotClosed means that the last point of a ring didn't repeat the first one when
+	// it was read from GeoJSON, WKB or WKT. Such rings are closed implicitly, like all rings of
+	// Polygons, so MakeValid only needs to keep their points.
+	ReasonRingNotClosed
+	// ReasonTooFewPoints means that a ring has less than 3 or a line less than 2 distinct points.
+	ReasonTooFewPoints
+	// ReasonWrongWinding means that an outer ring isn't clockwise or a hole is clockwise, as
+	// defined by Line.Clockwise.
+	ReasonWrongWinding
+	// ReasonHoleOutsideShell means that a hole is not located inside of the outer ring.
+	ReasonHoleOutsideShell
+	// ReasonDuplicatePoints means that a point is repeated directly after itself. This includes
+	// rings which repeat their first point at the end.
+	ReasonDuplicatePoints
+)
+
+func (r ValidationReason) String() string {
+	switch r {
+	case ReasonSelfIntersection:
+		return "self-intersection"
+	case ReasonRingNotClosed:
+		return "ring not closed"
+	case ReasonTooFewPoints:
+		return "too few points"
+	case ReasonWrongWinding:
+		return "wrong winding"
+	case ReasonHoleOutsideShell:
+		return "hole outside shell"
+	case ReasonDuplicatePoints:
+		return "duplicate points"
+	}
+	return "unknown"
+}
+
+// ValidationError describes a single problem of a geometry.
+type ValidationError struct {
+	Reason   ValidationReason
+	Location Point
+	// Part is the index of the member of a multi geometry or collection, 0 for single geometries.
+	Part int
+	// Ring is the index of the ring inside of the polygon, 0 for lines.
+	Ring int
+}
+
+func (e ValidationError) Error() string {
+	return fmt.Sprintf("%v at %v %v (part %v, ring %v)", e.Reason, e.Location.X, e.Location.Y, e.Part, e.Ring)
+}
+
+// Validate checks the geometry and returns all problems which have been found. If the geometry
+// is valid, the result is empty. Points are always valid.
+func (g Geom) Validate() []ValidationError {
+	return append(append([]ValidationError(nil), g.unclosed...), g.validate()...)
+}
+
+func (g Geom) validate() []ValidationError {
+	switch gm := g.g.(type) {
+	case Line:
+		return validateLine(gm, 0)
+	case Polygon:
+		return validatePolygons([]Polygon{gm})
+	case MultiLine:
+		var errs []ValidationError
+		for n, ln := range gm {
+			errs = append(errs, validateLine(ln, n)...)
+		}
+		return errs
+	case MultiPolygon:
+		return validatePolygons(gm)
+	case GeomCollection:
+		var errs []ValidationError
+		for n, m := range gm {
+			for _, err := range m.Validate() {
+				err.Part = n
+				errs = append(errs, err)
+			}
+		}
+		return errs
+	}
+	return nil
+}
+
+// Validate checks the polygon, see Geom.Validate.
+func (p Polygon) Validate() []ValidationError {
+	return validatePolygons([]Polygon{p})
+}
+
+func validateLine(ln Line, part int) []ValidationError {
+	var errs []ValidationError
+	for _, pt := range duplicatePoints(ln, false) {
+		errs = append(errs, ValidationError{Reason: ReasonDuplicatePoints, Location: pt, Part: part})
+	}
+	if distinctPoints(ln) < 2 {
+		var loc Point
+		if len(ln) > 0 {
+			loc = ln[0]
+		}
+		errs = append(errs, ValidationError{Reason: ReasonTooFewPoints, Location: loc, Part: part})
+	}
+	return errs
+}
+
+func validatePolygons(polys []Polygon) []ValidationError {
+	var (
+		errs []ValidationError
+		segs []ringSegment
+	)
+	for np, poly := range polys {
+		for nr, ring := range poly {
+			for _, pt := range duplicatePoints(ring, true) {
+				errs = append(errs, ValidationError{Reason: ReasonDuplicatePoints, Location: pt, Part: np, Ring: nr})
+			}
+			if distinctPoints(ring) < 3 {
+				var loc Point
+				if len(ring) > 0 {
+					loc = ring[0]
+				}
+				errs = append(errs, ValidationError{Reason: ReasonTooFewPoints, Location: loc, Part: np, Ring: nr})
+				continue
+			}
+			// The winding of self-intersecting rings without area is undefined.
+			if ring.Area() != 0 && ring.Clockwise() != (nr == 0) {
+				errs = append(errs, ValidationError{Reason: ReasonWrongWinding, Location: ring[0], Part: np, Ring: nr})
+			}
+			if nr > 0 && len(poly[0]) >= 3 && !poly[0].containsRing(ring) {
+				errs = append(errs, ValidationError{Reason: ReasonHoleOutsideShell, Location: ring[0], Part: np, Ring: nr})
+			}
+			segs = appendRingSegments(segs, withoutDuplicates(ring, true), np, nr)
+		}
+	}
+	return append(errs, selfIntersections(segs)...)
+}
+
+// duplicatePoints returns the points which are directly followed by the same point.
+func duplicatePoints(ln Line, closed bool) []Point {
+	var dups []Point
+	for i := 1; i < len(ln); i++ {
+		if ln[i] == ln[i-1] {
+			dups = append(dups, ln[i])
+		}
+	}
+	if closed && len(ln) > 1 && ln[0] == ln[len(ln)-1] {
+		dups = append(dups, ln[0])
+	}
+	return dups
+}
+
+func distinctPoints(ln Line) int {
+	var seen = map[Point]struct{}{}
+	for _, pt := range ln {
+		seen[pt] = struct{}{}
+	}
+	return len(seen)
+}
+
+// openRing removes the closing point of a ring from a serialization format, which repeats the
+// first point. Rings which aren't closed keep all of their points, closed reports whether the
+// point has been removed.
+func openRing(ring Line) (ln Line, closed bool) {
+	if len(ring) < 2 || ring[0] != ring[len(ring)-1] {
+		return ring, false
+	}
+	return ring[:len(ring)-1], true
+}
+
+// ringNotClosed returns the error which Validate reports for a ring that has not been closed.
+func ringNotClosed(ring Line, part, n int) ValidationError {
+	var loc Point
+	if len(ring) > 0 {
+		loc = ring[len(ring)-1]
+	}
+	return ValidationError{Reason: ReasonRingNotClosed, Location: loc, Part: part, Ring: n}
+}
+
+type ringSegment struct {
+	seg        Segment
+	part, ring int
+	idx, n     int // index of the segment in the ring and number of segments of the ring
+}
+
+func (s ringSegment) adjacent(o ringSegment) bool {
+	if s.part != o.part || s.ring != o.ring {
+		return false
+	}
+	d := s.idx - o.idx
+	return d == 1 || d == -1 || d == s.n-1 || d == 1-s.n
+}
+
+func appendRingSegments(segs []ringSegment, ring Line, part, n int) []ringSegment {
+	for i, seg := range ring.SegmentsWithClosing() {
+		segs = append(segs, ringSegment{seg: seg, part: part, ring: n, idx: i, n: len(ring)})
+	}
+	return segs
+}
+
+// selfIntersections finds segments which cross each other. Segments are sorted by their
+// minimum X, so only segments with overlapping X ranges need to be compared. Points where more
+// than two segments intersect are only reported once per ring.
+func selfIntersections(segs []ringSegment) []ValidationError {
+	sort.Slice(segs, func(i, j int) bool {
+		return math.Min(segs[i].seg[0].X, segs[i].seg[1].X) < math.Min(segs[j].seg[0].X, segs[j].seg[1].X)
+	})
+	var (
+		errs []ValidationError
+		seen = map[ValidationError]bool{}
+	)
+	for i, s := range segs {
+		maxX := math.Max(s.seg[0].X, s.seg[1].X)
+		for _, o := range segs[i+1:] {
+			if math.Min(o.seg[0].X, o.seg[1].X) > maxX {
+				break
+			}
+			pt, kind := segmentIntersection(s.seg, o.seg)
+			var invalid bool
+			switch {
+			case kind == intersectNone:
+			case s.adjacent(o):
+				// adjacent segments share a point, but must not overlap
+				invalid = kind == intersectOverlap
+			case s.part == o.part && s.ring == o.ring:
+				invalid = true
+			default:
+				// different rings may touch
+				invalid = kind != intersectTouch
+			}
+			if invalid {
+				// the error is reported for the later one of both rings
+				r := s
+				if o.part > r.part || (o.part == r.part && o.ring > r.ring) {
+					r = o
+				}
+				verr := ValidationError{Reason: ReasonSelfIntersection, Location: pt, Part: r.part, Ring: r.ring}
+				if !seen[verr] {
+					seen[verr] = true
+					errs = append(errs, verr)
+				}
+			}
+		}
+	}
+	return errs
+}
+
+type intersectKind uint8
+
+const (
+	intersectNone intersectKind = iota
+	// intersectCross means that the segments cross in a point, which is not an end point.
+	intersectCross
+	// intersectTouch means that an end point of a segment is located on the other segment.
+	intersectTouch
+	// intersectOverlap means that the segments are collinear and share more than one point.
+	intersectOverlap
+)
+
+func segmentIntersection(a, b Segment) (Point, intersectKind) {
+	var (
+		o1 = orientation(a[0], a[1], b[0])
+		o2 = orientation(a[0], a[1], b[1])
+		o3 = orientation(b[0], b[1], a[0])
+		o4 = orientation(b[0], b[1], a[1])
+	)
+	if o1 == 0 && o2 == 0 {
+		return collinearIntersection(a, b)
+	}
+	if o1*o2 > 0 || o3*o4 > 0 {
+		return Point{}, intersectNone
+	}
+	switch {
+	case o1 == 0:
+		return b[0], intersectTouch
+	case o2 == 0:
+		return b[1], intersectTouch
+	case o3 == 0:
+		return a[0], intersectTouch
+	case o4 == 0:
+		return a[1], intersectTouch
+	}
+	pt, _ := a.Intersection(b)
+	return pt, intersectCross
+}
+
+// collinearIntersection handles segments which are located on the same line.
+func collinearIntersection(a, b Segment) (Point, intersectKind) {
+	// project onto the axis with the larger extent
+	var (
+		dx = math.Abs(a[1].X - a[0].X)
+		dy = math.Abs(a[1].Y - a[0].Y)
+		v  = func(p Point) float64 { return p.X }
+	)
+	if dy > dx {
+		v = func(p Point) float64 { return p.Y }
+	}
+	var (
+		aMin, aMax = a[0], a[1]
+		bMin, bMax = b[0], b[1]
+	)
+	if v(aMin) > v(aMax) {
+		aMin, aMax = aMax, aMin
+	}
+	if v(bMin) > v(bMax) {
+		bMin, bMax = bMax, bMin
+	}
+	var (
+		lo = aMin
+		hi = aMax
+	)
+	if v(bMin) > v(lo) {
+		lo = bMin
+	}
+	if v(bMax) < v(hi) {
+		hi = bMax
+	}
+	switch {
+	case v(lo) > v(hi):
+		return Point{}, intersectNone
+	case v(lo) == v(hi):
+		return lo, intersectTouch
+	}
+	return lo, intersectOverlap
+}
+
+// orientation returns 1 if c is left of the line from a to b, -1 if it is right of it and 0
+// if the points are collinear.
+func orientation(a, b, c Point) int {
+	switch v := cross(a, b, c); {
+	case v > 0:
+		return 1
+	case v < 0:
+		return -1
+	}
+	return 0
+}
+
+// MakeValid repairs the geometry, so that Validate doesn't find any problems:
+//
+// Duplicate points are removed, as well as lines and rings which have too few points. The
+// area of polygons is determined by the even-odd rule, which means that self-intersecting
+// rings are split into multiple parts and holes outside of their shell become separate
+// polygons. Overlapping parts of multi polygons are merged. The result of repairing a
+// polygonal geometry is a Polygon, MultiPolygon or empty. If nothing remains of a geometry,
+// an empty geometry is returned.
+func (g Geom) MakeValid() (Geom, error) {
+	switch gm := g.g.(type) {
+	case Line:
+		ln := withoutDuplicates(gm, false)
+		if distinctPoints(ln) < 2 {
+			return Geom{}, nil
+		}
+		return MustNewGeom(ln), nil
+	case MultiLine:
+		var ml MultiLine
+		for _, ln := range gm {
+			ln = withoutDuplicates(ln, false)
+			if distinctPoints(ln) >= 2 {
+				ml = append(ml, ln)
+			}
+		}
+		if len(ml) == 0 {
+			return Geom{}, nil
+		}
+		return MustNewGeom(ml), nil
+	case Polygon:
+		return makeValidPolygons(g, []Polygon{gm})
+	case MultiPolygon:
+		return makeValidPolygons(g, gm)
+	case GeomCollection:
+		var gc GeomCollection
+		for _, m := range gm {
+			vm, err := m.MakeValid()
+			if err != nil {
+				return Geom{}, err
+			}
+			if vm.g != nil {
+				gc = append(gc, vm)
+			}
+		}
+		if len(gc) == 0 {
+			return Geom{}, nil
+		}
+		return MustNewGeom(gc), nil
+	}
+	return g, nil
+}
+
+func makeValidPolygons(g Geom, polys []Polygon) (Geom, error) {
+	var rings []overlayRing
+	for _, poly := range polys {
+		var polyRings []overlayRing
+		for _, ring := range poly {
+			if ring = withoutDuplicates(ring, true); distinctPoints(ring) >= 3 {
+				polyRings = append(polyRings, overlayRing{ring: ring, sign: 1})
+			}
+		}
+		resolved, err := overlayPolygons(polyRings, nil, fillEvenOdd, opUnion)
+		if err != nil {
+			return Geom{}, err
+		}
+		rings = appendPolygonRings(rings, resolved...)
+	}
+	// The parts might overlap each other, so they are merged.
+	merged, err := overlayPolygons(rings, nil, fillPositive, opUnion)
+	if err != nil {
+		return Geom{}, err
+	}
+	res := polygonsToGeom(merged)
+	res.inheritOrdinates(g)
+	return res, nil
+}
+
+// withoutDuplicates returns a copy of the line without points which repeat their predecessor.
+// For rings, a closing point which repeats the first point is removed as well.
+func withoutDuplicates(ln Line, closed bool) Line {
+	var res = make(Line, 0, len(ln))
+	for _, pt := range ln {
+		if len(res) == 0 || res[len(res)-1] != pt {
+			res = append(res, pt)
+		}
+	}
+	if closed && len(res) > 1 && res[0] == res[len(res)-1] {
+		res = res[:len(res)-1]
+	}
+	return res
+}
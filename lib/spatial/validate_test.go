@@ -0,0 +1,185 @@
+package spatial
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate(t *testing.T) {
+	var (
+		shell = Line{{0, 0}, {10, 0}, {10, 10}, {0, 10}}
+		hole  = Line{{2, 2}, {2, 4}, {4, 4}, {4, 2}}
+	)
+	for _, tc := range []struct {
+		name string
+		geom Geom
+		errs []ValidationError
+	}{
+		{"point", MustNewGeom(Point{1, 1}), nil},
+		{"valid line", MustNewGeom(Line{{0, 0}, {1, 1}, {0, 1}, {1, 0}}), nil},
+		{"valid polygon", MustNewGeom(Polygon{shell, hole}), nil},
+		{
+			"hole touching shell",
+			MustNewGeom(Polygon{shell, {{0, 5}, {2, 6}, {2, 4}}}),
+			nil,
+		},
+		{
+			"touching parts",
+			MustNewGeom(MultiPolygon{{shell}, {{{10, 10}, {20, 10}, {20, 20}}}}),
+			nil,
+		},
+		{
+			"line with single point",
+			MustNewGeom(Line{{1, 1}, {1, 1}}),
+			[]ValidationError{
+				{Reason: ReasonDuplicatePoints, Location: Point{1, 1}},
+				{Reason: ReasonTooFewPoints, Location: Point{1, 1}},
+			},
+		},
+		{
+			"bowtie",
+			MustNewGeom(Polygon{{{0, 0}, {2, 2}, {2, 0}, {0, 2}}}),
+			[]ValidationError{{Reason: ReasonSelfIntersection, Location: Point{1, 1}}},
+		},
+		{
+			"spike",
+			MustNewGeom(Polygon{{{0, 0}, {4, 0}, {4, 4}, {4, 6}, {4, 5}, {0, 4}}}),
+			[]ValidationError{{Reason: ReasonSelfIntersection, Location: Point{4, 5}}},
+		},
+		{
+			"star",
+			// three segments cross at the same point
+			MustNewGeom(Polygon{{{0, 0}, {6, 6}, {6, 3}, {0, 3}, {6, 0}, {0, 6}}}),
+			[]ValidationError{
+				{Reason: ReasonSelfIntersection, Location: Point{3, 3}},
+				{Reason: ReasonSelfIntersection, Location: Point{2, 2}},
+				{Reason: ReasonSelfIntersection, Location: Point{0, 3}},
+			},
+		},
+		{
+			"duplicate points",
+			MustNewGeom(Polygon{{{0, 0}, {10, 0}, {10, 0}, {10, 10}, {0, 10}, {0, 0}}}),
+			[]ValidationError{
+				{Reason: ReasonDuplicatePoints, Location: Point{10, 0}},
+				{Reason: ReasonDuplicatePoints, Location: Point{0, 0}},
+			},
+		},
+		{
+			"too few points",
+			MustNewGeom(Polygon{shell, {{1, 1}, {2, 2}, {1, 1}}}),
+			[]ValidationError{
+				{Reason: ReasonDuplicatePoints, Location: Point{1, 1}, Ring: 1},
+				{Reason: ReasonTooFewPoints, Location: Point{1, 1}, Ring: 1},
+			},
+		},
+		{
+			"wrong winding",
+			MustNewGeom(Polygon{Line{{0, 0}, {0, 10}, {10, 10}, {10, 0}}}),
+			[]ValidationError{{Reason: ReasonWrongWinding, Location: Point{0, 0}}},
+		},
+		{
+			"hole outside shell",
+			MustNewGeom(Polygon{shell, {{12, 2}, {12, 4}, {14, 4}, {14, 2}}}),
+			[]ValidationError{{Reason: ReasonHoleOutsideShell, Location: Point{12, 2}, Ring: 1}},
+		},
+		{
+			"overlapping parts",
+			MustNewGeom(MultiPolygon{{shell}, {{{5, 5}, {15, 5}, {15, 15}, {5, 15}}}}),
+			[]ValidationError{
+				{Reason: ReasonSelfIntersection, Location: Point{10, 5}, Part: 1},
+				{Reason: ReasonSelfIntersection, Location: Point{5, 10}, Part: 1},
+			},
+		},
+		{
+			"collection",
+			MustNewGeom(GeomCollection{MustNewGeom(Point{0, 0}), MustNewGeom(Line{{1, 1}})}),
+			[]ValidationError{{Reason: ReasonTooFewPoints, Location: Point{1, 1}, Part: 1}},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.ElementsMatch(t, tc.errs, tc.geom.Validate())
+		})
+	}
+}
+
+func TestMakeValid(t *testing.T) {
+	var shell = Line{{0, 0}, {10, 0}, {10, 10}, {0, 10}}
+	for _, tc := range []struct {
+		name string
+		geom Geom
+		area float64
+		typ  GeomType
+	}{
+		{"bowtie", MustNewGeom(Polygon{{{0, 0}, {2, 2}, {2, 0}, {0, 2}}}), 2, GeomTypeMultiPolygon},
+		{"duplicate points", MustNewGeom(Polygon{{{0, 0}, {10, 0}, {10, 0}, {10, 10}, {0, 10}, {0, 0}}}), 100, GeomTypePolygon},
+		{"wrong winding", MustNewGeom(Polygon{Line{{0, 0}, {0, 10}, {10, 10}, {10, 0}}}), 100, GeomTypePolygon},
+		{"hole outside shell", MustNewGeom(Polygon{shell, {{12, 2}, {12, 4}, {14, 4}, {14, 2}}}), 104, GeomTypeMultiPolygon},
+		{"overlapping parts", MustNewGeom(MultiPolygon{{shell}, {{{5, 5}, {15, 5}, {15, 15}, {5, 15}}}}), 175, GeomTypePolygon},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.NotEmpty(t, tc.geom.Validate())
+			g, err := tc.geom.MakeValid()
+			assert.NoError(t, err)
+			assert.Empty(t, g.Validate())
+			assert.Equal(t, tc.typ, g.Typ())
+			assert.InDelta(t, tc.area, overlayArea(t, g), 1e-9)
+		})
+	}
+
+	t.Run("degenerate", func(t *testing.T) {
+		g, err := MustNewGeom(Polygon{{{1, 1}, {2, 2}, {1, 1}}}).MakeValid()
+		assert.NoError(t, err)
+		assert.Equal(t, Geom{}, g)
+
+		g, err = MustNewGeom(Line{{1, 1}, {1, 1}, {2, 2}}).MakeValid()
+		assert.NoError(t, err)
+		assert.Equal(t, MustNewGeom(Line{{1, 1}, {2, 2}}), g)
+	})
+}
+
+func TestReadUnclosedRing(t *testing.T) {
+	var g Geom
+	assert.Nil(t, json.Unmarshal([]byte(`{"type": "Polygon", "coordinates": [[[0, 0], [1, 0], [1, 1], [0, 1]]]}`), &g))
+	assert.Equal(t, Polygon{{{0, 0}, {1, 0}, {1, 1}, {0, 1}}}, g.MustPolygon())
+	assert.Equal(t, []ValidationError{{Reason: ReasonRingNotClosed, Location: Point{0, 1}}}, g.Validate())
+	vg, err := g.MakeValid()
+	assert.Nil(t, err)
+	assert.Empty(t, vg.Validate())
+	assert.InDelta(t, 1, overlayArea(t, vg), 1e-9)
+
+	assert.Nil(t, json.Unmarshal([]byte(`{"type": "MultiPolygon", "coordinates": [[[[0, 0], [1, 0], [0, 1], [0, 0]]], [[]]]}`), &g))
+	assert.Equal(t, []ValidationError{
+		{Reason: ReasonRingNotClosed, Part: 1},
+		{Reason: ReasonTooFewPoints, Part: 1},
+	}, g.Validate())
+	vg, err = g.MakeValid()
+	assert.Nil(t, err)
+	assert.Equal(t, GeomTypePolygon, vg.Typ())
+
+	g, err = ParseWKT("MULTIPOLYGON(((5 5, 6 5, 6 6, 5 5)), ((0 0, 1 0, 1 1)))")
+	assert.Nil(t, err)
+	assert.Equal(t, []ValidationError{{Reason: ReasonRingNotClosed, Location: Point{1, 1}, Part: 1}}, g.Validate())
+
+	g, err = ParseWKT("POLYGON Z((0 0 1, 1 0 2, 1 1 3))")
+	assert.Nil(t, err)
+	assert.Equal(t, []float64{1, 2, 3}, g.Z())
+	assert.Equal(t, []ValidationError{{Reason: ReasonRingNotClosed, Location: Point{1, 1}}}, g.Validate())
+
+	// WKB ring with 3 points, which is not closed
+	var buf bytes.Buffer
+	buf.Write([]byte{1, 3, 0, 0, 0, 1, 0, 0, 0, 3, 0, 0, 0})
+	binary.Write(&buf, binary.LittleEndian, []float64{0, 0, 1, 0, 1, 1})
+	g, err = GeomFromWKB(&buf)
+	assert.Nil(t, err)
+	assert.Equal(t, Polygon{{{0, 0}, {1, 0}, {1, 1}}}, g.MustPolygon())
+	assert.Equal(t, []ValidationError{{Reason: ReasonRingNotClosed, Location: Point{1, 1}}}, g.Validate())
+
+	// closed rings are valid
+	g, err = ParseWKT("POLYGON((0 0, 1 1, 0 1, 0 0))")
+	assert.Nil(t, err)
+	assert.Empty(t, g.Validate())
+}
@@ -0,0 +1,366 @@
+package spatial
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+)
+
+// ValueType is the type of a property value, see Value.
+type ValueType uint8
+
+const (
+	ValueTypeNull   ValueType = 0
+	ValueTypeBool   ValueType = 1
+	ValueTypeInt    ValueType = 2
+	ValueTypeUint   ValueType = 3
+	ValueTypeFloat  ValueType = 4
+	ValueTypeString ValueType = 5
+	ValueTypeList   ValueType = 6
+	ValueTypeMap    ValueType = 7
+)
+
+func (t ValueType) String() string {
+	switch t {
+	case ValueTypeNull:
+		return "null"
+	case ValueTypeBool:
+		return "bool"
+	case ValueTypeInt:
+		return "int64"
+	case ValueTypeUint:
+		return "uint64"
+	case ValueTypeFloat:
+		return "float64"
+	case ValueTypeString:
+		return "string"
+	case ValueTypeList:
+		return "list"
+	case ValueTypeMap:
+		return "map"
+	}
+	return "unknown"
+}
+
+// Value is a typed property value. Properties of features are stored as interface{} values, codecs
+// use ValueOf to determine their type, so all codecs interpret properties the same way.
+//
+// Values holding lists or maps must not be compared with ==.
+type Value struct {
+	typ ValueType
+	v   interface{}
+}
+
+// NullValue and the following functions create values of the respective type.
+func NullValue() Value                  { return Value{} }
+func BoolValue(b bool) Value            { return Value{typ: ValueTypeBool, v: b} }
+func IntValue(i int64) Value            { return Value{typ: ValueTypeInt, v: i} }
+func UintValue(u uint64) Value          { return Value{typ: ValueTypeUint, v: u} }
+func FloatValue(f float64) Value        { return Value{typ: ValueTypeFloat, v: f} }
+func StringValue(s string) Value        { return Value{typ: ValueTypeString, v: s} }
+func ListValue(l []Value) Value         { return Value{typ: ValueTypeList, v: l} }
+func MapValue(m map[string]Value) Value { return Value{typ: ValueTypeMap, v: m} }
+
+// ValueOf converts a Go value into a Value. The following coercions are applied:
+//
+//	nil and nil pointers              null
+//	signed integers                   int64
+//	unsigned integers                 uint64
+//	float32                           float64
+//	[]byte                            string
+//	json.Number                       int64 if it is an integer, uint64 if it only fits into
+//	                                  uint64, otherwise float64
+//	slices and arrays                 list
+//	maps with string keys             map
+//	pointers                          the value they point to
+//
+// Types which are defined on top of these, e.g. type Name string, are treated like their
+// underlying type. Other types result in an error, they are not converted into strings.
+func ValueOf(v interface{}) (Value, error) {
+	switch t := v.(type) {
+	case nil:
+		return NullValue(), nil
+	case Value:
+		return t, nil
+	case bool:
+		return BoolValue(t), nil
+	case int:
+		return IntValue(int64(t)), nil
+	case int8:
+		return IntValue(int64(t)), nil
+	case int16:
+		return IntValue(int64(t)), nil
+	case int32:
+		return IntValue(int64(t)), nil
+	case int64:
+		return IntValue(t), nil
+	case uint:
+		return UintValue(uint64(t)), nil
+	case uint8:
+		return UintValue(uint64(t)), nil
+	case uint16:
+		return UintValue(uint64(t)), nil
+	case uint32:
+		return UintValue(uint64(t)), nil
+	case uint64:
+		return UintValue(t), nil
+	case float32:
+		return FloatValue(float64(t)), nil
+	case float64:
+		return FloatValue(t), nil
+	case string:
+		return StringValue(t), nil
+	case []byte:
+		return StringValue(string(t)), nil
+	case json.Number:
+		return numberValue(string(t))
+	case []interface{}:
+		var l = make([]Value, 0, len(t))
+		for _, e := range t {
+			ev, err := ValueOf(e)
+			if err != nil {
+				return Value{}, err
+			}
+			l = append(l, ev)
+		}
+		return ListValue(l), nil
+	case map[string]interface{}:
+		var m = make(map[string]Value, len(t))
+		for k, e := range t {
+			ev, err := ValueOf(e)
+			if err != nil {
+				return Value{}, err
+			}
+			m[k] = ev
+		}
+		return MapValue(m), nil
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Bool:
+		return BoolValue(rv.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return IntValue(rv.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return UintValue(rv.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return FloatValue(rv.Float()), nil
+	case reflect.String:
+		return StringValue(rv.String()), nil
+	case reflect.Ptr:
+		if rv.IsNil() {
+			return NullValue(), nil
+		}
+		return ValueOf(rv.Elem().Interface())
+	case reflect.Slice, reflect.Array:
+		if rv.Kind() == reflect.Slice && rv.IsNil() {
+			return NullValue(), nil
+		}
+		var l = make([]Value, 0, rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			ev, err := ValueOf(rv.Index(i).Interface())
+			if err != nil {
+				return Value{}, err
+			}
+			l = append(l, ev)
+		}
+		return ListValue(l), nil
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			break
+		}
+		if rv.IsNil() {
+			return NullValue(), nil
+		}
+		var m = make(map[string]Value, rv.Len())
+		for _, k := range rv.MapKeys() {
+			ev, err := ValueOf(rv.MapIndex(k).Interface())
+			if err != nil {
+				return Value{}, err
+			}
+			m[k.String()] = ev
+		}
+		return MapValue(m), nil
+	}
+	return Value{}, fmt.Errorf("unsupported property value type %T (value: %v)", v, v)
+}
+
+func numberValue(s string) (Value, error) {
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return IntValue(i), nil
+	}
+	if u, err := strconv.ParseUint(s, 10, 64); err == nil {
+		return UintValue(u), nil
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return Value{}, fmt.Errorf("invalid number %q", s)
+	}
+	return FloatValue(f), nil
+}
+
+// NormalizeProps replaces all values in props by their canonical Go values, see Value.Interface.
+func NormalizeProps(props map[string]interface{}) error {
+	for k, v := range props {
+		val, err := ValueOf(v)
+		if err != nil {
+			return fmt.Errorf("property %s: %v", k, err)
+		}
+		props[k] = val.Interface()
+	}
+	return nil
+}
+
+// equalValues compares property values. Values are compared by their canonical Go values, see
+// Value.Interface, so a Value equals the plain value it holds. Unlike ==, it doesn't panic for
+// lists and maps, which are compared deeply.
+func equalValues(a, b interface{}) bool {
+	va, erra := ValueOf(a)
+	vb, errb := ValueOf(b)
+	if erra == nil && errb == nil {
+		a, b = va.Interface(), vb.Interface()
+	}
+	ta := reflect.TypeOf(a)
+	if ta != reflect.TypeOf(b) {
+		return false
+	}
+	if ta == nil {
+		return true
+	}
+	switch ta.Kind() {
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64, reflect.String:
+		return a == b
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func (v Value) Typ() ValueType {
+	return v.typ
+}
+
+func (v Value) IsNull() bool {
+	return v.typ == ValueTypeNull
+}
+
+// Bool returns the value of a bool.
+func (v Value) Bool() (bool, bool) {
+	b, ok := v.v.(bool)
+	return b, ok
+}
+
+// Int returns the value of a number, if it can be represented as int64 without loss. Floats
+// need to be integral.
+func (v Value) Int() (int64, bool) {
+	switch n := v.v.(type) {
+	case int64:
+		return n, true
+	case uint64:
+		if n <= math.MaxInt64 {
+			return int64(n), true
+		}
+	case float64:
+		if n == math.Trunc(n) && n >= math.MinInt64 && n < math.MaxInt64 {
+			return int64(n), true
+		}
+	}
+	return 0, false
+}
+
+// Uint returns the value of a number, if it can be represented as uint64 without loss. Floats
+// need to be integral.
+func (v Value) Uint() (uint64, bool) {
+	switch n := v.v.(type) {
+	case int64:
+		if n >= 0 {
+			return uint64(n), true
+		}
+	case uint64:
+		return n, true
+	case float64:
+		if n == math.Trunc(n) && n >= 0 && n < math.MaxUint64 {
+			return uint64(n), true
+		}
+	}
+	return 0, false
+}
+
+// Float returns the value of a number. Integers with more than 53 significant bits are rounded.
+func (v Value) Float() (float64, bool) {
+	switch n := v.v.(type) {
+	case int64:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+// List returns the elements of a list or nil.
+func (v Value) List() []Value {
+	l, _ := v.v.([]Value)
+	return l
+}
+
+// Map returns the entries of a map or nil.
+func (v Value) Map() map[string]Value {
+	m, _ := v.v.(map[string]Value)
+	return m
+}
+
+// String returns a string itself and the textual representation of all other types: an empty
+// string for null, "true" or "false" for bools, the shortest representation of numbers, which
+// parses back into the same value, and JSON for lists and maps.
+func (v Value) String() string {
+	switch n := v.v.(type) {
+	case nil:
+		return ""
+	case bool:
+		return strconv.FormatBool(n)
+	case int64:
+		return strconv.FormatInt(n, 10)
+	case uint64:
+		return strconv.FormatUint(n, 10)
+	case float64:
+		return strconv.FormatFloat(n, 'g', -1, 64)
+	case string:
+		return n
+	}
+	buf, _ := json.Marshal(v)
+	return string(buf)
+}
+
+// Interface returns the value as nil, bool, int64, uint64, float64, string, []interface{} or
+// map[string]interface{}.
+func (v Value) Interface() interface{} {
+	switch v.typ {
+	case ValueTypeList:
+		var l = make([]interface{}, 0, len(v.List()))
+		for _, e := range v.List() {
+			l = append(l, e.Interface())
+		}
+		return l
+	case ValueTypeMap:
+		var m = make(map[string]interface{}, len(v.Map()))
+		for k, e := range v.Map() {
+			m[k] = e.Interface()
+		}
+		return m
+	}
+	return v.v
+}
+
+// MarshalJSON encodes the value as JSON. NaN and infinite floats are encoded as null, as JSON
+// can't represent them.
+func (v Value) MarshalJSON() ([]byte, error) {
+	if f, ok := v.v.(float64); ok && (math.IsNaN(f) || math.IsInf(f, 0)) {
+		return []byte("null"), nil
+	}
+	return json.Marshal(v.v)
+}
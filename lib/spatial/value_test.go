@@ -0,0 +1,123 @@
+package spatial
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValueOf(t *testing.T) {
+	type name string
+	var (
+		i   = 5
+		nip *int
+	)
+	for _, tc := range []struct {
+		name     string
+		in       interface{}
+		typ      ValueType
+		expected interface{}
+	}{
+		{"nil", nil, ValueTypeNull, nil},
+		{"bool", true, ValueTypeBool, true},
+		{"int", 3, ValueTypeInt, int64(3)},
+		{"int8", int8(-3), ValueTypeInt, int64(-3)},
+		{"uint16", uint16(3), ValueTypeUint, uint64(3)},
+		{"uint64", uint64(math.MaxUint64), ValueTypeUint, uint64(math.MaxUint64)},
+		{"float32", float32(0.5), ValueTypeFloat, 0.5},
+		{"string", "a", ValueTypeString, "a"},
+		{"bytes", []byte("a"), ValueTypeString, "a"},
+		{"named string", name("a"), ValueTypeString, "a"},
+		{"json int", json.Number("-12"), ValueTypeInt, int64(-12)},
+		{"json uint", json.Number("18446744073709551615"), ValueTypeUint, uint64(math.MaxUint64)},
+		{"json float", json.Number("1.5"), ValueTypeFloat, 1.5},
+		{"pointer", &i, ValueTypeInt, int64(5)},
+		{"nil pointer", nip, ValueTypeNull, nil},
+		{"list", []interface{}{1, "a", nil}, ValueTypeList, []interface{}{int64(1), "a", nil}},
+		{"string list", []string{"a", "b"}, ValueTypeList, []interface{}{"a", "b"}},
+		{"map", map[string]int{"a": 1}, ValueTypeMap, map[string]interface{}{"a": int64(1)}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			v, err := ValueOf(tc.in)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.typ, v.Typ())
+			assert.Equal(t, tc.expected, v.Interface())
+		})
+	}
+
+	for _, in := range []interface{}{struct{}{}, map[int]string{1: "a"}, []interface{}{func() {}}, json.Number("x")} {
+		_, err := ValueOf(in)
+		assert.NotNil(t, err, "%T", in)
+	}
+}
+
+func TestValueConversions(t *testing.T) {
+	i, ok := UintValue(math.MaxUint64).Int()
+	assert.False(t, ok)
+	i, ok = FloatValue(3).Int()
+	assert.True(t, ok)
+	assert.Equal(t, int64(3), i)
+	_, ok = FloatValue(3.5).Int()
+	assert.False(t, ok)
+	_, ok = StringValue("3").Int()
+	assert.False(t, ok)
+
+	u, ok := IntValue(7).Uint()
+	assert.True(t, ok)
+	assert.Equal(t, uint64(7), u)
+	_, ok = IntValue(-7).Uint()
+	assert.False(t, ok)
+
+	f, ok := IntValue(-2).Float()
+	assert.True(t, ok)
+	assert.Equal(t, -2.0, f)
+	_, ok = BoolValue(true).Float()
+	assert.False(t, ok)
+}
+
+func TestValueString(t *testing.T) {
+	for _, tc := range []struct {
+		v        Value
+		expected string
+	}{
+		{NullValue(), ""},
+		{BoolValue(false), "false"},
+		{IntValue(-3), "-3"},
+		{UintValue(math.MaxUint64), "18446744073709551615"},
+		{FloatValue(0.1), "0.1"},
+		{FloatValue(1e21), "1e+21"},
+		{StringValue("a"), "a"},
+		{ListValue([]Value{IntValue(1), StringValue("a")}), `[1,"a"]`},
+		{MapValue(map[string]Value{"b": NullValue(), "a": FloatValue(math.NaN())}), `{"a":null,"b":null}`},
+	} {
+		assert.Equal(t, tc.expected, tc.v.String())
+	}
+}
+
+func TestFeatureMarshalJSONProps(t *testing.T) {
+	ft := Feature{
+		Props:    map[string]interface{}{"big": uint64(math.MaxUint64), "nan": math.NaN(), "list": []int{1, 2}},
+		Geometry: MustNewGeom(Point{1, 2}),
+	}
+	buf, err := json.Marshal(ft)
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"type": "Feature", "properties": {"big": 18446744073709551615, "nan": null, "list": [1, 2]}, "geometry": {"type": "Point", "coordinates": [1, 2]}}`, string(buf))
+
+	ft.Props["invalid"] = struct{}{}
+	_, err = json.Marshal(ft)
+	assert.NotNil(t, err)
+}
+
+func TestEqualValues(t *testing.T) {
+	list := ListValue([]Value{IntValue(1), StringValue("a")})
+	assert.True(t, equalValues(list, ListValue([]Value{IntValue(1), StringValue("a")})))
+	assert.False(t, equalValues(list, ListValue([]Value{IntValue(1)})))
+	assert.True(t, equalValues(list, []interface{}{1, "a"}))
+	assert.True(t, equalValues(MapValue(map[string]Value{"a": list}), map[string]interface{}{"a": []interface{}{int64(1), "a"}}))
+	assert.True(t, equalValues(IntValue(3), 3))
+	assert.False(t, equalValues(IntValue(3), "3"))
+	assert.True(t, equalValues(nil, NullValue()))
+	assert.False(t, equalValues(1, nil))
+}
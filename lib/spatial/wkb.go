@@ -34,30 +34,132 @@ func GeomFromWKB(r io.Reader) (Geom, error) {
 		return g, errors.New("only little endian is supported")
 	}
 
-	g.typ, err = wkbReadHeader(r)
+	var layout Layout
+	g.typ, layout, err = wkbReadHeader(r)
 	if err != nil {
 		return g, err
 	}
+	var ord = &wkbOrdinates{layout: layout}
 	switch g.typ {
 	case GeomTypePoint:
 		var pt Point
-		pt, err = wkbReadPoint(r)
+		pt, err = wkbReadPoint(r, ord)
 		g.g = &pt
 	case GeomTypeLineString:
-		g.g, err = wkbReadLineString(r)
+		g.g, err = wkbReadLineString(r, ord)
 	case GeomTypePolygon:
-		g.g, err = wkbReadPolygon(r)
+		g.g, g.unclosed, err = wkbReadPolygon(r, ord)
+	case GeomTypeMultiPoint:
+		g.g, err = wkbReadMultiPoint(r, &g)
+	case GeomTypeMultiLineString:
+		g.g, err = wkbReadMultiLineString(r, &g)
+	case GeomTypeMultiPolygon:
+		g.g, err = wkbReadMultiPolygon(r, &g)
+	case GeomTypeGeometryCollection:
+		g.g, err = wkbReadGeometryCollection(r)
 	default:
 		return g, fmt.Errorf("unsupported GeomType: %v", g.typ)
 	}
-	return g, err
+	if err != nil {
+		return g, err
+	}
+	if g.typ <= GeomTypePolygon {
+		g.z, g.m = ord.z, ord.m
+	}
+	return g, nil
 }
 
-func wkbReadHeader(r io.Reader) (GeomType, error) {
+const (
+	ewkbFlagZ    = 0x80000000
+	ewkbFlagM    = 0x40000000
+	ewkbFlagSRID = 0x20000000
+)
+
+// wkbReadHeader reads the geometry type. Z and M are recognized both as ISO type codes (e.g.
+// 1003 for a PolygonZ) and as EWKB flags, as written by PostGIS.
+func wkbReadHeader(r io.Reader) (GeomType, Layout, error) {
 	var buf = make([]byte, 4)
-	_, err := r.Read(buf)
+	_, err := io.ReadFull(r, buf)
+	if err != nil {
+		return GeomTypeInvalid, LayoutXY, err
+	}
 	gt := endianness.Uint32(buf)
-	return GeomType(gt), err
+	if gt&(ewkbFlagZ|ewkbFlagM|ewkbFlagSRID) != 0 {
+		layout := newLayout(gt&ewkbFlagZ != 0, gt&ewkbFlagM != 0)
+		if gt&ewkbFlagSRID != 0 {
+			// the SRID is not part of Geom, so it is skipped
+			if _, err = io.ReadFull(r, buf); err != nil {
+				return GeomTypeInvalid, layout, err
+			}
+		}
+		return GeomType(gt &^ (ewkbFlagZ | ewkbFlagM | ewkbFlagSRID)), layout, nil
+	}
+	if gt/1000 > uint32(LayoutXYZM) {
+		return GeomTypeInvalid, LayoutXY, fmt.Errorf("invalid WKB geometry type %v", gt)
+	}
+	// ISO type codes are offset by 1000 per dimension combination, just like Layout
+	return GeomType(gt % 1000), Layout(gt / 1000), nil
+}
+
+func wkbWriteHeader(w io.Writer, gt GeomType, layout Layout) error {
+	return wkbWriteCount(w, int(gt)+1000*int(layout))
+}
+
+// wkbOrdinates holds the Z and M ordinates of a geometry while it is encoded or decoded. When
+// writing, pos points to the next vertex.
+type wkbOrdinates struct {
+	layout Layout
+	z, m   []float64
+	pos    int
+}
+
+func (o *wkbOrdinates) read(r io.Reader) error {
+	if o == nil || o.layout == LayoutXY {
+		return nil
+	}
+	var buf = make([]byte, 8)
+	if o.layout.HasZ() {
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return err
+		}
+		o.z = append(o.z, math.Float64frombits(endianness.Uint64(buf)))
+	}
+	if o.layout.HasM() {
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return err
+		}
+		o.m = append(o.m, math.Float64frombits(endianness.Uint64(buf)))
+	}
+	return nil
+}
+
+// write writes the ordinates of the vertex at position i.
+func (o *wkbOrdinates) write(w io.Writer, i int) error {
+	if o == nil {
+		return nil
+	}
+	var (
+		buf = make([]byte, 16)
+		n   int
+	)
+	if o.z != nil {
+		endianness.PutUint64(buf[n:n+8], math.Float64bits(o.z[i]))
+		n += 8
+	}
+	if o.m != nil {
+		endianness.PutUint64(buf[n:n+8], math.Float64bits(o.m[i]))
+		n += 8
+	}
+	_, err := w.Write(buf[:n])
+	return err
+}
+
+func (o *wkbOrdinates) writeNext(w io.Writer) error {
+	if o == nil {
+		return nil
+	}
+	o.pos++
+	return o.write(w, o.pos-1)
 }
 
 func wkbWritePoint(w io.Writer, p Point) error {
@@ -74,11 +176,9 @@ func wkbWritePoint(w io.Writer, p Point) error {
 	return nil
 }
 
-func wkbWriteLineString(w io.Writer, ls []Point) error {
+func wkbWriteLineString(w io.Writer, ls []Point, ord *wkbOrdinates) error {
 	// write number of points
-	var ln = make([]byte, 4)
-	endianness.PutUint32(ln, uint32(len(ls)))
-	_, err := w.Write(ln)
+	err := wkbWriteCount(w, len(ls))
 	if err != nil {
 		return err
 	}
@@ -88,22 +188,41 @@ func wkbWriteLineString(w io.Writer, ls []Point) error {
 		if err != nil {
 			return err
 		}
+		if err = ord.writeNext(w); err != nil {
+			return err
+		}
 	}
 	return nil
 }
 
-func wkbWritePolygon(w io.Writer, poly Polygon) error {
+func wkbWritePolygon(w io.Writer, poly Polygon, ord *wkbOrdinates) error {
 	// write number of rings
-	var lnr = make([]byte, 4)
-	endianness.PutUint32(lnr, uint32(len(poly)))
-	_, err := w.Write(lnr)
+	err := wkbWriteCount(w, len(poly))
 	if err != nil {
 		return err
 	}
 
 	for _, ring := range poly {
-		err = wkbWriteLineString(w, append(ring, ring[0])) // wkb closes rings with the first element, the internal implementation doesn't
-		if err != nil {
+		var ringStart int
+		if ord != nil {
+			ringStart = ord.pos
+		}
+		// wkb closes rings with the first element, the internal implementation doesn't
+		if err = wkbWriteCount(w, len(ring)+1); err != nil {
+			return err
+		}
+		for _, pt := range ring {
+			if err = wkbWritePoint(w, pt); err != nil {
+				return err
+			}
+			if err = ord.writeNext(w); err != nil {
+				return err
+			}
+		}
+		if err = wkbWritePoint(w, ring[0]); err != nil {
+			return err
+		}
+		if err = ord.write(w, ringStart); err != nil {
 			return err
 		}
 	}
@@ -111,7 +230,7 @@ func wkbWritePolygon(w io.Writer, poly Polygon) error {
 }
 
 // TODO: evaluate returning Geom instead of Point
-func wkbReadPoint(r io.Reader) (p Point, err error) {
+func wkbReadPoint(r io.Reader, ord *wkbOrdinates) (p Point, err error) {
 	var buf = make([]byte, wkbRawPointSize)
 	n, err := r.Read(buf)
 	if n != wkbRawPointSize {
@@ -122,11 +241,12 @@ func wkbReadPoint(r io.Reader) (p Point, err error) {
 	}
 	p.X = math.Float64frombits(endianness.Uint64(buf[:8]))
 	p.Y = math.Float64frombits(endianness.Uint64(buf[8:16]))
+	err = ord.read(r)
 	return
 }
 
 // TODO: evaluate returning Geom instead of Point
-func wkbReadLineString(r io.Reader) (Line, error) {
+func wkbReadLineString(r io.Reader, ord *wkbOrdinates) (Line, error) {
 	var buf = make([]byte, 4)
 	_, err := r.Read(buf)
 	if err != nil {
@@ -139,7 +259,7 @@ func wkbReadLineString(r io.Reader) (Line, error) {
 
 	var ls = make(Line, nop)
 	for i := 0; i < int(nop); i++ {
-		ls[i], err = wkbReadPoint(r)
+		ls[i], err = wkbReadPoint(r, ord)
 		if err != nil {
 			return ls, err
 		}
@@ -147,24 +267,142 @@ func wkbReadLineString(r io.Reader) (Line, error) {
 	return ls, nil
 }
 
-func wkbReadPolygon(r io.Reader) (Polygon, error) {
+// wkbReadPolygon reads a polygon, rings which aren't closed are returned as unclosed.
+func wkbReadPolygon(r io.Reader, ord *wkbOrdinates) (rings Polygon, unclosed []ValidationError, err error) {
 	var buf = make([]byte, 4)
-	_, err := r.Read(buf)
+	_, err = r.Read(buf)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	nor := endianness.Uint32(buf)
 	if nor == 0 {
-		return nil, errors.New("a polygon needs to have at least one ring")
+		return nil, nil, errors.New("a polygon needs to have at least one ring")
 	}
 
-	var rings = make(Polygon, nor)
+	rings = make(Polygon, nor)
 	for i := 0; i < int(nor); i++ {
-		rings[i], err = wkbReadLineString(r)
+		rings[i], err = wkbReadLineString(r, ord)
+		if err != nil {
+			return rings, unclosed, err
+		}
+		// wkb closes rings with the first element, the internal implementation doesn't
+		var closed bool
+		if rings[i], closed = openRing(rings[i]); !closed {
+			unclosed = append(unclosed, ringNotClosed(rings[i], 0, i))
+			continue
+		}
+		if ord != nil && ord.z != nil {
+			ord.z = ord.z[:len(ord.z)-1]
+		}
+		if ord != nil && ord.m != nil {
+			ord.m = ord.m[:len(ord.m)-1]
+		}
+	}
+	return rings, unclosed, nil
+}
+
+// wkbWriteMember writes a complete WKB geometry, as used for members of multi geometries.
+func wkbWriteMember(w io.Writer, g Geom) error {
+	buf, err := g.MarshalWKB()
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(buf)
+	return err
+}
+
+func wkbWriteCount(w io.Writer, n int) error {
+	var buf = make([]byte, 4)
+	endianness.PutUint32(buf, uint32(n))
+	_, err := w.Write(buf)
+	return err
+}
+
+func wkbWriteMembers(w io.Writer, members []Geom) error {
+	if err := wkbWriteCount(w, len(members)); err != nil {
+		return err
+	}
+	for _, m := range members {
+		if err := wkbWriteMember(w, m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// wkbReadMembers reads the member count and all members of a multi geometry, which can be empty.
+// If typ is not GeomTypeEmpty, all members need to be of this type.
+func wkbReadMembers(r io.Reader, typ GeomType) ([]Geom, error) {
+	var buf = make([]byte, 4)
+	_, err := io.ReadFull(r, buf)
+	if err != nil {
+		return nil, err
+	}
+	nom := endianness.Uint32(buf)
+
+	var members = make([]Geom, 0, nom)
+	for i := 0; i < int(nom); i++ {
+		g, err := GeomFromWKB(r)
 		if err != nil {
-			return rings, err
+			return members, err
 		}
-		rings[i] = rings[i][:len(rings[i])-1] // wkb closes rings with the first element, the internal implementation doesn't
+		if typ != GeomTypeEmpty && g.Typ() != typ {
+			return members, fmt.Errorf("unexpected member type %v, expected %v", g.Typ(), typ)
+		}
+		members = append(members, g)
+	}
+	return members, nil
+}
+
+// The following readers also set the ordinates of g, which are stored within the members.
+
+func wkbReadMultiPoint(r io.Reader, g *Geom) (MultiPoint, error) {
+	members, err := wkbReadMembers(r, GeomTypePoint)
+	if err != nil {
+		return nil, err
+	}
+	var mp = make(MultiPoint, 0, len(members))
+	for _, m := range members {
+		mp = append(mp, *m.MustPoint())
+	}
+	g.joinOrdinates(members)
+	return mp, nil
+}
+
+func wkbReadMultiLineString(r io.Reader, g *Geom) (MultiLine, error) {
+	members, err := wkbReadMembers(r, GeomTypeLineString)
+	if err != nil {
+		return nil, err
+	}
+	var ml = make(MultiLine, 0, len(members))
+	for _, m := range members {
+		ml = append(ml, m.MustLineString())
+	}
+	g.joinOrdinates(members)
+	return ml, nil
+}
+
+func wkbReadMultiPolygon(r io.Reader, g *Geom) (MultiPolygon, error) {
+	members, err := wkbReadMembers(r, GeomTypePolygon)
+	if err != nil {
+		return nil, err
+	}
+	var mp = make(MultiPolygon, 0, len(members))
+	for n, m := range members {
+		mp = append(mp, m.MustPolygon())
+		for _, err := range m.unclosed {
+			err.Part = n
+			g.unclosed = append(g.unclosed, err)
+		}
+	}
+	g.joinOrdinates(members)
+	return mp, nil
+}
+
+func wkbReadGeometryCollection(r io.Reader) (GeomCollection, error) {
+	members, err := wkbReadMembers(r, GeomTypeEmpty)
+	if err != nil {
+		return nil, err
 	}
-	return rings, err
+	return GeomCollection(members), nil
 }
@@ -28,6 +28,35 @@ func TestGeomFromWKB(t *testing.T) {
 	assert.Equal(t, g.Typ(), GeomTypePolygon)
 }
 
+func TestWKBEmptyMulti(t *testing.T) {
+	for _, g := range []Geom{
+		MustNewGeom(MultiPoint{}),
+		MustNewGeom(MultiLine{}),
+		MustNewGeom(MultiPolygon{}),
+		MustNewGeom(GeomCollection{}),
+		MustNewGeom(GeomCollection{MustNewGeom(Point{1, 2}), MustNewGeom(MultiPolygon{})}),
+	} {
+		t.Run(g.WKT(), func(t *testing.T) {
+			buf, err := g.MarshalWKB()
+			assert.Nil(t, err)
+			rg, err := GeomFromWKB(bytes.NewReader(buf))
+			assert.Nil(t, err)
+			assert.Equal(t, g.Typ(), rg.Typ())
+			assert.True(t, rg.IsEmpty() == g.IsEmpty())
+			assert.Equal(t, g.WKT(), rg.WKT())
+
+			// the same geometry from WKT
+			wg, err := ParseWKT(g.WKT())
+			assert.Nil(t, err)
+			buf, err = wg.MarshalWKB()
+			assert.Nil(t, err)
+			rg, err = GeomFromWKB(bytes.NewReader(buf))
+			assert.Nil(t, err)
+			assert.Equal(t, g.WKT(), rg.WKT())
+		})
+	}
+}
+
 func BenchmarkUnmarshalWKB(b *testing.B) {
 	buf, _ := hex.DecodeString("03000000000000000000f03f00000000000000400000000000000840000000000000104000000000000014400000000000001040")
 
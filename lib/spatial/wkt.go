@@ -0,0 +1,567 @@
+package spatial
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ErrorEmptyPoint is returned when reading "POINT EMPTY", as Point has no empty representation.
+// Parse errors wrap it, so it needs to be checked with errors.Is.
+var ErrorEmptyPoint = errors.New("empty points are not supported")
+
+var wktTypes = map[GeomType]string{
+	GeomTypePoint:              "POINT",
+	GeomTypeLineString:         "LINESTRING",
+	GeomTypePolygon:            "POLYGON",
+	GeomTypeMultiPoint:         "MULTIPOINT",
+	GeomTypeMultiLineString:    "MULTILINESTRING",
+	GeomTypeMultiPolygon:       "MULTIPOLYGON",
+	GeomTypeGeometryCollection: "GEOMETRYCOLLECTION",
+}
+
+// WKT returns the geometry as OGC Well-known Text, e.g. "POLYGON((0 0,0 1,1 1,0 0))". Z and M
+// ordinates are written with the ISO dimension keyword ("POINT Z (1 2 3)").
+func (g Geom) WKT() string {
+	var b strings.Builder
+	wktWriteGeom(&b, g, false)
+	return b.String()
+}
+
+// MarshalWKT returns the geometry as WKT, like WKT. It fails if a coordinate is NaN or infinite,
+// as the result couldn't be read by UnmarshalWKT.
+func (g Geom) MarshalWKT() (string, error) {
+	var b strings.Builder
+	if err := wktWriteGeom(&b, g, false); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// EWKT returns the geometry in the PostGIS Extended Well-known Text format, which includes the
+// SRID ("SRID=4326;POINT(1 2)"). If srid is 0, the prefix is omitted.
+func (g Geom) EWKT(srid int) string {
+	var b strings.Builder
+	if srid != 0 {
+		fmt.Fprintf(&b, "SRID=%d;", srid)
+	}
+	wktWriteGeom(&b, g, true)
+	return b.String()
+}
+
+func (g *Geom) UnmarshalWKT(s string) error {
+	ng, err := ParseWKT(s)
+	if err != nil {
+		return err
+	}
+	*g = ng
+	return nil
+}
+
+// ParseWKT reads a geometry from WKT or EWKT. The SRID of EWKT is discarded, use ParseEWKT to
+// retrieve it. Empty points can't be read, the error wraps ErrorEmptyPoint.
+func ParseWKT(s string) (Geom, error) {
+	g, _, err := ParseEWKT(s)
+	return g, err
+}
+
+// ParseEWKT reads a geometry from EWKT and returns the SRID, which is 0 if the input has no
+// SRID prefix. Plain WKT is accepted as well.
+func ParseEWKT(s string) (Geom, int, error) {
+	var (
+		p    = wktParser{s: s}
+		srid int
+	)
+	if p.peekWord() == "SRID" {
+		p.word()
+		if err := p.expect('='); err != nil {
+			return Geom{}, 0, err
+		}
+		n, err := p.number()
+		if err != nil {
+			return Geom{}, 0, err
+		}
+		srid = int(n)
+		if err = p.expect(';'); err != nil {
+			return Geom{}, 0, err
+		}
+	}
+	g, err := p.geom()
+	if err != nil {
+		return g, srid, err
+	}
+	p.skipSpace()
+	if p.pos != len(p.s) {
+		return g, srid, p.errorf("unexpected trailing content")
+	}
+	return g, srid, nil
+}
+
+// wktWriteGeom writes the geometry to b. Coordinates which are not finite are written anyway, but
+// an error is returned.
+func wktWriteGeom(b *strings.Builder, g Geom, ewkt bool) error {
+	if g.typ == GeomTypeEmpty || g.g == nil {
+		b.WriteString("GEOMETRYCOLLECTION EMPTY")
+		return nil
+	}
+	b.WriteString(wktTypes[g.typ])
+	layout := g.Layout()
+	if ewkt {
+		// PostGIS only marks geometries which have M but no Z, otherwise the
+		// number of ordinates is unambiguous.
+		if layout == LayoutXYM {
+			b.WriteByte('M')
+		}
+	} else if layout != LayoutXY {
+		b.WriteString(" " + strings.TrimPrefix(layout.String(), "XY") + " ")
+	}
+
+	var (
+		pos int
+		err error
+		num = func(f float64) {
+			if err == nil && (math.IsNaN(f) || math.IsInf(f, 0)) {
+				err = fmt.Errorf("%v can't be written as WKT", f)
+			}
+			b.WriteString(strconv.FormatFloat(f, 'f', -1, 64))
+		}
+		coord = func(pt Point) {
+			num(pt.X)
+			b.WriteByte(' ')
+			num(pt.Y)
+			if g.z != nil {
+				b.WriteByte(' ')
+				num(g.z[pos])
+			}
+			if g.m != nil {
+				b.WriteByte(' ')
+				num(g.m[pos])
+			}
+		}
+		line = func(ln Line, closed bool) {
+			b.WriteByte('(')
+			start := pos
+			for i, pt := range ln {
+				if i > 0 {
+					b.WriteByte(',')
+				}
+				coord(pt)
+				pos++
+			}
+			if closed && len(ln) > 0 {
+				b.WriteByte(',')
+				end := pos
+				pos = start
+				coord(ln[0])
+				pos = end
+			}
+			b.WriteByte(')')
+		}
+		polygon = func(poly Polygon) {
+			b.WriteByte('(')
+			for i, ring := range poly {
+				if i > 0 {
+					b.WriteByte(',')
+				}
+				line(ring, true)
+			}
+			b.WriteByte(')')
+		}
+		empty = func(n int) bool {
+			if n == 0 {
+				b.WriteString(" EMPTY")
+			}
+			return n == 0
+		}
+	)
+
+	switch gm := g.g.(type) {
+	case *Point:
+		b.WriteByte('(')
+		coord(*gm)
+		b.WriteByte(')')
+	case Line:
+		if empty(len(gm)) {
+			return nil
+		}
+		line(gm, false)
+	case Polygon:
+		if empty(len(gm)) {
+			return nil
+		}
+		polygon(gm)
+	case MultiPoint:
+		if empty(len(gm)) {
+			return nil
+		}
+		b.WriteByte('(')
+		for i, pt := range gm {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			b.WriteByte('(')
+			coord(pt)
+			pos++
+			b.WriteByte(')')
+		}
+		b.WriteByte(')')
+	case MultiLine:
+		if empty(len(gm)) {
+			return nil
+		}
+		b.WriteByte('(')
+		for i, ln := range gm {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			line(ln, false)
+		}
+		b.WriteByte(')')
+	case MultiPolygon:
+		if empty(len(gm)) {
+			return nil
+		}
+		b.WriteByte('(')
+		for i, poly := range gm {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			polygon(poly)
+		}
+		b.WriteByte(')')
+	case GeomCollection:
+		if empty(len(gm)) {
+			return nil
+		}
+		b.WriteByte('(')
+		for i, m := range gm {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			if merr := wktWriteGeom(b, m, ewkt); err == nil {
+				err = merr
+			}
+		}
+		b.WriteByte(')')
+	}
+	return err
+}
+
+type wktParser struct {
+	s   string
+	pos int
+}
+
+func (p *wktParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("invalid WKT at position %d: %s", p.pos, fmt.Sprintf(format, args...))
+}
+
+func (p *wktParser) skipSpace() {
+	for p.pos < len(p.s) && strings.IndexByte(" \t\r\n", p.s[p.pos]) >= 0 {
+		p.pos++
+	}
+}
+
+func (p *wktParser) peek() byte {
+	p.skipSpace()
+	if p.pos >= len(p.s) {
+		return 0
+	}
+	return p.s[p.pos]
+}
+
+func (p *wktParser) expect(c byte) error {
+	if p.peek() != c {
+		return p.errorf("expected '%c'", c)
+	}
+	p.pos++
+	return nil
+}
+
+func isWKTLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// word reads a keyword and returns it in upper case.
+func (p *wktParser) word() string {
+	p.skipSpace()
+	start := p.pos
+	for p.pos < len(p.s) && isWKTLetter(p.s[p.pos]) {
+		p.pos++
+	}
+	return strings.ToUpper(p.s[start:p.pos])
+}
+
+func (p *wktParser) peekWord() string {
+	pos := p.pos
+	w := p.word()
+	p.pos = pos
+	return w
+}
+
+func (p *wktParser) number() (float64, error) {
+	p.skipSpace()
+	start := p.pos
+	for p.pos < len(p.s) && strings.IndexByte("+-.0123456789eE", p.s[p.pos]) >= 0 {
+		p.pos++
+	}
+	if start == p.pos {
+		return 0, p.errorf("expected number")
+	}
+	f, err := strconv.ParseFloat(p.s[start:p.pos], 64)
+	if err != nil {
+		return 0, p.errorf("%v", err)
+	}
+	return f, nil
+}
+
+func (p *wktParser) geom() (Geom, error) {
+	var (
+		g  Geom
+		kw = p.word()
+		gt GeomType
+	)
+	for t, name := range wktTypes {
+		// EWKT appends the dimensions directly to the type, e.g. POINTM
+		if strings.HasPrefix(kw, name) {
+			switch suffix := kw[len(name):]; suffix {
+			case "", "Z", "M", "ZM":
+				if len(name) > len(wktTypes[gt]) {
+					gt = t
+				}
+			}
+		}
+	}
+	if gt == GeomTypeEmpty {
+		return g, p.errorf("unknown geometry type %q", kw)
+	}
+
+	gp := wktGeomParser{wktParser: p}
+	switch dims := kw[len(wktTypes[gt]):] + p.dimensionKeyword(); dims {
+	case "":
+	case "Z":
+		gp.layout, gp.explicit = LayoutXYZ, true
+	case "M":
+		gp.layout, gp.explicit = LayoutXYM, true
+	case "ZM":
+		gp.layout, gp.explicit = LayoutXYZM, true
+	default:
+		return g, p.errorf("invalid dimensions %q", dims)
+	}
+
+	if p.peekWord() == "EMPTY" {
+		p.word()
+		switch gt {
+		case GeomTypePoint:
+			return g, fmt.Errorf("invalid WKT at position %d: %w", p.pos, ErrorEmptyPoint)
+		case GeomTypeLineString:
+			return MustNewGeom(Line{}), nil
+		case GeomTypePolygon:
+			return MustNewGeom(Polygon{}), nil
+		case GeomTypeMultiPoint:
+			return MustNewGeom(MultiPoint{}), nil
+		case GeomTypeMultiLineString:
+			return MustNewGeom(MultiLine{}), nil
+		case GeomTypeMultiPolygon:
+			return MustNewGeom(MultiPolygon{}), nil
+		}
+		return MustNewGeom(GeomCollection{}), nil
+	}
+
+	var (
+		geom interface{}
+		err  error
+	)
+	switch gt {
+	case GeomTypePoint:
+		if err = p.expect('('); err != nil {
+			return g, err
+		}
+		var pt Point
+		if pt, err = gp.coord(); err != nil {
+			return g, err
+		}
+		geom, err = pt, p.expect(')')
+	case GeomTypeLineString:
+		geom, err = gp.line()
+	case GeomTypePolygon:
+		geom, err = gp.polygon(0)
+	case GeomTypeMultiPoint:
+		var mp MultiPoint
+		err = gp.list(func() error {
+			// both MULTIPOINT(1 2,3 4) and MULTIPOINT((1 2),(3 4)) are common
+			var parens = p.peek() == '('
+			if parens {
+				p.pos++
+			}
+			pt, err := gp.coord()
+			if err != nil {
+				return err
+			}
+			mp = append(mp, pt)
+			if parens {
+				return p.expect(')')
+			}
+			return nil
+		})
+		geom = mp
+	case GeomTypeMultiLineString:
+		var ml MultiLine
+		err = gp.list(func() error {
+			ln, err := gp.line()
+			ml = append(ml, ln)
+			return err
+		})
+		geom = ml
+	case GeomTypeMultiPolygon:
+		var mp MultiPolygon
+		err = gp.list(func() error {
+			poly, err := gp.polygon(len(mp))
+			mp = append(mp, poly)
+			return err
+		})
+		geom = mp
+	case GeomTypeGeometryCollection:
+		var gc GeomCollection
+		err = gp.list(func() error {
+			m, err := p.geom()
+			gc = append(gc, m)
+			return err
+		})
+		geom = gc
+	}
+	if err != nil {
+		return g, err
+	}
+	g = MustNewGeom(geom)
+	g.unclosed = gp.unclosed
+	if gt != GeomTypeGeometryCollection {
+		if err = g.SetZ(gp.z); err != nil {
+			return g, err
+		}
+		if err = g.SetM(gp.m); err != nil {
+			return g, err
+		}
+		// like GeoJSON, WKT doesn't define the winding
+		g.fixWinding()
+	}
+	return g, nil
+}
+
+// dimensionKeyword reads the optional Z, M or ZM after the geometry type.
+func (p *wktParser) dimensionKeyword() string {
+	switch w := p.peekWord(); w {
+	case "Z", "M", "ZM":
+		p.word()
+		return w
+	}
+	return ""
+}
+
+// wktGeomParser reads the coordinates of a single geometry.
+type wktGeomParser struct {
+	*wktParser
+	layout   Layout
+	explicit bool // whether layout was given or needs to be derived from the first coordinate
+	z, m     []float64
+	unclosed []ValidationError
+}
+
+func (gp *wktGeomParser) coord() (Point, error) {
+	var vals []float64
+	for {
+		if c := gp.peek(); c == ',' || c == ')' || c == 0 {
+			break
+		}
+		v, err := gp.number()
+		if err != nil {
+			return Point{}, err
+		}
+		vals = append(vals, v)
+	}
+	if !gp.explicit {
+		switch len(vals) {
+		case 3:
+			gp.layout = LayoutXYZ
+		case 4:
+			gp.layout = LayoutXYZM
+		}
+		gp.explicit = true
+	}
+
+	var dims = 2
+	if gp.layout.HasZ() {
+		dims++
+	}
+	if gp.layout.HasM() {
+		dims++
+	}
+	if len(vals) != dims {
+		return Point{}, gp.errorf("expected %v ordinates, got %v", dims, len(vals))
+	}
+	if gp.layout.HasZ() {
+		gp.z = append(gp.z, vals[2])
+	}
+	if gp.layout.HasM() {
+		gp.m = append(gp.m, vals[dims-1])
+	}
+	return Point{vals[0], vals[1]}, nil
+}
+
+// list reads a parenthesized, comma separated list.
+func (gp *wktGeomParser) list(elem func() error) error {
+	if err := gp.expect('('); err != nil {
+		return err
+	}
+	for {
+		if err := elem(); err != nil {
+			return err
+		}
+		if gp.peek() != ',' {
+			break
+		}
+		gp.pos++
+	}
+	return gp.expect(')')
+}
+
+// line reads a point list.
+func (gp *wktGeomParser) line() (Line, error) {
+	var ln Line
+	err := gp.list(func() error {
+		pt, err := gp.coord()
+		ln = append(ln, pt)
+		return err
+	})
+	return ln, err
+}
+
+// polygon reads the rings of a polygon. Closing points are removed, as the internal
+// representation doesn't repeat them, rings which aren't closed are recorded in unclosed. part
+// is the index of the polygon in a multi polygon.
+func (gp *wktGeomParser) polygon(part int) (Polygon, error) {
+	var poly Polygon
+	err := gp.list(func() error {
+		ring, err := gp.line()
+		if err != nil {
+			poly = append(poly, ring)
+			return err
+		}
+		ring, closed := openRing(ring)
+		if !closed {
+			gp.unclosed = append(gp.unclosed, ringNotClosed(ring, part, len(poly)))
+		} else {
+			if gp.z != nil {
+				gp.z = gp.z[:len(gp.z)-1]
+			}
+			if gp.m != nil {
+				gp.m = gp.m[:len(gp.m)-1]
+			}
+		}
+		poly = append(poly, ring)
+		return nil
+	})
+	return poly, err
+}
@@ -0,0 +1,187 @@
+package spatial
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWKTParse(t *testing.T) {
+	for _, tc := range []struct {
+		in   string
+		geom Geom
+	}{
+		{"POINT(1 2)", MustNewGeom(Point{1, 2})},
+		{"point ( -1.5 2e3 )", MustNewGeom(Point{-1.5, 2000})},
+		{"POINT Z (1 2 3)", geomWithOrdinates(t, Point{1, 2}, []float64{3}, nil)},
+		{"POINT(1 2 3)", geomWithOrdinates(t, Point{1, 2}, []float64{3}, nil)},
+		{"POINTM(1 2 4)", geomWithOrdinates(t, Point{1, 2}, nil, []float64{4})},
+		{"POINT ZM (1 2 3 4)", geomWithOrdinates(t, Point{1, 2}, []float64{3}, []float64{4})},
+		{"LINESTRING(1 2,3 4)", MustNewGeom(Line{{1, 2}, {3, 4}})},
+		{"POLYGON((1 1,0 1,0 0,1 1),(0.1 0.2,0.2 0.2,0.1 0.1,0.1 0.2))", MustNewGeom(Polygon{
+			{{1, 1}, {0, 1}, {0, 0}},
+			{{0.1, 0.2}, {0.2, 0.2}, {0.1, 0.1}},
+		})},
+		// the winding is fixed, together with the ordinates
+		{"POLYGON Z ((0 0 1,0 1 2,1 1 3,0 0 1))", geomWithOrdinates(t, Polygon{{{1, 1}, {0, 1}, {0, 0}}}, []float64{3, 2, 1}, nil)},
+		{"MULTIPOINT((1 2),(3 4))", MustNewGeom(MultiPoint{{1, 2}, {3, 4}})},
+		{"MULTIPOINT(1 2,3 4)", MustNewGeom(MultiPoint{{1, 2}, {3, 4}})},
+		{"MULTILINESTRING((1 2,3 4),(5 6,7 8))", MustNewGeom(MultiLine{{{1, 2}, {3, 4}}, {{5, 6}, {7, 8}}})},
+		{"MULTIPOLYGON(((1 1,0 1,0 0,1 1)),((5 5,5 6,6 6,5 5)))", MustNewGeom(MultiPolygon{
+			{{{1, 1}, {0, 1}, {0, 0}}},
+			{{{6, 6}, {5, 6}, {5, 5}}},
+		})},
+		{"GEOMETRYCOLLECTION(POINT(1 2),LINESTRING Z (1 2 3,4 5 6))", MustNewGeom(GeomCollection{
+			MustNewGeom(Point{1, 2}),
+			geomWithOrdinates(t, Line{{1, 2}, {4, 5}}, []float64{3, 6}, nil),
+		})},
+		{"MULTIPOLYGON EMPTY", MustNewGeom(MultiPolygon{})},
+		{"LINESTRING EMPTY", MustNewGeom(Line{})},
+		{"POLYGON Z EMPTY", MustNewGeom(Polygon{})},
+		{"GEOMETRYCOLLECTION EMPTY", MustNewGeom(GeomCollection{})},
+	} {
+		t.Run(tc.in, func(t *testing.T) {
+			g, err := ParseWKT(tc.in)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.geom, g)
+		})
+	}
+}
+
+func TestWKTParseInvalid(t *testing.T) {
+	for _, in := range []string{
+		"",
+		"CIRCLE(1 2)",
+		"POINT(1)",
+		"POINT Z (1 2)",
+		"POINT(1 2",
+		"POINT(1 2) trailing",
+		"LINESTRING(1 2,3 4 5)",
+		"POINTX(1 2)",
+		"SRID=abc;POINT(1 2)",
+		"POINT EMPTY",
+		"GEOMETRYCOLLECTION(POINT(1 2),POINT EMPTY)",
+	} {
+		t.Run(in, func(t *testing.T) {
+			_, err := ParseWKT(in)
+			assert.NotNil(t, err)
+		})
+	}
+}
+
+func TestWKTWrite(t *testing.T) {
+	for _, tc := range []struct {
+		geom Geom
+		wkt  string
+		ewkt string
+	}{
+		{MustNewGeom(Point{1, 2.5}), "POINT(1 2.5)", "POINT(1 2.5)"},
+		{geomWithOrdinates(t, Point{1, 2}, []float64{3}, nil), "POINT Z (1 2 3)", "POINT(1 2 3)"},
+		{geomWithOrdinates(t, Point{1, 2}, nil, []float64{4}), "POINT M (1 2 4)", "POINTM(1 2 4)"},
+		{geomWithOrdinates(t, Line{{1, 2}, {3, 4}}, []float64{5, 6}, []float64{7, 8}), "LINESTRING ZM (1 2 5 7,3 4 6 8)", "LINESTRING(1 2 5 7,3 4 6 8)"},
+		{
+			geomWithOrdinates(t, Polygon{{{0, 0}, {0, 1}, {1, 1}}}, []float64{1, 2, 3}, nil),
+			"POLYGON Z ((0 0 1,0 1 2,1 1 3,0 0 1))",
+			"POLYGON((0 0 1,0 1 2,1 1 3,0 0 1))",
+		},
+		{MustNewGeom(MultiPoint{{1, 2}, {3, 4}}), "MULTIPOINT((1 2),(3 4))", "MULTIPOINT((1 2),(3 4))"},
+		{MustNewGeom(MultiLine{}), "MULTILINESTRING EMPTY", "MULTILINESTRING EMPTY"},
+		{MustNewGeom(Line{}), "LINESTRING EMPTY", "LINESTRING EMPTY"},
+		{MustNewGeom(Polygon{}), "POLYGON EMPTY", "POLYGON EMPTY"},
+		{Geom{}, "GEOMETRYCOLLECTION EMPTY", "GEOMETRYCOLLECTION EMPTY"},
+		{
+			MustNewGeom(GeomCollection{MustNewGeom(Point{1, 2}), MustNewGeom(MultiPolygon{{{{0, 0}, {0, 1}, {1, 1}}}})}),
+			"GEOMETRYCOLLECTION(POINT(1 2),MULTIPOLYGON(((0 0,0 1,1 1,0 0))))",
+			"GEOMETRYCOLLECTION(POINT(1 2),MULTIPOLYGON(((0 0,0 1,1 1,0 0))))",
+		},
+	} {
+		t.Run(tc.wkt, func(t *testing.T) {
+			assert.Equal(t, tc.wkt, tc.geom.WKT())
+			assert.Equal(t, tc.ewkt, tc.geom.EWKT(0))
+		})
+	}
+}
+
+func TestEWKT(t *testing.T) {
+	g := geomWithOrdinates(t, Point{1, 2}, nil, []float64{4})
+	assert.Equal(t, "SRID=4326;POINTM(1 2 4)", g.EWKT(4326))
+
+	rg, srid, err := ParseEWKT("SRID=4326;POINTM(1 2 4)")
+	assert.Nil(t, err)
+	assert.Equal(t, 4326, srid)
+	assert.Equal(t, g, rg)
+
+	_, srid, err = ParseEWKT("POINT(1 2)")
+	assert.Nil(t, err)
+	assert.Equal(t, 0, srid)
+}
+
+func TestWKTRoundtripFixtures(t *testing.T) {
+	files, err := filepath.Glob("testfiles/*.geojson")
+	assert.Nil(t, err)
+	moreFiles, err := filepath.Glob("../geojson/testdata/*.geojson")
+	assert.Nil(t, err)
+	files = append(files, moreFiles...)
+	assert.NotEmpty(t, files)
+
+	for _, fn := range files {
+		t.Run(fn, func(t *testing.T) {
+			f, err := os.Open(fn)
+			assert.Nil(t, err)
+			defer f.Close()
+
+			var fc FeatureCollection
+			assert.Nil(t, json.NewDecoder(f).Decode(&fc))
+			assert.NotEmpty(t, fc.Features)
+			for _, ft := range fc.Features {
+				g, err := ParseWKT(ft.Geometry.WKT())
+				assert.Nil(t, err)
+				assert.Equal(t, ft.Geometry, g)
+
+				s, err := ft.Geometry.MarshalWKT()
+				assert.Nil(t, err)
+				g = Geom{}
+				assert.Nil(t, g.UnmarshalWKT(s))
+				assert.Equal(t, ft.Geometry, g)
+
+				g, srid, err := ParseEWKT(ft.Geometry.EWKT(4326))
+				assert.Nil(t, err)
+				assert.Equal(t, 4326, srid)
+				assert.Equal(t, ft.Geometry, g)
+			}
+		})
+	}
+}
+
+func TestWKTEmptyMembers(t *testing.T) {
+	g, err := ParseWKT("GEOMETRYCOLLECTION(POINT(1 2),LINESTRING EMPTY,POLYGON EMPTY)")
+	assert.Nil(t, err)
+	assert.Equal(t, BBox{SW: Point{1, 2}, NE: Point{1, 2}}, g.BBox())
+	assert.Equal(t, "GEOMETRYCOLLECTION(POINT(1 2),LINESTRING EMPTY,POLYGON EMPTY)", g.WKT())
+}
+
+func TestWKTEmptyPoint(t *testing.T) {
+	for _, in := range []string{"POINT EMPTY", "POINT Z EMPTY", "GEOMETRYCOLLECTION(POINT(1 2),POINT EMPTY)"} {
+		_, err := ParseWKT(in)
+		assert.True(t, errors.Is(err, ErrorEmptyPoint), in)
+	}
+	_, err := ParseWKT("POINT(1)")
+	assert.False(t, errors.Is(err, ErrorEmptyPoint))
+}
+
+func TestMarshalWKTInvalid(t *testing.T) {
+	for _, g := range []Geom{
+		MustNewGeom(Point{math.NaN(), 1}),
+		MustNewGeom(Line{{0, 0}, {math.Inf(1), 1}}),
+		geomWithOrdinates(t, Point{1, 2}, []float64{math.NaN()}, nil),
+		MustNewGeom(GeomCollection{MustNewGeom(Point{1, 2}), MustNewGeom(Point{math.Inf(-1), 2})}),
+	} {
+		_, err := g.MarshalWKT()
+		assert.NotNil(t, err, g.WKT())
+	}
+}
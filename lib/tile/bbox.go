@@ -2,7 +2,16 @@ package tile
 
 import "github.com/thomersch/grandine/lib/spatial"
 
+// Coverage returns all tiles of the zoom level which intersect with the bbox. Wrapped bboxes,
+// which cross the antimeridian, cover the tiles at both ends of the map.
 func Coverage(bb spatial.BBox, zoom int) []ID {
+	if bb.Wrapped() {
+		var tiles []ID
+		for _, b := range bb.Unwrap() {
+			tiles = append(tiles, Coverage(b, zoom)...)
+		}
+		return tiles
+	}
 	// Tiles are counted from top-left to bottom-right
 	tl := spatial.Point{bb.SW.X, bb.NE.Y}
 	br := spatial.Point{bb.NE.X, bb.SW.Y}
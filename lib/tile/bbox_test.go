@@ -3,9 +3,15 @@ package tile
 import (
 	"testing"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/thomersch/grandine/lib/spatial"
 )
 
 func TestCoverage(t *testing.T) {
 	Coverage(spatial.BBox{spatial.Point{-5, -5}, spatial.Point{10, 10}}, 7)
 }
+
+func TestCoverageWrapped(t *testing.T) {
+	tiles := Coverage(spatial.BBox{SW: spatial.Point{X: 170, Y: -10}, NE: spatial.Point{X: -170, Y: 10}}, 2)
+	assert.ElementsMatch(t, []ID{{X: 3, Y: 1, Z: 2}, {X: 3, Y: 2, Z: 2}, {X: 0, Y: 1, Z: 2}, {X: 0, Y: 2, Z: 2}}, tiles)
+}